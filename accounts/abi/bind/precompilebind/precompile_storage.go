@@ -0,0 +1,266 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package precompilebind
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+)
+
+// storageValueType is how a value type is decoded from storage and encoded as the key of a mapping
+type storageValueType struct {
+	goType string
+	// decode is the contract helper that decodes the value
+	decode string
+	// key is the expression that encodes the key %s of a mapping
+	key string
+}
+
+// storageValueTypes are the value types by the prefix of their type id in a StorageLayout
+var storageValueTypes = []struct {
+	prefix string
+	storageValueType
+}{
+	{"t_address", storageValueType{"common.Address", "StorageAddress", "contract.AddressKey(%s)"}},
+	{"t_contract(", storageValueType{"common.Address", "StorageAddress", "contract.AddressKey(%s)"}},
+	{"t_bool", storageValueType{"bool", "StorageBool", "contract.BoolKey(%s)"}},
+	{"t_uint", storageValueType{"*big.Int", "StorageUint", "contract.IntKey(%s)"}},
+	{"t_enum(", storageValueType{"*big.Int", "StorageUint", "contract.IntKey(%s)"}},
+	{"t_int", storageValueType{"*big.Int", "StorageInt", "contract.IntKey(%s)"}},
+	{"t_bytes_", storageValueType{"[]byte", "", "%s"}},
+	{"t_bytes", storageValueType{"common.Hash", "StorageFixedBytes", "contract.FixedBytesKey(%s)"}},
+	{"t_string", storageValueType{"string", "", "[]byte(%s)"}},
+}
+
+func lookupStorageValueType(typeID string) (storageValueType, bool) {
+	for _, valueType := range storageValueTypes {
+		if strings.HasPrefix(typeID, valueType.prefix) {
+			return valueType.storageValueType, true
+		}
+	}
+	return storageValueType{}, false
+}
+
+// tmplStorageData is the data structure required to fill the storage reader template
+type tmplStorageData struct {
+	Package   string
+	Contracts []*tmplStorageContract
+}
+
+// tmplStorageContract is the readers of the state variables of a contract
+type tmplStorageContract struct {
+	Type    string
+	Slots   []tmplStorageSlot
+	Readers []*tmplStorageReader
+	// Skipped are the state variables that have no readers, with the reason
+	Skipped []string
+}
+
+type tmplStorageSlot struct {
+	Name  string
+	Label string
+	Slot  string
+}
+
+// tmplStorageReader reads a value type at [Path] in the storage of a contract
+type tmplStorageReader struct {
+	Name   string
+	Path   string
+	Params []tmplStorageParam
+	// Slot are the statements that compute the slot of the value
+	Slot   []string
+	Offset string
+	Size   int
+	GoType string
+	Decode string
+}
+
+type tmplStorageParam struct {
+	Name string
+	Type string
+}
+
+// StorageBind generates Go readers of the state variables of contracts from their storage layouts, so that a precompile
+// can read the storage of a Solidity contract. types are the names of the contracts, which prefix their readers.
+func StorageBind(types []string, layouts []string, pkg string) (string, error) {
+	if len(types) != len(layouts) {
+		return "", errors.New("a contract name is required for each storage layout")
+	}
+	data := &tmplStorageData{Package: pkg}
+	for i, kind := range types {
+		layout := &contract.StorageLayout{}
+		if err := json.Unmarshal([]byte(layouts[i]), layout); err != nil {
+			return "", fmt.Errorf("failed to parse the storage layout of %s: %w", kind, err)
+		}
+		storageContract, err := bindStorageLayout(abi.ToCamelCase(kind), layout)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate the storage readers of %s: %w", kind, err)
+		}
+		data.Contracts = append(data.Contracts, storageContract)
+	}
+
+	buffer := new(bytes.Buffer)
+	tmpl := template.Must(template.New("").Parse(tmplSourcePrecompileStorageGo))
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("%v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+func bindStorageLayout(kind string, layout *contract.StorageLayout) (*tmplStorageContract, error) {
+	storageContract := &tmplStorageContract{Type: kind}
+	for _, variable := range layout.Storage {
+		// gaps only reserve slots for upgrades
+		if strings.HasPrefix(variable.Label, "__") {
+			continue
+		}
+		name := kind + abi.ToCamelCase(variable.Label)
+		if _, err := strconv.ParseInt(variable.Slot, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid slot %q of %s: %w", variable.Slot, variable.Label, err)
+		}
+		storageContract.Slots = append(storageContract.Slots, tmplStorageSlot{
+			Name:  name + "Slot",
+			Label: variable.Label,
+			Slot:  variable.Slot,
+		})
+		binder := &storageBinder{layout: layout, contract: storageContract}
+		reader := &tmplStorageReader{
+			Name: name,
+			Path: variable.Label,
+			Slot: []string{fmt.Sprintf("slot := contract.StorageSlot(%sSlot)", name)},
+		}
+		if err := binder.bind(reader, strconv.Itoa(variable.Offset), variable.Type); err != nil {
+			return nil, fmt.Errorf("%s: %w", variable.Label, err)
+		}
+	}
+	return storageContract, nil
+}
+
+// storageBinder walks the type of a state variable down to its value types and adds a reader for each
+type storageBinder struct {
+	layout   *contract.StorageLayout
+	contract *tmplStorageContract
+	keys     int
+	indexes  int
+}
+
+func (b *storageBinder) bind(reader *tmplStorageReader, offset string, typeID string) error {
+	storageType, ok := b.layout.Types[typeID]
+	if !ok {
+		return fmt.Errorf("type %s is not in the layout", typeID)
+	}
+	switch {
+	case storageType.Encoding == "mapping":
+		keyType, ok := lookupStorageValueType(storageType.Key)
+		if !ok {
+			return fmt.Errorf("unsupported mapping key %s", storageType.Key)
+		}
+		key := fmt.Sprintf("key%d", b.keys)
+		b.keys++
+		next := reader.with(
+			"",
+			fmt.Sprintf("[%s]", key),
+			fmt.Sprintf("slot = contract.MappingSlot(%s, slot)", fmt.Sprintf(keyType.key, key)),
+		)
+		next.Params = append(next.Params, tmplStorageParam{Name: key, Type: keyType.goType})
+		return b.bind(next, "0", storageType.Value)
+
+	case storageType.Encoding == "dynamic_array":
+		b.addReader(reader.with("Length", ".length"), "0", 32, storageValueType{goType: "*big.Int", decode: "StorageUint"})
+		return b.bindElement(reader.with("", "", "slot = contract.ArrayDataSlot(slot)"), storageType)
+
+	case storageType.Encoding == "bytes":
+		b.contract.Skipped = append(b.contract.Skipped, fmt.Sprintf("%s: %s is not supported", reader.Path, storageType.Label))
+		return nil
+
+	case len(storageType.Members) > 0:
+		for _, member := range storageType.Members {
+			next := reader.with(abi.ToCamelCase(member.Label), "."+member.Label)
+			if member.Slot != "0" {
+				next.Slot = append(next.Slot, fmt.Sprintf("slot = contract.AddToSlot(slot, big.NewInt(%s))", member.Slot))
+			}
+			if err := b.bind(next, strconv.Itoa(member.Offset), member.Type); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case storageType.Base != "":
+		// an inplace array has a fixed length
+		return b.bindElement(reader, storageType)
+	}
+
+	valueType, ok := lookupStorageValueType(typeID)
+	if !ok || valueType.decode == "" {
+		b.contract.Skipped = append(b.contract.Skipped, fmt.Sprintf("%s: %s is not supported", reader.Path, storageType.Label))
+		return nil
+	}
+	size, err := strconv.Atoi(storageType.NumberOfBytes)
+	if err != nil {
+		return fmt.Errorf("invalid size of %s: %w", typeID, err)
+	}
+	b.addReader(reader, offset, size, valueType)
+	return nil
+}
+
+// bindElement binds the elements of an array whose first element is at the slot of [reader]. Elements smaller than
+// a slot are packed, the larger ones start at a new slot.
+func (b *storageBinder) bindElement(reader *tmplStorageReader, arrayType *contract.StorageType) error {
+	elementType, ok := b.layout.Types[arrayType.Base]
+	if !ok {
+		return fmt.Errorf("type %s is not in the layout", arrayType.Base)
+	}
+	size, err := strconv.Atoi(elementType.NumberOfBytes)
+	if err != nil {
+		return fmt.Errorf("invalid size of %s: %w", arrayType.Base, err)
+	}
+	index := fmt.Sprintf("index%d", b.indexes)
+	b.indexes++
+	var next *tmplStorageReader
+	offset := "0"
+	if perSlot := 32 / size; perSlot <= 1 {
+		slots := (size + 31) / 32
+		step := index
+		if slots > 1 {
+			step = fmt.Sprintf("%s*%d", index, slots)
+		}
+		next = reader.with("", fmt.Sprintf("[%s]", index), fmt.Sprintf("slot = contract.AddToSlot(slot, new(big.Int).SetUint64(%s))", step))
+	} else {
+		next = reader.with("", fmt.Sprintf("[%s]", index), fmt.Sprintf("slot = contract.AddToSlot(slot, new(big.Int).SetUint64(%s/%d))", index, perSlot))
+		offset = fmt.Sprintf("int(%s%%%d)*%d", index, perSlot, size)
+	}
+	next.Params = append(next.Params, tmplStorageParam{Name: index, Type: "uint64"})
+	return b.bind(next, offset, arrayType.Base)
+}
+
+func (b *storageBinder) addReader(reader *tmplStorageReader, offset string, size int, valueType storageValueType) {
+	reader.Offset = offset
+	reader.Size = size
+	reader.GoType = valueType.goType
+	reader.Decode = valueType.decode
+	b.contract.Readers = append(b.contract.Readers, reader)
+}
+
+// with returns a copy of the reader that reads a part of the value of [r]
+func (r *tmplStorageReader) with(name string, path string, slot ...string) *tmplStorageReader {
+	return &tmplStorageReader{
+		Name:   r.Name + name,
+		Path:   r.Path + path,
+		Params: append([]tmplStorageParam{}, r.Params...),
+		Slot:   append(append([]string{}, r.Slot...), slot...),
+	}
+}
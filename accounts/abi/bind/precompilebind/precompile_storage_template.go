@@ -0,0 +1,49 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+package precompilebind
+
+// tmplSourcePrecompileStorageGo is the Go storage reader source template.
+const tmplSourcePrecompileStorageGo = `
+// Code generated
+// This file is generated from the solc storage layouts of Solidity contracts, to read their state variables from a precompile.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package {{.Package}}
+
+import (
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = common.Big0
+)
+{{range $contract := .Contracts}}
+// Storage slots of the state variables of {{.Type}}
+const (
+{{- range .Slots}}
+	{{.Name}} int64 = {{.Slot}} // {{.Label}}
+{{- end}}
+)
+{{- if .Skipped}}
+
+// Not generated:
+{{- range .Skipped}}
+//   - {{.}}
+{{- end}}
+{{- end}}
+{{range .Readers}}
+// {{.Name}} reads {{.Path}} from the storage of the {{$contract.Type}} at [address]
+func {{.Name}}(stateDB contract.StateDB, address common.Address{{range .Params}}, {{.Name}} {{.Type}}{{end}}) {{.GoType}} {
+	{{- range .Slot}}
+	{{.}}
+	{{- end}}
+	return contract.{{.Decode}}(contract.ReadStorage(stateDB, address, slot, {{.Offset}}, {{.Size}}))
+}
+{{end}}
+{{- end}}
+`
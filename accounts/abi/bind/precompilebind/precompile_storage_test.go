@@ -0,0 +1,81 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package precompilebind
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageBind(t *testing.T) {
+	layout, err := os.ReadFile("testdata/storage_layout.json")
+	require.NoError(t, err)
+
+	code, err := StorageBind([]string{"Sample"}, []string{string(layout)}, "sample")
+	require.NoError(t, err)
+
+	for _, expected := range []string{
+		"SampleOrderInfoSlot int64 = 1 // orderInfo",
+		// packed value types
+		"func SamplePaused(stateDB contract.StateDB, address common.Address) bool {",
+		"return contract.StorageInt(contract.ReadStorage(stateDB, address, slot, 21, 1))",
+		// a struct in a mapping
+		"func SampleOrderInfoFilledAmount(stateDB contract.StateDB, address common.Address, key0 common.Hash) *big.Int {",
+		"slot = contract.AddToSlot(slot, big.NewInt(2))\n\treturn contract.StorageUint(contract.ReadStorage(stateDB, address, slot, 0, 1))",
+		// a nested mapping
+		"func SamplePositionsSize(stateDB contract.StateDB, address common.Address, key0 *big.Int, key1 common.Address) *big.Int {",
+		"slot = contract.MappingSlot(contract.IntKey(key0), slot)\n\tslot = contract.MappingSlot(contract.AddressKey(key1), slot)",
+		// arrays, packed and unpacked
+		"func SamplePricesLength(stateDB contract.StateDB, address common.Address) *big.Int {",
+		"slot = contract.ArrayDataSlot(slot)\n\tslot = contract.AddToSlot(slot, new(big.Int).SetUint64(index0/8))",
+		"ReadStorage(stateDB, address, slot, int(index0%8)*4, 4)",
+		"func SampleMarkets(stateDB contract.StateDB, address common.Address, index0 uint64) common.Address {",
+		"slot = contract.AddToSlot(slot, new(big.Int).SetUint64(index0))\n\treturn contract.StorageAddress(contract.ReadStorage(stateDB, address, slot, 0, 20))",
+		"//   - name: string is not supported",
+	} {
+		require.Contains(t, code, expected)
+	}
+	require.NotContains(t, code, "Gap")
+}
+
+func TestStorageBindFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   string
+		errorMsg string
+	}{
+		{
+			name: "unsupported key",
+			layout: `{
+				"storage": [{"label": "prices", "offset": 0, "slot": "0", "type": "t_mapping(t_userDefinedValueType(Price)1,t_uint256)"}],
+				"types": {
+					"t_mapping(t_userDefinedValueType(Price)1,t_uint256)": {"encoding": "mapping", "key": "t_userDefinedValueType(Price)1", "label": "mapping(Price => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+					"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"}
+				}
+			}`,
+			errorMsg: "prices: unsupported mapping key t_userDefinedValueType(Price)1",
+		},
+		{
+			name: "missing type",
+			layout: `{
+				"storage": [{"label": "owner", "offset": 0, "slot": "0", "type": "t_address"}],
+				"types": {}
+			}`,
+			errorMsg: "owner: type t_address is not in the layout",
+		},
+		{
+			name:     "invalid layout",
+			layout:   `[]`,
+			errorMsg: "failed to parse the storage layout of Broken",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := StorageBind([]string{"Broken"}, []string{test.layout}, "broken")
+			require.ErrorContains(t, err, test.errorMsg)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// orderbook is a set of offline tools to debug the hubble order book of a node
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ava-labs/subnet-evm/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+var logLevelFlag = &cli.StringFlag{
+	Name:  "log-level",
+	Usage: "Log level of the order book code, it logs every event and order at info",
+	Value: "warn",
+}
+
+var app = flags.NewApp("Offline tools for the hubble order book")
+
+func init() {
+	app.Name = "orderbook"
+	app.Flags = []cli.Flag{
+		logLevelFlag,
+	}
+	app.Before = setupLogging
+	app.Commands = []*cli.Command{
+		replayCommand,
+		snapshotCommand,
+	}
+}
+
+func setupLogging(c *cli.Context) error {
+	level, err := log.LvlFromString(c.String(logLevelFlag.Name))
+	if err != nil {
+		return err
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(level, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	return nil
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/urfave/cli/v2"
+)
+
+var replayOutputFlag = &cli.StringFlag{
+	Name:  "out",
+	Usage: "File to write the emitted txs to (default = stdout)",
+}
+
+var replayCommand = &cli.Command{
+	Name:      "replay",
+	Usage:     "Replay a recording of hubble logs and oracle prices through the matching pipeline",
+	ArgsUsage: "<recording.jsonl | ->",
+	Description: `Feeds each recorded block (an orderbook.ReplayBlock per line) through the contract events processor
+and runs the matching pipeline after it, as a validator does when the block is accepted. The matches,
+liquidations, cancels and funding payments that the pipeline emits are printed as JSON lines instead
+of being sent to the tx pool.`,
+	Flags:  []cli.Flag{replayOutputFlag},
+	Action: replay,
+}
+
+func replay(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("expected the path of a recording, - for STDIN", 1)
+	}
+	var input io.Reader = os.Stdin
+	if path := c.Args().First(); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+	var output io.Writer = os.Stdout
+	if path := c.String(replayOutputFlag.Name); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		output = file
+	}
+	return orderbook.Replay(input, output)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	textFormat = "text"
+	jsonFormat = "json"
+	csvFormat  = "csv"
+)
+
+var (
+	chainIDFlag = &cli.StringFlag{
+		Name:  "chain-id",
+		Usage: "Blockchain ID of the hubble chain, to find its snapshot in a node's database",
+	}
+	formatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format: text, json or csv",
+		Value: textFormat,
+	}
+	tableFlag = &cli.StringFlag{
+		Name:  "table",
+		Usage: "Only show this table: " + strings.Join(tableNames, ", "),
+	}
+	snapshotOutputFlag = &cli.StringFlag{
+		Name:  "out",
+		Usage: "File to write to (default = stdout)",
+	}
+)
+
+const sourcesDescription = `A source is one of
+  - the database directory of a node (e.g. ~/.avalanchego/db/<network>/v1.4.5), with --chain-id. The node
+    holds a lock on its database, so stop it or copy the directory first
+  - the RPC URL of a node (e.g. http://127.0.0.1:9650/ext/bc/<chain>/rpc), to fetch the live order book
+    with orderbook_getDetailedOrderBookData
+  - a file written by "snapshot show --format json"`
+
+var snapshotCommand = &cli.Command{
+	Name:  "snapshot",
+	Usage: "Inspect the order book snapshot that a node saves in its database",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "show",
+			Usage:     "Print or export the orders, traders, positions, margins and markets of a snapshot",
+			ArgsUsage: "<source>",
+			Description: `The text format prints a table per part of the order book. The json format exports the whole order book,
+in the format of orderbook_getDetailedOrderBookData, unless --table is set. The csv format exports a single
+--table.
+
+` + sourcesDescription,
+			Flags:  []cli.Flag{chainIDFlag, formatFlag, tableFlag, snapshotOutputFlag},
+			Action: showSnapshot,
+		},
+		{
+			Name:      "diff",
+			Usage:     "Compare two snapshots, or a snapshot and the live order book of a node",
+			ArgsUsage: "<source> <source>",
+			Description: `Prints the rows of each table that only one of the sources has, and the columns that changed in the rows
+that both have. It exits with 1 when the sources differ. --chain-id is used for both database sources.
+
+` + sourcesDescription,
+			Flags:  []cli.Flag{chainIDFlag, tableFlag, snapshotOutputFlag},
+			Action: diffSnapshots,
+		},
+	},
+}
+
+func showSnapshot(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("expected a single source", 1)
+	}
+	tables, err := selectedTables(c)
+	if err != nil {
+		return err
+	}
+	chainID, err := parseChainID(c)
+	if err != nil {
+		return err
+	}
+	document, err := loadDocument(c.Context, c.Args().First(), chainID)
+	if err != nil {
+		return err
+	}
+	output, closeOutput, err := openOutput(c)
+	if err != nil {
+		return err
+	}
+	defer closeOutput()
+
+	switch format := c.String(formatFlag.Name); format {
+	case textFormat:
+		return writeText(output, document, tables)
+	case jsonFormat:
+		if !c.IsSet(tableFlag.Name) {
+			encoder := json.NewEncoder(output)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(document)
+		}
+		return writeJSONTable(output, buildTables(document)[tables[0]])
+	case csvFormat:
+		if !c.IsSet(tableFlag.Name) {
+			return fmt.Errorf("--%s is required for the csv format", tableFlag.Name)
+		}
+		return writeCSV(output, buildTables(document)[tables[0]])
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func diffSnapshots(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.Exit("expected two sources", 1)
+	}
+	tables, err := selectedTables(c)
+	if err != nil {
+		return err
+	}
+	chainID, err := parseChainID(c)
+	if err != nil {
+		return err
+	}
+	before, err := loadDocument(c.Context, c.Args().Get(0), chainID)
+	if err != nil {
+		return err
+	}
+	after, err := loadDocument(c.Context, c.Args().Get(1), chainID)
+	if err != nil {
+		return err
+	}
+	output, closeOutput, err := openOutput(c)
+	if err != nil {
+		return err
+	}
+	defer closeOutput()
+
+	beforeTables, afterTables := buildTables(before), buildTables(after)
+	differences := 0
+	for _, name := range tables {
+		differences += writeTableDiff(output, beforeTables[name], afterTables[name])
+	}
+	if differences > 0 {
+		return cli.Exit(fmt.Sprintf("%d differences", differences), 1)
+	}
+	fmt.Fprintln(output, "no differences")
+	return nil
+}
+
+func selectedTables(c *cli.Context) ([]string, error) {
+	name := c.String(tableFlag.Name)
+	if name == "" {
+		return tableNames, nil
+	}
+	for _, tableName := range tableNames {
+		if name == tableName {
+			return []string{name}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown table %q, expected one of %s", name, strings.Join(tableNames, ", "))
+}
+
+func parseChainID(c *cli.Context) (ids.ID, error) {
+	if !c.IsSet(chainIDFlag.Name) {
+		return ids.Empty, nil
+	}
+	chainID, err := ids.FromString(c.String(chainIDFlag.Name))
+	if err != nil {
+		return ids.Empty, fmt.Errorf("invalid --%s: %w", chainIDFlag.Name, err)
+	}
+	return chainID, nil
+}
+
+func openOutput(c *cli.Context) (io.Writer, func(), error) {
+	path := c.String(snapshotOutputFlag.Name)
+	if path == "" {
+		return os.Stdout, func() {}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, func() { file.Close() }, nil
+}
+
+func writeText(output io.Writer, document *orderBookDocument, names []string) error {
+	if document.AcceptedBlockNumber != nil {
+		fmt.Fprintf(output, "accepted block: %d\n\n", *document.AcceptedBlockNumber)
+	}
+	tables := buildTables(document)
+	for i, name := range names {
+		t := tables[name]
+		if i > 0 {
+			fmt.Fprintln(output)
+		}
+		fmt.Fprintf(output, "%s (%d)\n", t.name, len(t.rows))
+		writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(output io.Writer, t *table) error {
+	writer := csv.NewWriter(output)
+	if err := writer.Write(t.header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(t.rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func writeJSONTable(output io.Writer, t *table) error {
+	rows := make([]map[string]string, 0, len(t.rows))
+	for _, row := range t.rows {
+		object := make(map[string]string, len(t.header))
+		for i, column := range t.header {
+			object[column] = row[i]
+		}
+		rows = append(rows, object)
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+// writeTableDiff prints the rows that were removed (-), added (+) or changed (~) from [before] to [after] and returns their number
+func writeTableDiff(output io.Writer, before, after *table) int {
+	beforeRows := make(map[string][]string, len(before.rows))
+	for _, row := range before.rows {
+		beforeRows[before.key(row)] = row
+	}
+	afterRows := make(map[string][]string, len(after.rows))
+	for _, row := range after.rows {
+		afterRows[after.key(row)] = row
+	}
+
+	var lines []string
+	for _, row := range before.rows {
+		if _, ok := afterRows[before.key(row)]; !ok {
+			lines = append(lines, "- "+formatRow(before, row))
+		}
+	}
+	for _, row := range after.rows {
+		beforeRow, ok := beforeRows[after.key(row)]
+		if !ok {
+			lines = append(lines, "+ "+formatRow(after, row))
+			continue
+		}
+		var changes []string
+		for i := after.keyColumns; i < len(row); i++ {
+			if beforeRow[i] != row[i] {
+				changes = append(changes, fmt.Sprintf("%s: %s -> %s", after.header[i], beforeRow[i], row[i]))
+			}
+		}
+		if len(changes) > 0 {
+			lines = append(lines, fmt.Sprintf("~ %s %s", formatKey(after, row), strings.Join(changes, ", ")))
+		}
+	}
+	if len(lines) == 0 {
+		return 0
+	}
+	fmt.Fprintf(output, "%s\n", after.name)
+	for _, line := range lines {
+		fmt.Fprintf(output, "  %s\n", line)
+	}
+	return len(lines)
+}
+
+func formatKey(t *table, row []string) string {
+	return formatColumns(t.header[:t.keyColumns], row[:t.keyColumns])
+}
+
+func formatRow(t *table, row []string) string {
+	return formatColumns(t.header, row)
+}
+
+func formatColumns(header, row []string) string {
+	columns := make([]string, len(row))
+	for i := range row {
+		columns[i] = header[i] + "=" + row[i]
+	}
+	return strings.Join(columns, " ")
+}
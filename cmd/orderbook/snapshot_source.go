@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// these mirror hubbleDBPrefix and memoryDBSnapshotKey in plugin/evm
+const (
+	hubbleDBPrefix      = "hubble"
+	memoryDBSnapshotKey = "memoryDBSnapshot"
+	// avalanchego puts the database of a VM under this prefix in the chain's database
+	vmDBPrefix = "vm"
+)
+
+var errNoSnapshot = errors.New("the database has no order book snapshot for the chain")
+
+func init() {
+	// the raw orders are stored as the ContractOrder interface, same as plugin/evm registers them
+	gob.Register(&orderbook.LimitOrder{})
+	gob.Register(&orderbook.IOCOrder{})
+	gob.Register(&orderbook.GTTOrder{})
+}
+
+// orderBookDocument is the JSON of an orderbook.InMemoryDatabase, which is also what orderbook_getDetailedOrderBookData returns.
+// It is the common form of all the sources, so that a snapshot can be compared with a live node.
+type orderBookDocument struct {
+	// AcceptedBlockNumber is only known for snapshots, the data includes this block
+	AcceptedBlockNumber *uint64                           `json:"accepted_block_number,omitempty"`
+	OrderMap            map[common.Hash]orderDocument     `json:"order_map"`
+	TraderMap           map[common.Address]traderDocument `json:"trader_map"`
+	NextFundingTimes    map[orderbook.Market]uint64       `json:"next_funding_times"`
+	LastPrice           map[orderbook.Market]*big.Int     `json:"last_price"`
+}
+
+// orderDocument is the JSON of an orderbook.Order
+type orderDocument struct {
+	Market                  orderbook.Market      `json:"market"`
+	PositionType            string                `json:"position_type"`
+	UserAddress             string                `json:"user_address"`
+	BaseAssetQuantity       string                `json:"base_asset_quantity"`
+	FilledBaseAssetQuantity string                `json:"filled_base_asset_quantity"`
+	Salt                    string                `json:"salt"`
+	Price                   string                `json:"price"`
+	LifecycleList           []orderbook.Lifecycle `json:"lifecycle_list"`
+	BlockNumber             uint64                `json:"block_number"`
+	ReduceOnly              bool                  `json:"reduce_only"`
+	OrderType               string                `json:"order_type"`
+}
+
+// traderDocument is the JSON of an orderbook.Trader
+type traderDocument struct {
+	Positions map[orderbook.Market]positionDocument `json:"positions"`
+	Margin    struct {
+		Reserved  *big.Int                          `json:"reserved"`
+		Deposited map[orderbook.Collateral]*big.Int `json:"deposited"`
+	} `json:"margin"`
+}
+
+// positionDocument is the JSON of an orderbook.Position
+type positionDocument struct {
+	OpenNotional         string `json:"open_notional"`
+	Size                 string `json:"size"`
+	UnrealisedFunding    string `json:"unrealised_funding"`
+	LastPremiumFraction  string `json:"last_premium_fraction"`
+	LiquidationThreshold string `json:"liquidation_threshold"`
+}
+
+// loadDocument loads the order book from [source], which is one of
+//   - the URL of a node's RPC endpoint, to fetch the live order book with orderbook_getDetailedOrderBookData
+//   - the directory of a node's leveldb database, to decode the snapshot of the chain [chainID]
+//   - a JSON file exported with "snapshot show --format json"
+func loadDocument(ctx context.Context, source string, chainID ids.ID) (*orderBookDocument, error) {
+	if isURL(source) {
+		return loadLiveDocument(ctx, source)
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		if chainID == ids.Empty {
+			return nil, fmt.Errorf("the chain id is required to read the snapshot from %s", source)
+		}
+		snapshot, err := readSnapshot(source, chainID)
+		if err != nil {
+			return nil, err
+		}
+		return snapshotDocument(snapshot)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	document := &orderBookDocument{}
+	if err := json.Unmarshal(data, document); err != nil {
+		return nil, fmt.Errorf("%s is not an exported order book: %w", source, err)
+	}
+	return document, nil
+}
+
+func isURL(source string) bool {
+	for _, scheme := range []string{"http://", "https://", "ws://", "wss://"} {
+		if strings.HasPrefix(source, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadLiveDocument(ctx context.Context, url string) (*orderBookDocument, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", url, err)
+	}
+	defer client.Close()
+
+	document := &orderBookDocument{}
+	if err := client.CallContext(ctx, document, "orderbook_getDetailedOrderBookData"); err != nil {
+		return nil, fmt.Errorf("orderbook_getDetailedOrderBookData failed: %w", err)
+	}
+	return document, nil
+}
+
+// snapshotKey is the key of the snapshot in the node's database. The VM's hubbleDB is a prefixdb over a versiondb over the
+// chain's database, which avalanchego nests as prefixdb("vm") in prefixdb(chainID). prefixdb hashes its prefix and merges
+// directly nested prefixes before hashing them, so the chain's prefix is hash(hash(chainID) + "vm").
+func snapshotKey(chainID ids.ID) []byte {
+	chainPrefix := hashing.ComputeHash256(append(hashing.ComputeHash256(chainID[:]), vmDBPrefix...))
+	key := append(chainPrefix, hashing.ComputeHash256([]byte(hubbleDBPrefix))...)
+	return append(key, memoryDBSnapshotKey...)
+}
+
+// readSnapshot opens the leveldb database at [dir] read-only and decodes the order book snapshot of [chainID].
+// leveldb allows a single process to open a database, so the node must be stopped or the database copied first.
+func readSnapshot(dir string, chainID ids.ID) (*orderbook.Snapshot, error) {
+	db, err := leveldb.OpenFile(dir, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database at %s: %w", dir, err)
+	}
+	defer db.Close()
+
+	data, err := db.Get(snapshotKey(chainID), nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, errNoSnapshot
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &orderbook.Snapshot{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode the snapshot: %w", err)
+	}
+	if snapshot.Data == nil {
+		return nil, errNoSnapshot
+	}
+	return snapshot, nil
+}
+
+func snapshotDocument(snapshot *orderbook.Snapshot) (*orderBookDocument, error) {
+	data, err := json.Marshal(snapshot.Data)
+	if err != nil {
+		return nil, err
+	}
+	document := &orderBookDocument{}
+	if err := json.Unmarshal(data, document); err != nil {
+		return nil, err
+	}
+	if snapshot.AcceptedBlockNumber != nil {
+		acceptedBlockNumber := snapshot.AcceptedBlockNumber.Uint64()
+		document.AcceptedBlockNumber = &acceptedBlockNumber
+	}
+	return document, nil
+}
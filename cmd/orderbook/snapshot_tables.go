@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	ordersTable    = "orders"
+	tradersTable   = "traders"
+	positionsTable = "positions"
+	marginsTable   = "margins"
+	marketsTable   = "markets"
+)
+
+var tableNames = []string{ordersTable, tradersTable, positionsTable, marginsTable, marketsTable}
+
+// table is a flat view of a part of the order book, rows are sorted by their key
+type table struct {
+	name   string
+	header []string
+	// the first keyColumns columns identify a row
+	keyColumns int
+	rows       [][]string
+}
+
+func (t *table) key(row []string) string {
+	return fmt.Sprint(row[:t.keyColumns])
+}
+
+func (t *table) sort() {
+	sort.SliceStable(t.rows, func(i, j int) bool {
+		for k := 0; k < t.keyColumns; k++ {
+			if t.rows[i][k] != t.rows[j][k] {
+				return lessValue(t.rows[i][k], t.rows[j][k])
+			}
+		}
+		return false
+	})
+}
+
+// lessValue orders the markets and collaterals numerically and the rest as strings
+func lessValue(a, b string) bool {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return a < b
+}
+
+func buildTables(document *orderBookDocument) map[string]*table {
+	return map[string]*table{
+		ordersTable:    ordersView(document),
+		tradersTable:   tradersView(document),
+		positionsTable: positionsView(document),
+		marginsTable:   marginsView(document),
+		marketsTable:   marketsView(document),
+	}
+}
+
+// orderStatus is the status of the latest lifecycle of the order
+func orderStatus(order orderDocument) string {
+	if len(order.LifecycleList) == 0 {
+		return ""
+	}
+	return order.LifecycleList[len(order.LifecycleList)-1].Status.String()
+}
+
+func isOpen(order orderDocument) bool {
+	status := orderStatus(order)
+	return status == orderbook.Placed.String() || status == orderbook.Amended.String()
+}
+
+func ordersView(document *orderBookDocument) *table {
+	t := &table{
+		name:       ordersTable,
+		header:     []string{"id", "market", "trader", "order_type", "side", "quantity", "filled", "price", "salt", "reduce_only", "block_number", "status"},
+		keyColumns: 1,
+	}
+	for id, order := range document.OrderMap {
+		t.rows = append(t.rows, []string{
+			id.Hex(),
+			formatMarket(order.Market),
+			order.UserAddress,
+			order.OrderType,
+			order.PositionType,
+			order.BaseAssetQuantity,
+			order.FilledBaseAssetQuantity,
+			order.Price,
+			order.Salt,
+			strconv.FormatBool(order.ReduceOnly),
+			strconv.FormatUint(order.BlockNumber, 10),
+			orderStatus(order),
+		})
+	}
+	t.sort()
+	return t
+}
+
+func tradersView(document *orderBookDocument) *table {
+	openOrders := map[common.Address]int{}
+	for _, order := range document.OrderMap {
+		if isOpen(order) {
+			openOrders[common.HexToAddress(order.UserAddress)]++
+		}
+	}
+	t := &table{
+		name:       tradersTable,
+		header:     []string{"trader", "reserved_margin", "positions", "open_orders"},
+		keyColumns: 1,
+	}
+	for address, trader := range document.TraderMap {
+		t.rows = append(t.rows, []string{
+			address.Hex(),
+			formatBigInt(trader.Margin.Reserved),
+			strconv.Itoa(len(trader.Positions)),
+			strconv.Itoa(openOrders[address]),
+		})
+	}
+	t.sort()
+	return t
+}
+
+func positionsView(document *orderBookDocument) *table {
+	t := &table{
+		name:       positionsTable,
+		header:     []string{"trader", "market", "size", "open_notional", "unrealised_funding", "last_premium_fraction", "liquidation_threshold"},
+		keyColumns: 2,
+	}
+	for address, trader := range document.TraderMap {
+		for market, position := range trader.Positions {
+			t.rows = append(t.rows, []string{
+				address.Hex(),
+				formatMarket(market),
+				position.Size,
+				position.OpenNotional,
+				position.UnrealisedFunding,
+				position.LastPremiumFraction,
+				position.LiquidationThreshold,
+			})
+		}
+	}
+	t.sort()
+	return t
+}
+
+func marginsView(document *orderBookDocument) *table {
+	t := &table{
+		name:       marginsTable,
+		header:     []string{"trader", "collateral", "deposited"},
+		keyColumns: 2,
+	}
+	for address, trader := range document.TraderMap {
+		for collateral, deposited := range trader.Margin.Deposited {
+			t.rows = append(t.rows, []string{
+				address.Hex(),
+				strconv.Itoa(int(collateral)),
+				formatBigInt(deposited),
+			})
+		}
+	}
+	t.sort()
+	return t
+}
+
+func marketsView(document *orderBookDocument) *table {
+	markets := map[orderbook.Market]struct{}{}
+	for market := range document.LastPrice {
+		markets[market] = struct{}{}
+	}
+	for market := range document.NextFundingTimes {
+		markets[market] = struct{}{}
+	}
+	t := &table{
+		name:       marketsTable,
+		header:     []string{"market", "last_price", "next_funding_time"},
+		keyColumns: 1,
+	}
+	for market := range markets {
+		nextFundingTime := ""
+		if fundingTime, ok := document.NextFundingTimes[market]; ok {
+			nextFundingTime = strconv.FormatUint(fundingTime, 10)
+		}
+		t.rows = append(t.rows, []string{
+			formatMarket(market),
+			formatBigInt(document.LastPrice[market]),
+			nextFundingTime,
+		})
+	}
+	t.sort()
+	return t
+}
+
+func formatMarket(market orderbook.Market) string {
+	return strconv.FormatInt(int64(market), 10)
+}
+
+func formatBigInt(value *big.Int) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/database/prefixdb"
+	"github.com/ava-labs/avalanchego/database/versiondb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// TestReadSnapshot saves a snapshot through the same database layers as a node and reads it back from leveldb
+func TestReadSnapshot(t *testing.T) {
+	chainID := ids.GenerateTestID()
+	trader := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+
+	memoryDB := orderbook.NewInMemoryDatabase(nil)
+	order := &orderbook.Order{
+		Id:                      common.HexToHash("0x01"),
+		Market:                  1,
+		PositionType:            orderbook.LONG,
+		UserAddress:             trader.Hex(),
+		BaseAssetQuantity:       big.NewInt(5),
+		FilledBaseAssetQuantity: big.NewInt(2),
+		Salt:                    big.NewInt(7),
+		Price:                   big.NewInt(100),
+		BlockNumber:             big.NewInt(10),
+		RawOrder:                &orderbook.LimitOrder{},
+	}
+	memoryDB.Add(order)
+	memoryDB.UpdateMargin(trader, 0, big.NewInt(1000))
+	memoryDB.TraderMap[trader].Positions[1] = &orderbook.Position{
+		Size:                 big.NewInt(2),
+		OpenNotional:         big.NewInt(200),
+		UnrealisedFunding:    big.NewInt(0),
+		LastPremiumFraction:  big.NewInt(0),
+		LiquidationThreshold: big.NewInt(2),
+	}
+	memoryDB.UpdateLastPrice(1, big.NewInt(100))
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&orderbook.Snapshot{Data: memoryDB, AcceptedBlockNumber: big.NewInt(11)}))
+
+	// the layers that avalanchego and the VM put over the node's database
+	baseDB := memdb.New()
+	vmDB := prefixdb.New([]byte("vm"), prefixdb.New(chainID[:], baseDB))
+	versionDB := versiondb.New(vmDB)
+	require.NoError(t, prefixdb.New([]byte(hubbleDBPrefix), versionDB).Put([]byte(memoryDBSnapshotKey), buf.Bytes()))
+	require.NoError(t, versionDB.Commit())
+
+	dir := t.TempDir()
+	db, err := leveldb.OpenFile(dir, nil)
+	require.NoError(t, err)
+	it := baseDB.NewIterator()
+	for it.Next() {
+		require.NoError(t, db.Put(it.Key(), it.Value(), nil))
+	}
+	it.Release()
+	require.NoError(t, db.Close())
+
+	_, err = readSnapshot(dir, ids.GenerateTestID())
+	require.ErrorIs(t, err, errNoSnapshot)
+
+	snapshot, err := readSnapshot(dir, chainID)
+	require.NoError(t, err)
+	document, err := snapshotDocument(snapshot)
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), *document.AcceptedBlockNumber)
+
+	tables := buildTables(document)
+	require.Equal(t, [][]string{{order.Id.Hex(), "1", trader.Hex(), "limit", "long", "5", "2", "100", "7", "false", "10", "placed"}}, tables[ordersTable].rows)
+	require.Equal(t, [][]string{{trader.Hex(), "0", "1", "1"}}, tables[tradersTable].rows)
+	require.Equal(t, [][]string{{trader.Hex(), "1", "2", "200", "0", "0", "2"}}, tables[positionsTable].rows)
+	require.Equal(t, [][]string{{trader.Hex(), "0", "1000"}}, tables[marginsTable].rows)
+	require.Equal(t, [][]string{{"1", "100", ""}}, tables[marketsTable].rows)
+}
+
+func TestWriteTableDiff(t *testing.T) {
+	before := &table{
+		name:       positionsTable,
+		header:     []string{"trader", "market", "size"},
+		keyColumns: 2,
+		rows:       [][]string{{"0xa", "0", "1"}, {"0xa", "1", "2"}, {"0xb", "0", "3"}},
+	}
+	after := &table{
+		name:       positionsTable,
+		header:     []string{"trader", "market", "size"},
+		keyColumns: 2,
+		rows:       [][]string{{"0xa", "0", "1"}, {"0xa", "1", "4"}, {"0xc", "0", "3"}},
+	}
+
+	var buf bytes.Buffer
+	require.Equal(t, 3, writeTableDiff(&buf, before, after))
+	require.Equal(t, `positions
+  - trader=0xb market=0 size=3
+  ~ trader=0xa market=1 size: 2 -> 4
+  + trader=0xc market=0 size=3
+`, buf.String())
+
+	buf.Reset()
+	require.Equal(t, 0, writeTableDiff(&buf, after, after))
+	require.Empty(t, buf.String())
+}
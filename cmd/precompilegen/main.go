@@ -66,6 +66,10 @@ var (
 		Name:  "out",
 		Usage: "Output folder for the generated precompile files, - for STDOUT (default = ./precompile/contracts/{pkg}). Test files won't be generated if STDOUT is used",
 	}
+	storageLayoutFlag = &cli.StringSliceFlag{
+		Name:  "storage-layout",
+		Usage: "Path to the solc storage layout json of a contract whose storage the precompile reads, can be repeated. Generates storage.go with readers of its state variables, prefixed by {layout file name}. Only storage.go is generated if --abi is not set",
+	}
 )
 
 var app = flags.NewApp("subnet-evm precompile generator tool")
@@ -77,11 +81,17 @@ func init() {
 		outFlag,
 		pkgFlag,
 		typeFlag,
+		storageLayoutFlag,
 	}
 	app.Action = precompilegen
 }
 
 func precompilegen(c *cli.Context) error {
+	storageLayouts := c.StringSlice(storageLayoutFlag.Name)
+	if c.String(abiFlag.Name) == "" && len(storageLayouts) > 0 {
+		return storagegen(c, storageLayouts)
+	}
+
 	outFlagStr := c.String(outFlag.Name)
 	isOutStdout := outFlagStr == "-"
 
@@ -158,6 +168,11 @@ func precompilegen(c *cli.Context) error {
 	contractCode := bindedFiles.Contract
 	moduleCode := bindedFiles.Module
 
+	storageCode := ""
+	if len(storageLayouts) > 0 {
+		storageCode = bindStorage(storageLayouts, pkg)
+	}
+
 	// Either flush it out to a file or display on the standard output
 	// Skip displaying test codes here.
 	if isOutStdout {
@@ -167,6 +182,10 @@ func precompilegen(c *cli.Context) error {
 		fmt.Printf("%s\n", contractCode)
 		fmt.Print("-----Module Code-----\n")
 		fmt.Printf("%s\n", moduleCode)
+		if storageCode != "" {
+			fmt.Print("-----Storage Code-----\n")
+			fmt.Printf("%s\n", storageCode)
+		}
 		return nil
 	}
 
@@ -192,6 +211,11 @@ func precompilegen(c *cli.Context) error {
 		utils.Fatalf("Failed to write generated module code: %v", err)
 	}
 
+	// Write the generated storage readers to the output folder
+	if storageCode != "" {
+		writeStorageCode(outFlagStr, storageCode)
+	}
+
 	// Write the ABI to the output folder
 	if err := os.WriteFile(abipath, []byte(abis[0]), 0o600); err != nil {
 		utils.Fatalf("Failed to write ABI: %v", err)
@@ -222,6 +246,55 @@ func precompilegen(c *cli.Context) error {
 	return nil
 }
 
+// storagegen only generates the storage readers, for a precompile that already exists
+func storagegen(c *cli.Context, storageLayouts []string) error {
+	pkg := c.String(pkgFlag.Name)
+	if pkg == "" {
+		utils.Fatalf("package (--pkg) should be set explicitly to generate storage readers without an abi")
+	}
+	outFlagStr := c.String(outFlag.Name)
+	if outFlagStr == "" {
+		outFlagStr = filepath.Join("./precompile/contracts", pkg)
+	}
+	storageCode := bindStorage(storageLayouts, pkg)
+	if outFlagStr == "-" {
+		fmt.Printf("%s\n", storageCode)
+		return nil
+	}
+	if _, err := os.Stat(outFlagStr); os.IsNotExist(err) {
+		os.MkdirAll(outFlagStr, 0o700)
+	}
+	writeStorageCode(outFlagStr, storageCode)
+	fmt.Println("Storage readers generated successfully at: ", outFlagStr)
+	return nil
+}
+
+// bindStorage generates the readers of the contracts of [storageLayouts], which are named after the layout files
+func bindStorage(storageLayouts []string, pkg string) string {
+	var types, layouts []string
+	for _, path := range storageLayouts {
+		layout, err := os.ReadFile(path)
+		if err != nil {
+			utils.Fatalf("Failed to read storage layout: %v", err)
+		}
+		fn := filepath.Base(path)
+		types = append(types, strings.TrimSpace(strings.TrimSuffix(fn, filepath.Ext(fn))))
+		layouts = append(layouts, string(layout))
+	}
+	storageCode, err := precompilebind.StorageBind(types, layouts, pkg)
+	if err != nil {
+		utils.Fatalf("Failed to generate storage readers: %v", err)
+	}
+	return storageCode
+}
+
+func writeStorageCode(outFlagStr string, storageCode string) {
+	storageCodeOut := filepath.Join(outFlagStr, "storage.go")
+	if err := os.WriteFile(storageCodeOut, []byte(storageCode), 0o600); err != nil {
+		utils.Fatalf("Failed to write generated storage code: %v", err)
+	}
+}
+
 func main() {
 	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
 
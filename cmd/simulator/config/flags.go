@@ -28,12 +28,39 @@ const (
 	TimeoutKey        = "timeout"
 	BatchSizeKey      = "batch-size"
 	MetricsPortKey    = "metrics-port"
+	ModeKey           = "mode"
+
+	MarketsKey           = "markets"
+	MarginKey            = "margin"
+	OrderSizeKey         = "order-size"
+	OrdersPerTxKey       = "orders-per-tx"
+	PriceSpreadKey       = "price-spread"
+	PriceDistributionKey = "price-distribution"
+	PriceDriftKey        = "price-drift"
+	IOCRatioKey          = "ioc-ratio"
+	IOCExpiryKey         = "ioc-expiry"
+	CancelRatioKey       = "cancel-ratio"
+)
+
+// Workload modes
+const (
+	// TransferMode issues plain value transfers
+	TransferMode = "transfer"
+	// TradingMode funds traders with margin and places and cancels orders on the orderbooks
+	TradingMode = "trading"
+)
+
+// Distributions of the order prices around the oracle price in TradingMode
+const (
+	UniformDistribution = "uniform"
+	NormalDistribution  = "normal"
 )
 
 var (
 	ErrNoEndpoints = errors.New("must specify at least one endpoint")
 	ErrNoWorkers   = errors.New("must specify non-zero number of workers")
 	ErrNoTxs       = errors.New("must specify non-zero number of txs-per-worker")
+	ErrNoMarkets   = errors.New("must specify at least one market in trading mode")
 )
 
 type Config struct {
@@ -46,6 +73,19 @@ type Config struct {
 	Timeout      time.Duration `json:"timeout"`
 	BatchSize    uint64        `json:"batch-size"`
 	MetricsPort  uint64        `json:"metrics-port"`
+	Mode         string        `json:"mode"`
+
+	// TradingMode options
+	Markets           []int         `json:"markets"`
+	Margin            float64       `json:"margin"`
+	OrderSize         float64       `json:"order-size"`
+	OrdersPerTx       int           `json:"orders-per-tx"`
+	PriceSpread       float64       `json:"price-spread"`
+	PriceDistribution string        `json:"price-distribution"`
+	PriceDrift        float64       `json:"price-drift"`
+	IOCRatio          float64       `json:"ioc-ratio"`
+	IOCExpiry         time.Duration `json:"ioc-expiry"`
+	CancelRatio       float64       `json:"cancel-ratio"`
 }
 
 func BuildConfig(v *viper.Viper) (Config, error) {
@@ -59,6 +99,18 @@ func BuildConfig(v *viper.Viper) (Config, error) {
 		Timeout:      v.GetDuration(TimeoutKey),
 		BatchSize:    v.GetUint64(BatchSizeKey),
 		MetricsPort:  v.GetUint64(MetricsPortKey),
+		Mode:         v.GetString(ModeKey),
+
+		Markets:           v.GetIntSlice(MarketsKey),
+		Margin:            v.GetFloat64(MarginKey),
+		OrderSize:         v.GetFloat64(OrderSizeKey),
+		OrdersPerTx:       v.GetInt(OrdersPerTxKey),
+		PriceSpread:       v.GetFloat64(PriceSpreadKey),
+		PriceDistribution: v.GetString(PriceDistributionKey),
+		PriceDrift:        v.GetFloat64(PriceDriftKey),
+		IOCRatio:          v.GetFloat64(IOCRatioKey),
+		IOCExpiry:         v.GetDuration(IOCExpiryKey),
+		CancelRatio:       v.GetFloat64(CancelRatioKey),
 	}
 	if len(c.Endpoints) == 0 {
 		return c, ErrNoEndpoints
@@ -77,9 +129,54 @@ func BuildConfig(v *viper.Viper) (Config, error) {
 	if c.MaxTipCap < 0 {
 		return c, fmt.Errorf("invalid max tip cap %d <= 0", c.MaxTipCap)
 	}
+	switch c.Mode {
+	case TransferMode:
+	case TradingMode:
+		if err := validateTradingConfig(c); err != nil {
+			return c, err
+		}
+	default:
+		return c, fmt.Errorf("invalid mode %q, must be one of %q or %q", c.Mode, TransferMode, TradingMode)
+	}
 	return c, nil
 }
 
+func validateTradingConfig(c Config) error {
+	if len(c.Markets) == 0 {
+		return ErrNoMarkets
+	}
+	for _, market := range c.Markets {
+		if market < 0 {
+			return fmt.Errorf("invalid market %d < 0", market)
+		}
+	}
+	if c.Margin <= 0 {
+		return fmt.Errorf("invalid margin %f <= 0", c.Margin)
+	}
+	if c.OrderSize <= 0 {
+		return fmt.Errorf("invalid order size %f <= 0", c.OrderSize)
+	}
+	if c.OrdersPerTx <= 0 {
+		return fmt.Errorf("invalid orders per tx %d <= 0", c.OrdersPerTx)
+	}
+	if c.PriceSpread < 0 || c.PriceSpread >= 1 {
+		return fmt.Errorf("invalid price spread %f, must be in [0, 1)", c.PriceSpread)
+	}
+	if c.PriceDistribution != UniformDistribution && c.PriceDistribution != NormalDistribution {
+		return fmt.Errorf("invalid price distribution %q, must be one of %q or %q", c.PriceDistribution, UniformDistribution, NormalDistribution)
+	}
+	if c.PriceDrift <= -1 || c.PriceDrift >= 1 {
+		return fmt.Errorf("invalid price drift %f, must be in (-1, 1)", c.PriceDrift)
+	}
+	if c.IOCRatio < 0 || c.CancelRatio < 0 || c.IOCRatio+c.CancelRatio > 1 {
+		return fmt.Errorf("invalid ioc ratio %f and cancel ratio %f, must be >= 0 and add up to at most 1", c.IOCRatio, c.CancelRatio)
+	}
+	if c.IOCRatio > 0 && c.IOCExpiry <= 0 {
+		return fmt.Errorf("invalid ioc expiry %s <= 0", c.IOCExpiry)
+	}
+	return nil
+}
+
 func BuildViper(fs *pflag.FlagSet, args []string) (*viper.Viper, error) {
 	if err := fs.Parse(args); err != nil {
 		return nil, err
@@ -122,4 +219,17 @@ func addSimulatorFlags(fs *pflag.FlagSet) {
 	fs.String(LogLevelKey, "info", "Specify the log level to use in the simulator")
 	fs.Uint64(BatchSizeKey, 100, "Specify the batchsize for the worker to issue and confirm txs")
 	fs.Uint64(MetricsPortKey, 8082, "Specify the port to use for the metrics server")
+	fs.String(ModeKey, TransferMode, fmt.Sprintf("Specify the workload to run, %q issues value transfers and %q places and cancels orders on the orderbooks", TransferMode, TradingMode))
+
+	// Trading workload
+	fs.IntSlice(MarketsKey, []int{0}, "Specify a comma separated list of market indexes to place orders in")
+	fs.Float64(MarginKey, 1000, "Specify the margin in USD that each trader deposits before placing orders")
+	fs.Float64(OrderSizeKey, 0.1, "Specify the absolute base asset quantity of each order (must be a multiple of the market's min size)")
+	fs.Int(OrdersPerTxKey, 1, "Specify the number of orders to place or cancel in each transaction")
+	fs.Float64(PriceSpreadKey, 0.01, "Specify the spread of the order prices around the oracle price as a fraction of it")
+	fs.String(PriceDistributionKey, UniformDistribution, fmt.Sprintf("Specify the distribution of the order prices around the oracle price, one of %q or %q", UniformDistribution, NormalDistribution))
+	fs.Float64(PriceDriftKey, 0, "Specify the fraction by which each trader moves its price center after every order transaction, a non-zero drift pushes the mark price away from the oracle to trigger liquidations")
+	fs.Float64(IOCRatioKey, 0, "Specify the fraction of order transactions that place IOC orders instead of limit orders")
+	fs.Duration(IOCExpiryKey, 5*time.Second, "Specify how long IOC orders are valid for (must not exceed the IOC orderbook's expiration cap)")
+	fs.Float64(CancelRatioKey, 0.1, "Specify the fraction of order transactions that cancel previously placed limit orders")
 }
@@ -0,0 +1,177 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package load
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/cmd/simulator/metrics"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/ethclient"
+	"github.com/ava-labs/subnet-evm/interfaces"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// fillTracker measures the time from issuing an order to its first fill. Orders are identified by their salt until
+// their OrderPlaced log reveals their hash, after which the OrdersMatched, OrderMatched and LiquidationOrderMatched logs are matched by hash.
+type fillTracker struct {
+	metrics *metrics.Metrics
+
+	orderBookABI    abi.ABI
+	iocOrderBookABI abi.ABI
+
+	lock sync.Mutex
+	// tx hash => salts of the orders placed by the tx
+	txOrders map[common.Hash][]*big.Int
+	// salt => when the tx placing the order was issued
+	issued map[string]time.Time
+	// order hash => when the tx placing the order was issued, until the order is filled or cancelled
+	placed map[common.Hash]time.Time
+}
+
+func newFillTracker(m *metrics.Metrics) (*fillTracker, error) {
+	orderBookABI, err := abi.FromSolidityJson(string(abis.OrderBookAbi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse orderbook abi: %w", err)
+	}
+	iocOrderBookABI, err := abi.FromSolidityJson(string(abis.IOCOrderBookAbi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ioc orderbook abi: %w", err)
+	}
+	return &fillTracker{
+		metrics:         m,
+		orderBookABI:    orderBookABI,
+		iocOrderBookABI: iocOrderBookABI,
+		txOrders:        make(map[common.Hash][]*big.Int),
+		issued:          make(map[string]time.Time),
+		placed:          make(map[common.Hash]time.Time),
+	}, nil
+}
+
+// ordersGenerated records the salts of the orders placed by [txHash]
+func (f *fillTracker) ordersGenerated(txHash common.Hash, salts []*big.Int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.txOrders[txHash] = salts
+}
+
+// txIssued starts the clock of the orders placed by [txHash], if any
+func (f *fillTracker) txIssued(txHash common.Hash, now time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, salt := range f.txOrders[txHash] {
+		f.issued[salt.String()] = now
+	}
+	delete(f.txOrders, txHash)
+}
+
+// Run subscribes to the orderbook logs and records the order to fill latencies until [ctx] is done
+func (f *fillTracker) Run(ctx context.Context, client ethclient.Client) error {
+	query := interfaces.FilterQuery{
+		Addresses: []common.Address{orderbook.OrderBookContractAddress, orderbook.IOCOrderBookContractAddress},
+		Topics: [][]common.Hash{{
+			f.orderBookABI.Events["OrderPlaced"].ID,
+			f.iocOrderBookABI.Events["OrderPlaced"].ID,
+			f.orderBookABI.Events["OrdersMatched"].ID,
+			f.orderBookABI.Events["OrderMatched"].ID,
+			f.orderBookABI.Events["LiquidationOrderMatched"].ID,
+			f.orderBookABI.Events["OrderCancelled"].ID,
+		}},
+	}
+	logs := make(chan types.Log)
+	sub, err := client.SubscribeFilterLogs(ctx, query, logs)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to orderbook logs: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case l := <-logs:
+			f.handleLog(l, time.Now())
+		case err := <-sub.Err():
+			return fmt.Errorf("orderbook logs subscription failed: %w", err)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (f *fillTracker) handleLog(l types.Log, now time.Time) {
+	// a log is removed when its block is rejected, the order is seen again when it is placed in another block
+	if l.Removed || len(l.Topics) < 3 {
+		return
+	}
+	orderHash := l.Topics[2]
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case l.Address == orderbook.OrderBookContractAddress && l.Topics[0] == f.orderBookABI.Events["OrderPlaced"].ID:
+		f.orderPlaced(f.orderBookABI, l, orderHash)
+	case l.Address == orderbook.IOCOrderBookContractAddress && l.Topics[0] == f.iocOrderBookABI.Events["OrderPlaced"].ID:
+		f.orderPlaced(f.iocOrderBookABI, l, orderHash)
+	case l.Topics[0] == f.orderBookABI.Events["OrdersMatched"].ID:
+		// both orders of the match are indexed
+		f.orderFilled(l.Topics[1], now)
+		f.orderFilled(orderHash, now)
+	case l.Topics[0] == f.orderBookABI.Events["OrderMatched"].ID, l.Topics[0] == f.orderBookABI.Events["LiquidationOrderMatched"].ID:
+		f.orderFilled(orderHash, now)
+	case l.Topics[0] == f.orderBookABI.Events["OrderCancelled"].ID:
+		delete(f.placed, orderHash)
+	}
+}
+
+func (f *fillTracker) orderFilled(orderHash common.Hash, now time.Time) {
+	issuedAt, ok := f.placed[orderHash]
+	if !ok {
+		return
+	}
+	delete(f.placed, orderHash)
+	f.metrics.OrderToFillTimes.Observe(now.Sub(issuedAt).Seconds())
+	f.metrics.OrdersFilled.Inc()
+}
+
+func (f *fillTracker) orderPlaced(contractABI abi.ABI, l types.Log, orderHash common.Hash) {
+	args := map[string]interface{}{}
+	if err := contractABI.UnpackIntoMap(args, "OrderPlaced", l.Data); err != nil {
+		log.Warn("failed to unpack OrderPlaced log", "orderHash", orderHash, "err", err)
+		return
+	}
+	order := orderbook.LimitOrder{}
+	order.DecodeFromRawOrder(args["order"])
+	if order.Salt == nil {
+		return
+	}
+	issuedAt, ok := f.issued[order.Salt.String()]
+	if !ok {
+		// placed by someone else or by an earlier run
+		return
+	}
+	delete(f.issued, order.Salt.String())
+	f.placed[orderHash] = issuedAt
+	f.metrics.OrdersPlaced.Inc()
+}
+
+// tradingTxWorker starts the order to fill clock of the orders in a tx right before issuing it
+type tradingTxWorker struct {
+	*singleAddressTxWorker
+	fillTracker *fillTracker
+}
+
+func (tw *tradingTxWorker) IssueTx(ctx context.Context, tx *types.Transaction) error {
+	tw.fillTracker.txIssued(tx.Hash(), time.Now())
+	return tw.singleAddressTxWorker.IssueTx(ctx, tx)
+}
@@ -0,0 +1,193 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package load
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/subnet-evm/cmd/simulator/metrics"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+var testTrader = common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+
+func newTestFillTracker(t *testing.T) *fillTracker {
+	tracker, err := newFillTracker(metrics.NewMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	return tracker
+}
+
+func testLimitOrder(salt int64) orderbook.LimitOrder {
+	return orderbook.LimitOrder{
+		AmmIndex:          big.NewInt(0),
+		Trader:            testTrader,
+		BaseAssetQuantity: big.NewInt(1e18),
+		Price:             big.NewInt(1e6),
+		Salt:              big.NewInt(salt),
+		ReduceOnly:        false,
+	}
+}
+
+func (f *fillTracker) orderPlacedLog(t *testing.T, order orderbook.LimitOrder, orderHash common.Hash) types.Log {
+	event := f.orderBookABI.Events["OrderPlaced"]
+	data, err := event.Inputs.NonIndexed().Pack(order, big.NewInt(1))
+	require.NoError(t, err)
+	return types.Log{
+		Address: orderbook.OrderBookContractAddress,
+		Topics:  []common.Hash{event.ID, testTrader.Hash(), orderHash},
+		Data:    data,
+	}
+}
+
+func (f *fillTracker) iocOrderPlacedLog(t *testing.T, order orderbook.IOCOrder, orderHash common.Hash) types.Log {
+	event := f.iocOrderBookABI.Events["OrderPlaced"]
+	data, err := event.Inputs.NonIndexed().Pack(order, big.NewInt(1))
+	require.NoError(t, err)
+	return types.Log{
+		Address: orderbook.IOCOrderBookContractAddress,
+		Topics:  []common.Hash{event.ID, testTrader.Hash(), orderHash},
+		Data:    data,
+	}
+}
+
+func (f *fillTracker) orderMatchedLog(orderHash common.Hash) types.Log {
+	return types.Log{
+		Address: orderbook.OrderBookContractAddress,
+		Topics:  []common.Hash{f.orderBookABI.Events["OrderMatched"].ID, testTrader.Hash(), orderHash},
+	}
+}
+
+func (f *fillTracker) ordersMatchedLog(orderHash0 common.Hash, orderHash1 common.Hash) types.Log {
+	return types.Log{
+		Address: orderbook.OrderBookContractAddress,
+		Topics:  []common.Hash{f.orderBookABI.Events["OrdersMatched"].ID, orderHash0, orderHash1},
+	}
+}
+
+func (f *fillTracker) orderCancelledLog(orderHash common.Hash) types.Log {
+	return types.Log{
+		Address: orderbook.OrderBookContractAddress,
+		Topics:  []common.Hash{f.orderBookABI.Events["OrderCancelled"].ID, testTrader.Hash(), orderHash},
+	}
+}
+
+func orderToFillSamples(t *testing.T, m *metrics.Metrics) (uint64, float64) {
+	metric := &dto.Metric{}
+	require.NoError(t, m.OrderToFillTimes.Write(metric))
+	return metric.GetSummary().GetSampleCount(), metric.GetSummary().GetSampleSum()
+}
+
+func TestFillTracker(t *testing.T) {
+	issuedAt := time.Unix(1000, 0)
+	txHash := common.HexToHash("0x1")
+	orderHash := common.HexToHash("0xa")
+
+	t.Run("records the time from issuing to the first fill", func(t *testing.T) {
+		require := require.New(t)
+		tracker := newTestFillTracker(t)
+		order := testLimitOrder(1)
+		tracker.ordersGenerated(txHash, []*big.Int{order.Salt})
+		tracker.txIssued(txHash, issuedAt)
+
+		tracker.handleLog(tracker.orderPlacedLog(t, order, orderHash), issuedAt.Add(time.Second))
+		require.Equal(1.0, testutil.ToFloat64(tracker.metrics.OrdersPlaced))
+
+		tracker.handleLog(tracker.orderMatchedLog(orderHash), issuedAt.Add(3*time.Second))
+		require.Equal(1.0, testutil.ToFloat64(tracker.metrics.OrdersFilled))
+		count, sum := orderToFillSamples(t, tracker.metrics)
+		require.Equal(uint64(1), count)
+		require.Equal(3.0, sum)
+
+		// only the first fill is recorded
+		tracker.handleLog(tracker.orderMatchedLog(orderHash), issuedAt.Add(5*time.Second))
+		require.Equal(1.0, testutil.ToFloat64(tracker.metrics.OrdersFilled))
+	})
+
+	t.Run("both orders of a match are filled", func(t *testing.T) {
+		require := require.New(t)
+		tracker := newTestFillTracker(t)
+		long, short := testLimitOrder(1), testLimitOrder(2)
+		short.BaseAssetQuantity = big.NewInt(-1e18)
+		longHash, shortHash := common.HexToHash("0xa"), common.HexToHash("0xb")
+		tracker.ordersGenerated(txHash, []*big.Int{long.Salt, short.Salt})
+		tracker.txIssued(txHash, issuedAt)
+		tracker.handleLog(tracker.orderPlacedLog(t, long, longHash), issuedAt)
+		tracker.handleLog(tracker.orderPlacedLog(t, short, shortHash), issuedAt)
+		require.Equal(2.0, testutil.ToFloat64(tracker.metrics.OrdersPlaced))
+
+		tracker.handleLog(tracker.ordersMatchedLog(longHash, shortHash), issuedAt.Add(time.Second))
+		require.Equal(2.0, testutil.ToFloat64(tracker.metrics.OrdersFilled))
+	})
+
+	t.Run("ioc orders are tracked", func(t *testing.T) {
+		require := require.New(t)
+		tracker := newTestFillTracker(t)
+		order := orderbook.IOCOrder{LimitOrder: testLimitOrder(1), OrderType: 1, ExpireAt: big.NewInt(2000)}
+		tracker.ordersGenerated(txHash, []*big.Int{order.Salt})
+		tracker.txIssued(txHash, issuedAt)
+
+		tracker.handleLog(tracker.iocOrderPlacedLog(t, order, orderHash), issuedAt)
+		tracker.handleLog(tracker.orderMatchedLog(orderHash), issuedAt.Add(time.Second))
+		require.Equal(1.0, testutil.ToFloat64(tracker.metrics.OrdersPlaced))
+		require.Equal(1.0, testutil.ToFloat64(tracker.metrics.OrdersFilled))
+	})
+
+	t.Run("orders of other traders are ignored", func(t *testing.T) {
+		require := require.New(t)
+		tracker := newTestFillTracker(t)
+		tracker.handleLog(tracker.orderPlacedLog(t, testLimitOrder(1), orderHash), issuedAt)
+		tracker.handleLog(tracker.orderMatchedLog(orderHash), issuedAt)
+		require.Equal(0.0, testutil.ToFloat64(tracker.metrics.OrdersPlaced))
+		require.Equal(0.0, testutil.ToFloat64(tracker.metrics.OrdersFilled))
+	})
+
+	t.Run("orders are tracked from when their tx is issued", func(t *testing.T) {
+		require := require.New(t)
+		tracker := newTestFillTracker(t)
+		order := testLimitOrder(1)
+		// the tx was generated but never issued
+		tracker.ordersGenerated(txHash, []*big.Int{order.Salt})
+		tracker.handleLog(tracker.orderPlacedLog(t, order, orderHash), issuedAt)
+		require.Equal(0.0, testutil.ToFloat64(tracker.metrics.OrdersPlaced))
+	})
+
+	t.Run("removed logs are ignored", func(t *testing.T) {
+		require := require.New(t)
+		tracker := newTestFillTracker(t)
+		order := testLimitOrder(1)
+		tracker.ordersGenerated(txHash, []*big.Int{order.Salt})
+		tracker.txIssued(txHash, issuedAt)
+
+		removedLog := tracker.orderPlacedLog(t, order, orderHash)
+		removedLog.Removed = true
+		tracker.handleLog(removedLog, issuedAt)
+		require.Equal(0.0, testutil.ToFloat64(tracker.metrics.OrdersPlaced))
+
+		// the order is placed again in another block
+		tracker.handleLog(tracker.orderPlacedLog(t, order, orderHash), issuedAt)
+		require.Equal(1.0, testutil.ToFloat64(tracker.metrics.OrdersPlaced))
+	})
+
+	t.Run("cancelled orders are not tracked anymore", func(t *testing.T) {
+		require := require.New(t)
+		tracker := newTestFillTracker(t)
+		order := testLimitOrder(1)
+		tracker.ordersGenerated(txHash, []*big.Int{order.Salt})
+		tracker.txIssued(txHash, issuedAt)
+		tracker.handleLog(tracker.orderPlacedLog(t, order, orderHash), issuedAt)
+
+		tracker.handleLog(tracker.orderCancelledLog(orderHash), issuedAt.Add(time.Second))
+		tracker.handleLog(tracker.orderMatchedLog(orderHash), issuedAt.Add(2*time.Second))
+		require.Equal(0.0, testutil.ToFloat64(tracker.metrics.OrdersFilled))
+		require.Empty(tracker.placed)
+	})
+}
@@ -17,7 +17,7 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/ava-labs/subnet-evm/cmd/simulator/config"
+	simulatorConfig "github.com/ava-labs/subnet-evm/cmd/simulator/config"
 	"github.com/ava-labs/subnet-evm/cmd/simulator/key"
 	"github.com/ava-labs/subnet-evm/cmd/simulator/metrics"
 	"github.com/ava-labs/subnet-evm/cmd/simulator/txs"
@@ -37,7 +37,7 @@ const (
 )
 
 // ExecuteLoader creates txSequences from [config] and has txAgents execute the specified simulation.
-func ExecuteLoader(ctx context.Context, config config.Config) error {
+func ExecuteLoader(ctx context.Context, config simulatorConfig.Config) error {
 	if config.Timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
@@ -91,16 +91,41 @@ func ExecuteLoader(ctx context.Context, config config.Config) error {
 		}
 	}
 
-	// Each address needs: params.GWei * MaxFeeCap * params.TxGas * TxsPerWorker total wei
-	// to fund gas for all of their transactions.
-	maxFeeCap := new(big.Int).Mul(big.NewInt(params.GWei), big.NewInt(config.MaxFeeCap))
-	minFundsPerAddr := new(big.Int).Mul(maxFeeCap, big.NewInt(int64(config.TxsPerWorker*params.TxGas)))
+	bigGwei := big.NewInt(params.GWei)
+	gasTipCap := new(big.Int).Mul(bigGwei, big.NewInt(config.MaxTipCap))
+	gasFeeCap := new(big.Int).Mul(bigGwei, big.NewInt(config.MaxFeeCap))
+	client := clients[0]
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch chainID: %w", err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
 
 	// Create metrics
 	reg := prometheus.NewRegistry()
 	m := metrics.NewMetrics(reg)
 	metricsPort := strconv.Itoa(int(config.MetricsPort))
 
+	// Each address needs: params.GWei * MaxFeeCap * params.TxGas * TxsPerWorker total wei
+	// to fund gas for all of their transactions.
+	minFundsPerAddr := new(big.Int).Mul(gasFeeCap, big.NewInt(int64(config.TxsPerWorker*params.TxGas)))
+
+	var (
+		trading     *tradingWorkload
+		fillTracker *fillTracker
+	)
+	if config.Mode == simulatorConfig.TradingMode {
+		fillTracker, err = newFillTracker(m)
+		if err != nil {
+			return err
+		}
+		trading, err = newTradingWorkload(ctx, config, client, chainID, gasTipCap, gasFeeCap, fillTracker)
+		if err != nil {
+			return fmt.Errorf("failed to set up trading workload: %w", err)
+		}
+		minFundsPerAddr = trading.minFundsPerAddr()
+	}
+
 	log.Info("Distributing funds", "numTxsPerWorker", config.TxsPerWorker, "minFunds", minFundsPerAddr)
 	keys, err = DistributeFunds(ctx, clients[0], keys, config.Workers, minFundsPerAddr, m)
 	if err != nil {
@@ -115,43 +140,54 @@ func ExecuteLoader(ctx context.Context, config config.Config) error {
 		senders = append(senders, key.Address)
 	}
 
-	bigGwei := big.NewInt(params.GWei)
-	gasTipCap := new(big.Int).Mul(bigGwei, big.NewInt(config.MaxTipCap))
-	gasFeeCap := new(big.Int).Mul(bigGwei, big.NewInt(config.MaxFeeCap))
-	client := clients[0]
-	chainID, err := client.ChainID(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to fetch chainID: %w", err)
-	}
-	signer := types.LatestSignerForChainID(chainID)
-
-	log.Info("Creating transaction sequences...")
-	txGenerator := func(key *ecdsa.PrivateKey, nonce uint64) (*types.Transaction, error) {
-		addr := ethcrypto.PubkeyToAddress(key.PublicKey)
-		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
-			ChainID:   chainID,
-			Nonce:     nonce,
-			GasTipCap: gasTipCap,
-			GasFeeCap: gasFeeCap,
-			Gas:       params.TxGas,
-			To:        &addr,
-			Data:      nil,
-			Value:     common.Big0,
-		})
+	log.Info("Creating transaction sequences...", "mode", config.Mode)
+	var txSequences []txs.TxSequence[*types.Transaction]
+	if trading != nil {
+		txSequences, err = trading.txSequences(ctx, client, pks)
 		if err != nil {
-			return nil, err
+			return err
+		}
+	} else {
+		txGenerator := func(key *ecdsa.PrivateKey, nonce uint64) (*types.Transaction, error) {
+			addr := ethcrypto.PubkeyToAddress(key.PublicKey)
+			tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+				ChainID:   chainID,
+				Nonce:     nonce,
+				GasTipCap: gasTipCap,
+				GasFeeCap: gasFeeCap,
+				Gas:       params.TxGas,
+				To:        &addr,
+				Data:      nil,
+				Value:     common.Big0,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return tx, nil
+		}
+		txSequences, err = txs.GenerateTxSequences(ctx, txGenerator, clients[0], pks, config.TxsPerWorker)
+		if err != nil {
+			return err
 		}
-		return tx, nil
-	}
-	txSequences, err := txs.GenerateTxSequences(ctx, txGenerator, clients[0], pks, config.TxsPerWorker)
-	if err != nil {
-		return err
 	}
 
 	log.Info("Constructing tx agents...", "numAgents", config.Workers)
 	agents := make([]txs.Agent[*types.Transaction], 0, config.Workers)
 	for i := 0; i < config.Workers; i++ {
-		agents = append(agents, txs.NewIssueNAgent[*types.Transaction](txSequences[i], NewSingleAddressTxWorker(ctx, clients[i], senders[i]), config.BatchSize, m))
+		addressWorker := NewSingleAddressTxWorker(ctx, clients[i], senders[i])
+		var worker txs.Worker[*types.Transaction] = addressWorker
+		if fillTracker != nil {
+			worker = &tradingTxWorker{singleAddressTxWorker: addressWorker, fillTracker: fillTracker}
+		}
+		agents = append(agents, txs.NewIssueNAgent[*types.Transaction](txSequences[i], worker, config.BatchSize, m))
+	}
+
+	if fillTracker != nil {
+		go func() {
+			if err := fillTracker.Run(ctx, client); err != nil {
+				log.Warn("Order to fill times are not measured", "err", err)
+			}
+		}()
 	}
 
 	log.Info("Starting tx agents...")
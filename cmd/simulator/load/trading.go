@@ -0,0 +1,331 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package load
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/cmd/simulator/config"
+	"github.com/ava-labs/subnet-evm/cmd/simulator/txs"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/ethclient"
+	"github.com/ava-labs/subnet-evm/interfaces"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// Gas limit of a margin deposit through the MarginAccountHelper
+	marginTxGas uint64 = 500_000
+	// Gas limit of an order tx per order placed or cancelled, orders reserve and release margin which costs a lot more than a transfer
+	orderGas uint64 = 500_000
+
+	// Margin and prices have 6 decimals, base asset quantities have 18
+	priceDecimals    = 6
+	quantityDecimals = 18
+)
+
+// The Go MarginAccount ABI does not include the helper, which wraps the native gas token into the margin collateral
+const marginAccountHelperAbi = `[
+	{"inputs": [], "name": "marginAccountHelper", "outputs": [{"internalType": "address", "name": "", "type": "address"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [{"internalType": "uint256", "name": "amount", "type": "uint256"}, {"internalType": "address", "name": "to", "type": "address"}], "name": "addVUSDMarginWithReserve", "outputs": [], "stateMutability": "payable", "type": "function"}
+]`
+
+// the native gas token has 18 decimals, the margin collateral has 6
+var marginToGasToken = big.NewInt(1e12)
+
+// tradingWorkload funds traders with margin and has them place and cancel limit and IOC orders around the oracle price.
+type tradingWorkload struct {
+	config    config.Config
+	chainID   *big.Int
+	signer    types.Signer
+	gasTipCap *big.Int
+	gasFeeCap *big.Int
+
+	orderBookABI    abi.ABI
+	iocOrderBookABI abi.ABI
+	marginHelperABI abi.ABI
+	marginHelper    common.Address
+	marginAmount    *big.Int
+	orderSize       *big.Int
+	oraclePrices    map[int]float64
+	orderTxGas      uint64
+	fillTracker     *fillTracker
+}
+
+func newTradingWorkload(ctx context.Context, config config.Config, client ethclient.Client, chainID *big.Int, gasTipCap *big.Int, gasFeeCap *big.Int, tracker *fillTracker) (*tradingWorkload, error) {
+	orderBookABI, err := abi.FromSolidityJson(string(abis.OrderBookAbi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse orderbook abi: %w", err)
+	}
+	iocOrderBookABI, err := abi.FromSolidityJson(string(abis.IOCOrderBookAbi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ioc orderbook abi: %w", err)
+	}
+	clearingHouseABI, err := abi.FromSolidityJson(string(abis.ClearingHouseAbi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse clearing house abi: %w", err)
+	}
+	marginHelperABI, err := abi.JSON(strings.NewReader(marginAccountHelperAbi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse margin account helper abi: %w", err)
+	}
+
+	w := &tradingWorkload{
+		config:          config,
+		chainID:         chainID,
+		signer:          types.LatestSignerForChainID(chainID),
+		gasTipCap:       gasTipCap,
+		gasFeeCap:       gasFeeCap,
+		orderBookABI:    orderBookABI,
+		iocOrderBookABI: iocOrderBookABI,
+		marginHelperABI: marginHelperABI,
+		marginAmount:    scaleDecimal(config.Margin, priceDecimals),
+		orderSize:       scaleDecimal(config.OrderSize, quantityDecimals),
+		oraclePrices:    make(map[int]float64, len(config.Markets)),
+		orderTxGas:      orderGas * uint64(config.OrdersPerTx),
+		fillTracker:     tracker,
+	}
+
+	var helper common.Address
+	if err := callContract(ctx, client, orderbook.MarginAccountContractAddress, marginHelperABI, "marginAccountHelper", &helper); err != nil {
+		return nil, err
+	}
+	w.marginHelper = helper
+
+	var prices []*big.Int
+	if err := callContract(ctx, client, orderbook.ClearingHouseContractAddress, clearingHouseABI, "getUnderlyingPrice", &prices); err != nil {
+		return nil, err
+	}
+	for _, market := range config.Markets {
+		if market >= len(prices) {
+			return nil, fmt.Errorf("market %d does not exist, there are %d markets", market, len(prices))
+		}
+		price, _ := new(big.Float).Quo(new(big.Float).SetInt(prices[market]), big.NewFloat(math.Pow10(priceDecimals))).Float64()
+		if price <= 0 {
+			return nil, fmt.Errorf("market %d has no oracle price", market)
+		}
+		w.oraclePrices[market] = price
+	}
+	return w, nil
+}
+
+// minFundsPerAddr returns the gas for the margin deposit and [TxsPerWorker] order txs plus the deposited margin
+func (w *tradingWorkload) minFundsPerAddr() *big.Int {
+	gas := new(big.Int).SetUint64(marginTxGas + w.config.TxsPerWorker*w.orderTxGas)
+	funds := new(big.Int).Mul(w.gasFeeCap, gas)
+	return funds.Add(funds, w.marginValue())
+}
+
+func (w *tradingWorkload) marginValue() *big.Int {
+	return new(big.Int).Mul(w.marginAmount, marginToGasToken)
+}
+
+// txSequences returns a sequence per key of a margin deposit followed by [TxsPerWorker] order txs.
+// The sequences are generated lazily so that IOC orders expire relative to when they are issued.
+func (w *tradingWorkload) txSequences(ctx context.Context, client ethclient.Client, keys []*ecdsa.PrivateKey) ([]txs.TxSequence[*types.Transaction], error) {
+	txSequences := make([]txs.TxSequence[*types.Transaction], len(keys))
+	for i, key := range keys {
+		sequence, err := txs.GenerateLazyTxSequence(ctx, w.newTrader(int64(i)).nextTx, client, key, w.config.TxsPerWorker+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate tx sequence at index %d: %w", i, err)
+		}
+		txSequences[i] = sequence
+	}
+	return txSequences, nil
+}
+
+// trader holds the state of a single key's order flow, it is only used from the goroutine generating its tx sequence
+type trader struct {
+	workload *tradingWorkload
+	rng      *rand.Rand
+	funded   bool
+	// price around which the trader places its orders, it starts at the oracle price and moves by [PriceDrift] after every order tx
+	centers    map[int]float64
+	openOrders []orderbook.LimitOrder
+}
+
+func (w *tradingWorkload) newTrader(seed int64) *trader {
+	centers := make(map[int]float64, len(w.oraclePrices))
+	for market, price := range w.oraclePrices {
+		centers[market] = price
+	}
+	return &trader{
+		workload: w,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano() + seed)),
+		centers:  centers,
+	}
+}
+
+func (t *trader) nextTx(key *ecdsa.PrivateKey, nonce uint64) (*types.Transaction, error) {
+	w := t.workload
+	address := ethcrypto.PubkeyToAddress(key.PublicKey)
+	if !t.funded {
+		t.funded = true
+		data, err := w.marginHelperABI.Pack("addVUSDMarginWithReserve", w.marginAmount, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack margin deposit: %w", err)
+		}
+		return w.signTx(key, nonce, w.marginHelper, marginTxGas, w.marginValue(), data)
+	}
+
+	defer t.drift()
+	r := t.rng.Float64()
+	// limit orders are placed instead when there is nothing to cancel yet, so that the share of IOC orders is kept
+	cancel := r < w.config.CancelRatio
+	switch {
+	case cancel && len(t.openOrders) > 0:
+		n := len(t.openOrders)
+		if n > w.config.OrdersPerTx {
+			n = w.config.OrdersPerTx
+		}
+		// cancel the oldest orders first, the whole tx reverts if one of them was already filled
+		cancelled := t.openOrders[:n]
+		t.openOrders = t.openOrders[n:]
+		data, err := w.orderBookABI.Pack("cancelOrders", cancelled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack cancel orders: %w", err)
+		}
+		return w.signTx(key, nonce, orderbook.OrderBookContractAddress, w.orderTxGas, common.Big0, data)
+	case !cancel && r < w.config.CancelRatio+w.config.IOCRatio:
+		expireAt := big.NewInt(time.Now().Add(w.config.IOCExpiry).Unix())
+		orders := make([]orderbook.IOCOrder, 0, w.config.OrdersPerTx)
+		salts := make([]*big.Int, 0, w.config.OrdersPerTx)
+		for i := 0; i < w.config.OrdersPerTx; i++ {
+			order := orderbook.IOCOrder{
+				LimitOrder: t.newOrder(address),
+				OrderType:  1,
+				ExpireAt:   expireAt,
+			}
+			orders = append(orders, order)
+			salts = append(salts, order.Salt)
+		}
+		data, err := w.iocOrderBookABI.Pack("placeOrders", orders)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack ioc orders: %w", err)
+		}
+		return w.signOrderTx(key, nonce, orderbook.IOCOrderBookContractAddress, data, salts)
+	default:
+		orders := make([]orderbook.LimitOrder, 0, w.config.OrdersPerTx)
+		salts := make([]*big.Int, 0, w.config.OrdersPerTx)
+		for i := 0; i < w.config.OrdersPerTx; i++ {
+			order := t.newOrder(address)
+			orders = append(orders, order)
+			salts = append(salts, order.Salt)
+		}
+		data, err := w.orderBookABI.Pack("placeOrders", orders)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack limit orders: %w", err)
+		}
+		t.openOrders = append(t.openOrders, orders...)
+		return w.signOrderTx(key, nonce, orderbook.OrderBookContractAddress, data, salts)
+	}
+}
+
+// newOrder returns an order of [OrderSize] on a random side and market, priced around the trader's center for that market
+func (t *trader) newOrder(address common.Address) orderbook.LimitOrder {
+	w := t.workload
+	market := w.config.Markets[t.rng.Intn(len(w.config.Markets))]
+	baseAssetQuantity := new(big.Int).Set(w.orderSize)
+	if t.rng.Intn(2) == 0 {
+		baseAssetQuantity.Neg(baseAssetQuantity)
+	}
+	return orderbook.LimitOrder{
+		AmmIndex:          big.NewInt(int64(market)),
+		Trader:            address,
+		BaseAssetQuantity: baseAssetQuantity,
+		Price:             scaleDecimal(t.samplePrice(market), priceDecimals),
+		Salt:              big.NewInt(t.rng.Int63()),
+		ReduceOnly:        false,
+	}
+}
+
+func (t *trader) samplePrice(market int) float64 {
+	w := t.workload
+	center := t.centers[market]
+	var deviation float64
+	switch w.config.PriceDistribution {
+	case config.NormalDistribution:
+		// [PriceSpread] is the standard deviation, prices are clamped to 3 of them
+		deviation = math.Max(-3, math.Min(3, t.rng.NormFloat64())) * w.config.PriceSpread
+	default:
+		deviation = (2*t.rng.Float64() - 1) * w.config.PriceSpread
+	}
+	price := center * (1 + deviation)
+	// the smallest price the orderbook accepts
+	return math.Max(price, math.Pow10(-priceDecimals))
+}
+
+// drift moves the trader's prices so that its orders match away from the oracle price. The orderbook rejects orders
+// priced too far from the oracle, so the mark price can only be driven within the market's oracle spread.
+func (t *trader) drift() {
+	if t.workload.config.PriceDrift == 0 {
+		return
+	}
+	for market, center := range t.centers {
+		t.centers[market] = center * (1 + t.workload.config.PriceDrift)
+	}
+}
+
+func (w *tradingWorkload) signOrderTx(key *ecdsa.PrivateKey, nonce uint64, to common.Address, data []byte, salts []*big.Int) (*types.Transaction, error) {
+	tx, err := w.signTx(key, nonce, to, w.orderTxGas, common.Big0, data)
+	if err != nil {
+		return nil, err
+	}
+	w.fillTracker.ordersGenerated(tx.Hash(), salts)
+	return tx, nil
+}
+
+func (w *tradingWorkload) signTx(key *ecdsa.PrivateKey, nonce uint64, to common.Address, gas uint64, value *big.Int, data []byte) (*types.Transaction, error) {
+	return types.SignNewTx(key, w.signer, &types.DynamicFeeTx{
+		ChainID:   w.chainID,
+		Nonce:     nonce,
+		GasTipCap: w.gasTipCap,
+		GasFeeCap: w.gasFeeCap,
+		Gas:       gas,
+		To:        &to,
+		Data:      data,
+		Value:     value,
+	})
+}
+
+func callContract(ctx context.Context, client ethclient.Client, contract common.Address, contractABI abi.ABI, method string, out interface{}) error {
+	data, err := contractABI.Pack(method)
+	if err != nil {
+		return fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+	res, err := client.CallContract(ctx, interfaces.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to call %s on %s: %w", method, contract, err)
+	}
+	if err := contractABI.UnpackIntoInterface(out, method, res); err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+	return nil
+}
+
+// scaleDecimal converts [value] to an integer with [decimals] decimals, rounding to the nearest unit
+func scaleDecimal(value float64, decimals int) *big.Int {
+	// go through the shortest decimal representation so that e.g. 0.1 scales to exactly 1e17
+	scaled, _ := new(big.Float).SetPrec(256).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	scaled.Mul(scaled, new(big.Float).SetPrec(256).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if scaled.Sign() < 0 {
+		scaled.Sub(scaled, big.NewFloat(0.5))
+	} else {
+		scaled.Add(scaled, big.NewFloat(0.5))
+	}
+	result, _ := scaled.Int(nil)
+	return result
+}
@@ -0,0 +1,254 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package load
+
+import (
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/cmd/simulator/config"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+var testMarginHelper = common.HexToAddress("0x0300000000000000000000000000000000000099")
+
+func newTestTradingWorkload(t *testing.T, c config.Config) *tradingWorkload {
+	tracker := newTestFillTracker(t)
+	marginHelperABI, err := abi.JSON(strings.NewReader(marginAccountHelperAbi))
+	require.NoError(t, err)
+	chainID := big.NewInt(321123)
+	return &tradingWorkload{
+		config:          c,
+		chainID:         chainID,
+		signer:          types.LatestSignerForChainID(chainID),
+		gasTipCap:       big.NewInt(1),
+		gasFeeCap:       big.NewInt(100),
+		orderBookABI:    tracker.orderBookABI,
+		iocOrderBookABI: tracker.iocOrderBookABI,
+		marginHelperABI: marginHelperABI,
+		marginHelper:    testMarginHelper,
+		marginAmount:    scaleDecimal(c.Margin, priceDecimals),
+		orderSize:       scaleDecimal(c.OrderSize, quantityDecimals),
+		oraclePrices:    map[int]float64{0: 1000, 1: 10},
+		orderTxGas:      orderGas * uint64(c.OrdersPerTx),
+		fillTracker:     tracker,
+	}
+}
+
+func testTradingConfig() config.Config {
+	return config.Config{
+		TxsPerWorker:      10,
+		Markets:           []int{0, 1},
+		Margin:            1000,
+		OrderSize:         0.1,
+		OrdersPerTx:       2,
+		PriceSpread:       0.01,
+		PriceDistribution: config.UniformDistribution,
+		IOCExpiry:         5 * time.Second,
+	}
+}
+
+// unpackCall returns the arguments of the call to [method] in the data of [tx]
+func unpackCall(t *testing.T, contractABI abi.ABI, method string, tx *types.Transaction) []interface{} {
+	require.Equal(t, contractABI.Methods[method].ID, tx.Data()[:4])
+	args, err := contractABI.Methods[method].Inputs.Unpack(tx.Data()[4:])
+	require.NoError(t, err)
+	return args
+}
+
+func TestScaleDecimal(t *testing.T) {
+	require := require.New(t)
+	require.Equal(big.NewInt(1e17), scaleDecimal(0.1, quantityDecimals))
+	require.Equal(big.NewInt(1_500_000), scaleDecimal(1.5, priceDecimals))
+	// rounds to the nearest unit, away from zero on a tie
+	require.Equal(big.NewInt(1), scaleDecimal(0.0000005, priceDecimals))
+	require.Equal(big.NewInt(0), scaleDecimal(0.0000004, priceDecimals))
+	require.Equal(big.NewInt(-1), scaleDecimal(-0.0000005, priceDecimals))
+}
+
+func TestTradingWorkloadFunds(t *testing.T) {
+	w := newTestTradingWorkload(t, testTradingConfig())
+	// the margin is deposited in the native gas token, which has 12 more decimals than the margin
+	require.Equal(t, new(big.Int).Mul(big.NewInt(1000e6), big.NewInt(1e12)), w.marginValue())
+	gas := marginTxGas + 10*2*orderGas
+	expected := new(big.Int).Add(big.NewInt(int64(gas)*100), w.marginValue())
+	require.Equal(t, expected, w.minFundsPerAddr())
+}
+
+func TestTraderNextTx(t *testing.T) {
+	key, err := ethcrypto.GenerateKey()
+	require.NoError(t, err)
+	address := ethcrypto.PubkeyToAddress(key.PublicKey)
+
+	t.Run("the first tx deposits the margin", func(t *testing.T) {
+		require := require.New(t)
+		w := newTestTradingWorkload(t, testTradingConfig())
+		tx, err := w.newTrader(0).nextTx(key, 0)
+		require.NoError(err)
+		require.Equal(testMarginHelper, *tx.To())
+		require.Equal(w.marginValue(), tx.Value())
+		require.Equal(marginTxGas, tx.Gas())
+		args := unpackCall(t, w.marginHelperABI, "addVUSDMarginWithReserve", tx)
+		require.Equal(big.NewInt(1000e6), args[0])
+		require.Equal(address, args[1])
+	})
+
+	t.Run("limit orders are placed around the center price", func(t *testing.T) {
+		require := require.New(t)
+		w := newTestTradingWorkload(t, testTradingConfig())
+		trader := w.newTrader(0)
+		trader.funded = true
+		tx, err := trader.nextTx(key, 1)
+		require.NoError(err)
+		require.Equal(orderbook.OrderBookContractAddress, *tx.To())
+		require.Equal(uint64(1), tx.Nonce())
+		require.Equal(2*orderGas, tx.Gas())
+
+		var orders []orderbook.LimitOrder
+		require.NoError(w.orderBookABI.Methods["placeOrders"].Inputs.Copy(&orders, unpackCall(t, w.orderBookABI, "placeOrders", tx)))
+		require.Len(orders, 2)
+		for _, order := range orders {
+			require.Equal(address, order.Trader)
+			require.Equal(0, new(big.Int).Abs(order.BaseAssetQuantity).Cmp(big.NewInt(1e17)))
+			center := w.oraclePrices[int(order.AmmIndex.Int64())]
+			price, _ := new(big.Float).Quo(new(big.Float).SetInt(order.Price), big.NewFloat(1e6)).Float64()
+			require.InDelta(center, price, center*0.01+1e-6)
+		}
+		require.Len(trader.openOrders, 2)
+		for i, order := range orders {
+			require.Equal(order.Salt, trader.openOrders[i].Salt)
+		}
+		// the orders are tracked under the hash of the tx placing them
+		require.Equal([]*big.Int{orders[0].Salt, orders[1].Salt}, w.fillTracker.txOrders[tx.Hash()])
+	})
+
+	t.Run("ioc orders expire after the ioc expiry", func(t *testing.T) {
+		require := require.New(t)
+		c := testTradingConfig()
+		c.IOCRatio = 1
+		w := newTestTradingWorkload(t, c)
+		trader := w.newTrader(0)
+		trader.funded = true
+		before := time.Now()
+		tx, err := trader.nextTx(key, 1)
+		require.NoError(err)
+		require.Equal(orderbook.IOCOrderBookContractAddress, *tx.To())
+
+		// the orders are unpacked as structs without the embedded LimitOrder
+		rawOrders := reflect.ValueOf(unpackCall(t, w.iocOrderBookABI, "placeOrders", tx)[0])
+		require.Equal(2, rawOrders.Len())
+		for i := 0; i < rawOrders.Len(); i++ {
+			order := orderbook.IOCOrder{}
+			order.DecodeFromRawOrder(rawOrders.Index(i).Interface())
+			require.Equal(address, order.Trader)
+			require.Equal(uint8(1), order.OrderType)
+			require.GreaterOrEqual(order.ExpireAt.Int64(), before.Add(c.IOCExpiry).Unix())
+			require.LessOrEqual(order.ExpireAt.Int64(), time.Now().Add(c.IOCExpiry).Unix())
+		}
+		// ioc orders can't be cancelled
+		require.Empty(trader.openOrders)
+		require.Len(w.fillTracker.txOrders[tx.Hash()], 2)
+	})
+
+	t.Run("the oldest open orders are cancelled first", func(t *testing.T) {
+		require := require.New(t)
+		w := newTestTradingWorkload(t, testTradingConfig())
+		trader := w.newTrader(0)
+		trader.funded = true
+		for nonce := uint64(1); nonce <= 2; nonce++ {
+			_, err := trader.nextTx(key, nonce)
+			require.NoError(err)
+		}
+		openOrders := append([]orderbook.LimitOrder{}, trader.openOrders...)
+		require.Len(openOrders, 4)
+
+		w.config.CancelRatio = 1
+		tx, err := trader.nextTx(key, 3)
+		require.NoError(err)
+		require.Equal(orderbook.OrderBookContractAddress, *tx.To())
+		var cancelled []orderbook.LimitOrder
+		require.NoError(w.orderBookABI.Methods["cancelOrders"].Inputs.Copy(&cancelled, unpackCall(t, w.orderBookABI, "cancelOrders", tx)))
+		require.Len(cancelled, 2)
+		for i, order := range cancelled {
+			require.Equal(openOrders[i].Salt, order.Salt)
+		}
+		require.Equal(openOrders[2:], trader.openOrders)
+	})
+
+	t.Run("orders are placed when there is nothing to cancel", func(t *testing.T) {
+		require := require.New(t)
+		c := testTradingConfig()
+		c.CancelRatio = 0.5
+		c.IOCRatio = 0.5
+		w := newTestTradingWorkload(t, c)
+		trader := w.newTrader(0)
+		trader.funded = true
+		// a seed whose first draw is in the cancel share
+		seed := int64(0)
+		for rand.New(rand.NewSource(seed)).Float64() >= c.CancelRatio {
+			seed++
+		}
+		trader.rng = rand.New(rand.NewSource(seed))
+
+		tx, err := trader.nextTx(key, 1)
+		require.NoError(err)
+		require.Equal(orderbook.OrderBookContractAddress, *tx.To())
+		unpackCall(t, w.orderBookABI, "placeOrders", tx)
+	})
+}
+
+func TestTraderPrices(t *testing.T) {
+	t.Run("normal prices are clamped to 3 spreads", func(t *testing.T) {
+		c := testTradingConfig()
+		c.PriceDistribution = config.NormalDistribution
+		w := newTestTradingWorkload(t, c)
+		trader := w.newTrader(0)
+		trader.rng = rand.New(rand.NewSource(1))
+		for i := 0; i < 1000; i++ {
+			price := trader.samplePrice(0)
+			require.InDelta(t, 1000, price, 1000*3*c.PriceSpread+1e-9)
+		}
+	})
+
+	t.Run("prices don't go below the smallest price", func(t *testing.T) {
+		c := testTradingConfig()
+		c.PriceSpread = 2
+		w := newTestTradingWorkload(t, c)
+		trader := w.newTrader(0)
+		for i := 0; i < 100; i++ {
+			require.GreaterOrEqual(t, trader.samplePrice(1), 1e-6)
+		}
+	})
+
+	t.Run("the center drifts after every order tx", func(t *testing.T) {
+		require := require.New(t)
+		key, err := ethcrypto.GenerateKey()
+		require.NoError(err)
+		c := testTradingConfig()
+		c.PriceDrift = 0.01
+		w := newTestTradingWorkload(t, c)
+		trader := w.newTrader(0)
+
+		// the margin deposit doesn't move the prices
+		_, err = trader.nextTx(key, 0)
+		require.NoError(err)
+		require.Equal(1000.0, trader.centers[0])
+
+		_, err = trader.nextTx(key, 1)
+		require.NoError(err)
+		require.InDelta(1010.0, trader.centers[0], 1e-9)
+		require.InDelta(10.1, trader.centers[1], 1e-9)
+		// the oracle prices are shared by the traders and stay put
+		require.Equal(1000.0, w.oraclePrices[0])
+	})
+}
@@ -14,6 +14,12 @@ type Metrics struct {
 	ConfirmationTxTimes prometheus.Summary
 	// Summary of the quantiles of Individual Issuance To Confirmation Tx Times
 	IssuanceToConfirmationTxTimes prometheus.Summary
+	// Summary of the quantiles of Individual Order Issuance To First Fill Times (trading mode)
+	OrderToFillTimes prometheus.Summary
+	// Number of orders seen placed on the orderbooks (trading mode)
+	OrdersPlaced prometheus.Counter
+	// Number of placed orders seen filled at least once (trading mode)
+	OrdersFilled prometheus.Counter
 }
 
 // NewMetrics creates and returns a Metrics and registers it with a Collector
@@ -34,9 +40,25 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 			Help:       "Individual Tx Issuance To Confirmation Times for a Load Test",
 			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
 		}),
+		OrderToFillTimes: prometheus.NewSummary(prometheus.SummaryOpts{
+			Name:       "order_to_fill_time",
+			Help:       "Individual Order Issuance To First Fill Times for a Load Test",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		}),
+		OrdersPlaced: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_placed",
+			Help: "Number of Orders Placed on the Orderbooks for a Load Test",
+		}),
+		OrdersFilled: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_filled",
+			Help: "Number of Placed Orders Filled at least once for a Load Test",
+		}),
 	}
 	reg.MustRegister(m.IssuanceTxTimes)
 	reg.MustRegister(m.ConfirmationTxTimes)
 	reg.MustRegister(m.IssuanceToConfirmationTxTimes)
+	reg.MustRegister(m.OrderToFillTimes)
+	reg.MustRegister(m.OrdersPlaced)
+	reg.MustRegister(m.OrdersFilled)
 	return m
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/ava-labs/subnet-evm/core/types"
 	"github.com/ava-labs/subnet-evm/ethclient"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 var _ TxSequence[*types.Transaction] = (*txSequence)(nil)
@@ -47,6 +48,36 @@ func GenerateTxSequences(ctx context.Context, generator CreateTx, client ethclie
 	return txSequences, nil
 }
 
+// GenerateLazyTxSequence fetches the current nonce of key and returns a sequence that calls [generator] as the txs are consumed,
+// so that txs which depend on when they are issued (e.g. orders that expire) are generated at most one tx ahead of issuance.
+// The sequence ends early if [generator] fails or [ctx] is done.
+func GenerateLazyTxSequence(ctx context.Context, generator CreateTx, client ethclient.Client, key *ecdsa.PrivateKey, numTxs uint64) (TxSequence[*types.Transaction], error) {
+	address := ethcrypto.PubkeyToAddress(key.PublicKey)
+	startingNonce, err := client.NonceAt(ctx, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch nonce for address %s: %w", address, err)
+	}
+	txChan := make(chan *types.Transaction)
+	go func() {
+		defer close(txChan)
+		for i := uint64(0); i < numTxs; i++ {
+			tx, err := generator(key, startingNonce+i)
+			if err != nil {
+				log.Error("failed to sign tx", "address", address, "index", i, "err", err)
+				return
+			}
+			select {
+			case txChan <- tx:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return &txSequence{
+		txChan: txChan,
+	}, nil
+}
+
 type txSequence struct {
 	txChan chan *types.Transaction
 }
@@ -0,0 +1,10 @@
+package commontype
+
+// HubbleVersion activates the behaviour [Version] of the hubble precompiles (see bibliophile.Version) for blocks with
+// timestamp >= [BlockTimestamp]. The schedule of the bibliophile precompile config is only written to the state when
+// the precompile is activated, so chains that were launched before it existed set their schedule in the chain config
+// instead, which the precompiles read through their AccessibleState.
+type HubbleVersion struct {
+	BlockTimestamp uint64 `json:"blockTimestamp"`
+	Version        uint8  `json:"version"`
+}
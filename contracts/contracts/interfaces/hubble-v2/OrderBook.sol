@@ -22,6 +22,12 @@ contract OrderBook is IOrderBook, EIP712Upgradeable {
     }
     mapping(bytes32 => OrderInfo) public orderInfo;
 
+    // an amended order keeps its orderInfo under the hash it was placed with
+    // amendedOrderHash => hash the order was placed with
+    mapping(bytes32 => bytes32) public orderAmendedFrom;
+    // hash the order was placed with => hash of its latest amendment
+    mapping(bytes32 => bytes32) public orderAmendedTo;
+
     struct Position {
         int256 size;
         uint256 openNotional;
@@ -55,8 +61,8 @@ contract OrderBook is IOrderBook, EIP712Upgradeable {
         require(fillAmount > 0, "OB_fillAmount_is_neg");
         require(orders[0].price /* buy */ >= orders[1].price /* sell */, "OB_orders_do_not_match");
 
-        bytes32 orderHash0 = getOrderHash(orders[0]);
-        bytes32 orderHash1 = getOrderHash(orders[1]);
+        bytes32 orderHash0 = _placedOrderHash(getOrderHash(orders[0]));
+        bytes32 orderHash1 = _placedOrderHash(getOrderHash(orders[1]));
         // // Effects
         _updateOrder(orderHash0, fillAmount, orders[0].baseAssetQuantity);
         _updateOrder(orderHash1, -fillAmount, orders[1].baseAssetQuantity);
@@ -114,11 +120,30 @@ contract OrderBook is IOrderBook, EIP712Upgradeable {
         emit OrderCancelled(order.trader, orderHash, block.timestamp);
     }
 
+    function amendOrder(Order memory order, uint256 price, int256 baseAssetQuantity) external {
+        require(msg.sender == order.trader, "OB_sender_is_not_trader");
+        bytes32 orderHash = _placedOrderHash(getOrderHash(order));
+        require(orderInfo[orderHash].status == OrderStatus.Placed, "OB_Order_does_not_exist");
+        // the side can't change and the size can't go below what is already filled
+        require(order.baseAssetQuantity * baseAssetQuantity > 0, "OB_invalid_amendment");
+        require(abs(baseAssetQuantity) >= abs(orderInfo[orderHash].filledAmount), "OB_invalid_amendment");
+
+        order.price = price;
+        order.baseAssetQuantity = baseAssetQuantity;
+        bytes32 amendedOrderHash = getOrderHash(order);
+        orderAmendedFrom[amendedOrderHash] = orderHash;
+        orderAmendedTo[orderHash] = amendedOrderHash;
+
+        emit OrderAmended(order.trader, orderHash, amendedOrderHash, order, block.timestamp);
+    }
+
     /**
      * @dev is a no-op here but works in the implementation in the protocol repo
     */
     function settleFunding() external {}
 
+    function settleFundingForMarket(uint ammIndex) external {}
+
     /**
     @dev assuming one order is in liquidation zone and other is out of it
     @notice liquidate trader
@@ -174,6 +199,7 @@ contract OrderBook is IOrderBook, EIP712Upgradeable {
         returns (bytes32 /* orderHash */, uint /* blockPlaced */)
     {
         (, bytes32 orderHash) = verifySigner(order, signature);
+        orderHash = _placedOrderHash(orderHash);
         // order should be in placed status
         require(orderInfo[orderHash].status == OrderStatus.Placed, "OB_invalid_order");
         // order.baseAssetQuantity and fillAmount should have same sign
@@ -184,6 +210,22 @@ contract OrderBook is IOrderBook, EIP712Upgradeable {
         return (orderHash, orderInfo[orderHash].blockPlaced);
     }
 
+    /**
+    * @dev returns the hash that the order with orderHash keeps its orderInfo under.
+    * Only the latest amendment of an order can be executed or amended, the order as placed or as amended before can't.
+    */
+    function _placedOrderHash(bytes32 orderHash) internal view returns (bytes32 placedHash) {
+        placedHash = orderAmendedFrom[orderHash];
+        if (placedHash == bytes32(0)) {
+            placedHash = orderHash;
+        }
+        bytes32 latestHash = orderAmendedTo[placedHash];
+        if (latestHash == bytes32(0)) {
+            latestHash = placedHash;
+        }
+        require(latestHash == orderHash, "OB_order_amended");
+    }
+
     function _updateOrder(bytes32 orderHash, int256 fillAmount, int256 baseAssetQuantity) internal {
         orderInfo[orderHash].filledAmount += fillAmount;
         // update order status if filled
@@ -4,6 +4,8 @@ pragma solidity ^0.8.0;
 import { IClearingHouse } from "./IClearingHouse.sol";
 
 interface IJuror {
+    enum BadElement { Order0, Order1, Generic, NoError }
+
     function validateOrdersAndDetermineFillPrice(
         bytes[2] calldata data,
         int256 fillAmount
@@ -16,6 +18,21 @@ interface IJuror {
             uint256 fillPrice
         );
 
+    // same as validateOrdersAndDetermineFillPrice, but returns the error and the order that caused it instead of reverting
+    function validateMatchedOrders(
+        bytes[2] calldata data,
+        int256 fillAmount
+    )   external
+        view
+        returns(
+            string memory err,
+            BadElement element,
+            IClearingHouse.Instruction[2] memory instructions,
+            uint8[2] memory orderTypes,
+            bytes[2] memory encodedOrders,
+            uint256 fillPrice
+        );
+
     function validateLiquidationOrderAndDetermineFillPrice(bytes calldata data, uint256 liquidationAmount)
         external
         view
@@ -27,6 +44,9 @@ interface IJuror {
             int256 fillAmount
         );
 
+    // reverts if the trader is not liquidatable or liquidationAmount exceeds the liquidation threshold of the position
+    function validateLiquidation(address trader, uint256 ammIndex, uint256 liquidationAmount) external view;
+
     // IOC Orders
     function validatePlaceIOCOrders(IImmediateOrCancelOrders.Order[] memory orders, address sender) external view returns(bytes32[] memory orderHashes);
 }
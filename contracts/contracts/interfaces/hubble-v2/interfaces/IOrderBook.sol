@@ -32,15 +32,41 @@ interface IOrderBook {
         OrderExecutionMode mode;
     }
 
+    struct MatchedOrders {
+        bytes[2] orders;
+        int256 fillAmount;
+    }
+
+    struct LiquidationOrder {
+        address trader;
+        bytes order;
+        uint256 toLiquidate;
+    }
+
     event OrderPlaced(address indexed trader, bytes32 indexed orderHash, Order order, uint timestamp);
     event OrderCancelled(address indexed trader, bytes32 indexed orderHash, uint timestamp);
+    /**
+     * @notice the order placed with orderHash is now executed as amendedOrderHash, the order with the new price and baseAssetQuantity
+    */
+    event OrderAmended(address indexed trader, bytes32 indexed orderHash, bytes32 amendedOrderHash, Order order, uint timestamp);
     event OrdersMatched(bytes32 indexed orderHash0, bytes32 indexed orderHash1, uint256 fillAmount, uint price, uint openInterestNotional, address relayer, uint timestamp);
     event LiquidationOrderMatched(address indexed trader, bytes32 indexed orderHash, bytes signature, uint256 fillAmount, uint price, uint openInterestNotional, address relayer, uint timestamp);
     event OrderMatchingError(bytes32 indexed orderHash, string err);
     event LiquidationError(address indexed trader, bytes32 indexed orderHash, string err, uint256 toLiquidate);
 
     function executeMatchedOrders(Order[2] memory orders, int256 fillAmount) external;
+    /**
+     * @notice amends the price and baseAssetQuantity of an order, order is the order as placed or as last amended.
+     * The order keeps its orderInfo under the hash it was placed with, only its latest amendment can be executed.
+    */
+    function amendOrder(Order memory order, uint256 price, int256 baseAssetQuantity) external;
+    /**
+     * @notice executes the liquidations and then the matches in a single tx.
+     * A failing item doesn't revert the batch - LiquidationError or OrderMatchingError (for the order that juror.validateMatchedOrders blames) is emitted and the next item is executed
+    */
+    function executeBatch(LiquidationOrder[] calldata liquidations, MatchedOrders[] calldata matches) external;
     function settleFunding() external;
+    function settleFundingForMarket(uint ammIndex) external;
     function liquidateAndExecuteOrder(address trader, Order memory order, bytes memory signature, uint256 toLiquidate) external;
     function getLastTradePrices() external view returns(uint[] memory lastTradePrices);
 }
@@ -299,6 +299,9 @@ type BlockChain struct {
 
 	// [acceptedLogsCache] stores recently accepted logs to improve the performance of eth_getLogs.
 	acceptedLogsCache FIFOCache[common.Hash, [][]*types.Log]
+
+	// [testingStateOverrides] are applied to the blocks built or processed by this node, only set by the testing API
+	testingStateOverrides atomic.Pointer[TestingStateOverrides]
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -968,6 +971,13 @@ func (bc *BlockChain) stopWithoutSaving() {
 	bc.wg.Wait()
 }
 
+// EnableTestingStateOverrides makes the blocks built or processed by this node apply the storage overrides queued by
+// the testing API. See TestingStateOverrides for why it must only be used on a local chain.
+func (bc *BlockChain) EnableTestingStateOverrides() *TestingStateOverrides {
+	bc.testingStateOverrides.CompareAndSwap(nil, NewTestingStateOverrides())
+	return bc.testingStateOverrides.Load()
+}
+
 // Stop stops the blockchain service. If any imports are currently in progress
 // it will abort them using the procInterrupt.
 func (bc *BlockChain) Stop() {
@@ -325,6 +325,11 @@ func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscript
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
 }
 
+// TestingStateOverrides returns the storage overrides queued by the testing API, nil if it is not enabled.
+func (bc *BlockChain) TestingStateOverrides() *TestingStateOverrides {
+	return bc.testingStateOverrides.Load()
+}
+
 // SubscribeHubbleLogsEvent registers a subscription of []*types.Log.
 func (bc *BlockChain) SubscribeHubbleLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return bc.scope.Track(bc.hubbleFeed.Subscribe(ch))
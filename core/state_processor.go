@@ -86,6 +86,9 @@ func (p *StateProcessor) Process(block *types.Block, parent *types.Header, state
 		log.Error("failed to configure precompiles processing block", "hash", block.Hash(), "number", block.NumberU64(), "timestamp", block.Time(), "err", err)
 		return nil, nil, 0, err
 	}
+	if p.bc != nil {
+		p.bc.TestingStateOverrides().Apply(parent.Hash(), statedb)
+	}
 
 	blockContext := NewEVMBlockContext(header, p.bc, nil)
 	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, p.config, cfg)
@@ -0,0 +1,66 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// StorageOverride is a write of [Value] to the storage slot [Key] of [Address]
+type StorageOverride struct {
+	Address common.Address
+	Key     common.Hash
+	Value   common.Hash
+}
+
+// TestingStateOverrides are storage writes queued by the testing API of a local chain to seed state without going
+// through the contracts. They are applied to the next block that this node builds or processes, after the upgrades
+// of the block and before its transactions.
+// Other nodes don't know about them and compute a different state root for that block, so they must only be used on
+// a chain with a single validator. A block that is re-executed on restart doesn't apply them again either, so the
+// chain must run with pruning disabled. The VM only enables them with testing-state-overrides-enabled, which checks both.
+type TestingStateOverrides struct {
+	mu      sync.Mutex
+	pending []StorageOverride
+	// applied are the overrides that were taken from pending by the first child of [parent] that was built or processed,
+	// every other child of [parent] (or the same one, verified after it was built) applies them too
+	parent  common.Hash
+	applied []StorageOverride
+}
+
+func NewTestingStateOverrides() *TestingStateOverrides {
+	return &TestingStateOverrides{}
+}
+
+// Add queues [overrides] for the next block
+func (o *TestingStateOverrides) Add(overrides ...StorageOverride) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.pending = append(o.pending, overrides...)
+}
+
+// Apply writes the overrides for a child of [parent] to [statedb]. It is a no-op if [o] is nil.
+func (o *TestingStateOverrides) Apply(parent common.Hash, statedb *state.StateDB) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if parent != o.parent && len(o.pending) > 0 {
+		o.parent = parent
+		o.applied = o.pending
+		o.pending = nil
+	}
+	if parent != o.parent {
+		return
+	}
+	for _, override := range o.applied {
+		statedb.SetState(override.Address, override.Key, override.Value)
+	}
+}
@@ -0,0 +1,38 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestingStateOverrides(t *testing.T) {
+	require := require.New(t)
+	account, key := common.Address{1}, common.Hash{2}
+	parent, child := common.Hash{3}, common.Hash{4}
+
+	overrides := NewTestingStateOverrides()
+	overrides.Add(StorageOverride{Address: account, Key: key, Value: common.Hash{5}})
+
+	// every block built or processed on the same parent applies the overrides
+	for i := 0; i < 2; i++ {
+		statedb := state.NewTestStateDB(t).(*state.StateDB)
+		overrides.Apply(parent, statedb)
+		require.Equal(common.Hash{5}, statedb.GetState(account, key))
+	}
+
+	// they are not applied again to its child
+	statedb := state.NewTestStateDB(t).(*state.StateDB)
+	overrides.Apply(child, statedb)
+	require.Equal(common.Hash{}, statedb.GetState(account, key))
+
+	// a nil TestingStateOverrides is a no-op
+	var disabled *TestingStateOverrides
+	disabled.Apply(parent, statedb)
+	require.Equal(common.Hash{}, statedb.GetState(account, key))
+}
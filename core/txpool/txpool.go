@@ -296,6 +296,8 @@ type TxPool struct {
 	journal *journal    // Journal of local transaction to back up to disk
 
 	OrderBookTxMap map[common.Address]*list
+	// orderBookGasBudget is the gas that the orderbook txs may use in a block, 0 if they may fill the block
+	orderBookGasBudget uint64
 	pending        map[common.Address]*list     // All currently processable transactions
 	queue          map[common.Address]*list     // Queued but non-processable transactions
 	beats          map[common.Address]time.Time // Last heartbeat from each known account
@@ -1070,6 +1072,22 @@ func (pool *TxPool) PurgeOrderBookTxs() {
 	}
 }
 
+// SetOrderBookGasBudget sets the gas that the miner allows the orderbook txs to use in a block.
+// The matching pipeline only creates as many orderbook txs as fit in the same budget.
+func (pool *TxPool) SetOrderBookGasBudget(gas uint64) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.orderBookGasBudget = gas
+}
+
+func (pool *TxPool) GetOrderBookGasBudget() uint64 {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.orderBookGasBudget
+}
+
 func (pool *TxPool) GetOrderBookTxNonce(address common.Address) uint64 {
 	nonce := pool.Nonce(address)
 	val, ok := pool.OrderBookTxMap[address]
@@ -211,6 +211,11 @@ func (evm *EVM) GetSnowContext() *snow.Context {
 	return evm.chainConfig.SnowCtx
 }
 
+// GetChainConfig returns the evm's chain config
+func (evm *EVM) GetChainConfig() contract.ChainConfig {
+	return evm.chainConfig
+}
+
 // GetStateDB returns the evm's StateDB
 func (evm *EVM) GetStateDB() contract.StateDB {
 	return evm.StateDB
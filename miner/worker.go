@@ -194,6 +194,7 @@ func (w *worker) commitNewWork(predicateContext *precompileconfig.ProposerPredic
 		log.Error("failed to configure precompiles mining new block", "parent", parent.Hash(), "number", header.Number, "timestamp", header.Time, "err", err)
 		return nil, err
 	}
+	w.chain.TestingStateOverrides().Apply(parent.Hash(), env.state)
 
 	// Get the pending txs from TxPool
 	pending := w.eth.TxPool().Pending(true)
@@ -211,7 +212,7 @@ func (w *worker) commitNewWork(predicateContext *precompileconfig.ProposerPredic
 	orderBookTxs := w.eth.TxPool().GetOrderBookTxs()
 	if len(orderBookTxs) > 0 {
 		txs := types.NewTransactionsByPriceAndNonce(env.signer, orderBookTxs, header.BaseFee)
-		w.commitTransactions(env, txs, header.Coinbase)
+		w.commitOrderBookTransactions(env, txs, header.Coinbase, w.eth.TxPool().GetOrderBookGasBudget())
 	}
 	if len(localTxs) > 0 {
 		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, header.BaseFee)
@@ -243,6 +244,19 @@ func (w *worker) createCurrentEnvironment(predicateContext *precompileconfig.Pro
 	}, nil
 }
 
+// commitOrderBookTransactions commits the orderbook txs using at most [gasBudget] gas, so that the rest of the block is left for the other txs.
+// The orderbook txs that don't fit stay in the pool for the next block.
+func (w *worker) commitOrderBookTransactions(env *environment, txs *types.TransactionsByPriceAndNonce, coinbase common.Address, gasBudget uint64) {
+	available := env.gasPool.Gas()
+	if gasBudget == 0 || gasBudget >= available {
+		w.commitTransactions(env, txs, coinbase)
+		return
+	}
+	env.gasPool.SetGas(gasBudget)
+	w.commitTransactions(env, txs, coinbase)
+	env.gasPool.SetGas(available - (gasBudget - env.gasPool.Gas()))
+}
+
 func (w *worker) commitTransaction(env *environment, tx *types.Transaction, coinbase common.Address) ([]*types.Log, error) {
 	var (
 		snap = env.state.Snapshot()
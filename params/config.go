@@ -173,6 +173,10 @@ type UpgradeConfig struct {
 
 	// Config for enabling and disabling precompiles as network upgrades.
 	PrecompileUpgrades []PrecompileUpgrade `json:"precompileUpgrades,omitempty"`
+
+	// Schedule of the behaviour versions of the hubble precompiles, for the blocks that are not covered by the
+	// schedule in the bibliophile precompile config.
+	HubbleVersions []commontype.HubbleVersion `json:"hubbleVersions,omitempty"`
 }
 
 // AvalancheContext provides Avalanche specific context directly into the EVM.
@@ -445,6 +449,10 @@ func (c *ChainConfig) Verify() error {
 		return fmt.Errorf("invalid state upgrades: %w", err)
 	}
 
+	if err := c.verifyHubbleVersions(); err != nil {
+		return fmt.Errorf("invalid hubble versions: %w", err)
+	}
+
 	return nil
 }
 
@@ -590,6 +598,11 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, height *big.Int, time
 		return err
 	}
 
+	// Check that the hubble versions of the blocks that were already executed did not change.
+	if err := c.CheckHubbleVersionsCompatible(newcfg.HubbleVersions, time); err != nil {
+		return err
+	}
+
 	// TODO verify that the fee config is fully compatible between [c] and [newcfg].
 	return nil
 }
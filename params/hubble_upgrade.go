@@ -0,0 +1,125 @@
+package params
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// hubblePrecision is the precision (1e6) of the ratios of a market
+var hubblePrecision = big.NewInt(1e6)
+
+// hubbleTestOracleType is the oracle type of the TestOracle (bibliophile.TestOracle), which publishes neither a
+// timestamp nor a previous price
+const hubbleTestOracleType uint8 = 1
+
+// HubbleUpgrade lists markets and changes their parameters as part of a StateUpgrade. It is compiled into storage
+// writes to the ClearingHouse and the AMMs with the slots that the bibliophile reads (see stateupgrade), so the
+// upgrade doesn't have to spell out the raw slots.
+// The code and the rest of the storage of a new AMM are set in the accounts of the same StateUpgrade (or an earlier one).
+type HubbleUpgrade struct {
+	// AddMarkets appends the AMMs to the markets of the ClearingHouse, in order
+	AddMarkets []HubbleMarket `json:"addMarkets,omitempty"`
+	// MarketParams changes the parameters of existing markets, or of the ones added above
+	MarketParams []HubbleMarketParams `json:"marketParams,omitempty"`
+}
+
+// HubbleMarket is a new market, at the AMM [AMM]
+type HubbleMarket struct {
+	AMM common.Address `json:"amm"`
+	HubbleMarketConfig
+}
+
+// HubbleMarketParams changes the parameters of the market with id [Market]
+type HubbleMarketParams struct {
+	Market int64 `json:"market"`
+	HubbleMarketConfig
+}
+
+// HubbleMarketConfig are the parameters of a market; the ones that are not set are left as they are.
+// Ratios have 6 decimals.
+type HubbleMarketConfig struct {
+	Oracle                    *common.Address       `json:"oracle,omitempty"`
+	OracleType                *uint8                `json:"oracleType,omitempty"`
+	UnderlyingAsset           *common.Address       `json:"underlyingAsset,omitempty"`
+	MaxOracleSpreadRatio      *math.HexOrDecimal256 `json:"maxOracleSpreadRatio,omitempty"`
+	MaxLiquidationRatio       *math.HexOrDecimal256 `json:"maxLiquidationRatio,omitempty"`
+	MaxLiquidationPriceSpread *math.HexOrDecimal256 `json:"maxLiquidationPriceSpread,omitempty"`
+	MinSizeRequirement        *math.HexOrDecimal256 `json:"minSizeRequirement,omitempty"`
+	FundingPeriod             *uint64               `json:"fundingPeriod,omitempty"`
+	NextFundingTime           *uint64               `json:"nextFundingTime,omitempty"`
+	MaxOraclePriceAge         *uint64               `json:"maxOraclePriceAge,omitempty"`
+	MaxOraclePriceDeviation   *math.HexOrDecimal256 `json:"maxOraclePriceDeviation,omitempty"`
+}
+
+// IsEmpty returns true if no parameter is set
+func (c *HubbleMarketConfig) IsEmpty() bool {
+	return *c == HubbleMarketConfig{}
+}
+
+// Verify checks the parameters that can be checked without the state
+func (c *HubbleMarketConfig) Verify() error {
+	ratios := []struct {
+		name  string
+		value *math.HexOrDecimal256
+	}{
+		{"maxOracleSpreadRatio", c.MaxOracleSpreadRatio},
+		{"maxLiquidationRatio", c.MaxLiquidationRatio},
+		{"maxLiquidationPriceSpread", c.MaxLiquidationPriceSpread},
+		{"maxOraclePriceDeviation", c.MaxOraclePriceDeviation},
+	}
+	for _, ratio := range ratios {
+		if ratio.value == nil {
+			continue
+		}
+		if value := (*big.Int)(ratio.value); value.Sign() < 0 || value.Cmp(hubblePrecision) > 0 {
+			return fmt.Errorf("%s (%s) must be between 0 and 1e6", ratio.name, value)
+		}
+	}
+	if c.MaxLiquidationRatio != nil && (*big.Int)(c.MaxLiquidationRatio).Sign() == 0 {
+		return errors.New("maxLiquidationRatio must be positive")
+	}
+	if c.MinSizeRequirement != nil && (*big.Int)(c.MinSizeRequirement).Sign() <= 0 {
+		return errors.New("minSizeRequirement must be positive")
+	}
+	if c.FundingPeriod != nil && *c.FundingPeriod == 0 {
+		return errors.New("fundingPeriod must be positive")
+	}
+	if c.OracleType != nil && *c.OracleType == hubbleTestOracleType && (c.MaxOraclePriceAge != nil || c.MaxOraclePriceDeviation != nil) {
+		return errors.New("maxOraclePriceAge and maxOraclePriceDeviation can't be checked for a test oracle")
+	}
+	return nil
+}
+
+// Verify checks that the markets and their parameters are well formed. They are checked against the state when the
+// upgrade activates.
+func (u *HubbleUpgrade) Verify() error {
+	amms := make(map[common.Address]bool, len(u.AddMarkets))
+	for i, market := range u.AddMarkets {
+		if market.AMM == (common.Address{}) {
+			return fmt.Errorf("addMarkets[%d]: amm is not set", i)
+		}
+		if amms[market.AMM] {
+			return fmt.Errorf("addMarkets[%d]: amm %s is added more than once", i, market.AMM.Hex())
+		}
+		amms[market.AMM] = true
+		if err := market.Verify(); err != nil {
+			return fmt.Errorf("addMarkets[%d]: %w", i, err)
+		}
+	}
+	for i, params := range u.MarketParams {
+		if params.Market < 0 {
+			return fmt.Errorf("marketParams[%d]: invalid market %d", i, params.Market)
+		}
+		if params.IsEmpty() {
+			return fmt.Errorf("marketParams[%d]: no parameter is set", i)
+		}
+		if err := params.Verify(); err != nil {
+			return fmt.Errorf("marketParams[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
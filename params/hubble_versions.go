@@ -0,0 +1,63 @@
+package params
+
+import (
+	"fmt"
+
+	"github.com/ava-labs/subnet-evm/commontype"
+)
+
+// verifyHubbleVersions checks [c.HubbleVersions] is well formed:
+// - every item sets a version
+// - the specified blockTimestamps must monotonically increase
+func (c *ChainConfig) verifyHubbleVersions() error {
+	for i, version := range c.HubbleVersions {
+		if version.Version == 0 {
+			return fmt.Errorf("HubbleVersions[%d]: version is not set", i)
+		}
+		if i > 0 && version.BlockTimestamp <= c.HubbleVersions[i-1].BlockTimestamp {
+			return fmt.Errorf("HubbleVersions[%d]: block timestamp (%d) <= previous timestamp (%d)", i, version.BlockTimestamp, c.HubbleVersions[i-1].BlockTimestamp)
+		}
+	}
+	return nil
+}
+
+// CheckHubbleVersionsCompatible checks if [hubbleVersions] are compatible with [c] at [lastTimestamp], i.e. that the
+// blocks up to [lastTimestamp] keep their versions.
+// The schedule used to be built into the node, so a chain without one can set it for the first time retroactively,
+// it is then expected to match the versions that its blocks were executed with.
+func (c *ChainConfig) CheckHubbleVersionsCompatible(hubbleVersions []commontype.HubbleVersion, lastTimestamp uint64) *ConfigCompatError {
+	if len(c.HubbleVersions) == 0 {
+		return nil
+	}
+	activeVersions := getActiveHubbleVersions(c.HubbleVersions, lastTimestamp)
+	newVersions := getActiveHubbleVersions(hubbleVersions, lastTimestamp)
+	for i, version := range activeVersions {
+		if len(newVersions) <= i {
+			return newTimestampCompatError(fmt.Sprintf("missing HubbleVersions[%d]", i), &version.BlockTimestamp, nil)
+		}
+		if version != newVersions[i] {
+			return newTimestampCompatError(fmt.Sprintf("HubbleVersions[%d]", i), &version.BlockTimestamp, &newVersions[i].BlockTimestamp)
+		}
+	}
+	if len(newVersions) > len(activeVersions) {
+		return newTimestampCompatError(fmt.Sprintf("cannot retroactively add HubbleVersions[%d]", len(activeVersions)), nil, &newVersions[len(activeVersions)].BlockTimestamp)
+	}
+	return nil
+}
+
+// getActiveHubbleVersions returns the items of [versions] that are active at [timestamp]
+func getActiveHubbleVersions(versions []commontype.HubbleVersion, timestamp uint64) []commontype.HubbleVersion {
+	active := make([]commontype.HubbleVersion, 0, len(versions))
+	for _, version := range versions {
+		if version.BlockTimestamp <= timestamp {
+			active = append(active, version)
+		}
+	}
+	return active
+}
+
+// GetHubbleVersions returns the schedule of the behaviour versions of the hubble precompiles.
+// Implements precompile.ChainConfig interface.
+func (c *ChainConfig) GetHubbleVersions() []commontype.HubbleVersion {
+	return c.HubbleVersions
+}
@@ -0,0 +1,97 @@
+package params
+
+import (
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/commontype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyHubbleVersions(t *testing.T) {
+	tests := map[string]struct {
+		versions      []commontype.HubbleVersion
+		expectedError string
+	}{
+		"valid schedule": {
+			versions: []commontype.HubbleVersion{{BlockTimestamp: 0, Version: 1}, {BlockTimestamp: 1686321601, Version: 2}},
+		},
+		"version not set": {
+			versions:      []commontype.HubbleVersion{{BlockTimestamp: 0, Version: 1}, {BlockTimestamp: 10}},
+			expectedError: "HubbleVersions[1]: version is not set",
+		},
+		"timestamps not increasing": {
+			versions:      []commontype.HubbleVersion{{BlockTimestamp: 10, Version: 1}, {BlockTimestamp: 10, Version: 2}},
+			expectedError: "HubbleVersions[1]: block timestamp (10) <= previous timestamp (10)",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			chainConfig := *TestChainConfig
+			chainConfig.HubbleVersions = tt.versions
+			err := chainConfig.Verify()
+			if tt.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestCheckCompatibleHubbleVersions(t *testing.T) {
+	chainConfig := *TestChainConfig
+	legacy := []commontype.HubbleVersion{{BlockTimestamp: 0, Version: 1}, {BlockTimestamp: 6, Version: 2}}
+
+	tests := map[string]upgradeCompatibilityTest{
+		"set the schedule for the first time": {
+			startTimestamps: []uint64{5, 8},
+			configs: []*UpgradeConfig{
+				{},
+				{HubbleVersions: legacy},
+			},
+		},
+		"schedule a version": {
+			startTimestamps: []uint64{5, 8},
+			configs: []*UpgradeConfig{
+				{HubbleVersions: legacy},
+				{HubbleVersions: append(legacy, commontype.HubbleVersion{BlockTimestamp: 10, Version: 3})},
+			},
+		},
+		"reschedule a version before it activates": {
+			startTimestamps: []uint64{5, 8},
+			configs: []*UpgradeConfig{
+				{HubbleVersions: append(legacy, commontype.HubbleVersion{BlockTimestamp: 9, Version: 3})},
+				{HubbleVersions: append(legacy, commontype.HubbleVersion{BlockTimestamp: 10, Version: 3})},
+			},
+		},
+		"modify a version after it activates not allowed": {
+			expectedErrorString: "mismatching HubbleVersions[1]",
+			startTimestamps:     []uint64{5, 8},
+			configs: []*UpgradeConfig{
+				{HubbleVersions: legacy},
+				{HubbleVersions: []commontype.HubbleVersion{{BlockTimestamp: 0, Version: 1}, {BlockTimestamp: 7, Version: 2}}},
+			},
+		},
+		"remove a version after it activates not allowed": {
+			expectedErrorString: "missing HubbleVersions[1]",
+			startTimestamps:     []uint64{5, 8},
+			configs: []*UpgradeConfig{
+				{HubbleVersions: legacy},
+				{HubbleVersions: legacy[:1]},
+			},
+		},
+		"retroactively adding a version not allowed": {
+			expectedErrorString: "cannot retroactively add HubbleVersions[2]",
+			startTimestamps:     []uint64{5, 8},
+			configs: []*UpgradeConfig{
+				{HubbleVersions: legacy},
+				{HubbleVersions: append(legacy, commontype.HubbleVersion{BlockTimestamp: 7, Version: 3})},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.run(t, chainConfig)
+		})
+	}
+}
@@ -20,6 +20,9 @@ type StateUpgrade struct {
 
 	// map from account address to the modification to be made to the account.
 	StateUpgradeAccounts map[common.Address]StateUpgradeAccount `json:"accounts"`
+
+	// Hubble lists markets and changes their parameters, after the accounts are modified
+	Hubble *HubbleUpgrade `json:"hubble,omitempty"`
 }
 
 // StateUpgradeAccount describes the modifications to be made to an account during
@@ -52,6 +55,11 @@ func (c *ChainConfig) verifyStateUpgrades() error {
 		if previousUpgradeTimestamp != nil && *upgradeTimestamp <= *previousUpgradeTimestamp {
 			return fmt.Errorf("StateUpgrade[%d]: config block timestamp (%v) <= previous timestamp (%v)", i, *upgradeTimestamp, *previousUpgradeTimestamp)
 		}
+		if upgrade.Hubble != nil {
+			if err := upgrade.Hubble.Verify(); err != nil {
+				return fmt.Errorf("StateUpgrade[%d]: hubble: %w", i, err)
+			}
+		}
 		previousUpgradeTimestamp = upgradeTimestamp
 	}
 	return nil
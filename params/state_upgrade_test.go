@@ -15,6 +15,7 @@ import (
 )
 
 func TestVerifyStateUpgrades(t *testing.T) {
+	testOracleType := hubbleTestOracleType
 	modifiedAccounts := map[common.Address]StateUpgradeAccount{
 		{1}: {
 			BalanceChange: (*math.HexOrDecimal256)(common.Big1),
@@ -55,6 +56,76 @@ func TestVerifyStateUpgrades(t *testing.T) {
 			},
 			expectedError: "config block timestamp (0) must be greater than 0",
 		},
+		{
+			name: "valid hubble upgrade",
+			upgrades: []StateUpgrade{
+				{BlockTimestamp: utils.NewUint64(1), Hubble: &HubbleUpgrade{
+					AddMarkets: []HubbleMarket{{AMM: common.Address{1}, HubbleMarketConfig: HubbleMarketConfig{
+						Oracle:             &common.Address{2},
+						MinSizeRequirement: (*math.HexOrDecimal256)(big.NewInt(1e16)),
+					}}},
+					MarketParams: []HubbleMarketParams{{Market: 2, HubbleMarketConfig: HubbleMarketConfig{
+						MaxLiquidationRatio: (*math.HexOrDecimal256)(big.NewInt(25e4)),
+					}}},
+				}},
+			},
+		},
+		{
+			name: "hubble upgrade adds a market without amm",
+			upgrades: []StateUpgrade{
+				{BlockTimestamp: utils.NewUint64(1), Hubble: &HubbleUpgrade{AddMarkets: []HubbleMarket{{}}}},
+			},
+			expectedError: "StateUpgrade[0]: hubble: addMarkets[0]: amm is not set",
+		},
+		{
+			name: "hubble upgrade adds a market twice",
+			upgrades: []StateUpgrade{
+				{BlockTimestamp: utils.NewUint64(1), Hubble: &HubbleUpgrade{AddMarkets: []HubbleMarket{{AMM: common.Address{1}}, {AMM: common.Address{1}}}}},
+			},
+			expectedError: "addMarkets[1]: amm 0x0100000000000000000000000000000000000000 is added more than once",
+		},
+		{
+			name: "hubble upgrade sets no market params",
+			upgrades: []StateUpgrade{
+				{BlockTimestamp: utils.NewUint64(1), Hubble: &HubbleUpgrade{MarketParams: []HubbleMarketParams{{Market: 1}}}},
+			},
+			expectedError: "marketParams[0]: no parameter is set",
+		},
+		{
+			name: "hubble upgrade sets params of a negative market",
+			upgrades: []StateUpgrade{
+				{BlockTimestamp: utils.NewUint64(1), Hubble: &HubbleUpgrade{MarketParams: []HubbleMarketParams{{Market: -1, HubbleMarketConfig: HubbleMarketConfig{FundingPeriod: utils.NewUint64(3600)}}}}},
+			},
+			expectedError: "marketParams[0]: invalid market -1",
+		},
+		{
+			name: "hubble upgrade sets a ratio above 1e6",
+			upgrades: []StateUpgrade{
+				{BlockTimestamp: utils.NewUint64(1), Hubble: &HubbleUpgrade{MarketParams: []HubbleMarketParams{{Market: 0, HubbleMarketConfig: HubbleMarketConfig{
+					MaxOracleSpreadRatio: (*math.HexOrDecimal256)(big.NewInt(1e6 + 1)),
+				}}}}},
+			},
+			expectedError: "marketParams[0]: maxOracleSpreadRatio (1000001) must be between 0 and 1e6",
+		},
+		{
+			name: "hubble upgrade sets a zero min size",
+			upgrades: []StateUpgrade{
+				{BlockTimestamp: utils.NewUint64(1), Hubble: &HubbleUpgrade{MarketParams: []HubbleMarketParams{{Market: 0, HubbleMarketConfig: HubbleMarketConfig{
+					MinSizeRequirement: (*math.HexOrDecimal256)(big.NewInt(0)),
+				}}}}},
+			},
+			expectedError: "marketParams[0]: minSizeRequirement must be positive",
+		},
+		{
+			name: "hubble upgrade sets a max oracle price deviation for a test oracle",
+			upgrades: []StateUpgrade{
+				{BlockTimestamp: utils.NewUint64(1), Hubble: &HubbleUpgrade{MarketParams: []HubbleMarketParams{{Market: 0, HubbleMarketConfig: HubbleMarketConfig{
+					OracleType:              &testOracleType,
+					MaxOraclePriceDeviation: (*math.HexOrDecimal256)(big.NewInt(5e4)),
+				}}}}},
+			},
+			expectedError: "marketParams[0]: maxOraclePriceAge and maxOraclePriceDeviation can't be checked for a test oracle",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -185,3 +256,64 @@ func TestUnmarshalStateUpgradeJSON(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, upgradeConfig, unmarshaledConfig)
 }
+
+func TestUnmarshalHubbleStateUpgradeJSON(t *testing.T) {
+	jsonBytes := []byte(
+		`{
+			"stateUpgrades": [
+				{
+					"blockTimestamp": 1677608400,
+					"hubble": {
+						"addMarkets": [
+							{
+								"amm": "0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC",
+								"oracle": "0x0300000000000000000000000000000000000008",
+								"oracleType": 4,
+								"minSizeRequirement": "10000000000000000",
+								"fundingPeriod": 3600
+							}
+						],
+						"marketParams": [
+							{
+								"market": 2,
+								"maxLiquidationRatio": "250000"
+							}
+						]
+					}
+				}
+			]
+		}`,
+	)
+
+	oracleType := uint8(4)
+	upgradeConfig := UpgradeConfig{
+		StateUpgrades: []StateUpgrade{
+			{
+				BlockTimestamp: utils.NewUint64(1677608400),
+				Hubble: &HubbleUpgrade{
+					AddMarkets: []HubbleMarket{
+						{
+							AMM: common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC"),
+							HubbleMarketConfig: HubbleMarketConfig{
+								Oracle:             &common.Address{3, 19: 8},
+								OracleType:         &oracleType,
+								MinSizeRequirement: (*math.HexOrDecimal256)(big.NewInt(1e16)),
+								FundingPeriod:      utils.NewUint64(3600),
+							},
+						},
+					},
+					MarketParams: []HubbleMarketParams{
+						{
+							Market:             2,
+							HubbleMarketConfig: HubbleMarketConfig{MaxLiquidationRatio: (*math.HexOrDecimal256)(big.NewInt(25e4))},
+						},
+					},
+				},
+			},
+		},
+	}
+	var unmarshaledConfig UpgradeConfig
+	err := json.Unmarshal(jsonBytes, &unmarshaledConfig)
+	require.NoError(t, err)
+	require.Equal(t, upgradeConfig, unmarshaledConfig)
+}
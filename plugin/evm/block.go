@@ -240,6 +240,13 @@ func (b *Block) verify(predicateContext *precompileconfig.ProposerPredicateConte
 		return nil
 	}
 
+	// the sequencing doesn't depend on the context, so it is only checked the first time the block is verified
+	if b.vm.bootstrapped {
+		if err := b.vm.limitOrderProcesser.VerifyOrderBookSequencing(b.ethBlock); err != nil {
+			return fmt.Errorf("failed to verify orderbook sequencing: %w", err)
+		}
+	}
+
 	return b.vm.blockChain.InsertBlockManual(b.ethBlock, writes)
 }
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/ava-labs/subnet-evm/core/txpool"
 	"github.com/ava-labs/subnet-evm/eth"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/spf13/cast"
 )
@@ -61,6 +62,16 @@ const (
 
 	defaultIsValidator       = false
 	defaultTradingAPIEnabled = false
+
+	defaultOrderBookSequencingCheckEnabled = false
+	defaultOrderBookSequencingCheckReject  = false
+	defaultOrderBookGasBudgetPercent       = 100
+	defaultOrderBookTxPriorityPolicy       = "liquidations-first"
+	defaultOrderBookBatchExecutionEnabled  = false
+	// cancellations are not urgent, they don't overpay for a quick inclusion
+	defaultOrderBookCancelFeeCapPercent = 150
+	defaultOrderBookPreflightEnabled    = false
+	defaultOrderBookCheckpointInterval  = 0
 )
 
 var (
@@ -217,14 +228,69 @@ type Config struct {
 
 	// Path to validator private key file
 	ValidatorPrivateKeyFile string `json:"validator-private-key-file"`
+	// ValidatorKeystoreFile is an encrypted json key used instead of the plaintext validator-private-key-file,
+	// it is unlocked with the passphrase in ValidatorKeystorePassphraseFile
+	ValidatorKeystoreFile           string `json:"validator-keystore-file"`
+	ValidatorKeystorePassphraseFile string `json:"validator-keystore-passphrase-file"`
+	// ValidatorExternalSigner is the IPC path or http url of a clef compatible signer that signs the orderbook txs,
+	// the key never leaves the signer. ValidatorSignerAddress picks the account if the signer manages more than one.
+	ValidatorExternalSigner string `json:"validator-external-signer"`
+	ValidatorSignerAddress  string `json:"validator-signer-address"`
+	// Additional validator hot keys, each of them must be registered as a validator in the orderbook.
+	// The orderbook txs are sharded across all the keys so that a stuck nonce on one of them doesn't block the others.
+	// They are of the same kind as the key above: private key files, keystore files (sharing the passphrase file) or external signer accounts.
+	ValidatorPrivateKeyFiles []string `json:"validator-private-key-files"`
+	ValidatorKeystoreFiles   []string `json:"validator-keystore-files"`
+	ValidatorSignerAddresses []string `json:"validator-signer-addresses"`
 
 	// Testing apis enabled
 	TestingApiEnabled bool `json:"testing-api-enabled"`
+	// TestingStateOverridesEnabled lets the testing apis write to the state of the blocks this node builds or processes.
+	// Other nodes compute a different state root for these blocks, so it refuses to start unless the network has a
+	// single validator, and it requires pruning to be disabled as the writes are not applied again when a block is
+	// re-executed on restart.
+	TestingStateOverridesEnabled bool `json:"testing-state-overrides-enabled"`
 	// IsValidator is true if this node is a validator
 	IsValidator bool `json:"is-validator"`
 
 	// TradingAPI is for the sdk
 	TradingAPIEnabled bool `json:"trading-api-enabled"`
+
+	// OrderBookSequencingCheckEnabled re-runs the matching pipeline while verifying a block and flags blocks
+	// whose executeMatchedOrders/liquidateAndExecuteOrder txs deviate from it
+	OrderBookSequencingCheckEnabled bool `json:"order-book-sequencing-check-enabled"`
+	// OrderBookSequencingCheckReject fails the verification of such blocks instead of only flagging them
+	OrderBookSequencingCheckReject bool `json:"order-book-sequencing-check-reject"`
+
+	// OrderBookGasBudgetPercent is the percentage of the block gas limit that the orderbook txs of the validator may use
+	OrderBookGasBudgetPercent uint64 `json:"order-book-gas-budget-percent"`
+	// OrderBookTxPriorityPolicy decides which orderbook txs are sent first when they don't all fit in the gas budget.
+	// One of "liquidations-first" (liquidations, then cancellations, then matches by largest notional) or "sequential".
+	// Nodes running the sequencing check should use the same policy as the validators.
+	OrderBookTxPriorityPolicy string `json:"order-book-tx-priority-policy"`
+	// OrderBookBatchExecutionEnabled sends the liquidations and matches of the validator in a single executeBatch tx per block.
+	// The orderbook contract emits OrderMatchingError/LiquidationError for the executions that fail instead of reverting the batch.
+	// It needs an orderbook contract that implements executeBatch, which the deployed OrderBook doesn't yet; when the batch
+	// can't be executed, the executions are sent in separate txs as if this was disabled.
+	OrderBookBatchExecutionEnabled bool `json:"order-book-batch-execution-enabled"`
+	// OrderBookTxFeeCaps caps the gas price of the orderbook txs of a kind ("funding", "liquidation", "cancel" or "match")
+	// at a percentage of the suggested gas price, e.g. {"cancel": 150}. Kinds that are not in the map may pay whatever the fee strategy asks.
+	OrderBookTxFeeCaps map[string]uint64 `json:"order-book-tx-fee-caps"`
+	// OrderBookPreflightEnabled doesn't send the orderbook txs of the validator that fail when they are simulated on top of the
+	// txs sent before them for the block. The orders of such a tx are marked Execution_Failed right away and skipped until they
+	// are retried, instead of failing on-chain. These statuses are local to the validator, they are left out of the sequencing
+	// check and the checkpoints, so nodes running the sequencing check may flag the executions that were left out.
+	OrderBookPreflightEnabled bool `json:"order-book-preflight-enabled"`
+	// OrderBookCheckpointInterval signs a warp message committing to the Merkle root of the open orders and positions
+	// every that many accepted blocks; 0 disables the checkpoints. The validators must run with the same interval for the
+	// signatures to aggregate. See plugin/evm/orderbook/checkpoint for verifying the inclusion proofs.
+	OrderBookCheckpointInterval uint64 `json:"order-book-checkpoint-interval"`
+
+	// SkipStorageLayoutCheck only logs an error, instead of failing to start, when the storage layout of a deployed
+	// hubble contract does not match the slots that the precompiles read, or its code has no compiled storage layout
+	// to verify it with (see precompile/contracts/bibliophile/layouts). hUSD and the MarginAccount are always verified
+	// when the margin bridge is enabled.
+	SkipStorageLayoutCheck bool `json:"skip-storage-layout-check"`
 }
 
 // EthAPIs returns an array of strings representing the Eth APIs that should be enabled
@@ -287,6 +353,14 @@ func (c *Config) SetDefaults() {
 	c.TestingApiEnabled = defaultTestingApiEnabled
 	c.IsValidator = defaultIsValidator
 	c.TradingAPIEnabled = defaultTradingAPIEnabled
+	c.OrderBookSequencingCheckEnabled = defaultOrderBookSequencingCheckEnabled
+	c.OrderBookSequencingCheckReject = defaultOrderBookSequencingCheckReject
+	c.OrderBookGasBudgetPercent = defaultOrderBookGasBudgetPercent
+	c.OrderBookTxPriorityPolicy = defaultOrderBookTxPriorityPolicy
+	c.OrderBookBatchExecutionEnabled = defaultOrderBookBatchExecutionEnabled
+	c.OrderBookTxFeeCaps = map[string]uint64{"cancel": defaultOrderBookCancelFeeCapPercent}
+	c.OrderBookPreflightEnabled = defaultOrderBookPreflightEnabled
+	c.OrderBookCheckpointInterval = defaultOrderBookCheckpointInterval
 }
 
 func (d *Duration) UnmarshalJSON(data []byte) (err error) {
@@ -325,5 +399,43 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("cannot use commit interval of 0 with pruning enabled")
 	}
 
+	if c.TestingStateOverridesEnabled && !c.TestingApiEnabled {
+		return fmt.Errorf("testing-state-overrides-enabled requires testing-api-enabled")
+	}
+	if c.TestingStateOverridesEnabled && c.Pruning {
+		return fmt.Errorf("cannot enable testing state overrides while pruning is enabled")
+	}
+
+	if c.ValidatorKeystoreFile != "" && c.ValidatorExternalSigner != "" {
+		return fmt.Errorf("only one of validator-keystore-file and validator-external-signer can be set")
+	}
+	if c.ValidatorKeystoreFile != "" && c.ValidatorKeystorePassphraseFile == "" {
+		return fmt.Errorf("validator-keystore-passphrase-file is required with validator-keystore-file")
+	}
+	if c.ValidatorSignerAddress != "" && !common.IsHexAddress(c.ValidatorSignerAddress) {
+		return fmt.Errorf("invalid validator-signer-address %q", c.ValidatorSignerAddress)
+	}
+	for _, address := range c.ValidatorSignerAddresses {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("invalid address %q in validator-signer-addresses", address)
+		}
+	}
+	if len(c.ValidatorKeystoreFiles) > 0 && c.ValidatorKeystoreFile == "" {
+		return fmt.Errorf("validator-keystore-files requires validator-keystore-file")
+	}
+	if len(c.ValidatorSignerAddresses) > 0 && c.ValidatorExternalSigner == "" {
+		return fmt.Errorf("validator-signer-addresses requires validator-external-signer")
+	}
+
+	if c.OrderBookGasBudgetPercent == 0 || c.OrderBookGasBudgetPercent > 100 {
+		return fmt.Errorf("order book gas budget percent must be in (0, 100], got %d", c.OrderBookGasBudgetPercent)
+	}
+	if err := orderbook.TxPriorityPolicy(c.OrderBookTxPriorityPolicy).Validate(); err != nil {
+		return err
+	}
+	if err := orderbook.TxFeeCaps(c.OrderBookTxFeeCaps).Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"runtime"
 	"runtime/debug"
@@ -17,11 +19,19 @@ import (
 	"github.com/ava-labs/subnet-evm/eth"
 	"github.com/ava-labs/subnet-evm/eth/filters"
 	"github.com/ava-labs/subnet-evm/metrics"
+	"github.com/ava-labs/subnet-evm/params"
 	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/marginbridge"
+	"github.com/ava-labs/subnet-evm/stateupgrade"
 	"github.com/ava-labs/subnet-evm/utils"
+	"github.com/ava-labs/subnet-evm/warp"
 
 	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/snow"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	avalancheWarp "github.com/ava-labs/avalanchego/vms/platformvm/warp"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -34,8 +44,10 @@ const (
 type LimitOrderProcesser interface {
 	ListenAndProcessTransactions(blockBuilder *blockBuilder)
 	GetOrderBookAPI() *orderbook.OrderBookAPI
-	GetTestingAPI() *orderbook.TestingAPI
+	GetTestingAPI(stateOverrides *core.TestingStateOverrides) *orderbook.TestingAPI
 	GetTradingAPI() *orderbook.TradingAPI
+	VerifyOrderBookSequencing(block *types.Block) error
+	HandleBlockGasTooLow()
 }
 
 type limitOrderProcesser struct {
@@ -56,21 +68,29 @@ type limitOrderProcesser struct {
 	blockBuilder           *blockBuilder
 	isValidator            bool
 	tradingAPIEnabled      bool
+	sequencingCheckEnabled bool
+	sequencingCheckReject  bool
+	warpBackend            warp.WarpBackend
+	checkpointInterval     uint64
+	checkpoints            *orderbook.CheckpointStore
 }
 
-func NewLimitOrderProcesser(ctx *snow.Context, txPool *txpool.TxPool, shutdownChan <-chan struct{}, shutdownWg *sync.WaitGroup, backend *eth.EthAPIBackend, blockChain *core.BlockChain, hubbleDB database.Database, validatorPrivateKey string, isValidator bool, tradingAPIEnabled bool) LimitOrderProcesser {
+func NewLimitOrderProcesser(ctx *snow.Context, txPool *txpool.TxPool, shutdownChan <-chan struct{}, shutdownWg *sync.WaitGroup, backend *eth.EthAPIBackend, blockChain *core.BlockChain, hubbleDB database.Database, validatorSigners []orderbook.ValidatorSigner, isValidator bool, tradingAPIEnabled bool, sequencingCheckEnabled bool, sequencingCheckReject bool, gasBudgetPercent uint64, txPriorityPolicy orderbook.TxPriorityPolicy, batchExecution bool, txFeeCaps orderbook.TxFeeCaps, preflightEnabled bool, warpBackend warp.WarpBackend, checkpointInterval uint64) LimitOrderProcesser {
 	log.Info("**** NewLimitOrderProcesser")
 	configService := orderbook.NewConfigService(blockChain)
 	memoryDb := orderbook.NewInMemoryDatabase(configService)
-	lotp := orderbook.NewLimitOrderTxProcessor(txPool, memoryDb, backend, validatorPrivateKey)
+	lotp := orderbook.NewLimitOrderTxProcessor(txPool, memoryDb, backend, validatorSigners, gasBudgetPercent, txFeeCaps, preflightEnabled)
 	contractEventProcessor := orderbook.NewContractEventsProcessor(memoryDb)
 	matchingPipeline := orderbook.NewMatchingPipeline(memoryDb, lotp, configService)
+	matchingPipeline.TxPriorityPolicy = txPriorityPolicy
+	matchingPipeline.BatchExecution = batchExecution
 	filterSystem := filters.NewFilterSystem(backend, filters.Config{})
 	filterAPI := filters.NewFilterAPI(filterSystem)
 
 	// need to register the types for gob encoding because memory DB has an interface field(ContractOrder)
 	gob.Register(&orderbook.LimitOrder{})
 	gob.Register(&orderbook.IOCOrder{})
+	gob.Register(&orderbook.GTTOrder{})
 	return &limitOrderProcesser{
 		ctx:                    ctx,
 		mu:                     &sync.Mutex{},
@@ -88,9 +108,115 @@ func NewLimitOrderProcesser(ctx *snow.Context, txPool *txpool.TxPool, shutdownCh
 		configService:          configService,
 		isValidator:            isValidator,
 		tradingAPIEnabled:      tradingAPIEnabled,
+		sequencingCheckEnabled: sequencingCheckEnabled,
+		sequencingCheckReject:  sequencingCheckReject,
+		warpBackend:            warpBackend,
+		checkpointInterval:     checkpointInterval,
+		checkpoints:            orderbook.NewCheckpointStore(),
 	}
 }
 
+// compiledStorageLayouts are the layouts that the deployed hubble contracts are verified with
+var compiledStorageLayouts = bibliophile.CompiledStorageLayouts
+
+// verifyStorageLayouts checks that the hubble contracts at the head of [blockChain] are laid out in storage the way the
+// precompiles read them. A mismatch, or a contract whose code has no compiled storage layout, fails the startup unless
+// [skip] is set. The contracts that the margin bridge writes are always verified, see verifyMarginBridgeStorageLayouts.
+func verifyStorageLayouts(chainConfig *params.ChainConfig, blockChain *core.BlockChain, skip bool) error {
+	stateDB, err := blockChain.State()
+	if err != nil {
+		return err
+	}
+	layouts, err := compiledStorageLayouts()
+	if err != nil {
+		return err
+	}
+	if err := verifyMarginBridgeStorageLayouts(chainConfig, stateDB, layouts); err != nil {
+		return err
+	}
+	unverified, err := bibliophile.VerifyDeployedStorageLayouts(stateDB, layouts)
+	if err == nil && len(unverified) > 0 {
+		err = fmt.Errorf("no compiled storage layout for the code of the hubble contracts at %v", unverified)
+	}
+	if err != nil {
+		if skip {
+			log.Error("storage layout check failed, continuing since it is skipped", "err", err)
+			return nil
+		}
+		return fmt.Errorf("storage layout check failed, set skip-storage-layout-check to start anyway: %w", err)
+	}
+	return nil
+}
+
+// verifyMarginBridgeStorageLayouts checks the layouts of hUSD and the MarginAccount when the margin bridge is enabled in
+// [chainConfig]. The bridge mints and burns hUSD and credits the margin by writing their storage directly, at slots
+// that only a verified layout vouches for, so unlike the other contracts it can't be skipped: the node doesn't start
+// with the bridge enabled until the layouts of the deployed hUSD and MarginAccount are in bibliophile/layouts.
+func verifyMarginBridgeStorageLayouts(chainConfig *params.ChainConfig, stateDB bibliophile.CodeHashReader, layouts []*bibliophile.CompiledStorageLayout) error {
+	var husds []common.Address
+	enabled := false
+	for _, config := range chainConfig.GetActivatingPrecompileConfigs(marginbridge.ContractAddress, nil, math.MaxUint64, chainConfig.PrecompileUpgrades) {
+		if config.IsDisabled() {
+			continue
+		}
+		enabled = true
+		if husd := config.(*marginbridge.Config).HUSD; husd != (common.Address{}) {
+			husds = append(husds, husd)
+		}
+	}
+	if !enabled {
+		return nil
+	}
+	if err := bibliophile.VerifyDeployedStorageLayout(stateDB, layouts, orderbook.MarginAccountContractAddress, bibliophile.MarginAccountContract); err != nil {
+		return fmt.Errorf("the margin bridge is enabled, and writes the storage of the MarginAccount: %w", err)
+	}
+	for _, husd := range husds {
+		if err := bibliophile.VerifyDeployedStorageLayout(stateDB, layouts, husd, bibliophile.HUSDContract); err != nil {
+			return fmt.Errorf("the margin bridge is enabled, and writes the storage of hUSD: %w", err)
+		}
+	}
+	return nil
+}
+
+// verifyStateUpgrades checks the state upgrades of [chainConfig] that are not activated yet against the last accepted
+// state of [blockChain], in the order they activate. A hubble upgrade that doesn't apply is skipped when it activates
+// rather than halting the chain, so the mistake has to be caught here, when the config is loaded.
+func verifyStateUpgrades(chainConfig *params.ChainConfig, blockChain *core.BlockChain) error {
+	lastAccepted := blockChain.LastAcceptedBlock()
+	stateDB, err := blockChain.StateAt(lastAccepted.Root())
+	if err != nil {
+		return err
+	}
+	blockContext := types.NewBlockWithHeader(&types.Header{Number: new(big.Int).Add(lastAccepted.Number(), big.NewInt(1))})
+	for i, upgrade := range chainConfig.StateUpgrades {
+		if *upgrade.BlockTimestamp <= lastAccepted.Time() {
+			continue
+		}
+		if err := stateupgrade.Verify(&chainConfig.StateUpgrades[i], chainConfig, stateDB, blockContext); err != nil {
+			return fmt.Errorf("StateUpgrade[%d] does not apply to the last accepted state: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// verifySingleValidator checks that [subnetID] has a single validator, the only setup where the testing state
+// overrides of this node don't make the other nodes compute a different state root. It is checked once, when the
+// testing API is registered.
+func verifySingleValidator(ctx context.Context, validatorState validators.State, subnetID ids.ID) error {
+	height, err := validatorState.GetCurrentHeight(ctx)
+	if err != nil {
+		return err
+	}
+	validatorSet, err := validatorState.GetValidatorSet(ctx, height, subnetID)
+	if err != nil {
+		return err
+	}
+	if len(validatorSet) != 1 {
+		return fmt.Errorf("the subnet has %d validators, only a single validator is supported", len(validatorSet))
+	}
+	return nil
+}
+
 func (lop *limitOrderProcesser) ListenAndProcessTransactions(blockBuilder *blockBuilder) {
 	lop.mu.Lock()
 
@@ -136,6 +262,8 @@ func (lop *limitOrderProcesser) ListenAndProcessTransactions(blockBuilder *block
 		// needs to be run everytime as long as the db.UpdatePosition uses configService.GetCumulativePremiumFraction
 		lop.UpdateLastPremiumFractionFromStorage()
 	}
+	// the snapshot might be from before funding times were tracked per market, or a new market may have been listed since
+	lop.UpdateNextFundingTimeFromStorage()
 
 	lop.mu.Unlock()
 
@@ -149,23 +277,87 @@ func (lop *limitOrderProcesser) RunMatchingPipeline() {
 		return
 	}
 	executeFuncAndRecoverPanic(func() {
-		matchesFound := lop.matchingPipeline.Run(new(big.Int).Add(lop.blockChain.CurrentBlock().Number, big.NewInt(1)))
-		if matchesFound {
-			lop.blockBuilder.signalTxsReady()
-		}
+		lop.runMatchingPipeline()
 	}, orderbook.RunMatchingPipelinePanicMessage, orderbook.RunMatchingPipelinePanicsCounter)
 }
 
+func (lop *limitOrderProcesser) runMatchingPipeline() bool {
+	// funding is decided against the timestamp of the current head, the parent of the block being built, rather than the
+	// local clock, so that it is deterministic across validators. The oracle circuit breaker is checked at the timestamp
+	// the block will be built with, which is what the juror sees when the txs execute.
+	currentBlock := lop.blockChain.CurrentBlock()
+	matchesFound := lop.matchingPipeline.Run(new(big.Int).Add(currentBlock.Number, big.NewInt(1)), currentBlock.Time, orderbook.NextBlockTimestamp(currentBlock.Time))
+	if matchesFound {
+		lop.blockBuilder.signalTxsReady()
+	}
+	return matchesFound
+}
+
+// forceRunMatchingPipeline runs the matching pipeline for the testing API, without waiting for the matching ticker
+func (lop *limitOrderProcesser) forceRunMatchingPipeline() (bool, error) {
+	if !lop.isValidator {
+		return false, orderbook.ErrNotValidator
+	}
+	if lop.blockBuilder == nil {
+		return false, errors.New("the order book is not running yet")
+	}
+	return lop.runMatchingPipeline(), nil
+}
+
+// VerifyOrderBookSequencing checks that the executeMatchedOrders/liquidateAndExecuteOrder txs in the block are the ones
+// that the matching pipeline produces for the order book at its parent. Only a prefix of them is required to be in the block.
+// The order book in memory is at the current head, so blocks that are not built on top of it are not checked.
+func (lop *limitOrderProcesser) VerifyOrderBookSequencing(block *types.Block) error {
+	if !lop.sequencingCheckEnabled {
+		return nil
+	}
+	// the lock is only held to copy the order book at the parent, the matching pipeline then runs on the copy
+	lop.mu.Lock()
+	parent := lop.blockChain.CurrentBlock()
+	if parent.Hash() != block.ParentHash() {
+		lop.mu.Unlock()
+		log.Info("VerifyOrderBookSequencing - parent is not the current head, skipping", "number", block.NumberU64(), "hash", block.Hash().String())
+		orderbook.OrderBookSequencingSkippedCounter.Inc(1)
+		return nil
+	}
+	memoryDBCopy, err := lop.memoryDb.GetOrderBookDataCopy()
+	lop.mu.Unlock()
+	if err != nil {
+		// the block is not at fault
+		log.Error("VerifyOrderBookSequencing - error in copying the order book, skipping", "number", block.NumberU64(), "hash", block.Hash().String(), "err", err)
+		orderbook.OrderBookSequencingSkippedCounter.Inc(1)
+		return nil
+	}
+	// the block builder doesn't know about the statuses of this node's simulations
+	memoryDBCopy.DropLocalStatuses()
+
+	// same arguments as in RunMatchingPipeline when this block was built
+	expected := lop.matchingPipeline.PlanExecutions(memoryDBCopy, block.Number(), parent.Time, block.Time())
+	report := orderbook.CompareExecutions(expected, orderbook.GetOrderBookExecutions(block.Transactions()))
+	if !report.Deviates() {
+		return nil
+	}
+
+	orderbook.OrderBookSequencingDeviationsCounter.Inc(1)
+	log.Warn("VerifyOrderBookSequencing - orderbook txs deviate from the matching pipeline", "number", block.NumberU64(), "hash", block.Hash().String(), "coinbase", block.Coinbase().String(),
+		"expected", report.Expected, "included", report.Included, "unexpected", report.Unexpected, "skipped", report.Skipped, "reordered", report.Reordered)
+	if lop.sequencingCheckReject {
+		return fmt.Errorf("orderbook txs deviate from the matching pipeline: %d unexpected, %d skipped, reordered=%t", len(report.Unexpected), len(report.Skipped), report.Reordered)
+	}
+	return nil
+}
+
 func (lop *limitOrderProcesser) GetOrderBookAPI() *orderbook.OrderBookAPI {
-	return orderbook.NewOrderBookAPI(lop.memoryDb, lop.backend, lop.configService)
+	return orderbook.NewOrderBookAPI(lop.memoryDb, lop.backend, lop.configService, lop.checkpoints)
 }
 
 func (lop *limitOrderProcesser) GetTradingAPI() *orderbook.TradingAPI {
 	return orderbook.NewTradingAPI(lop.memoryDb, lop.backend, lop.configService)
 }
 
-func (lop *limitOrderProcesser) GetTestingAPI() *orderbook.TestingAPI {
-	return orderbook.NewTestingAPI(lop.memoryDb, lop.backend, lop.configService)
+// GetTestingAPI returns the testing API, which seeds state through [stateOverrides] if it is set
+func (lop *limitOrderProcesser) GetTestingAPI(stateOverrides *core.TestingStateOverrides) *orderbook.TestingAPI {
+	return orderbook.NewTestingAPI(lop.memoryDb, lop.backend, lop.configService, stateOverrides, lop.forceRunMatchingPipeline)
 }
 
 func (lop *limitOrderProcesser) listenAndStoreLimitOrderTransactions() {
@@ -266,6 +458,7 @@ func (lop *limitOrderProcesser) handleChainAcceptedEvent(event core.ChainEvent)
 	block := event.Block
 	log.Info("received ChainAcceptedEvent", "number", block.NumberU64(), "hash", block.Hash().String())
 	lop.memoryDb.Accept(block.NumberU64(), block.Time())
+	lop.applyHubbleUpgrades(block)
 
 	// update metrics asynchronously
 	go lop.limitOrderTxProcessor.UpdateMetrics(block)
@@ -275,6 +468,41 @@ func (lop *limitOrderProcesser) handleChainAcceptedEvent(event core.ChainEvent)
 			log.Error("Error in saving memory DB snapshot", "err", err)
 		}
 	}
+	if lop.checkpointInterval != 0 && block.NumberU64()%lop.checkpointInterval == 0 {
+		err := lop.createCheckpoint(block)
+		if err != nil {
+			log.Error("Error in creating orderbook checkpoint", "err", err)
+		}
+	}
+}
+
+// applyHubbleUpgrades refreshes the memory DB for the hubble state upgrades activated by [block], so that the markets
+// listed and the parameters changed by them are picked up without a restart
+func (lop *limitOrderProcesser) applyHubbleUpgrades(block *types.Block) {
+	parent := lop.blockChain.GetHeaderByHash(block.ParentHash())
+	if parent == nil {
+		return
+	}
+	config := lop.blockChain.Config()
+	for _, upgrade := range config.GetActivatingStateUpgrades(&parent.Time, block.Time(), config.StateUpgrades) {
+		if upgrade.Hubble == nil {
+			continue
+		}
+		if len(upgrade.Hubble.AddMarkets) > 0 {
+			// the new markets need their next funding time, it is only read from storage at startup otherwise
+			lop.UpdateNextFundingTimeFromStorage()
+		}
+		for _, params := range upgrade.Hubble.MarketParams {
+			market := orderbook.Market(params.Market)
+			if params.MaxLiquidationRatio != nil || params.MinSizeRequirement != nil {
+				lop.memoryDb.UpdateLiquidationThresholds(market)
+			}
+			if params.NextFundingTime != nil {
+				lop.memoryDb.UpdateNextFundingTime(market, lop.configService.GetNextFundingTime(market))
+			}
+		}
+		log.Info("applied hubble state upgrade to the memory DB", "number", block.NumberU64(), "addMarkets", len(upgrade.Hubble.AddMarkets), "marketParams", len(upgrade.Hubble.MarketParams))
+	}
 }
 
 func (lop *limitOrderProcesser) loadMemoryDBSnapshot() (acceptedBlockNumber uint64, err error) {
@@ -313,11 +541,67 @@ func (lop *limitOrderProcesser) loadMemoryDBSnapshot() (acceptedBlockNumber uint
 
 // assumes that memory DB lock is held
 func (lop *limitOrderProcesser) saveMemoryDBSnapshot(acceptedBlockNumber *big.Int) error {
+	memoryDBCopy, err := lop.getAcceptedMemoryDBCopy(acceptedBlockNumber)
+	if err != nil {
+		return err
+	}
+
+	snapshot := orderbook.Snapshot{
+		Data:                memoryDBCopy,
+		AcceptedBlockNumber: acceptedBlockNumber,
+	}
+
+	var buf bytes.Buffer
+	err = gob.NewEncoder(&buf).Encode(&snapshot)
+	if err != nil {
+		return fmt.Errorf("error in gob encoding: err=%v", err)
+	}
+
+	err = lop.hubbleDB.Put([]byte(memoryDBSnapshotKey), buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("Error in saving to DB: err=%v", err)
+	}
+
+	log.Info("Saved memory DB snapshot successfully", "accepted block", acceptedBlockNumber, "head block number", lop.blockChain.CurrentBlock().Number)
+
+	return nil
+}
+
+// createCheckpoint commits to the open orders and positions as of the accepted [block] and signs the checkpoint with
+// the warp backend, so that it can be aggregated with warp_getAggregateSignature.
+// assumes that memory DB lock is held
+func (lop *limitOrderProcesser) createCheckpoint(block *types.Block) error {
+	memoryDBCopy, err := lop.getAcceptedMemoryDBCopy(block.Number())
+	if err != nil {
+		return err
+	}
+	checkpoint := orderbook.NewOrderBookCheckpoint(memoryDBCopy, block.NumberU64(), block.Time(), block.Hash())
+	payload, err := checkpoint.Payload()
+	if err != nil {
+		return err
+	}
+	unsignedMessage, err := avalancheWarp.NewUnsignedMessage(lop.ctx.NetworkID, lop.ctx.ChainID, payload.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := lop.warpBackend.AddMessage(unsignedMessage); err != nil {
+		return err
+	}
+	checkpoint.MessageID = unsignedMessage.ID()
+	lop.checkpoints.Set(checkpoint)
+	log.Info("Created orderbook checkpoint", "block", block.NumberU64(), "root", checkpoint.Root(), "messageID", checkpoint.MessageID)
+	return nil
+}
+
+// getAcceptedMemoryDBCopy returns a copy of the memory DB as of [acceptedBlockNumber], without the events of the
+// blocks after it that are already processed
+// assumes that memory DB lock is held
+func (lop *limitOrderProcesser) getAcceptedMemoryDBCopy(acceptedBlockNumber *big.Int) (*orderbook.InMemoryDatabase, error) {
 	currentHeadBlock := lop.blockChain.CurrentBlock()
 
 	memoryDBCopy, err := lop.memoryDb.GetOrderBookDataCopy()
 	if err != nil {
-		return fmt.Errorf("Error in getting memory DB copy: err=%v", err)
+		return nil, fmt.Errorf("Error in getting memory DB copy: err=%v", err)
 	}
 	if currentHeadBlock.Number.Cmp(acceptedBlockNumber) == 1 {
 		// if current head is ahead of the accepted block, then certain events(OrderBook)
@@ -341,26 +625,7 @@ func (lop *limitOrderProcesser) saveMemoryDBSnapshot(acceptedBlockNumber *big.In
 		cev := orderbook.NewContractEventsProcessor(memoryDBCopy)
 		cev.ProcessEvents(logsToRemove)
 	}
-
-	snapshot := orderbook.Snapshot{
-		Data:                memoryDBCopy,
-		AcceptedBlockNumber: acceptedBlockNumber,
-	}
-
-	var buf bytes.Buffer
-	err = gob.NewEncoder(&buf).Encode(&snapshot)
-	if err != nil {
-		return fmt.Errorf("error in gob encoding: err=%v", err)
-	}
-
-	err = lop.hubbleDB.Put([]byte(memoryDBSnapshotKey), buf.Bytes())
-	if err != nil {
-		return fmt.Errorf("Error in saving to DB: err=%v", err)
-	}
-
-	log.Info("Saved memory DB snapshot successfully", "accepted block", acceptedBlockNumber, "head block number", currentHeadBlock.Number, "head block hash", currentHeadBlock.Hash())
-
-	return nil
+	return memoryDBCopy, nil
 }
 
 func (lop *limitOrderProcesser) getLogs(fromBlock, toBlock *big.Int) []*types.Log {
@@ -368,7 +633,7 @@ func (lop *limitOrderProcesser) getLogs(fromBlock, toBlock *big.Int) []*types.Lo
 	logs, err := lop.filterAPI.GetLogs(ctx, filters.FilterCriteria{
 		FromBlock: fromBlock,
 		ToBlock:   toBlock,
-		Addresses: []common.Address{orderbook.OrderBookContractAddress, orderbook.ClearingHouseContractAddress, orderbook.MarginAccountContractAddress},
+		Addresses: []common.Address{orderbook.OrderBookContractAddress, orderbook.ClearingHouseContractAddress, orderbook.MarginAccountContractAddress, orderbook.MarginBridgeContractAddress, orderbook.GTTOrderBookContractAddress},
 	})
 
 	if err != nil {
@@ -394,6 +659,15 @@ func (lop *limitOrderProcesser) UpdateLastPremiumFractionFromStorage() {
 	log.Info("@@@@ UpdateLastPremiumFractionFromStorage - update complete", "count", count, "time taken", time.Since(start))
 }
 
+func (lop *limitOrderProcesser) UpdateNextFundingTimeFromStorage() {
+	count := lop.configService.GetActiveMarketsCount()
+	for i := int64(0); i < count; i++ {
+		market := orderbook.Market(i)
+		lop.memoryDb.UpdateNextFundingTime(market, lop.configService.GetNextFundingTime(market))
+	}
+	log.Info("@@@@ UpdateNextFundingTimeFromStorage - update complete", "markets", count)
+}
+
 func executeFuncAndRecoverPanic(fn func(), panicMessage string, panicCounter metrics.Counter) {
 	defer func() {
 		if panicInfo := recover(); panicInfo != nil {
@@ -415,3 +689,11 @@ func executeFuncAndRecoverPanic(fn func(), panicMessage string, panicCounter met
 	}()
 	fn()
 }
+
+// HandleBlockGasTooLow is called when a block with the orderbook txs of the validator failed to be built with BLOCK_GAS_TOO_LOW
+func (lop *limitOrderProcesser) HandleBlockGasTooLow() {
+	lop.mu.Lock()
+	defer lop.mu.Unlock()
+
+	lop.limitOrderTxProcessor.HandleBlockGasTooLow()
+}
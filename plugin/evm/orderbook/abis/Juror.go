@@ -0,0 +1,76 @@
+package abis
+
+// JurorAbi only has the juror methods that the validator calls
+var JurorAbi = []byte(`{"abi": [
+  {
+    "inputs": [
+      {
+        "internalType": "bytes[2]",
+        "name": "data",
+        "type": "bytes[2]"
+      },
+      {
+        "internalType": "int256",
+        "name": "fillAmount",
+        "type": "int256"
+      }
+    ],
+    "name": "validateMatchedOrders",
+    "outputs": [
+      {
+        "internalType": "string",
+        "name": "err",
+        "type": "string"
+      },
+      {
+        "internalType": "enum IJuror.BadElement",
+        "name": "element",
+        "type": "uint8"
+      },
+      {
+        "components": [
+          {
+            "internalType": "uint256",
+            "name": "ammIndex",
+            "type": "uint256"
+          },
+          {
+            "internalType": "address",
+            "name": "trader",
+            "type": "address"
+          },
+          {
+            "internalType": "bytes32",
+            "name": "orderHash",
+            "type": "bytes32"
+          },
+          {
+            "internalType": "enum IClearingHouse.OrderExecutionMode",
+            "name": "mode",
+            "type": "uint8"
+          }
+        ],
+        "internalType": "struct IClearingHouse.Instruction[2]",
+        "name": "instructions",
+        "type": "tuple[2]"
+      },
+      {
+        "internalType": "uint8[2]",
+        "name": "orderTypes",
+        "type": "uint8[2]"
+      },
+      {
+        "internalType": "bytes[2]",
+        "name": "encodedOrders",
+        "type": "bytes[2]"
+      },
+      {
+        "internalType": "uint256",
+        "name": "fillPrice",
+        "type": "uint256"
+      }
+    ],
+    "stateMutability": "view",
+    "type": "function"
+  }
+]}`)
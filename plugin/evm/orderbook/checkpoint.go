@@ -0,0 +1,131 @@
+package orderbook
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/checkpoint"
+	"github.com/ava-labs/subnet-evm/warp/payload"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ErrNoCheckpoint = errors.New("no orderbook checkpoint has been taken yet")
+
+// OrderBookCheckpoint is the Merkle tree of the open orders and positions of the orderbook at an accepted block.
+// The validators sign its root in the warp message with [MessageID], which is aggregated with warp_getAggregateSignature.
+type OrderBookCheckpoint struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	MessageID   ids.ID
+
+	tree      *checkpoint.Tree
+	orders    map[common.Hash]checkpoint.OrderLeaf
+	positions map[common.Address]map[Market]checkpoint.PositionLeaf
+}
+
+// NewOrderBookCheckpoint builds the checkpoint of [db], which must hold the orderbook as of the accepted block [blockNumber].
+// Every validator has to arrive at the same root for the signatures to aggregate, so the leaves only use what the chain
+// decides: the statuses that nodes set locally are ignored, and orders that are fulfilled, cancelled or expired as of
+// [blockTimestamp] are left out whether or not this node has deleted them yet.
+func NewOrderBookCheckpoint(db *InMemoryDatabase, blockNumber uint64, blockTimestamp uint64, blockHash common.Hash) *OrderBookCheckpoint {
+	c := &OrderBookCheckpoint{
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+		orders:      map[common.Hash]checkpoint.OrderLeaf{},
+		positions:   map[common.Address]map[Market]checkpoint.PositionLeaf{},
+	}
+	leaves := []common.Hash{}
+	for id, order := range db.OrderMap {
+		if status := order.getChainOrderStatus().Status; status == FulFilled || status == Cancelled {
+			continue
+		}
+		if order.OrderType == IOCOrderType && order.isExpired(blockTimestamp) {
+			continue
+		}
+		leaf := checkpoint.OrderLeaf{
+			OrderId:                 id,
+			Trader:                  common.HexToAddress(order.UserAddress),
+			Market:                  int64(order.Market),
+			BaseAssetQuantity:       order.BaseAssetQuantity,
+			FilledBaseAssetQuantity: order.FilledBaseAssetQuantity,
+			Price:                   order.Price,
+		}
+		c.orders[id] = leaf
+		leaves = append(leaves, leaf.Hash())
+	}
+	for trader, traderInfo := range db.TraderMap {
+		for market, position := range traderInfo.Positions {
+			if position == nil || position.Size == nil || position.Size.Sign() == 0 {
+				continue
+			}
+			leaf := checkpoint.PositionLeaf{
+				Trader:       trader,
+				Market:       int64(market),
+				Size:         position.Size,
+				OpenNotional: position.OpenNotional,
+			}
+			if c.positions[trader] == nil {
+				c.positions[trader] = map[Market]checkpoint.PositionLeaf{}
+			}
+			c.positions[trader][market] = leaf
+			leaves = append(leaves, leaf.Hash())
+		}
+	}
+	c.tree = checkpoint.NewTree(leaves)
+	return c
+}
+
+// Root returns the Merkle root of the orders and positions
+func (c *OrderBookCheckpoint) Root() common.Hash {
+	return c.tree.Root()
+}
+
+// Payload returns the warp payload that the validators sign
+func (c *OrderBookCheckpoint) Payload() (*payload.OrderBookCheckpoint, error) {
+	return payload.NewOrderBookCheckpoint(c.BlockNumber, c.BlockHash, c.Root())
+}
+
+// OrderProof returns the leaf of the order [orderId] and its inclusion proof
+func (c *OrderBookCheckpoint) OrderProof(orderId common.Hash) (checkpoint.OrderLeaf, []common.Hash, error) {
+	leaf, ok := c.orders[orderId]
+	if !ok {
+		return checkpoint.OrderLeaf{}, nil, checkpoint.ErrLeafNotFound
+	}
+	proof, err := c.tree.Proof(leaf.Hash())
+	return leaf, proof, err
+}
+
+// PositionProof returns the leaf of the position of [trader] in [market] and its inclusion proof
+func (c *OrderBookCheckpoint) PositionProof(trader common.Address, market Market) (checkpoint.PositionLeaf, []common.Hash, error) {
+	leaf, ok := c.positions[trader][market]
+	if !ok {
+		return checkpoint.PositionLeaf{}, nil, checkpoint.ErrLeafNotFound
+	}
+	proof, err := c.tree.Proof(leaf.Hash())
+	return leaf, proof, err
+}
+
+// CheckpointStore keeps the latest checkpoint, which the orderbook API serves the proofs of.
+// It is not persisted, so there are no proofs after a restart until the next checkpoint is taken.
+type CheckpointStore struct {
+	mu     sync.RWMutex
+	latest *OrderBookCheckpoint
+}
+
+func NewCheckpointStore() *CheckpointStore {
+	return &CheckpointStore{}
+}
+
+func (s *CheckpointStore) Set(c *OrderBookCheckpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest = c
+}
+
+// Latest returns the latest checkpoint, or nil if none has been taken
+func (s *CheckpointStore) Latest() *OrderBookCheckpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest
+}
@@ -0,0 +1,198 @@
+// Package checkpoint commits to the open orders and positions of the orderbook in a Merkle tree, whose root the
+// validators sign in a warp message (see payload.OrderBookCheckpoint). It also verifies the inclusion of an order or
+// position in a signed checkpoint, for other chains and off-chain risk systems.
+//
+// The tree is compatible with the MerkleProof library of OpenZeppelin: a leaf is
+// keccak256(keccak256(abi.encode(kind, ...fields))) and the pairs of nodes are sorted before they are hashed, so a
+// proof is the list of the sibling hashes from the leaf to the root. A node without a sibling moves up a layer as is.
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	avalancheWarp "github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	"github.com/ava-labs/subnet-evm/warp/payload"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Kinds of the leaves, the first word of their encoding
+const (
+	OrderLeafKind    uint8 = 0
+	PositionLeafKind uint8 = 1
+)
+
+var (
+	ErrLeafNotFound     = errors.New("leaf is not in the checkpoint")
+	ErrInvalidProof     = errors.New("inclusion proof does not match the checkpoint root")
+	ErrUnexpectedSource = errors.New("checkpoint is not sourced from the expected chain")
+)
+
+// OrderLeaf is an open order of the orderbook
+type OrderLeaf struct {
+	OrderId                 common.Hash
+	Trader                  common.Address
+	Market                  int64
+	BaseAssetQuantity       *big.Int
+	FilledBaseAssetQuantity *big.Int
+	Price                   *big.Int
+}
+
+// Hash returns keccak256(keccak256(abi.encode(uint8(0), orderId, trader, market, baseAssetQuantity, filledBaseAssetQuantity, price)))
+func (l OrderLeaf) Hash() common.Hash {
+	return leafHash(
+		big.NewInt(int64(OrderLeafKind)).Bytes(),
+		l.OrderId.Bytes(),
+		l.Trader.Bytes(),
+		intWord(big.NewInt(l.Market)),
+		intWord(l.BaseAssetQuantity),
+		intWord(l.FilledBaseAssetQuantity),
+		intWord(l.Price),
+	)
+}
+
+// PositionLeaf is the position of a trader in a market
+type PositionLeaf struct {
+	Trader       common.Address
+	Market       int64
+	Size         *big.Int
+	OpenNotional *big.Int
+}
+
+// Hash returns keccak256(keccak256(abi.encode(uint8(1), trader, market, size, openNotional)))
+func (l PositionLeaf) Hash() common.Hash {
+	return leafHash(
+		big.NewInt(int64(PositionLeafKind)).Bytes(),
+		l.Trader.Bytes(),
+		intWord(big.NewInt(l.Market)),
+		intWord(l.Size),
+		intWord(l.OpenNotional),
+	)
+}
+
+// intWord is the two's complement of an int256
+func intWord(value *big.Int) []byte {
+	if value == nil {
+		return nil
+	}
+	return math.U256Bytes(new(big.Int).Set(value))
+}
+
+func leafHash(words ...[]byte) common.Hash {
+	encoded := make([]byte, 0, len(words)*common.HashLength)
+	for _, word := range words {
+		encoded = append(encoded, common.LeftPadBytes(word, common.HashLength)...)
+	}
+	return crypto.Keccak256Hash(crypto.Keccak256(encoded))
+}
+
+func hashPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+// Tree is the Merkle tree of a checkpoint
+type Tree struct {
+	// layers[0] are the sorted leaves and the last layer is the root
+	layers [][]common.Hash
+	index  map[common.Hash]int
+}
+
+// NewTree builds the tree of [leaves]. They are sorted first, so the root does not depend on their order.
+// The root of a tree without leaves is the zero hash.
+func NewTree(leaves []common.Hash) *Tree {
+	sorted := make([]common.Hash, len(leaves))
+	copy(sorted, leaves)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+	index := make(map[common.Hash]int, len(sorted))
+	for i, leaf := range sorted {
+		index[leaf] = i
+	}
+
+	layers := [][]common.Hash{sorted}
+	for layer := sorted; len(layer) > 1; {
+		next := make([]common.Hash, 0, (len(layer)+1)/2)
+		for i := 0; i < len(layer); i += 2 {
+			if i+1 == len(layer) {
+				next = append(next, layer[i])
+			} else {
+				next = append(next, hashPair(layer[i], layer[i+1]))
+			}
+		}
+		layers = append(layers, next)
+		layer = next
+	}
+	return &Tree{layers: layers, index: index}
+}
+
+// Root returns the Merkle root of the tree
+func (t *Tree) Root() common.Hash {
+	top := t.layers[len(t.layers)-1]
+	if len(top) == 0 {
+		return common.Hash{}
+	}
+	return top[0]
+}
+
+// Proof returns the sibling hashes from [leaf] to the root
+func (t *Tree) Proof(leaf common.Hash) ([]common.Hash, error) {
+	i, ok := t.index[leaf]
+	if !ok {
+		return nil, ErrLeafNotFound
+	}
+	proof := []common.Hash{}
+	for _, layer := range t.layers[:len(t.layers)-1] {
+		if sibling := i ^ 1; sibling < len(layer) {
+			proof = append(proof, layer[sibling])
+		}
+		i /= 2
+	}
+	return proof, nil
+}
+
+// VerifyProof returns true if [proof] proves that [leaf] is in the tree with [root]
+func VerifyProof(root common.Hash, leaf common.Hash, proof []common.Hash) bool {
+	computed := leaf
+	for _, sibling := range proof {
+		computed = hashPair(computed, sibling)
+	}
+	return computed == root
+}
+
+// VerifyCheckpoint checks that [message] was sourced from [sourceChainID] and signed by a [quorumNum]/[quorumDen]
+// weight of its validators at [pChainHeight], and returns the checkpoint it carries
+func VerifyCheckpoint(ctx context.Context, message *avalancheWarp.Message, networkID uint32, sourceChainID ids.ID, validatorState validators.State, pChainHeight uint64, quorumNum uint64, quorumDen uint64) (*payload.OrderBookCheckpoint, error) {
+	if message.SourceChainID != sourceChainID {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedSource, message.SourceChainID)
+	}
+	if err := message.Signature.Verify(ctx, &message.UnsignedMessage, networkID, validatorState, pChainHeight, quorumNum, quorumDen); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint signature: %w", err)
+	}
+	return payload.ParseOrderBookCheckpoint(message.Payload)
+}
+
+// VerifyOrder checks that [order] was open in [checkpoint]
+func VerifyOrder(checkpoint *payload.OrderBookCheckpoint, order OrderLeaf, proof []common.Hash) error {
+	if !VerifyProof(checkpoint.Root, order.Hash(), proof) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// VerifyPosition checks that [position] was held in [checkpoint]
+func VerifyPosition(checkpoint *payload.OrderBookCheckpoint, position PositionLeaf, proof []common.Hash) error {
+	if !VerifyProof(checkpoint.Root, position.Hash(), proof) {
+		return ErrInvalidProof
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package checkpoint
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/crypto/bls"
+	"github.com/ava-labs/avalanchego/utils/set"
+	avalancheWarp "github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	"github.com/ava-labs/subnet-evm/warp/payload"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	trader = common.HexToAddress("0x710bDB5fB6e8cDF9D0d8A0D0aAd1f25E5a2D48AD")
+	order  = OrderLeaf{
+		OrderId:                 common.HexToHash("0x01"),
+		Trader:                  trader,
+		Market:                  0,
+		BaseAssetQuantity:       big.NewInt(-5e18),
+		FilledBaseAssetQuantity: big.NewInt(-1e18),
+		Price:                   big.NewInt(1800e6),
+	}
+	position = PositionLeaf{Trader: trader, Market: 1, Size: big.NewInt(-3e18), OpenNotional: big.NewInt(5400e6)}
+)
+
+func testLeaves(n int) []common.Hash {
+	leaves := make([]common.Hash, n)
+	for i := range leaves {
+		leaves[i] = crypto.Keccak256Hash(big.NewInt(int64(i)).Bytes())
+	}
+	return leaves
+}
+
+func TestLeafHash(t *testing.T) {
+	// keccak256(keccak256(abi.encode(uint8(1), trader, int256(1), int256(-3e18), int256(5400e6))))
+	encoded := common.FromHex("0x" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"000000000000000000000000710bdb5fb6e8cdf9d0d8a0d0aad1f25e5a2d48ad" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"ffffffffffffffffffffffffffffffffffffffffffffffffd65ddbe509d40000" +
+		"0000000000000000000000000000000000000000000000000000000141dd7600")
+	require.Equal(t, crypto.Keccak256Hash(crypto.Keccak256(encoded)), position.Hash())
+
+	// every field is committed to
+	other := order
+	other.FilledBaseAssetQuantity = big.NewInt(-2e18)
+	require.NotEqual(t, order.Hash(), other.Hash())
+	// orders and positions don't collide
+	require.NotEqual(t, OrderLeaf{Trader: trader}.Hash(), PositionLeaf{Trader: trader}.Hash())
+}
+
+func TestTree(t *testing.T) {
+	require.Equal(t, common.Hash{}, NewTree(nil).Root())
+
+	for n := 1; n <= 9; n++ {
+		leaves := testLeaves(n)
+		tree := NewTree(leaves)
+		for _, leaf := range leaves {
+			proof, err := tree.Proof(leaf)
+			require.NoError(t, err)
+			require.True(t, VerifyProof(tree.Root(), leaf, proof), "n=%d", n)
+			if len(proof) > 0 {
+				tampered := append([]common.Hash{}, proof...)
+				tampered[0][0] ^= 1
+				require.False(t, VerifyProof(tree.Root(), leaf, tampered), "n=%d", n)
+			}
+		}
+		_, err := tree.Proof(common.HexToHash("0xbad"))
+		require.ErrorIs(t, err, ErrLeafNotFound)
+	}
+
+	// the root does not depend on the order of the leaves
+	leaves := testLeaves(5)
+	reversed := []common.Hash{leaves[4], leaves[3], leaves[2], leaves[1], leaves[0]}
+	require.Equal(t, NewTree(leaves).Root(), NewTree(reversed).Root())
+	require.Equal(t, leaves, testLeaves(5))
+}
+
+func TestVerifyCheckpoint(t *testing.T) {
+	networkID := uint32(1337)
+	sourceChainID := ids.GenerateTestID()
+	subnetID := ids.GenerateTestID()
+	secretKey, err := bls.NewSecretKey()
+	require.NoError(t, err)
+	nodeID := ids.GenerateTestNodeID()
+	validatorState := &validators.TestState{
+		GetSubnetIDF: func(ctx context.Context, chainID ids.ID) (ids.ID, error) {
+			return subnetID, nil
+		},
+		GetValidatorSetF: func(ctx context.Context, height uint64, subnetID ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+			return map[ids.NodeID]*validators.GetValidatorOutput{
+				nodeID: {NodeID: nodeID, PublicKey: bls.PublicFromSecretKey(secretKey), Weight: 100},
+			}, nil
+		},
+	}
+
+	tree := NewTree([]common.Hash{order.Hash(), position.Hash()})
+	signed, err := payload.NewOrderBookCheckpoint(1000, common.HexToHash("0xb10c"), tree.Root())
+	require.NoError(t, err)
+	sign := func(checkpoint []byte) *avalancheWarp.Message {
+		unsignedMessage, err := avalancheWarp.NewUnsignedMessage(networkID, sourceChainID, checkpoint)
+		require.NoError(t, err)
+		signature, err := avalancheWarp.NewSigner(secretKey, networkID, sourceChainID).Sign(unsignedMessage)
+		require.NoError(t, err)
+		bitSetSignature := &avalancheWarp.BitSetSignature{Signers: set.NewBits(0).Bytes()}
+		copy(bitSetSignature.Signature[:], signature)
+		message, err := avalancheWarp.NewMessage(unsignedMessage, bitSetSignature)
+		require.NoError(t, err)
+		return message
+	}
+	message := sign(signed.Bytes())
+
+	checkpoint, err := VerifyCheckpoint(context.Background(), message, networkID, sourceChainID, validatorState, 10, 67, 100)
+	require.NoError(t, err)
+	require.Equal(t, signed.Root, checkpoint.Root)
+	require.Equal(t, uint64(1000), checkpoint.BlockNumber)
+
+	orderProof, err := tree.Proof(order.Hash())
+	require.NoError(t, err)
+	require.NoError(t, VerifyOrder(checkpoint, order, orderProof))
+	positionProof, err := tree.Proof(position.Hash())
+	require.NoError(t, err)
+	require.NoError(t, VerifyPosition(checkpoint, position, positionProof))
+
+	// a position that differs from the checkpoint
+	changed := position
+	changed.Size = big.NewInt(-4e18)
+	require.ErrorIs(t, VerifyPosition(checkpoint, changed, positionProof), ErrInvalidProof)
+
+	// a checkpoint of another chain
+	_, err = VerifyCheckpoint(context.Background(), message, networkID, ids.GenerateTestID(), validatorState, 10, 67, 100)
+	require.ErrorIs(t, err, ErrUnexpectedSource)
+
+	// a checkpoint whose signature does not match the message
+	other, err := payload.NewOrderBookCheckpoint(1001, common.HexToHash("0xb10c"), tree.Root())
+	require.NoError(t, err)
+	forged, err := avalancheWarp.NewMessage(&sign(other.Bytes()).UnsignedMessage, message.Signature)
+	require.NoError(t, err)
+	_, err = VerifyCheckpoint(context.Background(), forged, networkID, sourceChainID, validatorState, 10, 67, 100)
+	require.ErrorContains(t, err, "invalid checkpoint signature")
+}
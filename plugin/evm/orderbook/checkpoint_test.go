@@ -0,0 +1,129 @@
+package orderbook
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/eth"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/checkpoint"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderBookCheckpoint(t *testing.T) {
+	db := getDatabase()
+	longOrder := getLongOrder()
+	db.Add(&longOrder)
+	shortOrder := getShortOrder()
+	shortOrder.Salt.Add(shortOrder.Salt, big.NewInt(100))
+	shortOrder.Id = getIdFromLimitOrder(shortOrder)
+	db.Add(&shortOrder)
+	trader := common.HexToAddress("0x22Bb736b64A0b4D4081E103f83bccF864F0404aa")
+	db.UpdatePosition(trader, Market(0), big.NewInt(5e18), big.NewInt(100e6), false)
+	// closed positions are left out
+	db.UpdatePosition(trader, Market(1), big.NewInt(0), big.NewInt(0), false)
+
+	c := NewOrderBookCheckpoint(db, 1000, 0, common.HexToHash("0xb10c"))
+	payload, err := c.Payload()
+	require.NoError(t, err)
+	assert.Equal(t, c.Root(), payload.Root)
+	assert.Equal(t, uint64(1000), payload.BlockNumber)
+
+	leaf, proof, err := c.OrderProof(shortOrder.Id)
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.OrderLeaf{
+		OrderId:                 shortOrder.Id,
+		Trader:                  trader,
+		Market:                  int64(shortOrder.Market),
+		BaseAssetQuantity:       shortOrder.BaseAssetQuantity,
+		FilledBaseAssetQuantity: shortOrder.FilledBaseAssetQuantity,
+		Price:                   shortOrder.Price,
+	}, leaf)
+	assert.NoError(t, checkpoint.VerifyOrder(payload, leaf, proof))
+
+	positionLeaf, proof, err := c.PositionProof(trader, Market(0))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5e18), positionLeaf.Size)
+	assert.NoError(t, checkpoint.VerifyPosition(payload, positionLeaf, proof))
+
+	_, _, err = c.PositionProof(trader, Market(1))
+	assert.ErrorIs(t, err, checkpoint.ErrLeafNotFound)
+	_, _, err = c.OrderProof(common.HexToHash("0x01"))
+	assert.ErrorIs(t, err, checkpoint.ErrLeafNotFound)
+
+	// the checkpoint does not change with the orderbook
+	db.Delete(longOrder.Id)
+	_, _, err = c.OrderProof(longOrder.Id)
+	assert.NoError(t, err)
+}
+
+func TestOrderBookCheckpointChainState(t *testing.T) {
+	newDB := func() (*InMemoryDatabase, Order) {
+		db := getDatabase()
+		longOrder := getLongOrder()
+		db.Add(&longOrder)
+		return db, longOrder
+	}
+	db, _ := newDB()
+	root := NewOrderBookCheckpoint(db, 1000, 100, common.HexToHash("0xb10c")).Root()
+
+	t.Run("local statuses don't change the root", func(t *testing.T) {
+		db, longOrder := newDB()
+		require.NoError(t, db.SetLocalOrderStatus(longOrder.Id, Execution_Failed, "OB_1", 1000))
+		assert.Equal(t, root, NewOrderBookCheckpoint(db, 1000, 100, common.HexToHash("0xb10c")).Root())
+	})
+
+	t.Run("orders closed on chain are left out before they are deleted", func(t *testing.T) {
+		db, _ := newDB()
+		shortOrder := getShortOrder()
+		shortOrder.Salt.Add(shortOrder.Salt, big.NewInt(100))
+		shortOrder.Id = getIdFromLimitOrder(shortOrder)
+		db.Add(&shortOrder)
+		require.NoError(t, db.SetOrderStatus(shortOrder.Id, Cancelled, "", 1000))
+		c := NewOrderBookCheckpoint(db, 1000, 100, common.HexToHash("0xb10c"))
+		assert.Equal(t, root, c.Root())
+		_, _, err := c.OrderProof(shortOrder.Id)
+		assert.ErrorIs(t, err, checkpoint.ErrLeafNotFound)
+	})
+
+	t.Run("expired ioc orders are left out before they are deleted", func(t *testing.T) {
+		db, _ := newDB()
+		iocOrder := getShortOrder()
+		iocOrder.Salt.Add(iocOrder.Salt, big.NewInt(100))
+		iocOrder.Id = getIdFromLimitOrder(iocOrder)
+		iocOrder.OrderType = IOCOrderType
+		iocOrder.RawOrder = &IOCOrder{ExpireAt: big.NewInt(99)}
+		db.Add(&iocOrder)
+		assert.Equal(t, root, NewOrderBookCheckpoint(db, 1000, 100, common.HexToHash("0xb10c")).Root())
+		assert.NotEqual(t, root, NewOrderBookCheckpoint(db, 1000, 99, common.HexToHash("0xb10c")).Root())
+	})
+}
+
+func TestCheckpointAPI(t *testing.T) {
+	db := getDatabase()
+	longOrder := getLongOrder()
+	db.Add(&longOrder)
+	checkpoints := NewCheckpointStore()
+	service := NewOrderBookAPI(db, &eth.EthAPIBackend{}, db.configService, checkpoints)
+
+	_, err := service.GetLatestCheckpoint(context.Background())
+	assert.ErrorIs(t, err, ErrNoCheckpoint)
+	_, err = service.GetOrderProof(context.Background(), longOrder.Id.Hex())
+	assert.ErrorIs(t, err, ErrNoCheckpoint)
+
+	c := NewOrderBookCheckpoint(db, 1000, 0, common.HexToHash("0xb10c"))
+	checkpoints.Set(c)
+	latest, err := service.GetLatestCheckpoint(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, c.Root(), latest.Root)
+
+	response, err := service.GetOrderProof(context.Background(), longOrder.Id.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, *latest, response.Checkpoint)
+	assert.True(t, checkpoint.VerifyProof(latest.Root, response.Leaf.Hash(), response.Proof))
+
+	_, err = service.GetPositionProof(context.Background(), longOrder.UserAddress, 0)
+	assert.ErrorIs(t, err, checkpoint.ErrLeafNotFound)
+}
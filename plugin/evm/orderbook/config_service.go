@@ -21,6 +21,9 @@ type IConfigService interface {
 	GetCumulativePremiumFraction(market Market) *big.Int
 	GetAcceptableBounds(market Market) (*big.Int, *big.Int)
 	GetAcceptableBoundsForLiquidation(market Market) (*big.Int, *big.Int)
+	GetNextFundingTime(market Market) uint64
+	GetFundingPeriod(market Market) uint64
+	CheckOracleCircuitBreaker(market Market, blockTimestamp uint64) error
 }
 
 type ConfigService struct {
@@ -34,11 +37,13 @@ func NewConfigService(blockChain *core.BlockChain) IConfigService {
 }
 
 func (cs *ConfigService) GetAcceptableBounds(market Market) (*big.Int, *big.Int) {
-	return bibliophile.GetAcceptableBounds(cs.getStateAtCurrentBlock(), int64(market))
+	stateDB := cs.getStateAtCurrentBlock()
+	return bibliophile.GetAcceptableBounds(stateDB, int64(market), cs.getVersionAtCurrentBlock(stateDB))
 }
 
 func (cs *ConfigService) GetAcceptableBoundsForLiquidation(market Market) (*big.Int, *big.Int) {
-	return bibliophile.GetAcceptableBoundsForLiquidation(cs.getStateAtCurrentBlock(), int64(market))
+	stateDB := cs.getStateAtCurrentBlock()
+	return bibliophile.GetAcceptableBoundsForLiquidation(stateDB, int64(market), cs.getVersionAtCurrentBlock(stateDB))
 }
 
 func (cs *ConfigService) getLiquidationSpreadThreshold(market Market) *big.Int {
@@ -66,12 +71,18 @@ func (cs *ConfigService) getStateAtCurrentBlock() *state.StateDB {
 	return stateDB
 }
 
+// getVersionAtCurrentBlock returns the version of the bibliophile logic that the current block was executed with
+func (cs *ConfigService) getVersionAtCurrentBlock(stateDB *state.StateDB) bibliophile.Version {
+	return bibliophile.GetVersion(cs.blockChain.Config(), stateDB, cs.blockChain.CurrentBlock().Time)
+}
+
 func (cs *ConfigService) GetActiveMarketsCount() int64 {
 	return bibliophile.GetActiveMarketsCount(cs.getStateAtCurrentBlock())
 }
 
 func (cs *ConfigService) GetUnderlyingPrices() []*big.Int {
-	return bibliophile.GetUnderlyingPrices(cs.getStateAtCurrentBlock())
+	stateDB := cs.getStateAtCurrentBlock()
+	return bibliophile.GetUnderlyingPrices(stateDB, cs.getVersionAtCurrentBlock(stateDB))
 }
 
 func (cs *ConfigService) GetLastPremiumFraction(market Market, trader *common.Address) *big.Int {
@@ -83,3 +94,16 @@ func (cs *ConfigService) GetCumulativePremiumFraction(market Market) *big.Int {
 	markets := bibliophile.GetMarkets(cs.getStateAtCurrentBlock())
 	return bibliophile.GetCumulativePremiumFraction(cs.getStateAtCurrentBlock(), markets[market])
 }
+
+func (cs *ConfigService) GetNextFundingTime(market Market) uint64 {
+	return bibliophile.GetNextFundingTime(cs.getStateAtCurrentBlock(), int64(market)).Uint64()
+}
+
+func (cs *ConfigService) GetFundingPeriod(market Market) uint64 {
+	return bibliophile.GetFundingPeriod(cs.getStateAtCurrentBlock(), int64(market)).Uint64()
+}
+
+// CheckOracleCircuitBreaker checks the circuit breaker for the next block, at [blockTimestamp], against the state at the current head
+func (cs *ConfigService) CheckOracleCircuitBreaker(market Market, blockTimestamp uint64) error {
+	return bibliophile.CheckOracleCircuitBreaker(cs.blockChain.Config(), cs.getStateAtCurrentBlock(), int64(market), blockTimestamp)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ava-labs/subnet-evm/core/types"
 	"github.com/ava-labs/subnet-evm/metrics"
 	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/marginbridge"
 	"github.com/ava-labs/subnet-evm/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -17,8 +18,10 @@ import (
 type ContractEventsProcessor struct {
 	orderBookABI     abi.ABI
 	iocOrderBookABI  abi.ABI
+	gttOrderBookABI  abi.ABI
 	marginAccountABI abi.ABI
 	clearingHouseABI abi.ABI
+	marginBridgeABI  abi.ABI
 	database         LimitOrderDatabase
 }
 
@@ -43,11 +46,18 @@ func NewContractEventsProcessor(database LimitOrderDatabase) *ContractEventsProc
 		panic(err)
 	}
 
+	gttOrderBookABI, err := abi.FromSolidityJson(string(abis.GTTOrderBookAbi))
+	if err != nil {
+		panic(err)
+	}
+
 	return &ContractEventsProcessor{
 		orderBookABI:     orderBookABI,
 		marginAccountABI: marginAccountABI,
 		clearingHouseABI: clearingHouseABI,
 		iocOrderBookABI:  iocOrderBookABI,
+		gttOrderBookABI:  gttOrderBookABI,
+		marginBridgeABI:  marginbridge.MarginBridgeABI,
 		database:         database,
 	}
 }
@@ -82,6 +92,14 @@ func (cep *ContractEventsProcessor) ProcessEvents(logs []*types.Log) {
 		return rebirthLogs[i].BlockNumber < rebirthLogs[j].BlockNumber
 	})
 
+	// the txs that reserved margin, a GTT order is placed along with the reservation of its margin
+	marginReservedTxs := map[common.Hash]bool{}
+	for _, event := range rebirthLogs {
+		if event.Address == MarginAccountContractAddress && len(event.Topics) > 0 && event.Topics[0] == cep.marginAccountABI.Events["MarginReserved"].ID {
+			marginReservedTxs[event.TxHash] = true
+		}
+	}
+
 	logs = append(deletedLogs, rebirthLogs...)
 	for _, event := range logs {
 		switch event.Address {
@@ -89,6 +107,8 @@ func (cep *ContractEventsProcessor) ProcessEvents(logs []*types.Log) {
 			cep.handleOrderBookEvent(event)
 		case IOCOrderBookContractAddress:
 			cep.handleIOCOrderBookEvent(event)
+		case GTTOrderBookContractAddress:
+			cep.handleGTTOrderBookEvent(event, marginReservedTxs)
 		}
 	}
 }
@@ -107,6 +127,8 @@ func (cep *ContractEventsProcessor) ProcessAcceptedEvents(logs []*types.Log, inB
 			cep.handleMarginAccountEvent(event)
 		case ClearingHouseContractAddress:
 			cep.handleClearingHouseEvent(event)
+		case MarginBridgeContractAddress:
+			cep.handleMarginBridgeEvent(event)
 		}
 	}
 	if !inBootstrap {
@@ -169,6 +191,28 @@ func (cep *ContractEventsProcessor) handleOrderBookEvent(event *types.Log) {
 				return
 			}
 		}
+	case cep.orderBookABI.Events["OrderAmended"].ID:
+		err := cep.orderBookABI.UnpackIntoMap(args, "OrderAmended", event.Data)
+		if err != nil {
+			log.Error("error in orderBookABI.UnpackIntoMap", "method", "OrderAmended", "err", err)
+			return
+		}
+		orderId := event.Topics[2]
+		amendedOrderHash := common.Hash(args["amendedOrderHash"].([32]byte))
+		log.Info("LimitOrder/OrderAmended", "orderId", orderId.String(), "amendedOrderHash", amendedOrderHash.String(), "removed", removed)
+		if !removed {
+			order := LimitOrder{}
+			order.DecodeFromRawOrder(args["order"])
+			if err := cep.database.AmendOrder(orderId, &order, amendedOrderHash, event.BlockNumber); err != nil {
+				log.Error("error in AmendOrder", "method", "LimitOrder/OrderAmended", "err", err)
+				return
+			}
+		} else {
+			if err := cep.database.RevertLastStatus(orderId); err != nil {
+				log.Error("error in RevertLastStatus", "method", "LimitOrder/OrderAmended", "removed", true, "err", err)
+				return
+			}
+		}
 	case cep.orderBookABI.Events["OrdersMatched"].ID:
 		err := cep.orderBookABI.UnpackIntoMap(args, "OrdersMatched", event.Data)
 		if err != nil {
@@ -226,6 +270,32 @@ func (cep *ContractEventsProcessor) handleOrderBookEvent(event *types.Log) {
 				return
 			}
 		}
+	case cep.orderBookABI.Events["LiquidationError"].ID:
+		err := cep.orderBookABI.UnpackIntoMap(args, "LiquidationError", event.Data)
+		if err != nil {
+			log.Error("error in orderBookAbi.UnpackIntoMap", "method", "LiquidationError", "err", err)
+			return
+		}
+		orderId := event.Topics[2]
+		failure := NewFailure(args["err"].(string))
+		if failure.Code == FailureNotLiquidatable {
+			// the liquidated trader is at fault, not the order
+			log.Info("LiquidationError", "args", args, "orderId", orderId.String(), "removed", removed)
+			return
+		}
+		if !removed {
+			log.Info("LiquidationError", "args", args, "orderId", orderId.String())
+			if err := cep.database.SetOrderStatus(orderId, Execution_Failed, failure.Reason, event.BlockNumber); err != nil {
+				log.Error("error in SetOrderStatus", "method", "LiquidationError", "err", err)
+				return
+			}
+		} else {
+			log.Info("LiquidationError removed", "args", args, "orderId", orderId.String(), "number", event.BlockNumber)
+			if err := cep.database.RevertLastStatus(orderId); err != nil {
+				log.Error("error in SetOrderStatus", "method", "LiquidationError", "removed", true, "err", err)
+				return
+			}
+		}
 	}
 }
 
@@ -266,6 +336,67 @@ func (cep *ContractEventsProcessor) handleIOCOrderBookEvent(event *types.Log) {
 	}
 }
 
+func (cep *ContractEventsProcessor) handleGTTOrderBookEvent(event *types.Log, marginReservedTxs map[common.Hash]bool) {
+	removed := event.Removed
+	args := map[string]interface{}{}
+	switch event.Topics[0] {
+	case cep.gttOrderBookABI.Events["OrderPlaced"].ID:
+		err := cep.gttOrderBookABI.UnpackIntoMap(args, "OrderPlaced", event.Data)
+		if err != nil {
+			log.Error("error in gttOrderBookABI.UnpackIntoMap", "method", "OrderPlaced", "err", err)
+			return
+		}
+		orderId := event.Topics[2]
+		if !removed {
+			order := GTTOrder{}
+			order.DecodeFromRawOrder(args["order"])
+			if !order.ReduceOnly && !marginReservedTxs[event.TxHash] {
+				// nothing backs the order, the orderbook would fail to fill it
+				log.Error("GTTOrder/OrderPlaced - no margin was reserved for the order, ignoring it", "orderId", orderId.String(), "tx", event.TxHash.String())
+				return
+			}
+			gttOrder := Order{
+				Id:                      orderId,
+				Market:                  Market(order.AmmIndex.Int64()),
+				PositionType:            getPositionTypeBasedOnBaseAssetQuantity(order.BaseAssetQuantity),
+				UserAddress:             getAddressFromTopicHash(event.Topics[1]).String(),
+				BaseAssetQuantity:       order.BaseAssetQuantity,
+				FilledBaseAssetQuantity: big.NewInt(0),
+				Price:                   order.Price,
+				RawOrder:                &order,
+				Salt:                    order.Salt,
+				ReduceOnly:              order.ReduceOnly,
+				BlockNumber:             big.NewInt(int64(event.BlockNumber)),
+				OrderType:               GTTOrderType,
+			}
+			log.Info("GTTOrder/OrderPlaced", "order", gttOrder)
+			cep.database.Add(&gttOrder)
+		} else {
+			log.Info("GTTOrder/OrderPlaced removed", "orderId", orderId.String(), "block", event.BlockHash.String(), "number", event.BlockNumber)
+			cep.database.Delete(orderId)
+		}
+	case cep.gttOrderBookABI.Events["OrderCancelled"].ID:
+		err := cep.gttOrderBookABI.UnpackIntoMap(args, "OrderCancelled", event.Data)
+		if err != nil {
+			log.Error("error in gttOrderBookABI.UnpackIntoMap", "method", "OrderCancelled", "err", err)
+			return
+		}
+		orderId := event.Topics[2]
+		log.Info("GTTOrder/OrderCancelled", "orderId", orderId.String(), "removed", removed)
+		if !removed {
+			if err := cep.database.SetOrderStatus(orderId, Cancelled, "", event.BlockNumber); err != nil {
+				log.Error("error in SetOrderStatus", "method", "GTTOrder/OrderCancelled", "err", err)
+				return
+			}
+		} else {
+			if err := cep.database.RevertLastStatus(orderId); err != nil {
+				log.Error("error in SetOrderStatus", "method", "GTTOrder/OrderCancelled", "removed", true, "err", err)
+				return
+			}
+		}
+	}
+}
+
 func (cep *ContractEventsProcessor) handleMarginAccountEvent(event *types.Log) {
 	args := map[string]interface{}{}
 	switch event.Topics[0] {
@@ -324,6 +455,34 @@ func (cep *ContractEventsProcessor) handleMarginAccountEvent(event *types.Log) {
 	}
 }
 
+// handleMarginBridgeEvent applies the deposits and withdrawals of HUSD margin through warp messages, which the
+// MarginBridge precompile writes directly to the storage of the MarginAccount without its events
+func (cep *ContractEventsProcessor) handleMarginBridgeEvent(event *types.Log) {
+	args := map[string]interface{}{}
+	switch event.Topics[0] {
+	case cep.marginBridgeABI.Events["MarginDeposited"].ID:
+		err := cep.marginBridgeABI.UnpackIntoMap(args, "MarginDeposited", event.Data)
+		if err != nil {
+			log.Error("error in marginBridgeABI.UnpackIntoMap", "method", "MarginDeposited", "err", err)
+			return
+		}
+		trader := getAddressFromTopicHash(event.Topics[1])
+		amount := args["amount"].(*big.Int)
+		log.Info("MarginDeposited", "trader", trader, "sourceChainID", event.Topics[2], "amount", amount.Uint64())
+		cep.database.UpdateMargin(trader, HUSD, amount)
+	case cep.marginBridgeABI.Events["MarginWithdrawn"].ID:
+		err := cep.marginBridgeABI.UnpackIntoMap(args, "MarginWithdrawn", event.Data)
+		if err != nil {
+			log.Error("error in marginBridgeABI.UnpackIntoMap", "method", "MarginWithdrawn", "err", err)
+			return
+		}
+		trader := getAddressFromTopicHash(event.Topics[1])
+		amount := args["amount"].(*big.Int)
+		log.Info("MarginWithdrawn", "trader", trader, "destinationChainID", event.Topics[2], "amount", amount.Uint64())
+		cep.database.UpdateMargin(trader, HUSD, big.NewInt(0).Neg(amount))
+	}
+}
+
 func (cep *ContractEventsProcessor) handleClearingHouseEvent(event *types.Log) {
 	args := map[string]interface{}{}
 	switch event.Topics[0] {
@@ -338,7 +497,7 @@ func (cep *ContractEventsProcessor) handleClearingHouseEvent(event *types.Log) {
 		market := Market(int(event.Topics[1].Big().Int64()))
 		log.Info("FundingRateUpdated", "args", args, "cumulativePremiumFraction", cumulativePremiumFraction, "market", market)
 		cep.database.UpdateUnrealisedFunding(market, cumulativePremiumFraction)
-		cep.database.UpdateNextFundingTime(nextFundingTime.Uint64())
+		cep.database.UpdateNextFundingTime(market, nextFundingTime.Uint64())
 
 	case cep.clearingHouseABI.Events["FundingPaid"].ID:
 		err := cep.clearingHouseABI.UnpackIntoMap(args, "FundingPaid", event.Data)
@@ -462,6 +621,20 @@ func (cep *ContractEventsProcessor) PushtoTraderFeed(events []*types.Log, blockS
 				orderId = event.Topics[2]
 				trader = getAddressFromTopicHash(event.Topics[1])
 
+			case cep.orderBookABI.Events["OrderAmended"].ID:
+				err := cep.orderBookABI.UnpackIntoMap(args, "OrderAmended", event.Data)
+				if err != nil {
+					log.Error("error in orderBookABI.UnpackIntoMap", "method", "OrderAmended", "err", err)
+					continue
+				}
+				eventName = "OrderAmended"
+				order := LimitOrder{}
+				order.DecodeFromRawOrder(args["order"])
+				args["order"] = order.Map()
+				args["amendedOrderHash"] = common.Hash(args["amendedOrderHash"].([32]byte))
+				orderId = event.Topics[2]
+				trader = getAddressFromTopicHash(event.Topics[1])
+
 			case cep.orderBookABI.Events["OrderCancelled"].ID:
 				err := cep.orderBookABI.UnpackIntoMap(args, "OrderCancelled", event.Data)
 				if err != nil {
@@ -492,6 +665,35 @@ func (cep *ContractEventsProcessor) PushtoTraderFeed(events []*types.Log, blockS
 				orderId = event.Topics[2]
 				trader = getAddressFromTopicHash(event.Topics[1])
 			}
+		case GTTOrderBookContractAddress:
+			orderType = "gtt"
+			switch event.Topics[0] {
+			case cep.gttOrderBookABI.Events["OrderPlaced"].ID:
+				err := cep.gttOrderBookABI.UnpackIntoMap(args, "OrderPlaced", event.Data)
+				if err != nil {
+					log.Error("error in gttOrderBookABI.UnpackIntoMap", "method", "OrderPlaced", "err", err)
+					continue
+				}
+				eventName = "OrderPlaced"
+				order := GTTOrder{}
+				order.DecodeFromRawOrder(args["order"])
+				args["order"] = order.Map()
+				orderId = event.Topics[2]
+				trader = getAddressFromTopicHash(event.Topics[1])
+
+			case cep.gttOrderBookABI.Events["OrderCancelled"].ID:
+				err := cep.gttOrderBookABI.UnpackIntoMap(args, "OrderCancelled", event.Data)
+				if err != nil {
+					log.Error("error in gttOrderBookABI.UnpackIntoMap", "method", "OrderCancelled", "err", err)
+					continue
+				}
+				eventName = "OrderCancelled"
+				orderId = event.Topics[2]
+				trader = getAddressFromTopicHash(event.Topics[1])
+
+			default:
+				continue
+			}
 		default:
 			continue
 		}
@@ -563,6 +765,8 @@ func (cep *ContractEventsProcessor) updateMetrics(logs []*types.Log) {
 			contractABI = cep.marginAccountABI
 		case ClearingHouseContractAddress:
 			contractABI = cep.clearingHouseABI
+		case MarginBridgeContractAddress:
+			contractABI = cep.marginBridgeABI
 		}
 
 		event_, err := contractABI.EventByID(event.Topics[0])
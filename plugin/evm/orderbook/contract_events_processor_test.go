@@ -9,6 +9,7 @@ import (
 	"github.com/ava-labs/subnet-evm/accounts/abi"
 	"github.com/ava-labs/subnet-evm/core/types"
 	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/marginbridge"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
@@ -232,6 +233,38 @@ func TestHandleOrderBookEvent(t *testing.T) {
 			assert.Equal(t, rawOrder, actualLimitOrder.RawOrder.(*LimitOrder))
 		})
 	})
+	t.Run("When event is OrderAmended", func(t *testing.T) {
+		db := getDatabase()
+		cep := newcep(t, db)
+		orderId := getIdFromOrder(order)
+		placedEvent := getEventFromABI(orderBookABI, "OrderPlaced")
+		placedEventData, err := placedEvent.Inputs.NonIndexed().Pack(order, timestamp)
+		assert.Nil(t, err)
+		cep.ProcessEvents([]*types.Log{getEventLog(OrderBookContractAddress, []common.Hash{placedEvent.ID, traderAddress.Hash(), orderId}, placedEventData, blockNumber)})
+
+		amendedOrder := getOrder(ammIndex, traderAddress, big.NewInt(0).Div(baseAssetQuantity, big.NewInt(2)), price, salt)
+		amendedOrderHash := common.HexToHash("0xa")
+		event := getEventFromABI(orderBookABI, "OrderAmended")
+		amendedEventData, err := event.Inputs.NonIndexed().Pack(amendedOrderHash, amendedOrder, timestamp)
+		assert.Nil(t, err)
+		log := getEventLog(OrderBookContractAddress, []common.Hash{event.ID, traderAddress.Hash(), orderId}, amendedEventData, blockNumber+2)
+
+		cep.ProcessEvents([]*types.Log{log})
+		actualLimitOrder := db.OrderMap[orderId]
+		assert.Equal(t, Amended, actualLimitOrder.getOrderStatus().Status)
+		assert.Equal(t, amendedOrderHash, actualLimitOrder.getLatestAmendment().AmendedOrderHash)
+		assert.Equal(t, amendedOrder.BaseAssetQuantity, actualLimitOrder.BaseAssetQuantity)
+		assert.Equal(t, &amendedOrder, actualLimitOrder.RawOrder)
+		// reducing the size keeps the time priority
+		assert.Equal(t, big.NewInt(int64(blockNumber)), actualLimitOrder.BlockNumber)
+
+		log.Removed = true
+		cep.ProcessEvents([]*types.Log{log})
+		actualLimitOrder = db.OrderMap[orderId]
+		assert.Equal(t, Placed, actualLimitOrder.getOrderStatus().Status)
+		assert.Equal(t, baseAssetQuantity, actualLimitOrder.BaseAssetQuantity)
+		assert.Equal(t, &order, actualLimitOrder.RawOrder)
+	})
 	t.Run("When event is OrderCancelled", func(t *testing.T) {
 		db := getDatabase()
 		cep := newcep(t, db)
@@ -348,6 +381,75 @@ func TestHandleOrderBookEvent(t *testing.T) {
 	})
 }
 
+func TestHandleGTTOrderBookEvent(t *testing.T) {
+	traderAddress := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	gttOrderBookABI := getABIfromJson(abis.GTTOrderBookAbi)
+	db := getDatabase()
+	cep := newcep(t, db)
+
+	order := GTTOrder{
+		LimitOrder: getOrder(big.NewInt(0), traderAddress, big.NewInt(5000000000000000000), big.NewInt(1000000000), big.NewInt(1675239557437)),
+		OrderType:  2,
+		ExpireAt:   big.NewInt(1688994854),
+	}
+	orderId := common.HexToHash("0x1")
+	blockNumber := uint64(12)
+
+	orderPlacedEvent := getEventFromABI(gttOrderBookABI, "OrderPlaced")
+	orderPlacedEventData, err := orderPlacedEvent.Inputs.NonIndexed().Pack(order, timestamp)
+	assert.Nil(t, err)
+	orderPlacedLog := getEventLog(GTTOrderBookContractAddress, []common.Hash{orderPlacedEvent.ID, traderAddress.Hash(), orderId}, orderPlacedEventData, blockNumber)
+	orderPlacedLog.TxHash = common.HexToHash("0x2")
+
+	marginAccountABI := getABIfromJson(abis.MarginAccountAbi)
+	marginReservedEvent := getEventFromABI(marginAccountABI, "MarginReserved")
+	marginReservedEventData, err := marginReservedEvent.Inputs.NonIndexed().Pack(big.NewInt(1e6))
+	assert.Nil(t, err)
+	marginReservedLog := getEventLog(MarginAccountContractAddress, []common.Hash{marginReservedEvent.ID, traderAddress.Hash()}, marginReservedEventData, blockNumber)
+	marginReservedLog.TxHash = orderPlacedLog.TxHash
+
+	t.Run("OrderPlaced without a margin reservation is ignored", func(t *testing.T) {
+		cep.ProcessEvents([]*types.Log{orderPlacedLog})
+		assert.Nil(t, db.OrderMap[orderId])
+
+		// a reservation in another tx doesn't back the order
+		otherTxLog := *marginReservedLog
+		otherTxLog.TxHash = common.HexToHash("0x3")
+		cep.ProcessEvents([]*types.Log{&otherTxLog, orderPlacedLog})
+		assert.Nil(t, db.OrderMap[orderId])
+	})
+
+	t.Run("OrderPlaced adds the order", func(t *testing.T) {
+		cep.ProcessEvents([]*types.Log{marginReservedLog, orderPlacedLog})
+		actualOrder := db.OrderMap[orderId]
+		assert.Equal(t, GTTOrderType, actualOrder.OrderType)
+		assert.Equal(t, LONG, actualOrder.PositionType)
+		assert.Equal(t, order.BaseAssetQuantity, actualOrder.BaseAssetQuantity)
+		assert.Equal(t, order.ExpireAt, actualOrder.getExpireAt())
+		assert.Equal(t, &order, actualOrder.RawOrder)
+	})
+
+	t.Run("OrderCancelled cancels the order", func(t *testing.T) {
+		orderCancelledEvent := getEventFromABI(gttOrderBookABI, "OrderCancelled")
+		orderCancelledEventData, err := orderCancelledEvent.Inputs.NonIndexed().Pack(timestamp)
+		assert.Nil(t, err)
+		orderCancelledLog := getEventLog(GTTOrderBookContractAddress, []common.Hash{orderCancelledEvent.ID, traderAddress.Hash(), orderId}, orderCancelledEventData, blockNumber+1)
+		cep.ProcessEvents([]*types.Log{orderCancelledLog})
+		assert.Equal(t, Cancelled, db.OrderMap[orderId].getOrderStatus().Status)
+		assert.Empty(t, db.GetExpiredGTTOrders(order.ExpireAt.Uint64()+1))
+
+		orderCancelledLog.Removed = true
+		cep.ProcessEvents([]*types.Log{orderCancelledLog})
+		assert.Equal(t, Placed, db.OrderMap[orderId].getOrderStatus().Status)
+	})
+
+	t.Run("removed OrderPlaced deletes the order", func(t *testing.T) {
+		orderPlacedLog.Removed = true
+		cep.ProcessEvents([]*types.Log{orderPlacedLog})
+		assert.Nil(t, db.OrderMap[orderId])
+	})
+}
+
 func TestHandleMarginAccountEvent(t *testing.T) {
 	traderAddress := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
 	blockNumber := uint64(12)
@@ -459,6 +561,52 @@ func TestHandleMarginAccountEvent(t *testing.T) {
 		})
 	})
 }
+func TestHandleMarginBridgeEvent(t *testing.T) {
+	traderAddress := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	blockNumber := uint64(12)
+	chainID := common.HexToHash("0x01")
+	transferID := common.HexToHash("0xd1")
+
+	t.Run("when event is MarginDeposited", func(t *testing.T) {
+		db := getDatabase()
+		cep := newcep(t, db)
+		event := getEventFromABI(marginbridge.MarginBridgeABI, "MarginDeposited")
+		topics := []common.Hash{event.ID, traderAddress.Hash(), chainID}
+		t.Run("When event parsing fails", func(t *testing.T) {
+			log := getEventLog(MarginBridgeContractAddress, topics, []byte{}, blockNumber)
+			cep.ProcessAcceptedEvents([]*types.Log{log}, true)
+			assert.Nil(t, db.GetOrderBookData().TraderMap[traderAddress])
+		})
+		t.Run("When event parsing succeeds", func(t *testing.T) {
+			deposited := big.NewInt(10000)
+			data, _ := event.Inputs.NonIndexed().Pack(transferID, deposited)
+			log := getEventLog(MarginBridgeContractAddress, topics, data, blockNumber)
+			cep.ProcessAcceptedEvents([]*types.Log{log}, true)
+			assert.Equal(t, deposited, db.GetOrderBookData().TraderMap[traderAddress].Margin.Deposited[HUSD])
+		})
+	})
+	t.Run("when event is MarginWithdrawn", func(t *testing.T) {
+		db := getDatabase()
+		cep := newcep(t, db)
+		db.TraderMap[traderAddress] = &Trader{
+			Margin: Margin{Deposited: map[Collateral]*big.Int{HUSD: big.NewInt(10000)}},
+		}
+		event := getEventFromABI(marginbridge.MarginBridgeABI, "MarginWithdrawn")
+		topics := []common.Hash{event.ID, traderAddress.Hash(), chainID}
+		t.Run("When event parsing fails", func(t *testing.T) {
+			log := getEventLog(MarginBridgeContractAddress, topics, []byte{}, blockNumber)
+			cep.ProcessAcceptedEvents([]*types.Log{log}, true)
+			assert.Equal(t, big.NewInt(10000), db.GetOrderBookData().TraderMap[traderAddress].Margin.Deposited[HUSD])
+		})
+		t.Run("When event parsing succeeds", func(t *testing.T) {
+			data, _ := event.Inputs.NonIndexed().Pack(transferID, common.HexToAddress("0x0456"), big.NewInt(4000))
+			log := getEventLog(MarginBridgeContractAddress, topics, data, blockNumber)
+			cep.ProcessAcceptedEvents([]*types.Log{log}, true)
+			assert.Equal(t, big.NewInt(6000), db.GetOrderBookData().TraderMap[traderAddress].Margin.Deposited[HUSD])
+		})
+	})
+}
+
 func TestHandleClearingHouseEvent(t *testing.T) {
 	traderAddress := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
 	blockNumber := uint64(12)
@@ -493,7 +641,7 @@ func TestHandleClearingHouseEvent(t *testing.T) {
 			log := getEventLog(ClearingHouseContractAddress, topics, pnlRealizedEventData, blockNumber)
 			cep.ProcessEvents([]*types.Log{log})
 
-			assert.Equal(t, uint64(0), db.NextFundingTime)
+			assert.Equal(t, uint64(0), db.NextFundingTimes[market])
 			assert.Equal(t, unrealisedFunding, db.TraderMap[traderAddress].Positions[market].UnrealisedFunding)
 		})
 		t.Run("When event parsing succeeds", func(t *testing.T) {
@@ -506,6 +654,7 @@ func TestHandleClearingHouseEvent(t *testing.T) {
 			cep.ProcessAcceptedEvents([]*types.Log{log}, true)
 			expectedUnrealisedFunding := dividePrecisionSize(big.NewInt(0).Mul(big.NewInt(0).Sub(cumulativePremiumFraction, position.LastPremiumFraction), position.Size))
 			assert.Equal(t, expectedUnrealisedFunding, db.TraderMap[traderAddress].Positions[market].UnrealisedFunding)
+			assert.Equal(t, nextFundingTime.Uint64(), db.NextFundingTimes[market])
 		})
 	})
 	t.Run("When event is FundingPaid", func(t *testing.T) {
@@ -780,6 +929,26 @@ func TestRemovedEvents(t *testing.T) {
 		cep.ProcessEvents([]*types.Log{orderMatchingErrorLog})
 		assert.Equal(t, db.OrderMap[longOrderId].getOrderStatus().Status, Placed)
 	})
+
+	t.Run("LiquidationError fails the order only if the order is at fault", func(t *testing.T) {
+		liquidationError := getEventFromABI(orderBookABI, "LiquidationError")
+		liquidationErrorTopics := []common.Hash{liquidationError.ID, traderAddress.Hash(), longOrderId}
+
+		notLiquidatableData, _ := liquidationError.Inputs.NonIndexed().Pack("trader is not liquidatable", baseAssetQuantity)
+		cep.ProcessEvents([]*types.Log{getEventLog(OrderBookContractAddress, liquidationErrorTopics, notLiquidatableData, blockNumber.Uint64()+3)})
+		assert.Equal(t, db.OrderMap[longOrderId].getOrderStatus().Status, Placed)
+
+		notReducingData, _ := liquidationError.Inputs.NonIndexed().Pack("not reducing pos", baseAssetQuantity)
+		liquidationErrorLog := getEventLog(OrderBookContractAddress, liquidationErrorTopics, notReducingData, blockNumber.Uint64()+3)
+		cep.ProcessEvents([]*types.Log{liquidationErrorLog})
+		status := db.OrderMap[longOrderId].getOrderStatus()
+		assert.Equal(t, Execution_Failed, status.Status)
+		assert.Equal(t, FailureNotReducing, status.FailureCode)
+
+		liquidationErrorLog.Removed = true
+		cep.ProcessEvents([]*types.Log{liquidationErrorLog})
+		assert.Equal(t, db.OrderMap[longOrderId].getOrderStatus().Status, Placed)
+	})
 }
 
 func newcep(t *testing.T, db LimitOrderDatabase) *ContractEventsProcessor {
@@ -0,0 +1,196 @@
+package orderbook
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// an order that failed for an oracle or margin reason is retried when the oracle price of its market moves by this many basis points...
+	RETRY_AFTER_ORACLE_MOVE_BPS = 50
+	// ...or after this many blocks anyway, e.g. if the trader deposited margin in the meantime
+	RETRY_AFTER_ORACLE_MOVE_MAX_BLOCKS = 100
+	// an order that isn't executable as it is (e.g. overfill, no trading authority) is retried after this many blocks,
+	// unless its fill changes before that (see getOrderStatus)
+	RETRY_AFTER_DELAY_BLOCKS = 100
+)
+
+// FailureCode classifies why the execution of an order failed
+type FailureCode string
+
+const (
+	// juror errors
+	FailureOrdersDoNotMatch  FailureCode = "ORDERS_DO_NOT_MATCH"
+	FailureLongPriceTooLow   FailureCode = "LONG_PRICE_TOO_LOW"
+	FailureShortPriceTooHigh FailureCode = "SHORT_PRICE_TOO_HIGH"
+	FailureOverFill          FailureCode = "OVERFILL"
+	FailureNotMultiple       FailureCode = "NOT_MULTIPLE_OF_MIN_SIZE"
+	FailureNotReducing       FailureCode = "REDUCE_ONLY_NOT_REDUCING"
+	FailureInvalidOrder      FailureCode = "INVALID_ORDER"
+	FailureIOCExpired        FailureCode = "IOC_EXPIRED"
+	FailureGTTExpired        FailureCode = "GTT_EXPIRED"
+	FailureNotLiquidatable   FailureCode = "NOT_LIQUIDATABLE"
+	FailureOrderAmended      FailureCode = "ORDER_AMENDED" // matched as it was before its latest amendment, retried as amended
+
+	// oracle circuit breakers
+	FailureStaleOraclePrice     FailureCode = "STALE_ORACLE_PRICE"
+	FailureOraclePriceDeviation FailureCode = "ORACLE_PRICE_DEVIATION"
+
+	// margin checks of the clearing house and margin account
+	FailureInsufficientMargin FailureCode = "INSUFFICIENT_MARGIN"
+
+	// FailureReverted - any other revert string of the contracts
+	FailureReverted FailureCode = "REVERTED"
+	// FailureUnknown - reverted without a reason, e.g. the precompiles revert without one
+	FailureUnknown FailureCode = "UNKNOWN"
+)
+
+// failureReasons maps the errors of the juror and the revert strings of the contracts to their code
+var failureReasons = map[string]FailureCode{
+	"OB_orders_do_not_match":               FailureOrdersDoNotMatch,
+	"OB_orders_for_different_amms":         FailureOrdersDoNotMatch,
+	"OB_order_0_is_not_long":               FailureOrdersDoNotMatch,
+	"OB_order_1_is_not_short":              FailureOrdersDoNotMatch,
+	"not long":                             FailureOrdersDoNotMatch,
+	"not short":                            FailureOrdersDoNotMatch,
+	"invalid fillAmount":                   FailureOrdersDoNotMatch,
+	"OB_long_order_price_too_low":          FailureLongPriceTooLow,
+	"OB_short_order_price_too_high":        FailureShortPriceTooHigh,
+	"overfill":                             FailureOverFill,
+	"not multiple":                         FailureNotMultiple,
+	"not reducing pos":                     FailureNotReducing,
+	"invalid order":                        FailureInvalidOrder,
+	"invalid order type":                   FailureInvalidOrder,
+	"OB_invalid_order":                     FailureInvalidOrder,
+	"OB_trader_mismatch":                   FailureInvalidOrder,
+	"no trading authority":                 FailureInvalidOrder,
+	"not ioc order":                        FailureInvalidOrder,
+	"not_ioc_order":                        FailureInvalidOrder,
+	"ioc expired":                          FailureIOCExpired,
+	"ioc expiration too far":               FailureIOCExpired,
+	"not gtt order":                        FailureInvalidOrder,
+	"order amended":                        FailureOrderAmended,
+	"gtt expired":                          FailureGTTExpired,
+	"trader is not liquidatable":           FailureNotLiquidatable,
+	"no position":                          FailureNotLiquidatable,
+	"liquidation amount exceeds threshold": FailureNotLiquidatable,
+	"OB_stale_oracle_price":                FailureStaleOraclePrice,
+	"OB_oracle_price_deviation_too_high":   FailureOraclePriceDeviation,
+}
+
+// RetryPolicy is when an order that failed with a code is taken up for matching again
+type RetryPolicy uint8
+
+const (
+	RetryAfterBlocks RetryPolicy = iota
+	RetryAfterOracleMove
+	RetryNever
+	RetryAfterDelay
+)
+
+func (policy RetryPolicy) String() string {
+	return [...]string{"AFTER_BLOCKS", "AFTER_ORACLE_MOVE", "NEVER", "AFTER_DELAY"}[policy]
+}
+
+func (code FailureCode) RetryPolicy() RetryPolicy {
+	switch code {
+	case FailureIOCExpired, FailureGTTExpired:
+		// the order can't be executed anymore, it stays until it is cancelled
+		return RetryNever
+	case FailureOverFill, FailureInvalidOrder:
+		// the order might become executable, e.g. once the trading authority is granted
+		return RetryAfterDelay
+	case FailureStaleOraclePrice, FailureOraclePriceDeviation, FailureInsufficientMargin:
+		return RetryAfterOracleMove
+	default:
+		return RetryAfterBlocks
+	}
+}
+
+// Failure is why the execution of an order failed
+type Failure struct {
+	Code   FailureCode
+	Reason string
+}
+
+// NewFailure classifies the error of a failed execution, as emitted by the orderbook or returned by the juror
+func NewFailure(reason string) Failure {
+	return Failure{Code: classifyFailure(reason), Reason: reason}
+}
+
+func (failure Failure) String() string {
+	return fmt.Sprintf("%s: %s", failure.Code, failure.Reason)
+}
+
+func classifyFailure(reason string) FailureCode {
+	reason = strings.TrimSpace(reason)
+	if code, ok := failureReasons[reason]; ok {
+		return code
+	}
+	if reason == "" || reason == "execution reverted" {
+		return FailureUnknown
+	}
+	// e.g. "CH: Below Minimum Allowable Margin", "MA_reserveMargin: Insufficient margin"
+	if strings.Contains(strings.ToLower(reason), "margin") {
+		return FailureInsufficientMargin
+	}
+	return FailureReverted
+}
+
+// failureCode returns the failure code of an Execution_Failed status
+func (lifecycle Lifecycle) failureCode() FailureCode {
+	if lifecycle.FailureCode == "" {
+		// failed before the failure codes were saved
+		return classifyFailure(lifecycle.Info)
+	}
+	return lifecycle.FailureCode
+}
+
+// isRetryable returns whether an order that failed with [status] can be matched again at [blockNumber].
+// [oraclePrice] is the current oracle price of the market of the order, nil if it isn't known.
+func isRetryable(status Lifecycle, blockNumber uint64, oraclePrice func() *big.Int) bool {
+	switch status.failureCode().RetryPolicy() {
+	case RetryNever:
+		return false
+	case RetryAfterDelay:
+		return status.BlockNumber+RETRY_AFTER_DELAY_BLOCKS <= blockNumber
+	case RetryAfterOracleMove:
+		if status.BlockNumber+RETRY_AFTER_ORACLE_MOVE_MAX_BLOCKS <= blockNumber {
+			return true
+		}
+		if status.OraclePrice == nil || status.OraclePrice.Sign() == 0 {
+			// the price wasn't known at the time of failure
+			return status.BlockNumber+RETRY_AFTER_BLOCKS <= blockNumber
+		}
+		price := oraclePrice()
+		if price == nil {
+			return status.BlockNumber+RETRY_AFTER_BLOCKS <= blockNumber
+		}
+		move := new(big.Int).Abs(new(big.Int).Sub(price, status.OraclePrice))
+		move.Mul(move, big.NewInt(1e4))
+		return move.Cmp(new(big.Int).Mul(status.OraclePrice, big.NewInt(RETRY_AFTER_ORACLE_MOVE_BPS))) >= 0
+	default:
+		return status.BlockNumber+RETRY_AFTER_BLOCKS <= blockNumber
+	}
+}
+
+// lazyOraclePrices reads the oracle prices only if an order needs them, and only once
+type lazyOraclePrices struct {
+	configService IConfigService
+	prices        []*big.Int
+	loaded        bool
+}
+
+func (l *lazyOraclePrices) get(market Market) *big.Int {
+	if !l.loaded {
+		l.loaded = true
+		if l.configService != nil {
+			l.prices = l.configService.GetUnderlyingPrices()
+		}
+	}
+	if int(market) < 0 || int(market) >= len(l.prices) {
+		return nil
+	}
+	return l.prices[market]
+}
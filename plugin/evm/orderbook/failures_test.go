@@ -0,0 +1,116 @@
+package orderbook
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	cases := map[string]FailureCode{
+		"OB_orders_do_not_match":             FailureOrdersDoNotMatch,
+		"OB_long_order_price_too_low":        FailureLongPriceTooLow,
+		"overfill":                           FailureOverFill,
+		"not multiple":                       FailureNotMultiple,
+		"not reducing pos":                   FailureNotReducing,
+		"OB_stale_oracle_price":              FailureStaleOraclePrice,
+		"OB_oracle_price_deviation_too_high": FailureOraclePriceDeviation,
+		"CH: Below Minimum Allowable Margin": FailureInsufficientMargin,
+		"INSUFFICIENT_MARGIN":                FailureInsufficientMargin,
+		"gtt expired":                        FailureGTTExpired,
+		"order amended":                      FailureOrderAmended,
+		"OB_order_already_exists":            FailureReverted,
+		"execution reverted":                 FailureUnknown,
+		"":                                   FailureUnknown,
+	}
+	for reason, code := range cases {
+		assert.Equal(t, code, NewFailure(reason).Code, reason)
+	}
+	assert.Equal(t, RetryAfterDelay, FailureOverFill.RetryPolicy())
+	assert.Equal(t, RetryAfterDelay, NewFailure("no trading authority").Code.RetryPolicy())
+	assert.Equal(t, RetryNever, FailureIOCExpired.RetryPolicy())
+	assert.Equal(t, RetryAfterOracleMove, FailureInsufficientMargin.RetryPolicy())
+	assert.Equal(t, RetryAfterBlocks, FailureNotMultiple.RetryPolicy())
+	assert.Equal(t, RetryAfterBlocks, FailureOrderAmended.RetryPolicy())
+	assert.Equal(t, "AFTER_ORACLE_MOVE", RetryAfterOracleMove.String())
+}
+
+func TestIsRetryable(t *testing.T) {
+	noPrice := func() *big.Int { return nil }
+	priceOf := func(p int64) func() *big.Int { return func() *big.Int { return big.NewInt(p) } }
+
+	t.Run("retry after blocks", func(t *testing.T) {
+		status := Lifecycle{BlockNumber: 100, Status: Execution_Failed, Info: "not multiple", FailureCode: FailureNotMultiple}
+		assert.False(t, isRetryable(status, 100+RETRY_AFTER_BLOCKS-1, noPrice))
+		assert.True(t, isRetryable(status, 100+RETRY_AFTER_BLOCKS, noPrice))
+	})
+	t.Run("never retried", func(t *testing.T) {
+		status := Lifecycle{BlockNumber: 100, Status: Execution_Failed, Info: "gtt expired", FailureCode: FailureGTTExpired}
+		assert.False(t, isRetryable(status, 100+RETRY_AFTER_ORACLE_MOVE_MAX_BLOCKS*10, noPrice))
+	})
+	t.Run("retry after a delay", func(t *testing.T) {
+		status := Lifecycle{BlockNumber: 100, Status: Execution_Failed, Info: "overfill", FailureCode: FailureOverFill}
+		assert.False(t, isRetryable(status, 100+RETRY_AFTER_BLOCKS, noPrice))
+		assert.True(t, isRetryable(status, 100+RETRY_AFTER_DELAY_BLOCKS, noPrice))
+	})
+	t.Run("retry after oracle move", func(t *testing.T) {
+		status := Lifecycle{BlockNumber: 100, Status: Execution_Failed, Info: "CH: Below Minimum Allowable Margin", FailureCode: FailureInsufficientMargin, OraclePrice: big.NewInt(1_000_000)}
+		// 0.4% move
+		assert.False(t, isRetryable(status, 101, priceOf(1_004_000)))
+		// 0.5% move, either way
+		assert.True(t, isRetryable(status, 101, priceOf(1_005_000)))
+		assert.True(t, isRetryable(status, 101, priceOf(995_000)))
+		// retried anyway eventually
+		assert.True(t, isRetryable(status, 100+RETRY_AFTER_ORACLE_MOVE_MAX_BLOCKS, priceOf(1_000_000)))
+
+		// the price wasn't known, falls back to the blocks
+		status.OraclePrice = nil
+		assert.False(t, isRetryable(status, 101, priceOf(2_000_000)))
+		assert.True(t, isRetryable(status, 100+RETRY_AFTER_BLOCKS, noPrice))
+	})
+	t.Run("statuses saved without a failure code are classified", func(t *testing.T) {
+		status := Lifecycle{BlockNumber: 100, Status: Execution_Failed, Info: "ioc expired"}
+		assert.False(t, isRetryable(status, 100+RETRY_AFTER_BLOCKS, noPrice))
+	})
+}
+
+func TestFailedOrdersAreRetriedPerPolicy(t *testing.T) {
+	db := getDatabase()
+	salt := big.NewInt(1)
+	retried := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(1), salt)
+	db.Add(&retried)
+	delayed := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(1), big.NewInt(2))
+	db.Add(&delayed)
+
+	assert.Nil(t, db.SetOrderStatus(retried.Id, Execution_Failed, "OB_orders_do_not_match", 5))
+	assert.Nil(t, db.SetOrderStatus(delayed.Id, Execution_Failed, "overfill", 5))
+	assert.Equal(t, FailureOrdersDoNotMatch, db.OrderMap[retried.Id].getOrderStatus().FailureCode)
+	assert.Equal(t, FailureOverFill, db.OrderMap[delayed.Id].getOrderStatus().FailureCode)
+
+	assert.Empty(t, db.GetLongOrders(market, nil, big.NewInt(6), 0))
+	longOrders := db.GetLongOrders(market, nil, big.NewInt(5+RETRY_AFTER_BLOCKS), 0)
+	assert.Equal(t, 1, len(longOrders))
+	assert.Equal(t, retried.Id, longOrders[0].Id)
+	assert.Equal(t, 2, len(db.GetLongOrders(market, nil, big.NewInt(5+RETRY_AFTER_DELAY_BLOCKS), 0)))
+}
+
+func TestFailureIsClearedWhenTheFillChanges(t *testing.T) {
+	db := getDatabase()
+	order := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(1), big.NewInt(1))
+	db.Add(&order)
+	db.UpdateFilledBaseAssetQuantity(big.NewInt(4), order.Id, 3)
+	assert.Nil(t, db.SetOrderStatus(order.Id, Execution_Failed, "overfill", 5))
+	assert.Empty(t, db.GetLongOrders(market, nil, big.NewInt(6), 0))
+
+	// matched by another validator
+	db.UpdateFilledBaseAssetQuantity(big.NewInt(2), order.Id, 6)
+	assert.Equal(t, Placed, db.OrderMap[order.Id].getOrderStatus().Status)
+	longOrders := db.GetLongOrders(market, nil, big.NewInt(7), 0)
+	assert.Equal(t, 1, len(longOrders))
+	assert.Equal(t, big.NewInt(6), longOrders[0].FilledBaseAssetQuantity)
+
+	// the fill is reorged out, the failure applies again
+	db.UpdateFilledBaseAssetQuantity(big.NewInt(-2), order.Id, 6)
+	assert.Equal(t, Execution_Failed, db.OrderMap[order.Id].getOrderStatus().Status)
+}
@@ -0,0 +1,131 @@
+package orderbook
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// initialOrderBookGasPerBlock is the gas used by the orderbook txs in a block, assumed until it is learnt from the receipts
+	initialOrderBookGasPerBlock uint64 = 200_000
+
+	// the premium over the suggested gas price, in percent
+	minFeePremiumPercent  uint64 = 10
+	maxFeePremiumPercent  uint64 = 100
+	feePremiumStepPercent uint64 = 10
+)
+
+var txKindNames = map[string]txKind{
+	"funding":     fundingPaymentTx,
+	"liquidation": liquidationTx,
+	"cancel":      cancelTx,
+	"match":       matchedOrdersTx,
+}
+
+// TxFeeCaps caps the gas price of the orderbook txs of a kind ("funding", "liquidation", "cancel" or "match"),
+// as a percentage of the suggested gas price. The kinds that are not in the map are not capped.
+type TxFeeCaps map[string]uint64
+
+func (caps TxFeeCaps) Validate() error {
+	for name, capPercent := range caps {
+		if _, ok := txKindNames[name]; !ok {
+			return fmt.Errorf("unknown orderbook tx kind %q in fee caps", name)
+		}
+		if capPercent < 100 {
+			return fmt.Errorf("fee cap of %s txs must be at least 100%% of the suggested gas price, got %d", name, capPercent)
+		}
+	}
+	return nil
+}
+
+// feeStrategy prices the orderbook txs of the validator so that the blocks it builds pay for their block gas cost.
+//
+// The block fee (blockGasCost * baseFee) has to be paid by the tips of the txs in the block, and the orderbook txs are most of them.
+// Instead of assuming a fixed gas usage, the strategy learns from the receipts of the accepted blocks how much gas the orderbook txs use
+// in a block, and spreads the block fee over it. The premium over the suggested gas price goes up every time a block fails to be built
+// with BLOCK_GAS_TOO_LOW, and slowly comes back down while the blocks are built.
+// The txs are priced by the matching pipeline while the blocks are observed by UpdateMetrics in its own goroutine.
+type feeStrategy struct {
+	// mu guards gasPerBlock and premiumPercent
+	mu             sync.Mutex
+	gasPerBlock    uint64
+	premiumPercent uint64
+	capsPercent    map[txKind]uint64
+}
+
+func newFeeStrategy(caps TxFeeCaps) *feeStrategy {
+	capsPercent := map[txKind]uint64{}
+	for name, capPercent := range caps {
+		capsPercent[txKindNames[name]] = capPercent
+	}
+	return &feeStrategy{
+		gasPerBlock:    initialOrderBookGasPerBlock,
+		premiumPercent: minFeePremiumPercent,
+		capsPercent:    capsPercent,
+	}
+}
+
+// fee returns the gas price of a tx of [kind], given the suggested gas price and the estimated block gas cost of the next block
+func (fs *feeStrategy) fee(kind txKind, suggestedPrice *big.Int, blockGasCost *big.Int) *big.Int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	price := new(big.Int).Mul(suggestedPrice, new(big.Int).SetUint64(100+fs.premiumPercent))
+	price.Div(price, big.NewInt(100))
+
+	// example calculation for blockGasCost = 10,000, price = 60 gwei, gasPerBlock = 200,000
+	// tip = (10000 * 60 * 1e9) / 200000 = 3 gwei
+	tip := new(big.Int).Mul(blockGasCost, price)
+	tip.Div(tip, new(big.Int).SetUint64(fs.gasPerBlock))
+	fee := price.Add(price, tip)
+
+	if capPercent, ok := fs.capsPercent[kind]; ok {
+		maxFee := new(big.Int).Mul(suggestedPrice, new(big.Int).SetUint64(capPercent))
+		maxFee.Div(maxFee, big.NewInt(100))
+		if fee.Cmp(maxFee) > 0 {
+			return maxFee
+		}
+	}
+	return fee
+}
+
+// observeBlock learns from the orderbook txs of the validator that were included in an accepted block
+func (fs *feeStrategy) observeBlock(orderBookGasUsed uint64) {
+	if orderBookGasUsed == 0 {
+		// the block was built by another validator
+		return
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	// exponential moving average over the last ~8 blocks
+	fs.gasPerBlock = (fs.gasPerBlock*7 + orderBookGasUsed) / 8
+	if fs.gasPerBlock < params.TxGas {
+		fs.gasPerBlock = params.TxGas
+	}
+	if fs.premiumPercent > minFeePremiumPercent {
+		fs.premiumPercent--
+	}
+	orderBookGasPerBlockGauge.Update(int64(fs.gasPerBlock))
+	orderBookFeePremiumGauge.Update(int64(fs.premiumPercent))
+}
+
+// observeBlockGasTooLow is called when a block with the orderbook txs of the validator failed to be built because their tips didn't cover the block gas cost
+func (fs *feeStrategy) observeBlockGasTooLow() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.premiumPercent += feePremiumStepPercent
+	if fs.premiumPercent > maxFeePremiumPercent {
+		fs.premiumPercent = maxFeePremiumPercent
+	}
+	// the orderbook txs of the failed block can be fewer than usual, spread the block fee over less gas
+	fs.gasPerBlock = fs.gasPerBlock / 2
+	if fs.gasPerBlock < params.TxGas {
+		fs.gasPerBlock = params.TxGas
+	}
+	log.Warn("orderbook txs didn't pay for the block gas cost, raising their fee", "premiumPercent", fs.premiumPercent, "gasPerBlock", fs.gasPerBlock)
+	orderBookGasPerBlockGauge.Update(int64(fs.gasPerBlock))
+	orderBookFeePremiumGauge.Update(int64(fs.premiumPercent))
+}
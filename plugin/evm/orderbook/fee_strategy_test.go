@@ -0,0 +1,156 @@
+package orderbook
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/commontype"
+	"github.com/ava-labs/subnet-evm/consensus/dummy"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/stretchr/testify/assert"
+)
+
+// the fee config of hubblenext
+var simulatedFeeConfig = commontype.FeeConfig{
+	GasLimit:                 big.NewInt(15_000_000),
+	TargetBlockRate:          1,
+	MinBaseFee:               big.NewInt(30_000_000_000),
+	TargetGas:                big.NewInt(150_000_000),
+	BaseFeeChangeDenominator: big.NewInt(50),
+	MinBlockGasCost:          big.NewInt(0),
+	MaxBlockGasCost:          big.NewInt(1_000_000),
+	BlockGasCostStep:         big.NewInt(10_000),
+}
+
+// simulateBlock builds a block on [parent] with the orderbook txs of the validator, [txGas] being the gas used by each of them.
+// Like the dummy consensus engine, it fails if their tips don't pay for the block gas cost.
+func simulateBlock(t *testing.T, fs *feeStrategy, parent *types.Header, txGas []uint64) (*types.Header, *big.Int, bool) {
+	timestamp := parent.Time + 1
+	extra, baseFee, err := dummy.CalcBaseFee(params.TestChainConfig, simulatedFeeConfig, parent, timestamp)
+	assert.Nil(t, err)
+	blockGasCost := dummy.EstimateNextBlockGasCost(simulatedFeeConfig, parent, timestamp)
+
+	// what the validator sees when it creates the txs
+	fee := fs.fee(matchedOrdersTx, parent.BaseFee, dummy.EstimateNextBlockGasCost(simulatedFeeConfig, parent, parent.Time+1))
+
+	gasUsed := uint64(0)
+	totalTip := big.NewInt(0)
+	for _, gas := range txGas {
+		gasUsed += gas
+		tip := new(big.Int).Sub(fee, baseFee)
+		totalTip.Add(totalTip, tip.Mul(tip, new(big.Int).SetUint64(gas)))
+	}
+	if fee.Cmp(baseFee) < 0 || new(big.Int).Div(totalTip, baseFee).Cmp(blockGasCost) < 0 {
+		fs.observeBlockGasTooLow()
+		return parent, fee, false
+	}
+
+	fs.observeBlock(gasUsed)
+	return &types.Header{
+		Number:       new(big.Int).Add(parent.Number, big.NewInt(1)),
+		Time:         timestamp,
+		BaseFee:      baseFee,
+		BlockGasCost: blockGasCost,
+		GasUsed:      gasUsed,
+		Extra:        extra,
+	}, fee, true
+}
+
+func TestFeeStrategyOnSimulatedChain(t *testing.T) {
+	genesis := &types.Header{
+		Number:       big.NewInt(1),
+		Time:         1000,
+		BaseFee:      big.NewInt(30_000_000_000),
+		BlockGasCost: big.NewInt(50_000),
+		Extra:        make([]byte, params.ExtraDataSize),
+	}
+
+	t.Run("learns the gas of the orderbook txs in quiet blocks", func(t *testing.T) {
+		fs := newFeeStrategy(nil)
+		parent := genesis
+		failures := []int{}
+		for i := 0; i < 40; i++ {
+			var built bool
+			// a single small match per block
+			parent, _, built = simulateBlock(t, fs, parent, []uint64{60_000})
+			if !built {
+				failures = append(failures, i)
+			}
+		}
+		// the first blocks fail while the strategy assumes 200k gas per block, then they are built
+		assert.NotEmpty(t, failures)
+		assert.Less(t, failures[len(failures)-1], 5)
+		assert.Less(t, fs.gasPerBlock, initialOrderBookGasPerBlock)
+	})
+	t.Run("spreads the block fee over the gas of busy blocks", func(t *testing.T) {
+		fs := newFeeStrategy(nil)
+		parent := genesis
+		txGas := []uint64{}
+		for i := 0; i < 20; i++ {
+			txGas = append(txGas, 200_000)
+		}
+		fees := []*big.Int{}
+		for i := 0; i < 40; i++ {
+			var fee *big.Int
+			var built bool
+			parent, fee, built = simulateBlock(t, fs, parent, txGas)
+			assert.True(t, built)
+			fees = append(fees, fee)
+		}
+		// no overpaying once the gas of the blocks is learnt
+		assert.Equal(t, -1, fees[len(fees)-1].Cmp(fees[0]))
+		assert.Greater(t, fs.gasPerBlock, initialOrderBookGasPerBlock)
+	})
+	t.Run("premium comes back down after a failure", func(t *testing.T) {
+		fs := newFeeStrategy(nil)
+		fs.observeBlockGasTooLow()
+		fs.observeBlockGasTooLow()
+		assert.Equal(t, minFeePremiumPercent+2*feePremiumStepPercent, fs.premiumPercent)
+		for i := 0; i < 100; i++ {
+			fs.observeBlock(200_000)
+		}
+		assert.Equal(t, minFeePremiumPercent, fs.premiumPercent)
+
+		for i := 0; i < 100; i++ {
+			fs.observeBlockGasTooLow()
+		}
+		assert.Equal(t, maxFeePremiumPercent, fs.premiumPercent)
+		assert.Equal(t, params.TxGas, fs.gasPerBlock)
+	})
+}
+
+func TestFeeStrategyCaps(t *testing.T) {
+	fs := newFeeStrategy(TxFeeCaps{"cancel": 150})
+	suggestedPrice := big.NewInt(30_000_000_000)
+	blockGasCost := big.NewInt(500_000)
+
+	// 33 gwei + 500000 * 33 gwei / 200000
+	assert.Equal(t, big.NewInt(115_500_000_000), fs.fee(liquidationTx, suggestedPrice, blockGasCost))
+	assert.Equal(t, big.NewInt(45_000_000_000), fs.fee(cancelTx, suggestedPrice, blockGasCost))
+	// below the cap
+	assert.Equal(t, big.NewInt(33_000_000_000), fs.fee(cancelTx, suggestedPrice, big.NewInt(0)))
+
+	t.Run("blocks are observed while txs are priced", func(t *testing.T) {
+		fs := newFeeStrategy(nil)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		// like UpdateMetrics, in its own goroutine
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				fs.observeBlock(initialOrderBookGasPerBlock)
+				fs.observeBlockGasTooLow()
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			assert.True(t, fs.fee(matchedOrdersTx, suggestedPrice, blockGasCost).Cmp(suggestedPrice) > 0)
+		}
+		wg.Wait()
+	})
+
+	assert.NoError(t, TxFeeCaps{"cancel": 150, "match": 100}.Validate())
+	assert.Error(t, TxFeeCaps{"cancel": 99}.Validate())
+	assert.Error(t, TxFeeCaps{"withdraw": 150}.Validate())
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"sort"
 
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -37,11 +38,8 @@ func calcMarginFractionWithDebugInfo(addr common.Address, trader *Trader, pendin
 func calcMarginFraction(trader *Trader, pendingFunding *big.Int, oraclePrices map[Market]*big.Int, lastPrices map[Market]*big.Int, markets []Market) *big.Int {
 	margin := new(big.Int).Sub(getNormalisedMargin(trader), pendingFunding)
 	notionalPosition, unrealizePnL := getTotalNotionalPositionAndUnrealizedPnl(trader, margin, Maintenance_Margin, oraclePrices, lastPrices, markets)
-	if notionalPosition.Sign() == 0 {
-		return big.NewInt(math.MaxInt64)
-	}
-	margin.Add(margin, unrealizePnL)
-	return new(big.Int).Div(multiplyBasePrecision(margin), notionalPosition)
+	// same computation as the juror's validateLiquidation
+	return bibliophile.CalcMarginFraction(margin.Add(margin, unrealizePnL), notionalPosition)
 }
 
 func sortLiquidableSliceByMarginFraction(positions []LiquidablePosition) []LiquidablePosition {
@@ -67,7 +65,7 @@ func getTotalFunding(trader *Trader, markets []Market) *big.Int {
 }
 
 func getNotionalPosition(price *big.Int, size *big.Int) *big.Int {
-	return big.NewInt(0).Abs(dividePrecisionSize(big.NewInt(0).Mul(size, price)))
+	return bibliophile.GetNotionalPosition(price, size)
 }
 
 type MarginMode uint8
@@ -120,19 +118,7 @@ func getOptimalPnl(market Market, oraclePrice *big.Int, lastPrice *big.Int, trad
 }
 
 func getPositionMetadata(price *big.Int, openNotional *big.Int, size *big.Int, margin *big.Int) (notionalPosition *big.Int, unrealisedPnl *big.Int, marginFraction *big.Int) {
-	// log.Info("in getPositionMetadata", "price", price, "openNotional", openNotional, "size", size, "margin", margin)
-	notionalPosition = getNotionalPosition(price, size)
-	uPnL := new(big.Int)
-	if notionalPosition.Cmp(big.NewInt(0)) == 0 {
-		return big.NewInt(0), big.NewInt(0), big.NewInt(0)
-	}
-	if size.Cmp(big.NewInt(0)) > 0 {
-		uPnL = new(big.Int).Sub(notionalPosition, openNotional)
-	} else {
-		uPnL = new(big.Int).Sub(openNotional, notionalPosition)
-	}
-	mf := new(big.Int).Div(multiplyBasePrecision(new(big.Int).Add(margin, uPnL)), notionalPosition)
-	return notionalPosition, uPnL, mf
+	return bibliophile.GetPositionMetadata(price, openNotional, size, margin)
 }
 
 func multiplyBasePrecision(number *big.Int) *big.Int {
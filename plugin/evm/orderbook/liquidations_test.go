@@ -4,7 +4,12 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -365,3 +370,68 @@ func getDatabase() *InMemoryDatabase {
 
 	return NewInMemoryDatabase(configService)
 }
+
+// FuzzCalcMarginFraction checks that the margin fraction computed from the in-memory trader matches
+// the one the juror computes from storage in validateLiquidation
+func FuzzCalcMarginFraction(f *testing.F) {
+	f.Add(int64(500e6), int64(5e18), int64(5000e6), int64(1100e6), int64(1000e6), int64(2e15))
+	f.Add(int64(100e6), int64(-3e18), int64(2400e6), int64(900e6), int64(950e6), int64(-7e14))
+	f.Add(int64(0), int64(-1e17+1), int64(3), int64(1), int64(2), int64(-1))
+	f.Fuzz(func(t *testing.T, margin, size, openNotional, lastPrice, oraclePrice, premiumFraction int64) {
+		if lastPrice <= 0 || oraclePrice <= 0 || openNotional < 0 {
+			t.Skip()
+		}
+		trader := common.HexToAddress("0x22Bb736b64A0b4D4081E103f83bccF864F0404aa")
+		amm := common.HexToAddress("0xa72b463C21dA61cCc86069cFab82e9e8491152a0")
+		stateDB := state.NewTestStateDB(t)
+		setupMarketState(stateDB, amm, big.NewInt(lastPrice), big.NewInt(oraclePrice), big.NewInt(premiumFraction))
+		setupTraderState(stateDB, amm, trader, big.NewInt(margin), big.NewInt(size), big.NewInt(openNotional))
+
+		output := bibliophile.GetNotionalPositionAndMargin(stateDB, &bibliophile.GetNotionalPositionAndMarginInput{Trader: trader, IncludeFundingPayments: true, Mode: uint8(bibliophile.Maintenance_Margin)}, bibliophile.LatestVersion)
+		expected := bibliophile.CalcMarginFraction(output.Margin, output.NotionalPosition)
+
+		position := &Position{
+			Size:                big.NewInt(size),
+			OpenNotional:        big.NewInt(openNotional),
+			LastPremiumFraction: big.NewInt(0),
+		}
+		position.UnrealisedFunding = dividePrecisionSize(new(big.Int).Mul(big.NewInt(premiumFraction), position.Size))
+		inMemoryTrader := &Trader{
+			Positions: map[Market]*Position{market: position},
+			Margin:    Margin{Deposited: map[Collateral]*big.Int{HUSD: big.NewInt(margin)}},
+		}
+		markets := []Market{market}
+		actual := calcMarginFraction(inMemoryTrader, getTotalFunding(inMemoryTrader, markets), map[Market]*big.Int{market: big.NewInt(oraclePrice)}, map[Market]*big.Int{market: big.NewInt(lastPrice)}, markets)
+		assert.Equal(t, expected, actual)
+	})
+}
+
+func setupMarketState(stateDB contract.StateDB, amm common.Address, lastPrice, oraclePrice, cumulativePremiumFraction *big.Int) {
+	clearingHouse := common.HexToAddress(bibliophile.CLEARING_HOUSE_GENESIS_ADDRESS)
+	stateDB.SetState(clearingHouse, common.BigToHash(big.NewInt(bibliophile.AMMS_SLOT)), common.BigToHash(big.NewInt(1)))
+	stateDB.SetState(clearingHouse, crypto.Keccak256Hash(common.LeftPadBytes(big.NewInt(bibliophile.AMMS_SLOT).Bytes(), 32)), amm.Hash())
+	stateDB.SetState(amm, common.BigToHash(big.NewInt(bibliophile.MARK_PRICE_TWAP_DATA_SLOT)), common.BigToHash(lastPrice))
+	stateDB.SetState(amm, common.BigToHash(big.NewInt(bibliophile.VAR_CUMULATIVE_PREMIUM_FRACTION)), toTwosComplementHash(cumulativePremiumFraction))
+
+	oracle := common.HexToAddress("0x0000000000000000000000000000000000000b22")
+	underlying := common.HexToAddress("0x0000000000000000000000000000000000000c33")
+	stateDB.SetState(amm, common.BigToHash(big.NewInt(bibliophile.ORACLE_SLOT)), oracle.Hash())
+	stateDB.SetState(amm, common.BigToHash(big.NewInt(bibliophile.UNDERLYING_ASSET_SLOT)), underlying.Hash())
+	priceSlot := crypto.Keccak256Hash(common.LeftPadBytes(underlying.Bytes(), 32), common.LeftPadBytes(big.NewInt(bibliophile.TEST_ORACLE_PRICES_MAPPING_SLOT).Bytes(), 32))
+	stateDB.SetState(oracle, priceSlot, common.BigToHash(oraclePrice))
+}
+
+func setupTraderState(stateDB contract.StateDB, amm common.Address, trader common.Address, margin, size, openNotional *big.Int) {
+	marginSlot := crypto.Keccak256(common.LeftPadBytes(big.NewInt(0).Bytes(), 32), common.LeftPadBytes(big.NewInt(bibliophile.VAR_MARGIN_MAPPING_STORAGE_SLOT).Bytes(), 32))
+	marginSlot = crypto.Keccak256(common.LeftPadBytes(trader.Bytes(), 32), marginSlot)
+	stateDB.SetState(common.HexToAddress(bibliophile.MARGIN_ACCOUNT_GENESIS_ADDRESS), common.BytesToHash(marginSlot), toTwosComplementHash(margin))
+
+	positionSlot := new(big.Int).SetBytes(crypto.Keccak256(common.LeftPadBytes(trader.Bytes(), 32), common.LeftPadBytes(big.NewInt(bibliophile.VAR_POSITIONS_SLOT).Bytes(), 32)))
+	stateDB.SetState(amm, common.BigToHash(positionSlot), toTwosComplementHash(size))
+	stateDB.SetState(amm, common.BigToHash(new(big.Int).Add(positionSlot, big.NewInt(1))), common.BigToHash(openNotional))
+	// last premium fraction is left at 0
+}
+
+func toTwosComplementHash(n *big.Int) common.Hash {
+	return common.BytesToHash(math.U256Bytes(new(big.Int).Set(n)))
+}
@@ -22,6 +22,10 @@ type MatchingPipeline struct {
 	lotp           LimitOrderTxProcessor
 	configService  IConfigService
 	MatchingTicker *time.Ticker
+	// TxPriorityPolicy decides which txs make it into the block when they don't all fit in the gas budget
+	TxPriorityPolicy TxPriorityPolicy
+	// BatchExecution sends the liquidations and matches of a run in a single executeBatch tx
+	BatchExecution bool
 }
 
 func NewMatchingPipeline(
@@ -30,58 +34,76 @@ func NewMatchingPipeline(
 	configService IConfigService) *MatchingPipeline {
 
 	return &MatchingPipeline{
-		db:             db,
-		lotp:           lotp,
-		configService:  configService,
-		MatchingTicker: time.NewTicker(matchingTickerDuration),
+		db:               db,
+		lotp:             lotp,
+		configService:    configService,
+		MatchingTicker:   time.NewTicker(matchingTickerDuration),
+		TxPriorityPolicy: PriorityLiquidationsFirst,
 	}
 }
 
-func (pipeline *MatchingPipeline) Run(blockNumber *big.Int) bool {
+// Run matches the orders for the block [blockNumber]. [parentTimestamp] is the timestamp of its parent, the current head,
+// as the timestamp of the block itself is only known when it is built. [blockTimestamp] is the expected timestamp of the
+// block (see NextBlockTimestamp), the oracle circuit breaker is checked at it like the juror does when the block executes.
+func (pipeline *MatchingPipeline) Run(blockNumber *big.Int, parentTimestamp uint64, blockTimestamp uint64) bool {
 	pipeline.mu.Lock()
 	defer pipeline.mu.Unlock()
 
 	// reset ticker
 	pipeline.MatchingTicker.Reset(matchingTickerDuration)
+	matchesFound, circuitBreakers := pipeline.run(pipeline.lotp, blockNumber, parentTimestamp, blockTimestamp)
+	recordHaltedMarkets(circuitBreakers)
+	return matchesFound
+}
+
+// run executes the pipeline with [lotp], which is either the tx processor of this validator
+// or a recorder when the canonical executions are only being computed (see PlanExecutions).
+// The txs are queued and sent to [lotp] at the end, in the order of the TxPriorityPolicy.
+// It also returns the result of the oracle circuit breaker of each market (see checkCircuitBreakers), which is only
+// recorded in the metrics by Run, so that planning the executions of a block doesn't touch them.
+func (pipeline *MatchingPipeline) run(lotp LimitOrderTxProcessor, blockNumber *big.Int, parentTimestamp uint64, blockTimestamp uint64) (bool, map[Market]error) {
 	markets := pipeline.GetActiveMarkets()
 
 	if len(markets) == 0 {
-		return false
+		return false, nil
 	}
 
 	// start fresh and purge all local transactions
-	pipeline.lotp.PurgeOrderBookTxs()
+	queue := newTxQueue(lotp, pipeline.TxPriorityPolicy, pipeline.BatchExecution)
+	queue.PurgeOrderBookTxs()
 
-	if isFundingPaymentTime(pipeline.db.GetNextFundingTime()) {
-		log.Info("MatchingPipeline:isFundingPaymentTime")
-		err := executeFundingPayment(pipeline.lotp)
-		if err != nil {
-			log.Error("Funding payment job failed", "err", err)
-		}
-	}
+	pipeline.runFundingPayments(queue, markets, parentTimestamp)
 
 	// fetch the underlying price and run the matching engine
 	underlyingPrices := pipeline.GetUnderlyingPrices()
 
 	// build trader map
 	liquidablePositions, ordersToCancel := pipeline.db.GetNaughtyTraders(underlyingPrices, markets)
-	cancellableOrderIds := pipeline.cancelLimitOrders(ordersToCancel)
+	cancellableOrderIds := pipeline.cancelLimitOrders(queue, ordersToCancel)
+	pipeline.cancelExpiredGTTOrders(queue, parentTimestamp)
+	circuitBreakers := pipeline.checkCircuitBreakers(markets, blockTimestamp)
 	orderMap := make(map[Market]*Orders)
 	for _, market := range markets {
-		orderMap[market] = pipeline.fetchOrders(market, underlyingPrices[market], cancellableOrderIds, blockNumber)
+		if circuitBreakers[market] != nil {
+			// the oracle price cannot be trusted, so neither match nor liquidate in this market
+			orderMap[market] = &Orders{}
+			continue
+		}
+		orderMap[market] = pipeline.fetchOrders(market, underlyingPrices[market], cancellableOrderIds, blockNumber, parentTimestamp)
 	}
-	pipeline.runLiquidations(liquidablePositions, orderMap, underlyingPrices)
+	pipeline.runLiquidations(queue, liquidablePositions, orderMap, underlyingPrices)
 	for _, market := range markets {
 		// @todo should we prioritize matching in any particular market?
-		pipeline.runMatchingEngine(pipeline.lotp, orderMap[market].longOrders, orderMap[market].shortOrders)
+		pipeline.runMatchingEngine(queue, orderMap[market].longOrders, orderMap[market].shortOrders)
 	}
+	queue.flush()
 
-	orderBookTxsCount := pipeline.lotp.GetOrderBookTxsCount()
+	orderBookTxsCount := lotp.GetOrderBookTxsCount()
 	if orderBookTxsCount > 0 {
-		return true
+		return true, circuitBreakers
 	}
 
-	return false
+	return false, circuitBreakers
 }
 
 type Orders struct {
@@ -108,7 +130,7 @@ func (pipeline *MatchingPipeline) GetUnderlyingPrices() map[Market]*big.Int {
 	return underlyingPrices
 }
 
-func (pipeline *MatchingPipeline) cancelLimitOrders(cancellableOrders map[common.Address][]Order) map[common.Hash]struct{} {
+func (pipeline *MatchingPipeline) cancelLimitOrders(lotp LimitOrderTxProcessor, cancellableOrders map[common.Address][]Order) map[common.Hash]struct{} {
 	cancellableOrderIds := map[common.Hash]struct{}{}
 	// @todo: if there are too many cancellable orders, they might not fit in a single block. Need to adjust for that.
 	for _, orders := range cancellableOrders {
@@ -120,7 +142,7 @@ func (pipeline *MatchingPipeline) cancelLimitOrders(cancellableOrders map[common
 			}
 			log.Info("orders to cancel", "num", len(orders))
 			// cancel max of 30 orders
-			err := pipeline.lotp.ExecuteLimitOrderCancel(rawOrders[0:int(math.Min(float64(len(rawOrders)), 30))]) // change this if the tx gas limit (1.5m) is changed
+			err := lotp.ExecuteLimitOrderCancel(rawOrders[0:int(math.Min(float64(len(rawOrders)), 30))]) // change this if the tx gas limit (1.5m) is changed
 			if err != nil {
 				log.Error("Error in ExecuteOrderCancel", "orders", orders, "err", err)
 			} else {
@@ -133,11 +155,64 @@ func (pipeline *MatchingPipeline) cancelLimitOrders(cancellableOrders map[common
 	return cancellableOrderIds
 }
 
-func (pipeline *MatchingPipeline) fetchOrders(market Market, underlyingPrice *big.Int, cancellableOrderIds map[common.Hash]struct{}, blockNumber *big.Int) *Orders {
+// cancelExpiredGTTOrders cancels the expired GTT orders on-chain to release their reserved margin.
+// They are already left out of matching, see getCleanOrder.
+func (pipeline *MatchingPipeline) cancelExpiredGTTOrders(lotp LimitOrderTxProcessor, blockTimestamp uint64) {
+	expiredOrders := pipeline.db.GetExpiredGTTOrders(blockTimestamp)
+	if len(expiredOrders) == 0 {
+		return
+	}
+	log.Info("expired gtt orders to cancel", "num", len(expiredOrders))
+	// cancel max of 30 orders, same as the limit order cancellations
+	expiredOrders = expiredOrders[0:int(math.Min(float64(len(expiredOrders)), 30))]
+	rawOrders := make([]GTTOrder, len(expiredOrders))
+	for i, order := range expiredOrders {
+		rawOrders[i] = *order.RawOrder.(*GTTOrder)
+	}
+	if err := lotp.ExecuteGTTOrderCancel(rawOrders); err != nil {
+		log.Error("Error in ExecuteGTTOrderCancel", "orders", expiredOrders, "err", err)
+	}
+}
+
+// checkCircuitBreakers checks the oracle circuit breaker of the [markets] at [blockTimestamp], the markets with a non nil
+// error are halted
+func (pipeline *MatchingPipeline) checkCircuitBreakers(markets []Market, blockTimestamp uint64) map[Market]error {
+	circuitBreakers := make(map[Market]error, len(markets))
+	for _, market := range markets {
+		circuitBreakers[market] = pipeline.configService.CheckOracleCircuitBreaker(market, blockTimestamp)
+	}
+	return circuitBreakers
+}
+
+// recordHaltedMarkets logs the markets that are halted by the oracle circuit breaker and records the status of every
+// market in the metrics
+func recordHaltedMarkets(circuitBreakers map[Market]error) {
+	for market, err := range circuitBreakers {
+		if err != nil {
+			log.Warn("MatchingPipeline: oracle circuit breaker tripped, halting market", "market", market, "err", err)
+			marketHaltedGauge(market).Update(1)
+			marketHaltedCounter.Inc(1)
+			continue
+		}
+		marketHaltedGauge(market).Update(0)
+	}
+}
+
+// NextBlockTimestamp returns the timestamp the next block on top of a parent at [parentTimestamp] is built with,
+// the same way the miner picks it: the local clock, unless the parent is ahead of it.
+func NextBlockTimestamp(parentTimestamp uint64) uint64 {
+	timestamp := uint64(time.Now().Unix())
+	if parentTimestamp >= timestamp {
+		timestamp = parentTimestamp
+	}
+	return timestamp
+}
+
+func (pipeline *MatchingPipeline) fetchOrders(market Market, underlyingPrice *big.Int, cancellableOrderIds map[common.Hash]struct{}, blockNumber *big.Int, blockTimestamp uint64) *Orders {
 	_, lowerBoundForLongs := pipeline.configService.GetAcceptableBounds(market)
 	// any long orders below the permissible lowerbound are irrelevant, because they won't be matched no matter what.
 	// this assumes that all above cancelOrder transactions got executed successfully (or atleast they are not meant to be executed anyway if they passed the cancellation criteria)
-	longOrders := removeOrdersWithIds(pipeline.db.GetLongOrders(market, lowerBoundForLongs, blockNumber), cancellableOrderIds)
+	longOrders := removeOrdersWithIds(pipeline.db.GetLongOrders(market, lowerBoundForLongs, blockNumber, blockTimestamp), cancellableOrderIds)
 
 	// say if there were no long orders, then shord orders above liquidation upper bound are irrelevant, because they won't be matched no matter what
 	// note that this assumes that permissible liquidation spread <= oracle spread
@@ -149,11 +224,11 @@ func (pipeline *MatchingPipeline) fetchOrders(market Market, underlyingPrice *bi
 		// take the max of price of highest long and liq upper bound. But say longOrders[0].Price > oracleUpperBound ? - then we discard orders above oracleUpperBound, because they won't be matched no matter what
 		upperBoundforShorts = utils.BigIntMin(utils.BigIntMax(longOrders[0].Price, upperBoundforShorts), oracleUpperBound)
 	}
-	shortOrders := removeOrdersWithIds(pipeline.db.GetShortOrders(market, upperBoundforShorts, blockNumber), cancellableOrderIds)
+	shortOrders := removeOrdersWithIds(pipeline.db.GetShortOrders(market, upperBoundforShorts, blockNumber, blockTimestamp), cancellableOrderIds)
 	return &Orders{longOrders, shortOrders}
 }
 
-func (pipeline *MatchingPipeline) runLiquidations(liquidablePositions []LiquidablePosition, orderMap map[Market]*Orders, underlyingPrices map[Market]*big.Int) {
+func (pipeline *MatchingPipeline) runLiquidations(lotp LimitOrderTxProcessor, liquidablePositions []LiquidablePosition, orderMap map[Market]*Orders, underlyingPrices map[Market]*big.Int) {
 	if len(liquidablePositions) == 0 {
 		return
 	}
@@ -183,7 +258,7 @@ func (pipeline *MatchingPipeline) runLiquidations(liquidablePositions []Liquidab
 					break
 				}
 				fillAmount := utils.BigIntMinAbs(liquidable.GetUnfilledSize(), order.GetUnFilledBaseAssetQuantity())
-				pipeline.lotp.ExecuteLiquidation(liquidable.Address, order, fillAmount)
+				lotp.ExecuteLiquidation(liquidable.Address, order, fillAmount)
 				order.FilledBaseAssetQuantity.Add(order.FilledBaseAssetQuantity, fillAmount)
 				liquidable.FilledSize.Add(liquidable.FilledSize, fillAmount)
 				if order.GetUnFilledBaseAssetQuantity().Sign() == 0 {
@@ -201,7 +276,7 @@ func (pipeline *MatchingPipeline) runLiquidations(liquidablePositions []Liquidab
 					break
 				}
 				fillAmount := utils.BigIntMinAbs(liquidable.GetUnfilledSize(), order.GetUnFilledBaseAssetQuantity())
-				pipeline.lotp.ExecuteLiquidation(liquidable.Address, order, fillAmount)
+				lotp.ExecuteLiquidation(liquidable.Address, order, fillAmount)
 				order.FilledBaseAssetQuantity.Sub(order.FilledBaseAssetQuantity, fillAmount)
 				liquidable.FilledSize.Sub(liquidable.FilledSize, fillAmount)
 				if order.GetUnFilledBaseAssetQuantity().Sign() == 0 {
@@ -262,19 +337,32 @@ func matchLongAndShortOrder(lotp LimitOrderTxProcessor, longOrder, shortOrder Or
 	return longOrder, shortOrder, true
 }
 
-func isFundingPaymentTime(nextFundingTime uint64) bool {
+// runFundingPayments settles funding for every market whose funding time has come.
+// The decision is based on the timestamp of the parent block (and not the local clock) so that all validators agree on it.
+// The block being built is at least as late as its parent, so the settlement is also due on chain when it is executed.
+func (pipeline *MatchingPipeline) runFundingPayments(lotp LimitOrderTxProcessor, markets []Market, parentTimestamp uint64) {
+	for _, market := range markets {
+		if isFundingPaymentTime(pipeline.db.GetNextFundingTime(market), parentTimestamp) {
+			log.Info("MatchingPipeline:isFundingPaymentTime", "market", market, "parentTimestamp", parentTimestamp)
+			err := executeFundingPayment(lotp, market)
+			if err != nil {
+				log.Error("Funding payment job failed", "market", market, "err", err)
+			}
+		}
+	}
+}
+
+func isFundingPaymentTime(nextFundingTime uint64, blockTimestamp uint64) bool {
 	if nextFundingTime == 0 {
 		return false
 	}
 
-	now := uint64(time.Now().Unix())
-	return now >= nextFundingTime
+	return blockTimestamp >= nextFundingTime
 }
 
-func executeFundingPayment(lotp LimitOrderTxProcessor) error {
-	// @todo get index twap for each market with warp msging
-
-	return lotp.ExecuteFundingPaymentTx()
+func executeFundingPayment(lotp LimitOrderTxProcessor, market Market) error {
+	// AMMs whose oracle is the warporacle precompile settle funding with the index twap of the prices delivered to it over warp
+	return lotp.ExecuteFundingPaymentTx(market)
 }
 
 func removeOrdersWithIds(orders []Order, orderIds map[common.Hash]struct{}) []Order {
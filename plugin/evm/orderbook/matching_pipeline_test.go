@@ -1,10 +1,17 @@
 package orderbook
 
 import (
+	"bytes"
+	"flag"
 	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 	"github.com/ava-labs/subnet-evm/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -12,6 +19,8 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+var updateGolden = flag.Bool("update", false, "update the golden files of the replay tests")
+
 func TestRunLiquidations(t *testing.T) {
 	traderAddress := common.HexToAddress("0x710bf5f942331874dcbc7783319123679033b63b")
 	traderAddress1 := common.HexToAddress("0x376c47978271565f56DEB45495afa69E59c16Ab2")
@@ -25,7 +34,7 @@ func TestRunLiquidations(t *testing.T) {
 		shortOrders := []Order{getShortOrder()}
 
 		orderMap := map[Market]*Orders{market: {longOrders, shortOrders}}
-		pipeline.runLiquidations([]LiquidablePosition{}, orderMap, underlyingPrices)
+		pipeline.runLiquidations(lotp, []LiquidablePosition{}, orderMap, underlyingPrices)
 		assert.Equal(t, longOrders, orderMap[market].longOrders)
 		assert.Equal(t, shortOrders, orderMap[market].shortOrders)
 		lotp.AssertNotCalled(t, "ExecuteLiquidation", mock.Anything, mock.Anything, mock.Anything)
@@ -40,7 +49,7 @@ func TestRunLiquidations(t *testing.T) {
 			orderMap := map[Market]*Orders{market: {longOrders, shortOrders}}
 
 			cs.On("GetAcceptableBoundsForLiquidation", market).Return(liqUpperBound, liqLowerBound)
-			pipeline.runLiquidations([]LiquidablePosition{getLiquidablePos(traderAddress, LONG, 7)}, orderMap, underlyingPrices)
+			pipeline.runLiquidations(lotp, []LiquidablePosition{getLiquidablePos(traderAddress, LONG, 7)}, orderMap, underlyingPrices)
 			assert.Equal(t, longOrders, orderMap[market].longOrders)
 			assert.Equal(t, shortOrders, orderMap[market].shortOrders)
 			lotp.AssertNotCalled(t, "ExecuteLiquidation", mock.Anything, mock.Anything, mock.Anything)
@@ -57,7 +66,7 @@ func TestRunLiquidations(t *testing.T) {
 
 			orderMap := map[Market]*Orders{market: {[]Order{longOrder}, []Order{shortOrder}}}
 
-			pipeline.runLiquidations(liquidablePositions, orderMap, underlyingPrices)
+			pipeline.runLiquidations(lotp, liquidablePositions, orderMap, underlyingPrices)
 
 			lotp.AssertCalled(t, "ExecuteLiquidation", traderAddress, longOrder, expectedFillAmount)
 			cs.AssertCalled(t, "GetAcceptableBoundsForLiquidation", market)
@@ -79,7 +88,7 @@ func TestRunLiquidations(t *testing.T) {
 
 			orderMap := map[Market]*Orders{market: {[]Order{longOrder, longOrder2}, []Order{}}}
 
-			pipeline.runLiquidations(liquidablePositions, orderMap, underlyingPrices)
+			pipeline.runLiquidations(lotp, liquidablePositions, orderMap, underlyingPrices)
 
 			lotp.AssertCalled(t, "ExecuteLiquidation", traderAddress, longOrder, expectedFillAmount)
 			cs.AssertCalled(t, "GetAcceptableBoundsForLiquidation", market)
@@ -106,7 +115,7 @@ func TestRunLiquidations(t *testing.T) {
 			lotp.On("ExecuteLiquidation", traderAddress1, orderMap[market].shortOrders[0], big.NewInt(1)).Return(nil)
 			lotp.On("ExecuteLiquidation", traderAddress1, orderMap[market].shortOrders[1], big.NewInt(1)).Return(nil)
 
-			pipeline.runLiquidations(liquidablePositions, orderMap, underlyingPrices)
+			pipeline.runLiquidations(lotp, liquidablePositions, orderMap, underlyingPrices)
 			cs.AssertCalled(t, "GetAcceptableBoundsForLiquidation", market)
 
 			lotp.AssertCalled(t, "ExecuteLiquidation", traderAddress, longOrder0, big.NewInt(5))
@@ -144,7 +153,7 @@ func TestRunLiquidations(t *testing.T) {
 			orderMap := map[Market]*Orders{market: {longOrders, shortOrders}}
 
 			cs.On("GetAcceptableBoundsForLiquidation", market).Return(liqUpperBound, liqLowerBound)
-			pipeline.runLiquidations(liquidablePositions, orderMap, underlyingPrices)
+			pipeline.runLiquidations(lotp, liquidablePositions, orderMap, underlyingPrices)
 			assert.Equal(t, longOrders, orderMap[market].longOrders)
 			assert.Equal(t, shortOrders, orderMap[market].shortOrders)
 			lotp.AssertNotCalled(t, "ExecuteLiquidation", mock.Anything, mock.Anything, mock.Anything)
@@ -159,7 +168,7 @@ func TestRunLiquidations(t *testing.T) {
 			cs.On("GetAcceptableBoundsForLiquidation", market).Return(liqUpperBound, liqLowerBound)
 
 			orderMap := map[Market]*Orders{market: {[]Order{longOrder}, []Order{shortOrder}}}
-			pipeline.runLiquidations(liquidablePositions, orderMap, underlyingPrices)
+			pipeline.runLiquidations(lotp, liquidablePositions, orderMap, underlyingPrices)
 
 			lotp.AssertCalled(t, "ExecuteLiquidation", traderAddress, shortOrder, expectedFillAmount)
 			cs.AssertCalled(t, "GetAcceptableBoundsForLiquidation", market)
@@ -491,6 +500,55 @@ func TestMatchLongAndShortOrder(t *testing.T) {
 	})
 }
 
+func TestRunFundingPayments(t *testing.T) {
+	market0 := Market(0)
+	market1 := Market(1)
+	blockTimestamp := uint64(1700000000)
+	t.Run("when next funding time is not set for any market", func(t *testing.T) {
+		db, lotp, pipeline, _, _ := setupDependencies(t)
+		db.On("GetNextFundingTime", market0).Return(uint64(0))
+		db.On("GetNextFundingTime", market1).Return(uint64(0))
+		pipeline.runFundingPayments(lotp, []Market{market0, market1}, blockTimestamp)
+		lotp.AssertNotCalled(t, "ExecuteFundingPaymentTx", mock.Anything)
+	})
+	t.Run("when block timestamp is before next funding time", func(t *testing.T) {
+		db, lotp, pipeline, _, _ := setupDependencies(t)
+		db.On("GetNextFundingTime", market0).Return(blockTimestamp + 1)
+		pipeline.runFundingPayments(lotp, []Market{market0}, blockTimestamp)
+		lotp.AssertNotCalled(t, "ExecuteFundingPaymentTx", mock.Anything)
+	})
+	t.Run("when markets have different funding schedules, it settles funding only for the due market", func(t *testing.T) {
+		db, lotp, pipeline, _, _ := setupDependencies(t)
+		db.On("GetNextFundingTime", market0).Return(blockTimestamp)
+		db.On("GetNextFundingTime", market1).Return(blockTimestamp + 3600)
+		lotp.On("ExecuteFundingPaymentTx", market0).Return(nil)
+		pipeline.runFundingPayments(lotp, []Market{market0, market1}, blockTimestamp)
+		lotp.AssertCalled(t, "ExecuteFundingPaymentTx", market0)
+		lotp.AssertNotCalled(t, "ExecuteFundingPaymentTx", market1)
+	})
+}
+
+func TestCheckCircuitBreakers(t *testing.T) {
+	t.Run("when oracle circuit breaker is not tripped", func(t *testing.T) {
+		_, _, pipeline, _, cs := setupDependencies(t)
+		cs.On("CheckOracleCircuitBreaker", market, uint64(100)).Return(nil)
+		circuitBreakers := pipeline.checkCircuitBreakers([]Market{market}, 100)
+		assert.Equal(t, map[Market]error{market: nil}, circuitBreakers)
+		recordHaltedMarkets(circuitBreakers)
+		assert.Equal(t, int64(0), marketHaltedGauge(market).Value())
+	})
+	t.Run("when oracle circuit breaker is tripped", func(t *testing.T) {
+		_, _, pipeline, _, cs := setupDependencies(t)
+		cs.On("CheckOracleCircuitBreaker", market, uint64(100)).Return(bibliophile.ErrStaleOraclePrice)
+		circuitBreakers := pipeline.checkCircuitBreakers([]Market{market}, 100)
+		assert.Equal(t, map[Market]error{market: bibliophile.ErrStaleOraclePrice}, circuitBreakers)
+		// checking the circuit breakers doesn't touch the metrics
+		assert.Equal(t, int64(0), marketHaltedGauge(market).Value())
+		recordHaltedMarkets(circuitBreakers)
+		assert.Equal(t, int64(1), marketHaltedGauge(market).Value())
+	})
+}
+
 func getShortOrder() Order {
 	salt := big.NewInt(time.Now().Unix())
 	shortOrder := createLimitOrder(SHORT, "0x22Bb736b64A0b4D4081E103f83bccF864F0404aa", big.NewInt(-10), big.NewInt(20.0), Placed, big.NewInt(2), salt)
@@ -534,3 +592,64 @@ func setupDependencies(t *testing.T) (*MockLimitOrderDatabase, *MockLimitOrderTx
 	underlyingPrices[market] = big.NewInt(20.0)
 	return db, lotp, pipeline, underlyingPrices, cs
 }
+
+func TestCancelExpiredGTTOrders(t *testing.T) {
+	db := getDatabase()
+	lotp := NewMockLimitOrderTxProcessor()
+	pipeline := NewMatchingPipeline(db, lotp, NewMockConfigService())
+
+	gttOrder := createGTTOrder(LONG, userAddress, big.NewInt(10), price, big.NewInt(2), big.NewInt(1), 100)
+	db.Add(&gttOrder)
+
+	pipeline.cancelExpiredGTTOrders(lotp, 100)
+	lotp.AssertNotCalled(t, "ExecuteGTTOrderCancel", mock.Anything)
+
+	lotp.On("ExecuteGTTOrderCancel", []GTTOrder{*gttOrder.RawOrder.(*GTTOrder)}).Return(nil)
+	pipeline.cancelExpiredGTTOrders(lotp, 101)
+	lotp.AssertCalled(t, "ExecuteGTTOrderCancel", []GTTOrder{*gttOrder.RawOrder.(*GTTOrder)})
+
+	// the cancellation is encoded as the contract expects
+	gttOrderBookABI := getABIfromJson(abis.GTTOrderBookAbi)
+	_, err := gttOrderBookABI.Pack("cancelExpiredOrders", []GTTOrder{*gttOrder.RawOrder.(*GTTOrder)})
+	assert.Nil(t, err)
+}
+
+// TestReplayGolden replays the recordings in testdata/replay and compares the pipeline's txs with the .golden files.
+// Run with -update to regenerate the golden files after an intended change in the matching pipeline.
+func TestReplayGolden(t *testing.T) {
+	recordings, err := filepath.Glob("testdata/replay/*.jsonl")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, recordings)
+	for _, recording := range recordings {
+		recording := recording
+		t.Run(filepath.Base(recording), func(t *testing.T) {
+			input, err := os.Open(recording)
+			assert.Nil(t, err)
+			defer input.Close()
+
+			output := &bytes.Buffer{}
+			assert.Nil(t, Replay(input, output))
+
+			goldenFile := strings.TrimSuffix(recording, ".jsonl") + ".golden"
+			if *updateGolden {
+				assert.Nil(t, os.WriteFile(goldenFile, output.Bytes(), 0o644))
+				return
+			}
+			golden, err := os.ReadFile(goldenFile)
+			assert.Nil(t, err)
+			assert.Equal(t, string(golden), output.String())
+		})
+	}
+}
+
+func TestReplayBlock(t *testing.T) {
+	t.Run("the first block must carry the config", func(t *testing.T) {
+		_, err := NewReplayer().ReplayBlock(ReplayBlock{BlockNumber: 1, OraclePrices: []*big.Int{big.NewInt(1e6)}})
+		assert.Equal(t, ErrReplayNotConfigured, err)
+	})
+	t.Run("there must be an oracle price per market", func(t *testing.T) {
+		config := &ReplayConfig{Markets: []ReplayMarketConfig{{}, {}}}
+		_, err := NewReplayer().ReplayBlock(ReplayBlock{BlockNumber: 1, Config: config, OraclePrices: []*big.Int{big.NewInt(1e6)}})
+		assert.NotNil(t, err)
+	})
+}
@@ -11,32 +11,34 @@ import (
 	"sync"
 
 	"github.com/ava-labs/subnet-evm/metrics"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 	"github.com/ava-labs/subnet-evm/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 )
 
 type InMemoryDatabase struct {
-	mu              *sync.RWMutex              `json:"-"`
-	OrderMap        map[common.Hash]*Order     `json:"order_map"`  // ID => order
-	TraderMap       map[common.Address]*Trader `json:"trader_map"` // address => trader info
-	NextFundingTime uint64                     `json:"next_funding_time"`
-	LastPrice       map[Market]*big.Int        `json:"last_price"`
-	configService   IConfigService
+	mu               *sync.RWMutex              `json:"-"`
+	OrderMap         map[common.Hash]*Order     `json:"order_map"`  // ID => order
+	TraderMap        map[common.Address]*Trader `json:"trader_map"` // address => trader info
+	NextFundingTimes map[Market]uint64          `json:"next_funding_times"`
+	LastPrice        map[Market]*big.Int        `json:"last_price"`
+	configService    IConfigService
 }
 
 func NewInMemoryDatabase(configService IConfigService) *InMemoryDatabase {
 	orderMap := map[common.Hash]*Order{}
 	lastPrice := map[Market]*big.Int{}
 	traderMap := map[common.Address]*Trader{}
+	nextFundingTimes := map[Market]uint64{}
 
 	return &InMemoryDatabase{
-		OrderMap:        orderMap,
-		TraderMap:       traderMap,
-		NextFundingTime: 0,
-		LastPrice:       lastPrice,
-		mu:              &sync.RWMutex{},
-		configService:   configService,
+		OrderMap:         orderMap,
+		TraderMap:        traderMap,
+		NextFundingTimes: nextFundingTimes,
+		LastPrice:        lastPrice,
+		mu:               &sync.RWMutex{},
+		configService:    configService,
 	}
 }
 
@@ -75,23 +77,51 @@ const (
 	FulFilled
 	Cancelled
 	Execution_Failed
+	// Amended - the price or the size of the order was changed, the order is open just like Placed
+	Amended
 )
 
+func (s Status) String() string {
+	return [...]string{"placed", "fulfilled", "cancelled", "execution_failed", "amended"}[s]
+}
+
 type OrderType uint8
 
 const (
 	LimitOrderType OrderType = iota
 	IOCOrderType
+	GTTOrderType
 )
 
 func (o OrderType) String() string {
-	return [...]string{"limit", "ioc"}[o]
+	return [...]string{"limit", "ioc", "gtt"}[o]
 }
 
 type Lifecycle struct {
 	BlockNumber uint64
 	Status      Status
 	Info        string
+	// FailureCode classifies Info when the status is Execution_Failed
+	FailureCode FailureCode `json:",omitempty"`
+	// OraclePrice is the oracle price of the market when the order failed, for the failures that are retried after the oracle moves
+	OraclePrice *big.Int `json:",omitempty"`
+	// FilledBaseAssetQuantity is the filled quantity of the order when it failed, the failure is cleared once the fill changes
+	FilledBaseAssetQuantity *big.Int `json:",omitempty"`
+	// Amendment is set when the status is Amended
+	Amendment *Amendment `json:",omitempty"`
+	// Local is set for the statuses that the validator sets from its own simulations, the other nodes don't have them
+	Local bool `json:",omitempty"`
+}
+
+// Amendment is an amendment of an order, along with what the order was before it, so that it can be reverted on a reorg.
+// The order keeps the id it was placed with, the orderbook keeps its info under that hash too.
+type Amendment struct {
+	AmendedOrderHash      common.Hash
+	PrevPrice             *big.Int
+	PrevBaseAssetQuantity *big.Int
+	PrevBlockNumber       *big.Int
+	PrevRawOrder          ContractOrder `json:"-"`
+	KeepsTimePriority     bool
 }
 
 type Order struct {
@@ -142,18 +172,60 @@ func (order Order) GetUnFilledBaseAssetQuantity() *big.Int {
 	return big.NewInt(0).Sub(order.BaseAssetQuantity, order.FilledBaseAssetQuantity)
 }
 
+// getOrderStatus returns the latest status of the order. An Execution_Failed status no longer applies once the fill of the
+// order has changed since, e.g. an order that overfilled is matched again with what is left of it, and the status that the
+// order had before it failed is returned instead.
 func (order Order) getOrderStatus() Lifecycle {
 	lifecycle := order.LifecycleList
-	return lifecycle[len(lifecycle)-1]
+	status := lifecycle[len(lifecycle)-1]
+	if status.Status != Execution_Failed || status.FilledBaseAssetQuantity == nil || order.FilledBaseAssetQuantity == nil || status.FilledBaseAssetQuantity.Cmp(order.FilledBaseAssetQuantity) == 0 {
+		return status
+	}
+	for i := len(lifecycle) - 2; i >= 0; i-- {
+		if lifecycle[i].Status != Execution_Failed {
+			return lifecycle[i]
+		}
+	}
+	return Lifecycle{BlockNumber: status.BlockNumber, Status: Placed}
+}
+
+// getChainOrderStatus returns the latest status of the order that comes from the chain, leaving out the local statuses
+// that each node sets on its own, see SetLocalOrderStatus
+func (order Order) getChainOrderStatus() Lifecycle {
+	for i := len(order.LifecycleList) - 1; i >= 0; i-- {
+		if !order.LifecycleList[i].Local {
+			return order.LifecycleList[i]
+		}
+	}
+	return Lifecycle{Status: Placed}
+}
+
+// getLatestAmendment returns the latest amendment of the order, nil if it was never amended
+func (order Order) getLatestAmendment() *Amendment {
+	for i := len(order.LifecycleList) - 1; i >= 0; i-- {
+		if amendment := order.LifecycleList[i].Amendment; amendment != nil {
+			return amendment
+		}
+	}
+	return nil
 }
 
 func (order Order) getExpireAt() *big.Int {
-	if order.OrderType == IOCOrderType {
+	switch order.OrderType {
+	case IOCOrderType:
 		return order.RawOrder.(*IOCOrder).ExpireAt
+	case GTTOrderType:
+		return order.RawOrder.(*GTTOrder).ExpireAt
 	}
 	return big.NewInt(0)
 }
 
+// isExpired is true for IOC and GTT orders that can't be filled in a block with [blockTimestamp]
+func (order Order) isExpired(blockTimestamp uint64) bool {
+	expireAt := order.getExpireAt()
+	return expireAt.Sign() > 0 && expireAt.Cmp(new(big.Int).SetUint64(blockTimestamp)) < 0
+}
+
 func (order Order) String() string {
 	return fmt.Sprintf("Order: Id: %s, OrderType: %s, Market: %v, PositionType: %v, UserAddress: %v, BaseAssetQuantity: %s, FilledBaseAssetQuantity: %s, Salt: %v, Price: %s, ReduceOnly: %v, BlockNumber: %s", order.Id, order.OrderType, order.Market, order.PositionType, order.UserAddress, prettifyScaledBigInt(order.BaseAssetQuantity, 18), prettifyScaledBigInt(order.FilledBaseAssetQuantity, 18), order.Salt, prettifyScaledBigInt(order.Price, 6), order.ReduceOnly, order.BlockNumber)
 }
@@ -208,15 +280,17 @@ type LimitOrderDatabase interface {
 	Add(order *Order)
 	Delete(orderId common.Hash)
 	UpdateFilledBaseAssetQuantity(quantity *big.Int, orderId common.Hash, blockNumber uint64)
-	GetLongOrders(market Market, lowerbound *big.Int, blockNumber *big.Int) []Order
-	GetShortOrders(market Market, upperbound *big.Int, blockNumber *big.Int) []Order
+	GetLongOrders(market Market, lowerbound *big.Int, blockNumber *big.Int, blockTimestamp uint64) []Order
+	GetShortOrders(market Market, upperbound *big.Int, blockNumber *big.Int, blockTimestamp uint64) []Order
+	GetExpiredGTTOrders(blockTimestamp uint64) []Order
 	UpdatePosition(trader common.Address, market Market, size *big.Int, openNotional *big.Int, isLiquidation bool)
 	UpdateMargin(trader common.Address, collateral Collateral, addAmount *big.Int)
 	UpdateReservedMargin(trader common.Address, addAmount *big.Int)
 	UpdateUnrealisedFunding(market Market, cumulativePremiumFraction *big.Int)
 	ResetUnrealisedFunding(market Market, trader common.Address, cumulativePremiumFraction *big.Int)
-	UpdateNextFundingTime(nextFundingTime uint64)
-	GetNextFundingTime() uint64
+	UpdateNextFundingTime(market Market, nextFundingTime uint64)
+	UpdateLiquidationThresholds(market Market)
+	GetNextFundingTime(market Market) uint64
 	UpdateLastPrice(market Market, lastPrice *big.Int)
 	GetLastPrice(market Market) *big.Int
 	GetLastPrices() map[Market]*big.Int
@@ -225,7 +299,9 @@ type LimitOrderDatabase interface {
 	GetOrderBookDataCopy() (*InMemoryDatabase, error)
 	Accept(blockNumber uint64, blockTimestamp uint64)
 	SetOrderStatus(orderId common.Hash, status Status, info string, blockNumber uint64) error
+	SetLocalOrderStatus(orderId common.Hash, status Status, info string, blockNumber uint64) error
 	RevertLastStatus(orderId common.Hash) error
+	AmendOrder(orderId common.Hash, amendedOrder *LimitOrder, amendedOrderHash common.Hash, blockNumber uint64) error
 	GetNaughtyTraders(oraclePrices map[Market]*big.Int, markets []Market) ([]LiquidablePosition, map[common.Address][]Order)
 	GetAllOpenOrdersForTrader(trader common.Address) []Order
 	GetOpenOrdersForTraderByType(trader common.Address, orderType OrderType) []Order
@@ -250,7 +326,10 @@ func (db *InMemoryDatabase) LoadFromSnapshot(snapshot Snapshot) error {
 	db.OrderMap = snapshot.Data.OrderMap
 	db.TraderMap = snapshot.Data.TraderMap
 	db.LastPrice = snapshot.Data.LastPrice
-	db.NextFundingTime = snapshot.Data.NextFundingTime
+	if snapshot.Data.NextFundingTimes != nil {
+		// snapshots taken before funding was tracked per market will not have this; it is re-populated from the AMMs at startup
+		db.NextFundingTimes = snapshot.Data.NextFundingTimes
+	}
 
 	return nil
 }
@@ -266,8 +345,8 @@ func (db *InMemoryDatabase) Accept(blockNumber uint64, blockTimestamp uint64) {
 			delete(db.OrderMap, orderId)
 			continue
 		}
-		expireAt := order.getExpireAt()
-		if expireAt.Sign() > 0 && expireAt.Int64() < int64(blockTimestamp) {
+		// expired GTT orders stay until the validators cancel them, which releases their reserved margin
+		if order.OrderType == IOCOrderType && order.isExpired(blockTimestamp) {
 			delete(db.OrderMap, orderId)
 		}
 
@@ -275,13 +354,32 @@ func (db *InMemoryDatabase) Accept(blockNumber uint64, blockTimestamp uint64) {
 }
 
 func (db *InMemoryDatabase) SetOrderStatus(orderId common.Hash, status Status, info string, blockNumber uint64) error {
+	return db.setOrderStatus(orderId, Lifecycle{BlockNumber: blockNumber, Status: status, Info: info})
+}
+
+// SetLocalOrderStatus sets a status that doesn't come from the chain, see DropLocalStatuses
+func (db *InMemoryDatabase) SetLocalOrderStatus(orderId common.Hash, status Status, info string, blockNumber uint64) error {
+	return db.setOrderStatus(orderId, Lifecycle{BlockNumber: blockNumber, Status: status, Info: info, Local: true})
+}
+
+func (db *InMemoryDatabase) setOrderStatus(orderId common.Hash, lifecycle Lifecycle) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
 	if db.OrderMap[orderId] == nil {
 		return fmt.Errorf("invalid orderId %s", orderId.Hex())
 	}
-	db.OrderMap[orderId].LifecycleList = append(db.OrderMap[orderId].LifecycleList, Lifecycle{blockNumber, status, info})
+	if lifecycle.Status == Execution_Failed {
+		lifecycle.FailureCode = classifyFailure(lifecycle.Info)
+		if filled := db.OrderMap[orderId].FilledBaseAssetQuantity; filled != nil {
+			lifecycle.FilledBaseAssetQuantity = new(big.Int).Set(filled)
+		}
+		if lifecycle.FailureCode.RetryPolicy() == RetryAfterOracleMove {
+			oraclePrices := &lazyOraclePrices{configService: db.configService}
+			lifecycle.OraclePrice = oraclePrices.get(db.OrderMap[orderId].Market)
+		}
+	}
+	db.OrderMap[orderId].LifecycleList = append(db.OrderMap[orderId].LifecycleList, lifecycle)
 	return nil
 }
 
@@ -293,13 +391,68 @@ func (db *InMemoryDatabase) RevertLastStatus(orderId common.Hash) error {
 		return fmt.Errorf("invalid orderId %s", orderId.Hex())
 	}
 
-	lifeCycleList := db.OrderMap[orderId].LifecycleList
+	order := db.OrderMap[orderId]
+	lifeCycleList := order.LifecycleList
+	// the local statuses after the reverted one were set on top of it
+	for len(lifeCycleList) > 0 && lifeCycleList[len(lifeCycleList)-1].Local {
+		lifeCycleList = lifeCycleList[:len(lifeCycleList)-1]
+	}
+	order.LifecycleList = lifeCycleList
 	if len(lifeCycleList) > 0 {
-		db.OrderMap[orderId].LifecycleList = lifeCycleList[:len(lifeCycleList)-1]
+		if amendment := lifeCycleList[len(lifeCycleList)-1].Amendment; amendment != nil {
+			order.Price = amendment.PrevPrice
+			order.BaseAssetQuantity = amendment.PrevBaseAssetQuantity
+			order.BlockNumber = amendment.PrevBlockNumber
+			order.RawOrder = amendment.PrevRawOrder
+		}
+		order.LifecycleList = lifeCycleList[:len(lifeCycleList)-1]
 	}
 	return nil
 }
 
+// AmendOrder changes the price and the size of an order in place, as emitted in OrderAmended.
+// Reducing the size keeps the time priority of the order, any other change resets it to [blockNumber], same as the orderbook does.
+// The size can't be reduced below what was already filled, nor change the side of a filled order.
+func (db *InMemoryDatabase) AmendOrder(orderId common.Hash, amendedOrder *LimitOrder, amendedOrderHash common.Hash, blockNumber uint64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	order := db.OrderMap[orderId]
+	if order == nil {
+		return fmt.Errorf("invalid orderId %s", orderId.Hex())
+	}
+	if order.OrderType != LimitOrderType {
+		return fmt.Errorf("order %s of type %s can't be amended", orderId.Hex(), order.OrderType)
+	}
+	if filled := order.FilledBaseAssetQuantity; filled != nil && filled.Sign() != 0 &&
+		(filled.Sign() != amendedOrder.BaseAssetQuantity.Sign() || new(big.Int).Abs(amendedOrder.BaseAssetQuantity).Cmp(new(big.Int).Abs(filled)) < 0) {
+		return fmt.Errorf("order %s can't be amended to %s, %s of it is already filled", orderId.Hex(), amendedOrder.BaseAssetQuantity, filled)
+	}
+	amendment := &Amendment{
+		AmendedOrderHash:      amendedOrderHash,
+		PrevPrice:             order.Price,
+		PrevBaseAssetQuantity: order.BaseAssetQuantity,
+		PrevBlockNumber:       order.BlockNumber,
+		PrevRawOrder:          order.RawOrder,
+		KeepsTimePriority:     keepsTimePriority(order, amendedOrder),
+	}
+	order.Price = new(big.Int).Set(amendedOrder.Price)
+	order.BaseAssetQuantity = new(big.Int).Set(amendedOrder.BaseAssetQuantity)
+	order.RawOrder = amendedOrder
+	if !amendment.KeepsTimePriority {
+		order.BlockNumber = new(big.Int).SetUint64(blockNumber)
+	}
+	order.LifecycleList = append(order.LifecycleList, Lifecycle{BlockNumber: blockNumber, Status: Amended, Amendment: amendment})
+	return nil
+}
+
+// keepsTimePriority returns whether the order stays in its place in the queue after the amendment, which is when only its size is reduced
+func keepsTimePriority(order *Order, amendedOrder *LimitOrder) bool {
+	return order.Price.Cmp(amendedOrder.Price) == 0 &&
+		order.BaseAssetQuantity.Sign() == amendedOrder.BaseAssetQuantity.Sign() &&
+		new(big.Int).Abs(amendedOrder.BaseAssetQuantity).Cmp(new(big.Int).Abs(order.BaseAssetQuantity)) < 0
+}
+
 func (db *InMemoryDatabase) GetAllOrders() []Order {
 	db.mu.RLock() // only read lock required
 	defer db.mu.RUnlock()
@@ -315,7 +468,7 @@ func (db *InMemoryDatabase) Add(order *Order) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	order.LifecycleList = append(order.LifecycleList, Lifecycle{order.BlockNumber.Uint64(), Placed, ""})
+	order.LifecycleList = append(order.LifecycleList, Lifecycle{BlockNumber: order.BlockNumber.Uint64(), Status: Placed})
 	db.OrderMap[order.Id] = order
 }
 
@@ -344,7 +497,7 @@ func (db *InMemoryDatabase) UpdateFilledBaseAssetQuantity(quantity *big.Int, ord
 	}
 
 	if limitOrder.BaseAssetQuantity.Cmp(limitOrder.FilledBaseAssetQuantity) == 0 {
-		limitOrder.LifecycleList = append(limitOrder.LifecycleList, Lifecycle{blockNumber, FulFilled, ""})
+		limitOrder.LifecycleList = append(limitOrder.LifecycleList, Lifecycle{BlockNumber: blockNumber, Status: FulFilled})
 	}
 
 	if quantity.Cmp(big.NewInt(0)) == -1 && limitOrder.getOrderStatus().Status == FulFilled {
@@ -353,28 +506,31 @@ func (db *InMemoryDatabase) UpdateFilledBaseAssetQuantity(quantity *big.Int, ord
 	}
 }
 
-func (db *InMemoryDatabase) GetNextFundingTime() uint64 {
+func (db *InMemoryDatabase) GetNextFundingTime(market Market) uint64 {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	return db.NextFundingTime
+	return db.NextFundingTimes[market]
 }
 
-func (db *InMemoryDatabase) UpdateNextFundingTime(nextFundingTime uint64) {
+func (db *InMemoryDatabase) UpdateNextFundingTime(market Market, nextFundingTime uint64) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	db.NextFundingTime = nextFundingTime
+	db.NextFundingTimes[market] = nextFundingTime
 }
 
-func (db *InMemoryDatabase) GetLongOrders(market Market, lowerbound *big.Int, blockNumber *big.Int) []Order {
+// GetLongOrders returns the long orders that can be matched in the block [blockNumber], at [blockTimestamp].
+// A nil [blockNumber] leaves out the failed orders and a zero [blockTimestamp] keeps the expired orders.
+func (db *InMemoryDatabase) GetLongOrders(market Market, lowerbound *big.Int, blockNumber *big.Int, blockTimestamp uint64) []Order {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
 	var longOrders []Order
+	oraclePrices := &lazyOraclePrices{configService: db.configService}
 	for _, order := range db.OrderMap {
 		if order.PositionType == LONG && order.Market == market && (lowerbound == nil || order.Price.Cmp(lowerbound) >= 0) {
-			if _order := db.getCleanOrder(order, blockNumber); _order != nil {
+			if _order := db.getCleanOrder(order, blockNumber, blockTimestamp, oraclePrices); _order != nil {
 				longOrders = append(longOrders, *_order)
 			}
 		}
@@ -383,14 +539,15 @@ func (db *InMemoryDatabase) GetLongOrders(market Market, lowerbound *big.Int, bl
 	return longOrders
 }
 
-func (db *InMemoryDatabase) GetShortOrders(market Market, upperbound *big.Int, blockNumber *big.Int) []Order {
+func (db *InMemoryDatabase) GetShortOrders(market Market, upperbound *big.Int, blockNumber *big.Int, blockTimestamp uint64) []Order {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
 	var shortOrders []Order
+	oraclePrices := &lazyOraclePrices{configService: db.configService}
 	for _, order := range db.OrderMap {
 		if order.PositionType == SHORT && order.Market == market && (upperbound == nil || order.Price.Cmp(upperbound) <= 0) {
-			if _order := db.getCleanOrder(order, blockNumber); _order != nil {
+			if _order := db.getCleanOrder(order, blockNumber, blockTimestamp, oraclePrices); _order != nil {
 				shortOrders = append(shortOrders, *_order)
 			}
 		}
@@ -399,11 +556,14 @@ func (db *InMemoryDatabase) GetShortOrders(market Market, upperbound *big.Int, b
 	return shortOrders
 }
 
-func (db *InMemoryDatabase) getCleanOrder(order *Order, blockNumber *big.Int) *Order {
+func (db *InMemoryDatabase) getCleanOrder(order *Order, blockNumber *big.Int, blockTimestamp uint64, oraclePrices *lazyOraclePrices) *Order {
+	if blockTimestamp > 0 && order.isExpired(blockTimestamp) {
+		return nil
+	}
 	eligibleForExecution := false
 	orderStatus := order.getOrderStatus()
 	switch orderStatus.Status {
-	case Placed:
+	case Placed, Amended:
 		eligibleForExecution = true
 	case Execution_Failed:
 		// ideally these orders should have been auto-cancelled (by the validator) at the same time that they were fulfilling the criteria to fail
@@ -414,14 +574,17 @@ func (db *InMemoryDatabase) getCleanOrder(order *Order, blockNumber *big.Int) *O
 		//		b. specially true in multi-collateral, where the price of 1 collateral dipped but recovered again after the order was taken for matching (but failed execution)
 		// 3. There might be a bug in the cancellation logic in either of EVM or smart contract code
 		// 4. We might have made margin requirements for order fulfillment more liberal at a later stage
-		// Hence, in view of the above and to serve as a catch-all we retry failed orders after every 100 blocks
+		// Hence, in view of the above and to serve as a catch-all we retry failed orders, when depends on the failure code:
+		// expired orders are never retried, orders that aren't executable as they are after RETRY_AFTER_DELAY_BLOCKS (or once
+		// their fill changes), margin and oracle failures once the oracle price moves, and the rest after RETRY_AFTER_BLOCKS
 		// Note at if an order is failing multiple times and it is also not being caught in the auto-cancel logic, then something/somewhere definitely needs fixing
 		if blockNumber != nil {
-			if orderStatus.BlockNumber+RETRY_AFTER_BLOCKS <= blockNumber.Uint64() {
+			oraclePrice := func() *big.Int { return oraclePrices.get(order.Market) }
+			if isRetryable(orderStatus, blockNumber.Uint64(), oraclePrice) {
 				eligibleForExecution = true
 			} else if blockNumber.Uint64()%10 == 0 {
 				// to not make the log too noisy
-				log.Warn("eligible order is in Execution_Failed state", "orderId", order.String(), "retryInBlocks", orderStatus.BlockNumber+RETRY_AFTER_BLOCKS-blockNumber.Uint64())
+				log.Warn("eligible order is in Execution_Failed state", "orderId", order.String(), "failureCode", orderStatus.failureCode(), "retry", orderStatus.failureCode().RetryPolicy())
 			}
 		}
 	}
@@ -514,6 +677,24 @@ func (db *InMemoryDatabase) UpdateUnrealisedFunding(market Market, cumulativePre
 	}
 }
 
+// UpdateLiquidationThresholds recomputes the liquidation threshold of all positions in the market, after its
+// maxLiquidationRatio or minSizeRequirement is changed
+func (db *InMemoryDatabase) UpdateLiquidationThresholds(market Market) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	maxLiquidationRatio := db.configService.getMaxLiquidationRatio(market)
+	minSizeRequirement := db.configService.getMinSizeRequirement(market)
+	for _, trader := range db.TraderMap {
+		position := trader.Positions[market]
+		if position == nil || position.Size == nil {
+			continue
+		}
+		threshold := utils.BigIntMinAbs(getLiquidationThreshold(maxLiquidationRatio, minSizeRequirement, position.Size), position.Size)
+		position.LiquidationThreshold = threshold.Mul(threshold, big.NewInt(int64(position.Size.Sign()))) // same sign as size
+	}
+}
+
 func (db *InMemoryDatabase) ResetUnrealisedFunding(market Market, trader common.Address, cumulativePremiumFraction *big.Int) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
@@ -581,6 +762,31 @@ func (db *InMemoryDatabase) GetOpenOrdersForTraderByType(trader common.Address,
 	return db.getTraderOrders(trader, orderType)
 }
 
+// GetExpiredGTTOrders returns the GTT orders that expired before [blockTimestamp] and are still open on-chain
+func (db *InMemoryDatabase) GetExpiredGTTOrders(blockTimestamp uint64) []Order {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	expiredOrders := []Order{}
+	for _, order := range db.OrderMap {
+		if order.OrderType != GTTOrderType || !order.isExpired(blockTimestamp) {
+			continue
+		}
+		if status := order.getOrderStatus().Status; status == Cancelled || status == FulFilled {
+			continue
+		}
+		expiredOrders = append(expiredOrders, deepCopyOrder(order))
+	}
+	// oldest expiry first, so that the same orders are cancelled by every validator
+	sort.Slice(expiredOrders, func(i, j int) bool {
+		if cmp := expiredOrders[i].getExpireAt().Cmp(expiredOrders[j].getExpireAt()); cmp != 0 {
+			return cmp < 0
+		}
+		return expiredOrders[i].Id.Big().Cmp(expiredOrders[j].Id.Big()) < 0
+	})
+	return expiredOrders
+}
+
 func (db *InMemoryDatabase) GetAllOpenOrdersForTrader(trader common.Address) []Order {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -780,6 +986,10 @@ func sortLongOrders(orders []Order) []Order {
 			if orders[i].BlockNumber.Cmp(orders[j].BlockNumber) == -1 {
 				return true
 			}
+			// orders placed in the same block at the same price are ordered by id, so that every validator matches them in the same order
+			if orders[i].BlockNumber.Cmp(orders[j].BlockNumber) == 0 {
+				return orders[i].Id.Big().Cmp(orders[j].Id.Big()) == -1
+			}
 		}
 		return false
 	})
@@ -795,6 +1005,10 @@ func sortShortOrders(orders []Order) []Order {
 			if orders[i].BlockNumber.Cmp(orders[j].BlockNumber) == -1 {
 				return true
 			}
+			// orders placed in the same block at the same price are ordered by id, so that every validator matches them in the same order
+			if orders[i].BlockNumber.Cmp(orders[j].BlockNumber) == 0 {
+				return orders[i].Id.Big().Cmp(orders[j].Id.Big()) == -1
+			}
 		}
 		return false
 	})
@@ -826,14 +1040,29 @@ func (db *InMemoryDatabase) GetOrderBookDataCopy() (*InMemoryDatabase, error) {
 	}
 
 	memoryDBCopy.mu = &sync.RWMutex{}
+	memoryDBCopy.configService = db.configService
 	return memoryDBCopy, nil
 }
 
+// DropLocalStatuses removes the statuses that the validator set from its own simulations, so that what is left is
+// derived from the chain only and is the same on every node. It is meant for a copy of the database, see GetOrderBookDataCopy.
+func (db *InMemoryDatabase) DropLocalStatuses() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, order := range db.OrderMap {
+		lifecycleList := make([]Lifecycle, 0, len(order.LifecycleList))
+		for _, lifecycle := range order.LifecycleList {
+			if !lifecycle.Local {
+				lifecycleList = append(lifecycleList, lifecycle)
+			}
+		}
+		order.LifecycleList = lifecycleList
+	}
+}
+
 func getLiquidationThreshold(maxLiquidationRatio *big.Int, minSizeRequirement *big.Int, size *big.Int) *big.Int {
-	absSize := big.NewInt(0).Abs(size)
-	maxLiquidationSize := divideByBasePrecision(big.NewInt(0).Mul(absSize, maxLiquidationRatio))
-	liquidationThreshold := utils.BigIntMax(maxLiquidationSize, minSizeRequirement)
-	return big.NewInt(0).Mul(liquidationThreshold, big.NewInt(int64(size.Sign()))) // same sign as size
+	return bibliophile.GetLiquidationThreshold(maxLiquidationRatio, minSizeRequirement, size)
 }
 
 func getBlankTrader() *Trader {
@@ -877,6 +1106,7 @@ func deepCopyOrder(order *Order) Order {
 		LifecycleList:           *lifecycleList,
 		BlockNumber:             big.NewInt(0).Set(order.BlockNumber),
 		RawOrder:                order.RawOrder,
+		OrderType:               order.OrderType,
 	}
 }
 
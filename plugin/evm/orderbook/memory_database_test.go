@@ -101,7 +101,7 @@ func TestGetShortOrders(t *testing.T) {
 	shortOrder4.ReduceOnly = true
 	inMemoryDatabase.Add(&shortOrder4)
 
-	returnedShortOrders := inMemoryDatabase.GetShortOrders(market, nil, nil)
+	returnedShortOrders := inMemoryDatabase.GetShortOrders(market, nil, nil, 0)
 	assert.Equal(t, 3, len(returnedShortOrders))
 
 	for _, returnedOrder := range returnedShortOrders {
@@ -124,7 +124,7 @@ func TestGetShortOrders(t *testing.T) {
 	size := big.NewInt(0).Mul(big.NewInt(2), _1e18)
 	inMemoryDatabase.UpdatePosition(trader, market, size, big.NewInt(0).Mul(big.NewInt(100), _1e6), false)
 
-	returnedShortOrders = inMemoryDatabase.GetShortOrders(market, nil, nil)
+	returnedShortOrders = inMemoryDatabase.GetShortOrders(market, nil, nil, 0)
 	assert.Equal(t, 4, len(returnedShortOrders))
 
 	// at least one of the orders should be reduce only
@@ -170,7 +170,7 @@ func TestGetLongOrders(t *testing.T) {
 	longOrder3 := createLimitOrder(LONG, userAddress, longOrderBaseAssetQuantity, price3, status, blockNumber3, salt3)
 	inMemoryDatabase.Add(&longOrder3)
 
-	returnedLongOrders := inMemoryDatabase.GetLongOrders(market, nil, nil)
+	returnedLongOrders := inMemoryDatabase.GetLongOrders(market, nil, nil, 0)
 	assert.Equal(t, 3, len(returnedLongOrders))
 
 	//Test returnedLongOrders are sorted by price highest to lowest first and then block number from lowest to highest
@@ -379,6 +379,24 @@ func TestUpdatePosition(t *testing.T) {
 	})
 }
 
+func TestUpdateLiquidationThresholds(t *testing.T) {
+	configService := NewMockConfigService()
+	configService.Mock.On("getMaxLiquidationRatio").Return(big.NewInt(25e4))
+	inMemoryDatabase := NewInMemoryDatabase(configService)
+	var market Market = 1
+	long := common.HexToAddress("0x22Bb736b64A0b4D4081E103f83bccF864F0404aa")
+	short := common.HexToAddress("0x710bf5F942331874dcBC7783319123679033b63b")
+	inMemoryDatabase.UpdatePosition(long, market, big.NewInt(8e18), big.NewInt(8e18), false)
+	inMemoryDatabase.UpdatePosition(short, market, big.NewInt(-8e18), big.NewInt(8e18), false)
+	// thresholds computed with the maxLiquidationRatio before the change
+	inMemoryDatabase.TraderMap[long].Positions[market].LiquidationThreshold = big.NewInt(8e18)
+	inMemoryDatabase.TraderMap[short].Positions[market].LiquidationThreshold = big.NewInt(-8e18)
+
+	inMemoryDatabase.UpdateLiquidationThresholds(market)
+	assert.Equal(t, big.NewInt(2e18), inMemoryDatabase.TraderMap[long].Positions[market].LiquidationThreshold)
+	assert.Equal(t, big.NewInt(-2e18), inMemoryDatabase.TraderMap[short].Positions[market].LiquidationThreshold)
+}
+
 func TestUpdateMargin(t *testing.T) {
 	t.Run("when adding margin for first time it updates margin in tradermap", func(t *testing.T) {
 		inMemoryDatabase := getDatabase()
@@ -469,6 +487,154 @@ func TestAccept(t *testing.T) {
 		_, ok := inMemoryDatabase.OrderMap[orderId]
 		assert.True(t, ok)
 	})
+
+	t.Run("Expired GTT order is kept until it is cancelled", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		gttOrder := createGTTOrder(LONG, userAddress, big.NewInt(10), price, big.NewInt(2), big.NewInt(1), 100)
+		inMemoryDatabase.Add(&gttOrder)
+		inMemoryDatabase.Accept(50, 101)
+
+		_, ok := inMemoryDatabase.OrderMap[gttOrder.Id]
+		assert.True(t, ok)
+	})
+}
+
+func TestGTTOrders(t *testing.T) {
+	t.Run("expired orders are not matched", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		gttOrder := createGTTOrder(LONG, userAddress, big.NewInt(10), price, big.NewInt(2), big.NewInt(1), 100)
+		inMemoryDatabase.Add(&gttOrder)
+
+		assert.Equal(t, 1, len(inMemoryDatabase.GetLongOrders(market, nil, nil, 100)))
+		assert.Equal(t, 0, len(inMemoryDatabase.GetLongOrders(market, nil, nil, 101)))
+	})
+
+	t.Run("expired orders are returned for cancellation, oldest expiry first", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		gttOrder1 := createGTTOrder(LONG, userAddress, big.NewInt(10), price, big.NewInt(2), big.NewInt(1), 200)
+		gttOrder2 := createGTTOrder(SHORT, userAddress, big.NewInt(-10), price, big.NewInt(2), big.NewInt(2), 100)
+		gttOrder3 := createGTTOrder(SHORT, userAddress, big.NewInt(-10), price, big.NewInt(2), big.NewInt(3), 300)
+		cancelledOrder := createGTTOrder(LONG, userAddress, big.NewInt(10), price, big.NewInt(2), big.NewInt(4), 100)
+		limitOrder := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(2), big.NewInt(5))
+		for _, order := range []Order{gttOrder1, gttOrder2, gttOrder3, cancelledOrder, limitOrder} {
+			order := order
+			inMemoryDatabase.Add(&order)
+		}
+		assert.Nil(t, inMemoryDatabase.SetOrderStatus(cancelledOrder.Id, Cancelled, "", 3))
+
+		expiredOrders := inMemoryDatabase.GetExpiredGTTOrders(250)
+		assert.Equal(t, 2, len(expiredOrders))
+		assert.Equal(t, gttOrder2.Id, expiredOrders[0].Id)
+		assert.Equal(t, gttOrder1.Id, expiredOrders[1].Id)
+		assert.Equal(t, GTTOrderType, expiredOrders[0].OrderType)
+	})
+
+	t.Run("an expiry beyond uint64 doesn't wrap around", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		gttOrder := createGTTOrder(LONG, userAddress, big.NewInt(10), price, big.NewInt(2), big.NewInt(1), 0)
+		gttOrder.RawOrder.(*GTTOrder).ExpireAt = new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(100))
+		inMemoryDatabase.Add(&gttOrder)
+
+		assert.Equal(t, 1, len(inMemoryDatabase.GetLongOrders(market, nil, nil, 250)))
+		assert.Empty(t, inMemoryDatabase.GetExpiredGTTOrders(250))
+	})
+}
+
+func TestAmendOrder(t *testing.T) {
+	amend := func(order Order, price int64, baseAssetQuantity int64) *LimitOrder {
+		return &LimitOrder{
+			AmmIndex:          big.NewInt(int64(order.Market)),
+			Trader:            common.HexToAddress(order.UserAddress),
+			BaseAssetQuantity: big.NewInt(baseAssetQuantity),
+			Price:             big.NewInt(price),
+			Salt:              order.Salt,
+		}
+	}
+	amendedOrderHash := common.HexToHash("0xa")
+
+	t.Run("reducing the size keeps the time priority", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		first := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(2), big.NewInt(1))
+		second := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(3), big.NewInt(2))
+		inMemoryDatabase.Add(&first)
+		inMemoryDatabase.Add(&second)
+
+		assert.Nil(t, inMemoryDatabase.AmendOrder(first.Id, amend(first, 20, 5), amendedOrderHash, 4))
+		longOrders := inMemoryDatabase.GetLongOrders(market, nil, nil, 0)
+		assert.Equal(t, first.Id, longOrders[0].Id)
+		assert.Equal(t, big.NewInt(5), longOrders[0].BaseAssetQuantity)
+		assert.Equal(t, big.NewInt(2), longOrders[0].BlockNumber)
+		assert.Equal(t, Amended, longOrders[0].getOrderStatus().Status)
+		assert.Equal(t, amendedOrderHash, longOrders[0].getLatestAmendment().AmendedOrderHash)
+	})
+
+	t.Run("changing the price resets the time priority", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		first := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(2), big.NewInt(1))
+		second := createLimitOrder(LONG, userAddress, big.NewInt(10), big.NewInt(21), Placed, big.NewInt(3), big.NewInt(2))
+		inMemoryDatabase.Add(&first)
+		inMemoryDatabase.Add(&second)
+
+		assert.Nil(t, inMemoryDatabase.AmendOrder(first.Id, amend(first, 21, 10), amendedOrderHash, 4))
+		longOrders := inMemoryDatabase.GetLongOrders(market, nil, nil, 0)
+		assert.Equal(t, second.Id, longOrders[0].Id)
+		assert.Equal(t, first.Id, longOrders[1].Id)
+		assert.Equal(t, big.NewInt(21), longOrders[1].Price)
+		assert.Equal(t, big.NewInt(4), longOrders[1].BlockNumber)
+	})
+
+	t.Run("increasing the size resets the time priority", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		order := createLimitOrder(SHORT, userAddress, big.NewInt(-10), price, Placed, big.NewInt(2), big.NewInt(1))
+		inMemoryDatabase.Add(&order)
+
+		assert.Nil(t, inMemoryDatabase.AmendOrder(order.Id, amend(order, 20, -15), amendedOrderHash, 4))
+		assert.Equal(t, big.NewInt(4), inMemoryDatabase.OrderMap[order.Id].BlockNumber)
+	})
+
+	t.Run("reverting the amendment restores the order", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		order := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(2), big.NewInt(1))
+		order.RawOrder = amend(order, 20, 10)
+		inMemoryDatabase.Add(&order)
+
+		amendedOrder := amend(order, 22, 8)
+		assert.Nil(t, inMemoryDatabase.AmendOrder(order.Id, amendedOrder, amendedOrderHash, 4))
+		assert.Equal(t, amendedOrder, inMemoryDatabase.OrderMap[order.Id].RawOrder)
+
+		assert.Nil(t, inMemoryDatabase.RevertLastStatus(order.Id))
+		actualOrder := inMemoryDatabase.OrderMap[order.Id]
+		assert.Equal(t, price, actualOrder.Price)
+		assert.Equal(t, big.NewInt(10), actualOrder.BaseAssetQuantity)
+		assert.Equal(t, big.NewInt(2), actualOrder.BlockNumber)
+		assert.Equal(t, order.RawOrder, actualOrder.RawOrder)
+		assert.Equal(t, Placed, actualOrder.getOrderStatus().Status)
+		assert.Nil(t, actualOrder.getLatestAmendment())
+	})
+
+	t.Run("the size can't be amended below the filled amount", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		order := createLimitOrder(LONG, userAddress, big.NewInt(10), price, Placed, big.NewInt(2), big.NewInt(1))
+		inMemoryDatabase.Add(&order)
+		inMemoryDatabase.UpdateFilledBaseAssetQuantity(big.NewInt(6), order.Id, 3)
+
+		assert.NotNil(t, inMemoryDatabase.AmendOrder(order.Id, amend(order, 20, 5), amendedOrderHash, 4))
+		assert.NotNil(t, inMemoryDatabase.AmendOrder(order.Id, amend(order, 20, -10), amendedOrderHash, 4))
+		actualOrder := inMemoryDatabase.OrderMap[order.Id]
+		assert.Equal(t, big.NewInt(10), actualOrder.BaseAssetQuantity)
+		assert.Nil(t, actualOrder.getLatestAmendment())
+
+		assert.Nil(t, inMemoryDatabase.AmendOrder(order.Id, amend(order, 20, 8), amendedOrderHash, 4))
+		assert.Equal(t, big.NewInt(8), inMemoryDatabase.OrderMap[order.Id].BaseAssetQuantity)
+	})
+
+	t.Run("only limit orders can be amended", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		gttOrder := createGTTOrder(LONG, userAddress, big.NewInt(10), price, big.NewInt(2), big.NewInt(1), 100)
+		inMemoryDatabase.Add(&gttOrder)
+		assert.NotNil(t, inMemoryDatabase.AmendOrder(gttOrder.Id, amend(gttOrder, 21, 10), amendedOrderHash, 4))
+		assert.NotNil(t, inMemoryDatabase.AmendOrder(common.HexToHash("0xb"), amend(gttOrder, 21, 10), amendedOrderHash, 4))
+	})
 }
 
 func TestRevertLastStatus(t *testing.T) {
@@ -513,6 +679,30 @@ func TestRevertLastStatus(t *testing.T) {
 		err = inMemoryDatabase.RevertLastStatus(orderId)
 		assert.Error(t, err)
 	})
+
+	t.Run("revert status set before a local status", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		orderId := addLimitOrder(inMemoryDatabase)
+		assert.Nil(t, inMemoryDatabase.SetOrderStatus(orderId, Execution_Failed, "OB_trader_does_not_have_enough_margin", 3))
+		assert.Nil(t, inMemoryDatabase.SetLocalOrderStatus(orderId, Execution_Failed, "OB_trader_does_not_have_enough_margin", 4))
+
+		assert.Nil(t, inMemoryDatabase.RevertLastStatus(orderId))
+		assert.Equal(t, []Lifecycle{{BlockNumber: 2, Status: Placed}}, inMemoryDatabase.OrderMap[orderId].LifecycleList)
+	})
+}
+
+func TestDropLocalStatuses(t *testing.T) {
+	inMemoryDatabase := getDatabase()
+	orderId := addLimitOrder(inMemoryDatabase)
+	assert.Nil(t, inMemoryDatabase.SetLocalOrderStatus(orderId, Execution_Failed, "OB_trader_does_not_have_enough_margin", 3))
+	assert.Equal(t, Execution_Failed, inMemoryDatabase.OrderMap[orderId].getOrderStatus().Status)
+
+	memoryDBCopy, err := inMemoryDatabase.GetOrderBookDataCopy()
+	assert.Nil(t, err)
+	memoryDBCopy.DropLocalStatuses()
+	assert.Equal(t, Placed, memoryDBCopy.OrderMap[orderId].getOrderStatus().Status)
+	// the node keeps using its own statuses
+	assert.Equal(t, Execution_Failed, inMemoryDatabase.OrderMap[orderId].getOrderStatus().Status)
 }
 
 func TestUpdateUnrealizedFunding(t *testing.T) {
@@ -595,20 +785,25 @@ func TestResetUnrealisedFunding(t *testing.T) {
 func TestUpdateNextFundingTime(t *testing.T) {
 	inMemoryDatabase := getDatabase()
 	nextFundingTime := uint64(time.Now().Unix())
-	inMemoryDatabase.UpdateNextFundingTime(nextFundingTime)
-	assert.Equal(t, nextFundingTime, inMemoryDatabase.NextFundingTime)
+	inMemoryDatabase.UpdateNextFundingTime(market, nextFundingTime)
+	assert.Equal(t, nextFundingTime, inMemoryDatabase.NextFundingTimes[market])
 }
 
 func TestGetNextFundingTime(t *testing.T) {
 	t.Run("when funding time is not set", func(t *testing.T) {
 		inMemoryDatabase := getDatabase()
-		assert.Equal(t, uint64(0), inMemoryDatabase.GetNextFundingTime())
+		assert.Equal(t, uint64(0), inMemoryDatabase.GetNextFundingTime(market))
 	})
 	t.Run("when funding time is set", func(t *testing.T) {
 		inMemoryDatabase := getDatabase()
 		nextFundingTime := uint64(time.Now().Unix())
-		inMemoryDatabase.UpdateNextFundingTime(nextFundingTime)
-		assert.Equal(t, nextFundingTime, inMemoryDatabase.GetNextFundingTime())
+		inMemoryDatabase.UpdateNextFundingTime(market, nextFundingTime)
+		assert.Equal(t, nextFundingTime, inMemoryDatabase.GetNextFundingTime(market))
+	})
+	t.Run("when funding time is set for a different market", func(t *testing.T) {
+		inMemoryDatabase := getDatabase()
+		inMemoryDatabase.UpdateNextFundingTime(Market(1), uint64(time.Now().Unix()))
+		assert.Equal(t, uint64(0), inMemoryDatabase.GetNextFundingTime(market))
 	})
 }
 
@@ -656,6 +851,23 @@ func createLimitOrder(positionType PositionType, userAddress string, baseAssetQu
 	return lo
 }
 
+func createGTTOrder(positionType PositionType, userAddress string, baseAssetQuantity *big.Int, price *big.Int, blockNumber *big.Int, salt *big.Int, expireAt int64) Order {
+	order := createLimitOrder(positionType, userAddress, baseAssetQuantity, price, Placed, blockNumber, salt)
+	order.OrderType = GTTOrderType
+	order.RawOrder = &GTTOrder{
+		LimitOrder: LimitOrder{
+			AmmIndex:          big.NewInt(int64(order.Market)),
+			Trader:            common.HexToAddress(userAddress),
+			BaseAssetQuantity: baseAssetQuantity,
+			Price:             price,
+			Salt:              salt,
+		},
+		OrderType: 2,
+		ExpireAt:  big.NewInt(expireAt),
+	}
+	return order
+}
+
 func TestGetUnfilledBaseAssetQuantity(t *testing.T) {
 	t.Run("When limit FilledBaseAssetQuantity is zero, it returns BaseAssetQuantity", func(t *testing.T) {
 		baseAssetQuantityLongOrder := big.NewInt(10)
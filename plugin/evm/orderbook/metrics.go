@@ -1,6 +1,8 @@
 package orderbook
 
 import (
+	"fmt"
+
 	"github.com/ava-labs/subnet-evm/metrics"
 )
 
@@ -16,6 +18,8 @@ var (
 	// only valid for OrderBook transactions send by this validator
 	orderBookTransactionsSuccessTotalCounter = metrics.NewRegisteredCounter("orderbooktxs/total/success", nil)
 	orderBookTransactionsFailureTotalCounter = metrics.NewRegisteredCounter("orderbooktxs/total/failure", nil)
+	// orderbook txs that did not fit in the gas budget of the block and were left for the next one
+	orderBookTxsDeferredCounter = metrics.NewRegisteredCounter("orderbooktxs/total/deferred", nil)
 
 	// panics are recovered but monitored
 	RunMatchingPipelinePanicsCounter         = metrics.NewRegisteredCounter("matching_pipeline_panics", nil)
@@ -25,4 +29,26 @@ var (
 	HandleMatchingPipelineTimerPanicsCounter = metrics.NewRegisteredCounter("handle_matching_pipeline_timer_panics", nil)
 
 	BuildBlockFailedWithLowBlockGasCounter = metrics.NewRegisteredCounter("build_block_failed_low_block_gas", nil)
+
+	// number of matching pipeline runs in which a market was halted by the oracle circuit breaker
+	marketHaltedCounter = metrics.NewRegisteredCounter("market_halted_by_oracle", nil)
+
+	// blocks whose orderbook executions deviate from the matching pipeline, and blocks that could not be checked
+	OrderBookSequencingDeviationsCounter = metrics.NewRegisteredCounter("orderbook_sequencing/deviations", nil)
+	OrderBookSequencingSkippedCounter    = metrics.NewRegisteredCounter("orderbook_sequencing/skipped", nil)
+
+	// number of times a validator key was taken out of rotation because its orderbook txs kept failing
+	validatorKeyFailoverCounter = metrics.NewRegisteredCounter("validator_key_failover", nil)
+
+	// the fee strategy of the orderbook txs: the gas they are expected to use in a block and the premium over the suggested gas price
+	orderBookGasPerBlockGauge = metrics.NewRegisteredGauge("orderbooktxs/fee/gas_per_block", nil)
+	orderBookFeePremiumGauge  = metrics.NewRegisteredGauge("orderbooktxs/fee/premium_percent", nil)
+
+	// orderbook txs that were not sent because their dry run failed
+	orderBookPreflightFailuresCounter = metrics.NewRegisteredCounter("orderbooktxs/preflight/failure", nil)
 )
+
+// marketHaltedGauge is 1 while matching is halted for the market, 0 otherwise
+func marketHaltedGauge(market Market) metrics.Gauge {
+	return metrics.GetOrRegisterGauge(fmt.Sprintf("market_halted/%d", market), nil)
+}
@@ -20,10 +20,18 @@ func (db *MockLimitOrderDatabase) SetOrderStatus(orderId common.Hash, status Sta
 	return nil
 }
 
+func (db *MockLimitOrderDatabase) SetLocalOrderStatus(orderId common.Hash, status Status, info string, blockNumber uint64) error {
+	return nil
+}
+
 func (db *MockLimitOrderDatabase) RevertLastStatus(orderId common.Hash) error {
 	return nil
 }
 
+func (db *MockLimitOrderDatabase) AmendOrder(orderId common.Hash, amendedOrder *LimitOrder, amendedOrderHash common.Hash, blockNumber uint64) error {
+	return nil
+}
+
 func (db *MockLimitOrderDatabase) Accept(blockNumber uint64, blockTimestamp uint64) {
 }
 
@@ -41,16 +49,20 @@ func (db *MockLimitOrderDatabase) UpdateFilledBaseAssetQuantity(quantity *big.In
 func (db *MockLimitOrderDatabase) Delete(id common.Hash) {
 }
 
-func (db *MockLimitOrderDatabase) GetLongOrders(market Market, lowerbound *big.Int, blockNumber *big.Int) []Order {
+func (db *MockLimitOrderDatabase) GetLongOrders(market Market, lowerbound *big.Int, blockNumber *big.Int, blockTimestamp uint64) []Order {
 	args := db.Called()
 	return args.Get(0).([]Order)
 }
 
-func (db *MockLimitOrderDatabase) GetShortOrders(market Market, upperbound *big.Int, blockNumber *big.Int) []Order {
+func (db *MockLimitOrderDatabase) GetShortOrders(market Market, upperbound *big.Int, blockNumber *big.Int, blockTimestamp uint64) []Order {
 	args := db.Called()
 	return args.Get(0).([]Order)
 }
 
+func (db *MockLimitOrderDatabase) GetExpiredGTTOrders(blockTimestamp uint64) []Order {
+	return []Order{}
+}
+
 func (db *MockLimitOrderDatabase) UpdatePosition(trader common.Address, market Market, size *big.Int, openNotional *big.Int, isLiquidation bool) {
 }
 
@@ -66,11 +78,15 @@ func (db *MockLimitOrderDatabase) UpdateUnrealisedFunding(market Market, funding
 func (db *MockLimitOrderDatabase) ResetUnrealisedFunding(market Market, trader common.Address, cumulativePremiumFraction *big.Int) {
 }
 
-func (db *MockLimitOrderDatabase) UpdateNextFundingTime(uint64) {
+func (db *MockLimitOrderDatabase) UpdateNextFundingTime(market Market, nextFundingTime uint64) {
 }
 
-func (db *MockLimitOrderDatabase) GetNextFundingTime() uint64 {
-	return 0
+func (db *MockLimitOrderDatabase) UpdateLiquidationThresholds(market Market) {
+}
+
+func (db *MockLimitOrderDatabase) GetNextFundingTime(market Market) uint64 {
+	args := db.Called(market)
+	return args.Get(0).(uint64)
 }
 
 func (db *MockLimitOrderDatabase) GetAllTraders() map[common.Address]Trader {
@@ -161,8 +177,9 @@ func (lotp *MockLimitOrderTxProcessor) GetOrderBookTxsCount() uint64 {
 	return uint64(args.Int(0))
 }
 
-func (lotp *MockLimitOrderTxProcessor) ExecuteFundingPaymentTx() error {
-	return nil
+func (lotp *MockLimitOrderTxProcessor) ExecuteFundingPaymentTx(market Market) error {
+	args := lotp.Called(market)
+	return args.Error(0)
 }
 
 func (lotp *MockLimitOrderTxProcessor) ExecuteLiquidation(trader common.Address, matchedOrder Order, fillAmount *big.Int) error {
@@ -175,6 +192,16 @@ func (lotp *MockLimitOrderTxProcessor) ExecuteLimitOrderCancel(orderIds []LimitO
 	return args.Error(0)
 }
 
+func (lotp *MockLimitOrderTxProcessor) ExecuteGTTOrderCancel(orders []GTTOrder) error {
+	args := lotp.Called(orders)
+	return args.Error(0)
+}
+
+func (lotp *MockLimitOrderTxProcessor) ExecuteBatch(liquidations []LiquidationInstruction, matches []MatchInstruction) (int, error) {
+	args := lotp.Called(liquidations, matches)
+	return args.Int(0), args.Error(1)
+}
+
 func (lotp *MockLimitOrderTxProcessor) HandleOrderBookEvent(event *types.Log) {
 }
 
@@ -192,6 +219,10 @@ func (lotp *MockLimitOrderTxProcessor) UpdateMetrics(block *types.Block) {
 	lotp.Called()
 }
 
+func (lotp *MockLimitOrderTxProcessor) HandleBlockGasTooLow() {
+	lotp.Called()
+}
+
 type MockConfigService struct {
 	mock.Mock
 }
@@ -250,6 +281,19 @@ func (cs *MockConfigService) GetCumulativePremiumFraction(market Market) *big.In
 	return big.NewInt(0)
 }
 
+func (cs *MockConfigService) GetNextFundingTime(market Market) uint64 {
+	return 0
+}
+
+func (cs *MockConfigService) GetFundingPeriod(market Market) uint64 {
+	return 0
+}
+
+func (cs *MockConfigService) CheckOracleCircuitBreaker(market Market, blockTimestamp uint64) error {
+	args := cs.Called(market, blockTimestamp)
+	return args.Error(0)
+}
+
 func NewMockConfigService() *MockConfigService {
 	return &MockConfigService{}
 }
@@ -33,6 +33,15 @@ type IOCOrder struct {
 	ExpireAt  *big.Int `json:"expireAt"`
 }
 
+// GTTOrder type is copy of GTTOrder struct defined in the GTT Orderbook contract.
+// It is a limit order that expires at ExpireAt, after which the validators cancel it to release its reserved margin.
+// The GTT orderbook reserves the margin in the tx that places the order, orders placed without it are ignored.
+type GTTOrder struct {
+	LimitOrder
+	OrderType uint8    `json:"orderType"`
+	ExpireAt  *big.Int `json:"expireAt"`
+}
+
 // LimitOrder
 func (order *LimitOrder) EncodeToABI() ([]byte, error) {
 	limitOrderType, err := getOrderType("limit")
@@ -141,6 +150,62 @@ func DecodeIOCOrder(encodedOrder []byte) (*IOCOrder, error) {
 	return iocOrder, nil
 }
 
+// ----------------------------------------------------------------------------
+// GTTOrder
+
+func (order *GTTOrder) EncodeToABI() ([]byte, error) {
+	gttOrderType, err := getOrderType("gtt")
+	if err != nil {
+		return nil, fmt.Errorf("failed getting abi type: %w", err)
+	}
+	encodedGTTOrder, err := abi.Arguments{{Type: gttOrderType}}.Pack(order)
+	if err != nil {
+		return nil, fmt.Errorf("gtt order packing failed: %w", err)
+	}
+
+	orderType, _ := abi.NewType("uint8", "uint8", nil)
+	orderBytesType, _ := abi.NewType("bytes", "bytes", nil)
+	// 2 means ordertype = GTT order
+	encodedOrder, err := abi.Arguments{{Type: orderType}, {Type: orderBytesType}}.Pack(uint8(2), encodedGTTOrder)
+	if err != nil {
+		return nil, fmt.Errorf("order encoding failed: %w", err)
+	}
+
+	return encodedOrder, nil
+}
+
+func (order *GTTOrder) DecodeFromRawOrder(rawOrder interface{}) {
+	marshalledOrder, _ := json.Marshal(rawOrder)
+	json.Unmarshal(marshalledOrder, &order)
+}
+
+func (order *GTTOrder) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"ammIndex":          order.AmmIndex,
+		"trader":            order.Trader,
+		"baseAssetQuantity": utils.BigIntToFloat(order.BaseAssetQuantity, 18),
+		"price":             utils.BigIntToFloat(order.Price, 6),
+		"reduceOnly":        order.ReduceOnly,
+		"salt":              order.Salt,
+		"orderType":         order.OrderType,
+		"expireAt":          order.ExpireAt,
+	}
+}
+
+func DecodeGTTOrder(encodedOrder []byte) (*GTTOrder, error) {
+	gttOrderType, err := getOrderType("gtt")
+	if err != nil {
+		return nil, fmt.Errorf("failed getting abi type: %w", err)
+	}
+	order, err := abi.Arguments{{Type: gttOrderType}}.Unpack(encodedOrder)
+	if err != nil {
+		return nil, err
+	}
+	gttOrder := &GTTOrder{}
+	gttOrder.DecodeFromRawOrder(order[0])
+	return gttOrder, nil
+}
+
 // ----------------------------------------------------------------------------
 // Helper functions
 func getOrderType(orderType string) (abi.Type, error) {
@@ -154,7 +219,8 @@ func getOrderType(orderType string) (abi.Type, error) {
 			{Name: "reduceOnly", Type: "bool"},
 		})
 	}
-	if orderType == "ioc" {
+	// same layout as the IOC order
+	if orderType == "ioc" || orderType == "gtt" {
 		return abi.NewType("tuple", "", []abi.ArgumentMarshaling{
 			{Name: "orderType", Type: "uint8"},
 			{Name: "expireAt", Type: "uint256"},
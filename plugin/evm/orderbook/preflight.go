@@ -0,0 +1,99 @@
+package orderbook
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/core"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var JurorContractAddress = common.HexToAddress("0x0300000000000000000000000000000000000005")
+
+// PreflightError is returned instead of sending an orderbook tx that would fail on-chain
+type PreflightError struct {
+	Method  string
+	Failure Failure
+	// Gas is the gas that the tx used in the simulation before it failed, with the margin of estimateGas
+	Gas uint64
+}
+
+func (err *PreflightError) Error() string {
+	return fmt.Sprintf("preflight of %s failed - %s", err.Method, err.Failure.String())
+}
+
+// faultyOrders asks the juror to validate a match whose tx would fail, to tell which of the orders is at fault and why.
+// When the juror can't tell, both orders are marked with the failure of the tx.
+func (lotp *limitOrderTxProcessor) faultyOrders(failure Failure, longOrder Order, shortOrder Order, orders [2][]byte, fillAmount *big.Int) (Failure, []common.Hash) {
+	both := []common.Hash{longOrder.Id, shortOrder.Id}
+	data, err := lotp.jurorABI.Pack("validateMatchedOrders", orders, fillAmount)
+	if err != nil {
+		return failure, both
+	}
+
+	lotp.mu.Lock()
+	defer lotp.mu.Unlock()
+	if lotp.runState == nil {
+		return failure, both
+	}
+	result, revert, err := lotp.simulate(lotp.runState, lotp.orderBookContractAddress, JurorContractAddress, data, orderBookTxGasLimit)
+	if err != nil {
+		log.Warn("faultyOrders - validateMatchedOrders failed", "err", err)
+		return failure, both
+	}
+	// a view call, it doesn't change the state of the run
+	revert()
+	if result.Failed() {
+		log.Warn("faultyOrders - validateMatchedOrders failed", "err", result.Err)
+		return failure, both
+	}
+	reason, element, err := decodeValidateMatchedOrders(lotp.jurorABI, result.Return())
+	if err != nil {
+		log.Error("faultyOrders - error in decoding validateMatchedOrders", "err", err)
+		return failure, both
+	}
+	if reason == "" {
+		// the juror was fine with the match, so there's no telling which of the orders made the tx revert
+		return failure, both
+	}
+
+	failure = NewFailure(reason)
+	switch element {
+	case 0:
+		return failure, []common.Hash{longOrder.Id}
+	case 1:
+		return failure, []common.Hash{shortOrder.Id}
+	default:
+		// a generic error is about the match itself (e.g. the fill amount), not the orders
+		return failure, nil
+	}
+}
+
+// markOrdersFailed sets the orders to Execution_Failed right away, instead of waiting for the tx to fail on-chain.
+// The status is local to this validator and is left out of what the nodes have to agree on, see DropLocalStatuses.
+func (lotp *limitOrderTxProcessor) markOrdersFailed(failure Failure, orderIds ...common.Hash) {
+	blockNumber := lotp.backend.CurrentHeader().Number.Uint64()
+	for _, orderId := range orderIds {
+		if err := lotp.memoryDb.SetLocalOrderStatus(orderId, Execution_Failed, failure.Reason, blockNumber); err != nil {
+			log.Error("markOrdersFailed - SetLocalOrderStatus failed", "orderId", orderId.String(), "err", err)
+		}
+	}
+}
+
+// revertReason returns the reason string of a reverted call, or the evm error if the call didn't revert with a reason
+func revertReason(result *core.ExecutionResult) string {
+	if reason, err := abi.UnpackRevert(result.Revert()); err == nil {
+		return reason
+	}
+	return result.Err.Error()
+}
+
+func decodeValidateMatchedOrders(jurorABI abi.ABI, output []byte) (string, uint8, error) {
+	values, err := jurorABI.Unpack("validateMatchedOrders", output)
+	if err != nil {
+		return "", 0, err
+	}
+	return values[0].(string), values[1].(uint8), nil
+}
@@ -0,0 +1,79 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/core"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ava-labs/subnet-evm/vmerrs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevertReason(t *testing.T) {
+	t.Run("reason string is decoded", func(t *testing.T) {
+		// Error(string) selector followed by the abi encoded reason
+		stringType, _ := abi.NewType("string", "", nil)
+		encoded, err := abi.Arguments{{Type: stringType}}.Pack("OB_order_already_exists")
+		assert.Nil(t, err)
+		revertData := append(crypto.Keccak256([]byte("Error(string)"))[:4], encoded...)
+
+		result := &core.ExecutionResult{Err: vmerrs.ErrExecutionReverted, ReturnData: revertData}
+		assert.Equal(t, "OB_order_already_exists", revertReason(result))
+	})
+	t.Run("evm error is used without a reason", func(t *testing.T) {
+		result := &core.ExecutionResult{Err: vmerrs.ErrExecutionReverted}
+		assert.Equal(t, vmerrs.ErrExecutionReverted.Error(), revertReason(result))
+
+		result = &core.ExecutionResult{Err: vmerrs.ErrOutOfGas}
+		assert.Equal(t, vmerrs.ErrOutOfGas.Error(), revertReason(result))
+	})
+}
+
+func TestDecodeValidateMatchedOrders(t *testing.T) {
+	jurorABI, err := abi.FromSolidityJson(string(abis.JurorAbi))
+	assert.Nil(t, err)
+	outputs := jurorABI.Methods["validateMatchedOrders"].Outputs
+
+	type instruction struct {
+		AmmIndex  *big.Int
+		Trader    common.Address
+		OrderHash [32]byte
+		Mode      uint8
+	}
+	instructions := [2]instruction{{AmmIndex: big.NewInt(0)}, {AmmIndex: big.NewInt(0)}}
+	output, err := outputs.Pack("OB_orders_do_not_match", uint8(1), instructions, [2]uint8{}, [2][]byte{{}, {}}, big.NewInt(0))
+	assert.Nil(t, err)
+	reason, element, err := decodeValidateMatchedOrders(jurorABI, output)
+	assert.Nil(t, err)
+	assert.Equal(t, "OB_orders_do_not_match", reason)
+	assert.Equal(t, uint8(1), element)
+
+	_, _, err = decodeValidateMatchedOrders(jurorABI, []byte{1, 2, 3})
+	assert.NotNil(t, err)
+}
+
+func TestPreflightError(t *testing.T) {
+	var err error = &PreflightError{Method: "liquidateAndExecuteOrder", Failure: NewFailure("trader is not liquidatable")}
+	assert.Equal(t, "preflight of liquidateAndExecuteOrder failed - NOT_LIQUIDATABLE: trader is not liquidatable", err.Error())
+
+	var preflightErr *PreflightError
+	assert.True(t, errors.As(err, &preflightErr))
+	assert.True(t, strings.HasPrefix(preflightErr.Failure.String(), string(FailureNotLiquidatable)))
+}
+
+func TestGasOfFailedEstimate(t *testing.T) {
+	t.Run("a tx that reverted in the simulation is charged the gas it used", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", &PreflightError{Method: "executeMatchedOrders", Failure: NewFailure("OB_invalid_order"), Gas: 120_000})
+		assert.Equal(t, uint64(120_000), gasOfFailedEstimate(err))
+	})
+	t.Run("the max gas limit is used when the gas could not be estimated", func(t *testing.T) {
+		assert.Equal(t, orderBookTxGasLimit, gasOfFailedEstimate(errors.New("the state of the run is not available")))
+	})
+}
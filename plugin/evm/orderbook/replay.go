@@ -0,0 +1,336 @@
+package orderbook
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	ReplayMatch          = "match"
+	ReplayLiquidation    = "liquidation"
+	ReplayCancel         = "cancel"
+	ReplayGTTCancel      = "gttCancel"
+	ReplayFundingPayment = "fundingPayment"
+)
+
+var ErrReplayNotConfigured = errors.New("replay: the first block must carry the config")
+
+// ReplayMarketConfig holds the parameters of a market that the matching pipeline reads from the chain state.
+// Spreads and ratios have 6 decimals, like in the contracts.
+type ReplayMarketConfig struct {
+	MaxOraclePriceSpread      *big.Int `json:"maxOraclePriceSpread"`
+	MaxLiquidationPriceSpread *big.Int `json:"maxLiquidationPriceSpread"`
+	MaxLiquidationRatio       *big.Int `json:"maxLiquidationRatio"`
+	MinSizeRequirement        *big.Int `json:"minSizeRequirement"`
+	FundingPeriod             uint64   `json:"fundingPeriod"`
+}
+
+// ReplayConfig is the chain config of the orderbook during a replay
+type ReplayConfig struct {
+	MaintenanceMargin  *big.Int             `json:"maintenanceMargin"`
+	MinAllowableMargin *big.Int             `json:"minAllowableMargin"`
+	Markets            []ReplayMarketConfig `json:"markets"`
+}
+
+// ReplayBlock is a line of a replay recording: the hubble logs of an accepted block and the oracle prices at that block
+type ReplayBlock struct {
+	BlockNumber    uint64 `json:"blockNumber"`
+	BlockTimestamp uint64 `json:"blockTimestamp"`
+	// Config replaces the config from this block on, it is required in the first block
+	Config *ReplayConfig `json:"config,omitempty"`
+	// OraclePrices are the underlying prices of the markets, by market index
+	OraclePrices []*big.Int `json:"oraclePrices"`
+	// HaltedMarkets had their oracle circuit breaker tripped
+	HaltedMarkets []Market     `json:"haltedMarkets,omitempty"`
+	Logs          []*types.Log `json:"logs"`
+}
+
+// ReplayAction is a tx that the matching pipeline emitted after a replayed block
+type ReplayAction struct {
+	// BlockNumber is the replayed block, the txs would be included in the next one
+	BlockNumber uint64          `json:"blockNumber"`
+	Action      string          `json:"action"`
+	Trader      *common.Address `json:"trader,omitempty"`
+	Market      *Market         `json:"market,omitempty"`
+	// Orders are [long, short] for matches, the matched order for liquidations and the cancelled orders for cancels
+	Orders     []common.Hash `json:"orders,omitempty"`
+	FillAmount *big.Int      `json:"fillAmount,omitempty"`
+}
+
+// Replayer feeds recorded blocks through the ContractEventsProcessor and the MatchingPipeline, with a recorder in place of the tx processor,
+// so that a bad match can be reproduced without the chain conditions that led to it.
+type Replayer struct {
+	configService *replayConfigService
+	db            *InMemoryDatabase
+	cep           *ContractEventsProcessor
+	pipeline      *MatchingPipeline
+	recorder      *replayRecorder
+}
+
+func NewReplayer() *Replayer {
+	configService := &replayConfigService{}
+	db := NewInMemoryDatabase(configService)
+	pipeline := NewMatchingPipeline(db, nil, configService)
+	pipeline.MatchingTicker.Stop()
+	return &Replayer{
+		configService: configService,
+		db:            db,
+		cep:           NewContractEventsProcessor(db),
+		pipeline:      pipeline,
+		recorder:      &replayRecorder{db: db},
+	}
+}
+
+// ReplayBlock applies the logs of [block] as the node does when the block is accepted, and runs the matching pipeline for the next block
+func (r *Replayer) ReplayBlock(block ReplayBlock) ([]ReplayAction, error) {
+	if block.Config != nil {
+		r.configService.config = *block.Config
+	}
+	if r.configService.config.Markets == nil {
+		return nil, ErrReplayNotConfigured
+	}
+	if len(block.OraclePrices) != len(r.configService.config.Markets) {
+		return nil, fmt.Errorf("replay: block %d has %d oracle prices for %d markets", block.BlockNumber, len(block.OraclePrices), len(r.configService.config.Markets))
+	}
+	r.configService.oraclePrices = block.OraclePrices
+	r.configService.haltedMarkets = map[Market]bool{}
+	for _, market := range block.HaltedMarkets {
+		r.configService.haltedMarkets[market] = true
+	}
+
+	r.cep.ProcessEvents(block.Logs)
+	// replayed events are not counted in the metrics
+	r.cep.ProcessAcceptedEvents(block.Logs, true)
+	r.db.Accept(block.BlockNumber, block.BlockTimestamp)
+
+	r.recorder.blockNumber = block.BlockNumber
+	r.pipeline.mu.Lock()
+	defer r.pipeline.mu.Unlock()
+	// the halted markets come from the recording, so the timestamp of the next block doesn't matter for the circuit breaker
+	r.pipeline.run(r.recorder, new(big.Int).SetUint64(block.BlockNumber+1), block.BlockTimestamp, block.BlockTimestamp)
+	return r.recorder.sortedActions(), nil
+}
+
+// Replay reads a JSONL recording of ReplayBlocks from [input] and writes the ReplayActions as JSONL to [output]
+func Replay(input io.Reader, output io.Writer) error {
+	replayer := NewReplayer()
+	scanner := bufio.NewScanner(input)
+	// a block can carry a lot of logs
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	encoder := json.NewEncoder(output)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		block := ReplayBlock{}
+		if err := json.Unmarshal(scanner.Bytes(), &block); err != nil {
+			return fmt.Errorf("replay: invalid block at line %d: %w", line, err)
+		}
+		actions, err := replayer.ReplayBlock(block)
+		if err != nil {
+			return err
+		}
+		for _, action := range actions {
+			if err := encoder.Encode(action); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// replayConfigService serves the recorded config and oracle prices instead of reading them from the chain state
+type replayConfigService struct {
+	config        ReplayConfig
+	oraclePrices  []*big.Int
+	haltedMarkets map[Market]bool
+}
+
+func (cs *replayConfigService) getMaxLiquidationRatio(market Market) *big.Int {
+	return cs.config.Markets[market].MaxLiquidationRatio
+}
+
+func (cs *replayConfigService) getLiquidationSpreadThreshold(market Market) *big.Int {
+	return cs.config.Markets[market].MaxLiquidationPriceSpread
+}
+
+func (cs *replayConfigService) getMinAllowableMargin() *big.Int {
+	return cs.config.MinAllowableMargin
+}
+
+func (cs *replayConfigService) getMaintenanceMargin() *big.Int {
+	return cs.config.MaintenanceMargin
+}
+
+func (cs *replayConfigService) getMinSizeRequirement(market Market) *big.Int {
+	return cs.config.Markets[market].MinSizeRequirement
+}
+
+func (cs *replayConfigService) GetActiveMarketsCount() int64 {
+	return int64(len(cs.config.Markets))
+}
+
+func (cs *replayConfigService) GetUnderlyingPrices() []*big.Int {
+	return cs.oraclePrices
+}
+
+// premium fractions are tracked by the order book from the funding events
+func (cs *replayConfigService) GetLastPremiumFraction(market Market, trader *common.Address) *big.Int {
+	return big.NewInt(0)
+}
+
+func (cs *replayConfigService) GetCumulativePremiumFraction(market Market) *big.Int {
+	return big.NewInt(0)
+}
+
+func (cs *replayConfigService) GetAcceptableBounds(market Market) (*big.Int, *big.Int) {
+	return calculateReplayBounds(cs.config.Markets[market].MaxOraclePriceSpread, cs.oraclePrices[market])
+}
+
+func (cs *replayConfigService) GetAcceptableBoundsForLiquidation(market Market) (*big.Int, *big.Int) {
+	return calculateReplayBounds(cs.config.Markets[market].MaxLiquidationPriceSpread, cs.oraclePrices[market])
+}
+
+func (cs *replayConfigService) GetNextFundingTime(market Market) uint64 {
+	return 0
+}
+
+func (cs *replayConfigService) GetFundingPeriod(market Market) uint64 {
+	return cs.config.Markets[market].FundingPeriod
+}
+
+func (cs *replayConfigService) CheckOracleCircuitBreaker(market Market, blockTimestamp uint64) error {
+	if cs.haltedMarkets[market] {
+		return fmt.Errorf("market %d is halted in the recording", market)
+	}
+	return nil
+}
+
+// calculateReplayBounds is bibliophile.calculateBounds, returning the (upper, lower) bounds around the oracle price
+func calculateReplayBounds(spreadLimit, oraclePrice *big.Int) (*big.Int, *big.Int) {
+	_1e6 := big.NewInt(1e6)
+	upperbound := new(big.Int).Div(new(big.Int).Mul(oraclePrice, new(big.Int).Add(_1e6, spreadLimit)), _1e6)
+	lowerbound := big.NewInt(0)
+	if spreadLimit.Cmp(_1e6) == -1 {
+		lowerbound = new(big.Int).Div(new(big.Int).Mul(oraclePrice, new(big.Int).Sub(_1e6, spreadLimit)), _1e6)
+	}
+	return upperbound, lowerbound
+}
+
+// replayRecorder is a LimitOrderTxProcessor that records the txs of a pipeline run as ReplayActions
+type replayRecorder struct {
+	db          LimitOrderDatabase
+	blockNumber uint64
+	actions     []ReplayAction
+}
+
+func (recorder *replayRecorder) GetOrderBookTxsCount() uint64 {
+	return uint64(len(recorder.actions))
+}
+
+func (recorder *replayRecorder) PurgeOrderBookTxs() {
+	recorder.actions = nil
+}
+
+func (recorder *replayRecorder) ExecuteMatchedOrdersTx(longOrder Order, shortOrder Order, fillAmount *big.Int) error {
+	recorder.record(ReplayAction{
+		Action:     ReplayMatch,
+		Orders:     []common.Hash{longOrder.Id, shortOrder.Id},
+		FillAmount: new(big.Int).Set(fillAmount),
+	})
+	return nil
+}
+
+func (recorder *replayRecorder) ExecuteLiquidation(trader common.Address, matchedOrder Order, fillAmount *big.Int) error {
+	recorder.record(ReplayAction{
+		Action:     ReplayLiquidation,
+		Trader:     &trader,
+		Orders:     []common.Hash{matchedOrder.Id},
+		FillAmount: new(big.Int).Set(fillAmount),
+	})
+	return nil
+}
+
+func (recorder *replayRecorder) ExecuteBatch(liquidations []LiquidationInstruction, matches []MatchInstruction) (int, error) {
+	for _, liquidation := range liquidations {
+		recorder.ExecuteLiquidation(liquidation.Trader, liquidation.Order, liquidation.FillAmount)
+	}
+	for _, match := range matches {
+		recorder.ExecuteMatchedOrdersTx(match.LongOrder, match.ShortOrder, match.FillAmount)
+	}
+	return len(liquidations) + len(matches), nil
+}
+
+func (recorder *replayRecorder) ExecuteFundingPaymentTx(market Market) error {
+	recorder.record(ReplayAction{Action: ReplayFundingPayment, Market: &market})
+	return nil
+}
+
+func (recorder *replayRecorder) ExecuteLimitOrderCancel(orders []LimitOrder) error {
+	recorder.recordCancel(ReplayCancel, &orders[0].Trader, orders)
+	return nil
+}
+
+// expired GTT orders of all traders are cancelled in a single tx
+func (recorder *replayRecorder) ExecuteGTTOrderCancel(orders []GTTOrder) error {
+	limitOrders := make([]LimitOrder, len(orders))
+	for i, order := range orders {
+		limitOrders[i] = order.LimitOrder
+	}
+	recorder.recordCancel(ReplayGTTCancel, nil, limitOrders)
+	return nil
+}
+
+// recordCancel identifies the cancelled orders by their id in the order book, the cancel txs only carry the raw orders
+func (recorder *replayRecorder) recordCancel(action string, trader *common.Address, orders []LimitOrder) {
+	ids := make([]common.Hash, 0, len(orders))
+	for _, order := range orders {
+		for _, openOrder := range recorder.db.GetAllOpenOrdersForTrader(order.Trader) {
+			if openOrder.Salt.Cmp(order.Salt) == 0 {
+				ids = append(ids, openOrder.Id)
+				break
+			}
+		}
+	}
+	recorder.record(ReplayAction{Action: action, Trader: trader, Orders: ids})
+}
+
+func (recorder *replayRecorder) record(action ReplayAction) {
+	action.BlockNumber = recorder.blockNumber
+	recorder.actions = append(recorder.actions, action)
+}
+
+// sortedActions returns the actions in the order they were sent, except for the limit order cancels. Those are sent per trader
+// in map order, so each run of consecutive cancels is sorted by trader to keep the output deterministic.
+func (recorder *replayRecorder) sortedActions() []ReplayAction {
+	actions := recorder.actions
+	recorder.actions = nil
+	for start := 0; start < len(actions); {
+		end := start
+		for end < len(actions) && actions[end].Action == ReplayCancel {
+			end++
+		}
+		if end == start {
+			start++
+			continue
+		}
+		cancels := actions[start:end]
+		sort.SliceStable(cancels, func(i, j int) bool {
+			return cancels[i].Trader.Big().Cmp(cancels[j].Trader.Big()) < 0
+		})
+		start = end
+	}
+	return actions
+}
+
+func (recorder *replayRecorder) UpdateMetrics(block *types.Block) {}
+
+func (recorder *replayRecorder) HandleBlockGasTooLow() {}
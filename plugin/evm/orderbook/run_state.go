@@ -0,0 +1,82 @@
+package orderbook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/subnet-evm/core"
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/core/vm"
+	"github.com/ava-labs/subnet-evm/rpc"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// errGasExceedsCap is returned when a tx runs out of gas at the gas cap it is simulated with
+var errGasExceedsCap = errors.New("gas required exceeds the cap")
+
+// runState is the state that the orderbook txs of a run of the matching pipeline are simulated on: the state at the
+// head with the txs that were sent so far in the run applied on top, in the order they were sent. This is the state
+// each tx executes on in the next block, the pending state of the chain doesn't have the txs of the run yet.
+type runState struct {
+	stateDB *state.StateDB
+	header  *types.Header
+}
+
+// newRunState returns the state at the head, with the header of the block that the txs of the run are built into
+func (lotp *limitOrderTxProcessor) newRunState() (*runState, error) {
+	stateDB, head, err := lotp.backend.StateAndHeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if stateDB == nil {
+		return nil, fmt.Errorf("no state at the head %d", head.Number.Uint64())
+	}
+	baseFee, err := lotp.backend.EstimateBaseFee(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	header := types.CopyHeader(head)
+	header.ParentHash = head.Hash()
+	header.Number = new(big.Int).Add(head.Number, big.NewInt(1))
+	header.Time = uint64(time.Now().Unix())
+	header.BaseFee = baseFee
+	return &runState{stateDB: stateDB, header: header}, nil
+}
+
+// simulate applies the call to the run state and returns its result. The changes of the call stay in the run state,
+// for the calls that are simulated after it, unless the returned revert is called.
+func (lotp *limitOrderTxProcessor) simulate(rs *runState, from common.Address, contract common.Address, data []byte, gas uint64) (*core.ExecutionResult, func(), error) {
+	msg := &core.Message{
+		From:              from,
+		To:                &contract,
+		Value:             big.NewInt(0),
+		GasLimit:          gas,
+		GasPrice:          big.NewInt(0),
+		GasFeeCap:         big.NewInt(0),
+		GasTipCap:         big.NewInt(0),
+		Data:              data,
+		SkipAccountChecks: true,
+	}
+	// settle the previous call, like the end of a tx in a block
+	rs.stateDB.Finalise(true)
+	snapshot := rs.stateDB.Snapshot()
+	revert := func() { rs.stateDB.RevertToSnapshot(snapshot) }
+	evm, vmError, err := lotp.backend.GetEVM(context.Background(), msg, rs.stateDB, rs.header, &vm.Config{NoBaseFee: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if err == nil {
+		err = vmError()
+	}
+	if err != nil {
+		revert()
+		return nil, nil, err
+	}
+	return result, revert, nil
+}
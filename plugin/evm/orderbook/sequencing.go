@@ -0,0 +1,240 @@
+package orderbook
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	executeMatchedOrdersMethod     = "executeMatchedOrders"
+	liquidateAndExecuteOrderMethod = "liquidateAndExecuteOrder"
+	executeBatchMethod             = "executeBatch"
+)
+
+// sequencingOrderBookABI is parsed once, since the txs of every verified block are decoded with it
+var sequencingOrderBookABI = func() abi.ABI {
+	orderBookABI, err := abi.FromSolidityJson(string(abis.OrderBookAbi))
+	if err != nil {
+		panic(err)
+	}
+	return orderBookABI
+}()
+
+// Execution is a single executeMatchedOrders or liquidateAndExecuteOrder call.
+// Orders are identified by the hash of their ABI encoding, which is exactly what is sent in the tx.
+type Execution struct {
+	Method string
+	// Trader is the trader being liquidated, zero for matched orders
+	Trader common.Address
+	// Orders is [long, short] for matched orders and [matchedOrder, 0] for liquidations
+	Orders     [2]common.Hash
+	FillAmount string
+}
+
+func (e Execution) String() string {
+	return fmt.Sprintf("%s(trader=%s, orders=[%s, %s], fillAmount=%s)", e.Method, e.Trader.String(), e.Orders[0].String(), e.Orders[1].String(), e.FillAmount)
+}
+
+// SequencingReport is the result of comparing the orderbook executions in a block with the canonical ones
+type SequencingReport struct {
+	Expected int
+	Included int
+	// Unexpected executions are in the block but would not have been produced by the matching pipeline
+	Unexpected []Execution
+	// Skipped executions would have been produced before the last one included in the block, but are missing from it
+	Skipped []Execution
+	// Reordered is true if the executions in the block are not in the canonical order
+	Reordered bool
+}
+
+// Deviates returns true if the block producer did not follow the matching pipeline.
+// A block may contain only a prefix of the canonical executions (the rest did not fit in the block or had not reached the mempool yet), so a truncated tail is tolerated.
+func (report *SequencingReport) Deviates() bool {
+	return len(report.Unexpected) > 0 || len(report.Skipped) > 0 || report.Reordered
+}
+
+// PlanExecutions runs the matching pipeline against the order book [db] without sending any txs, and returns the
+// executions that an honest block producer would include in the block [blockNumber], which has the timestamp [blockTimestamp]
+// and a parent at [parentTimestamp] (see Run).
+// [db] should be a copy of the order book, the pipeline doesn't wait for the order book events while it runs on it.
+func (pipeline *MatchingPipeline) PlanExecutions(db LimitOrderDatabase, blockNumber *big.Int, parentTimestamp uint64, blockTimestamp uint64) []Execution {
+	recorder := &executionRecorder{}
+	planner := &MatchingPipeline{
+		db:               db,
+		lotp:             recorder,
+		configService:    pipeline.configService,
+		TxPriorityPolicy: pipeline.TxPriorityPolicy,
+		BatchExecution:   pipeline.BatchExecution,
+	}
+	// the circuit breakers are not recorded in the metrics, the block may be verified more than once
+	planner.run(recorder, blockNumber, parentTimestamp, blockTimestamp)
+	return recorder.executions
+}
+
+// GetOrderBookExecutions decodes the executeMatchedOrders and liquidateAndExecuteOrder calls from the txs, in the order of the txs.
+// An executeBatch tx is expanded into its liquidations followed by its matches, which is the order in which the contract executes them.
+func GetOrderBookExecutions(txs types.Transactions) []Execution {
+	executions := []Execution{}
+	for _, tx := range txs {
+		if tx.To() == nil || *tx.To() != OrderBookContractAddress || len(tx.Data()) < 4 {
+			continue
+		}
+		method, err := sequencingOrderBookABI.MethodById(tx.Data()[:4])
+		if err != nil || (method.Name != executeMatchedOrdersMethod && method.Name != liquidateAndExecuteOrderMethod && method.Name != executeBatchMethod) {
+			continue
+		}
+		args, err := method.Inputs.Unpack(tx.Data()[4:])
+		if err != nil {
+			log.Error("GetOrderBookExecutions: error in unpacking tx data", "method", method.Name, "tx", tx.Hash().String(), "err", err)
+			continue
+		}
+		switch method.Name {
+		case executeMatchedOrdersMethod:
+			orders := args[0].([2][]byte)
+			executions = append(executions, newMatchedOrdersExecution(orders[0], orders[1], args[1].(*big.Int)))
+		case liquidateAndExecuteOrderMethod:
+			executions = append(executions, newLiquidationExecution(args[0].(common.Address), args[1].([]byte), args[2].(*big.Int)))
+		case executeBatchMethod:
+			liquidations := *abi.ConvertType(args[0], new([]liquidationOrder)).(*[]liquidationOrder)
+			matches := *abi.ConvertType(args[1], new([]matchedOrders)).(*[]matchedOrders)
+			for _, liquidation := range liquidations {
+				executions = append(executions, newLiquidationExecution(liquidation.Trader, liquidation.Order, liquidation.ToLiquidate))
+			}
+			for _, match := range matches {
+				executions = append(executions, newMatchedOrdersExecution(match.Orders[0], match.Orders[1], match.FillAmount))
+			}
+		}
+	}
+	return executions
+}
+
+// CompareExecutions checks that [included] is a prefix of [expected]
+func CompareExecutions(expected []Execution, included []Execution) *SequencingReport {
+	report := &SequencingReport{
+		Expected:   len(expected),
+		Included:   len(included),
+		Unexpected: []Execution{},
+		Skipped:    []Execution{},
+	}
+
+	// an execution can appear more than once, e.g. when a liquidation is repeated with the same order
+	positions := map[Execution][]int{}
+	for i, execution := range expected {
+		positions[execution] = append(positions[execution], i)
+	}
+
+	used := make([]bool, len(expected))
+	last := -1
+	for _, execution := range included {
+		if len(positions[execution]) == 0 {
+			report.Unexpected = append(report.Unexpected, execution)
+			continue
+		}
+		position := positions[execution][0]
+		positions[execution] = positions[execution][1:]
+		used[position] = true
+		if position < last {
+			report.Reordered = true
+		}
+		if position > last {
+			last = position
+		}
+	}
+
+	for i := 0; i < last; i++ {
+		if !used[i] {
+			report.Skipped = append(report.Skipped, expected[i])
+		}
+	}
+	return report
+}
+
+func newMatchedOrdersExecution(longOrder []byte, shortOrder []byte, fillAmount *big.Int) Execution {
+	return Execution{
+		Method:     executeMatchedOrdersMethod,
+		Orders:     [2]common.Hash{crypto.Keccak256Hash(longOrder), crypto.Keccak256Hash(shortOrder)},
+		FillAmount: fillAmount.String(),
+	}
+}
+
+func newLiquidationExecution(trader common.Address, order []byte, fillAmount *big.Int) Execution {
+	return Execution{
+		Method:     liquidateAndExecuteOrderMethod,
+		Trader:     trader,
+		Orders:     [2]common.Hash{crypto.Keccak256Hash(order)},
+		FillAmount: fillAmount.String(),
+	}
+}
+
+// executionRecorder is a LimitOrderTxProcessor that records the executions instead of sending txs
+type executionRecorder struct {
+	executions []Execution
+}
+
+func (recorder *executionRecorder) GetOrderBookTxsCount() uint64 {
+	return uint64(len(recorder.executions))
+}
+
+func (recorder *executionRecorder) PurgeOrderBookTxs() {
+	recorder.executions = []Execution{}
+}
+
+func (recorder *executionRecorder) ExecuteMatchedOrdersTx(longOrder Order, shortOrder Order, fillAmount *big.Int) error {
+	longOrderBytes, err := longOrder.RawOrder.EncodeToABI()
+	if err != nil {
+		return err
+	}
+	shortOrderBytes, err := shortOrder.RawOrder.EncodeToABI()
+	if err != nil {
+		return err
+	}
+	recorder.executions = append(recorder.executions, newMatchedOrdersExecution(longOrderBytes, shortOrderBytes, fillAmount))
+	return nil
+}
+
+func (recorder *executionRecorder) ExecuteLiquidation(trader common.Address, matchedOrder Order, fillAmount *big.Int) error {
+	orderBytes, err := matchedOrder.RawOrder.EncodeToABI()
+	if err != nil {
+		return err
+	}
+	recorder.executions = append(recorder.executions, newLiquidationExecution(trader, orderBytes, fillAmount))
+	return nil
+}
+
+func (recorder *executionRecorder) ExecuteBatch(liquidations []LiquidationInstruction, matches []MatchInstruction) (int, error) {
+	for _, liquidation := range liquidations {
+		if err := recorder.ExecuteLiquidation(liquidation.Trader, liquidation.Order, liquidation.FillAmount); err != nil {
+			return 0, err
+		}
+	}
+	for _, match := range matches {
+		if err := recorder.ExecuteMatchedOrdersTx(match.LongOrder, match.ShortOrder, match.FillAmount); err != nil {
+			return 0, err
+		}
+	}
+	return len(liquidations) + len(matches), nil
+}
+
+// funding payments and cancellations are not verified
+func (recorder *executionRecorder) ExecuteFundingPaymentTx(market Market) error {
+	return nil
+}
+
+func (recorder *executionRecorder) ExecuteLimitOrderCancel(orderIds []LimitOrder) error {
+	return nil
+}
+
+func (recorder *executionRecorder) ExecuteGTTOrderCancel(orders []GTTOrder) error {
+	return nil
+}
+
+func (recorder *executionRecorder) UpdateMetrics(block *types.Block) {}
+
+func (recorder *executionRecorder) HandleBlockGasTooLow() {}
@@ -0,0 +1,120 @@
+package orderbook
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanExecutions(t *testing.T) {
+	db, lotp, pipeline, _, cs := setupDependencies(t)
+	longOrder := withRawOrder(buildLongOrder(21, 10))
+	shortOrder := withRawOrder(buildShortOrder(20, -4))
+	db.On("GetNextFundingTime", market).Return(uint64(0))
+	db.On("GetLongOrders").Return([]Order{longOrder})
+	db.On("GetShortOrders").Return([]Order{shortOrder})
+	// the circuit breaker is checked at the timestamp of the block, not of its parent
+	cs.On("CheckOracleCircuitBreaker", market, uint64(102)).Return(nil)
+	cs.On("GetAcceptableBounds").Return(big.NewInt(30), big.NewInt(10))
+	cs.On("GetAcceptableBoundsForLiquidation", market).Return(big.NewInt(25), big.NewInt(15))
+
+	executions := pipeline.PlanExecutions(db, big.NewInt(2), 100, 102)
+
+	longOrderBytes, _ := longOrder.RawOrder.EncodeToABI()
+	shortOrderBytes, _ := shortOrder.RawOrder.EncodeToABI()
+	assert.Equal(t, []Execution{newMatchedOrdersExecution(longOrderBytes, shortOrderBytes, big.NewInt(4))}, executions)
+	// the validator's tx processor is not touched
+	lotp.AssertNotCalled(t, "PurgeOrderBookTxs")
+	lotp.AssertNotCalled(t, "ExecuteMatchedOrdersTx")
+}
+
+func TestGetOrderBookExecutions(t *testing.T) {
+	orderBookABI, _ := abi.FromSolidityJson(string(abis.OrderBookAbi))
+	longOrderBytes, _ := withRawOrder(buildLongOrder(21, 10)).RawOrder.EncodeToABI()
+	shortOrderBytes, _ := withRawOrder(buildShortOrder(20, -4)).RawOrder.EncodeToABI()
+	trader := common.HexToAddress("0x710bf5f942331874dcbc7783319123679033b63b")
+
+	matchData, _ := orderBookABI.Pack("executeMatchedOrders", [2][]byte{longOrderBytes, shortOrderBytes}, big.NewInt(4))
+	liquidationData, _ := orderBookABI.Pack("liquidateAndExecuteOrder", trader, longOrderBytes, big.NewInt(6))
+	fundingData, _ := orderBookABI.Pack("settleFundingForMarket", big.NewInt(0))
+	other := common.HexToAddress("0x1")
+	txs := types.Transactions{
+		types.NewTransaction(0, OrderBookContractAddress, big.NewInt(0), 1500000, big.NewInt(1), matchData),
+		types.NewTransaction(1, OrderBookContractAddress, big.NewInt(0), 1500000, big.NewInt(1), fundingData),
+		types.NewTransaction(2, other, big.NewInt(0), 1500000, big.NewInt(1), matchData),
+		types.NewTransaction(3, OrderBookContractAddress, big.NewInt(0), 1500000, big.NewInt(1), liquidationData),
+	}
+
+	assert.Equal(t, []Execution{
+		newMatchedOrdersExecution(longOrderBytes, shortOrderBytes, big.NewInt(4)),
+		newLiquidationExecution(trader, longOrderBytes, big.NewInt(6)),
+	}, GetOrderBookExecutions(txs))
+
+	t.Run("executeBatch is expanded into liquidations and then matches", func(t *testing.T) {
+		batchData, err := orderBookABI.Pack("executeBatch",
+			[]liquidationOrder{{Trader: trader, Order: longOrderBytes, ToLiquidate: big.NewInt(6)}},
+			[]matchedOrders{{Orders: [2][]byte{longOrderBytes, shortOrderBytes}, FillAmount: big.NewInt(4)}},
+		)
+		assert.Nil(t, err)
+		txs := types.Transactions{types.NewTransaction(0, OrderBookContractAddress, big.NewInt(0), 1500000, big.NewInt(1), batchData)}
+		assert.Equal(t, []Execution{
+			newLiquidationExecution(trader, longOrderBytes, big.NewInt(6)),
+			newMatchedOrdersExecution(longOrderBytes, shortOrderBytes, big.NewInt(4)),
+		}, GetOrderBookExecutions(txs))
+	})
+}
+
+func TestCompareExecutions(t *testing.T) {
+	trader := common.HexToAddress("0x710bf5f942331874dcbc7783319123679033b63b")
+	a := newMatchedOrdersExecution([]byte{1}, []byte{2}, big.NewInt(1))
+	b := newMatchedOrdersExecution([]byte{3}, []byte{4}, big.NewInt(2))
+	c := newLiquidationExecution(trader, []byte{5}, big.NewInt(3))
+	expected := []Execution{a, b, c}
+
+	t.Run("all executions in order", func(t *testing.T) {
+		assert.False(t, CompareExecutions(expected, []Execution{a, b, c}).Deviates())
+	})
+	t.Run("truncated tail is tolerated", func(t *testing.T) {
+		assert.False(t, CompareExecutions(expected, []Execution{a, b}).Deviates())
+		assert.False(t, CompareExecutions(expected, []Execution{}).Deviates())
+	})
+	t.Run("skipped execution", func(t *testing.T) {
+		report := CompareExecutions(expected, []Execution{a, c})
+		assert.True(t, report.Deviates())
+		assert.Equal(t, []Execution{b}, report.Skipped)
+	})
+	t.Run("reordered executions", func(t *testing.T) {
+		report := CompareExecutions(expected, []Execution{b, a})
+		assert.True(t, report.Deviates())
+		assert.True(t, report.Reordered)
+		assert.Empty(t, report.Skipped)
+	})
+	t.Run("unexpected execution", func(t *testing.T) {
+		frontRun := newMatchedOrdersExecution([]byte{1}, []byte{2}, big.NewInt(2))
+		report := CompareExecutions(expected, []Execution{frontRun, a})
+		assert.True(t, report.Deviates())
+		assert.Equal(t, []Execution{frontRun}, report.Unexpected)
+	})
+	t.Run("repeated execution", func(t *testing.T) {
+		assert.False(t, CompareExecutions([]Execution{a, a}, []Execution{a, a}).Deviates())
+		report := CompareExecutions([]Execution{a}, []Execution{a, a})
+		assert.Equal(t, []Execution{a}, report.Unexpected)
+	})
+}
+
+func withRawOrder(order Order) Order {
+	order.RawOrder = &LimitOrder{
+		AmmIndex:          big.NewInt(int64(order.Market)),
+		Trader:            common.HexToAddress(order.UserAddress),
+		BaseAssetQuantity: order.BaseAssetQuantity,
+		Price:             order.Price,
+		Salt:              order.Salt,
+		ReduceOnly:        order.ReduceOnly,
+	}
+	return order
+}
@@ -11,8 +11,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/subnet-evm/core"
 	"github.com/ava-labs/subnet-evm/eth"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/checkpoint"
 	"github.com/ava-labs/subnet-evm/rpc"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/event"
@@ -22,13 +24,15 @@ type OrderBookAPI struct {
 	db            LimitOrderDatabase
 	backend       *eth.EthAPIBackend
 	configService IConfigService
+	checkpoints   *CheckpointStore
 }
 
-func NewOrderBookAPI(database LimitOrderDatabase, backend *eth.EthAPIBackend, configService IConfigService) *OrderBookAPI {
+func NewOrderBookAPI(database LimitOrderDatabase, backend *eth.EthAPIBackend, configService IConfigService, checkpoints *CheckpointStore) *OrderBookAPI {
 	return &OrderBookAPI{
 		db:            database,
 		backend:       backend,
 		configService: configService,
+		checkpoints:   checkpoints,
 	}
 }
 
@@ -148,6 +152,48 @@ func (api *OrderBookAPI) GetOrderBook(ctx context.Context, marketStr string) (*O
 	return &OrderBookResponse{Orders: orders}, nil
 }
 
+type FundingSchedule struct {
+	Market
+	NextFundingTime uint64
+	FundingPeriod   uint64
+}
+
+func (api *OrderBookAPI) GetFundingSchedule(ctx context.Context) []FundingSchedule {
+	count := api.configService.GetActiveMarketsCount()
+	schedule := make([]FundingSchedule, count)
+	for i := int64(0); i < count; i++ {
+		market := Market(i)
+		schedule[i] = FundingSchedule{
+			Market:          market,
+			NextFundingTime: api.db.GetNextFundingTime(market),
+			FundingPeriod:   api.configService.GetFundingPeriod(market),
+		}
+	}
+	return schedule
+}
+
+type MarketStatus struct {
+	Market
+	Halted bool
+	Reason string
+}
+
+// GetMarketsStatus returns whether matching and liquidations are halted in each market by the oracle circuit breaker
+func (api *OrderBookAPI) GetMarketsStatus(ctx context.Context) []MarketStatus {
+	count := api.configService.GetActiveMarketsCount()
+	blockTimestamp := NextBlockTimestamp(api.backend.CurrentHeader().Time)
+	statuses := make([]MarketStatus, count)
+	for i := int64(0); i < count; i++ {
+		market := Market(i)
+		statuses[i] = MarketStatus{Market: market}
+		if err := api.configService.CheckOracleCircuitBreaker(market, blockTimestamp); err != nil {
+			statuses[i].Halted = true
+			statuses[i].Reason = err.Error()
+		}
+	}
+	return statuses
+}
+
 func parseMarket(marketStr string) (*int, error) {
 	var market *int
 	if len(marketStr) > 0 {
@@ -185,6 +231,70 @@ func (api *OrderBookAPI) GetOpenOrders(ctx context.Context, trader string, marke
 	return &OpenOrdersResponse{Orders: traderOrders}, nil
 }
 
+type CheckpointResponse struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	Root        common.Hash
+	// MessageID is the warp message to aggregate the signatures of with warp_getAggregateSignature
+	MessageID ids.ID
+}
+
+type OrderProofResponse struct {
+	Checkpoint CheckpointResponse
+	Leaf       checkpoint.OrderLeaf
+	Proof      []common.Hash
+}
+
+type PositionProofResponse struct {
+	Checkpoint CheckpointResponse
+	Leaf       checkpoint.PositionLeaf
+	Proof      []common.Hash
+}
+
+// GetLatestCheckpoint returns the latest checkpoint of the open orders and positions
+func (api *OrderBookAPI) GetLatestCheckpoint(ctx context.Context) (*CheckpointResponse, error) {
+	latest := api.checkpoints.Latest()
+	if latest == nil {
+		return nil, ErrNoCheckpoint
+	}
+	return newCheckpointResponse(latest), nil
+}
+
+// GetOrderProof returns the inclusion proof of the open order [orderId] in the latest checkpoint
+func (api *OrderBookAPI) GetOrderProof(ctx context.Context, orderId string) (*OrderProofResponse, error) {
+	latest := api.checkpoints.Latest()
+	if latest == nil {
+		return nil, ErrNoCheckpoint
+	}
+	leaf, proof, err := latest.OrderProof(common.HexToHash(orderId))
+	if err != nil {
+		return nil, err
+	}
+	return &OrderProofResponse{Checkpoint: *newCheckpointResponse(latest), Leaf: leaf, Proof: proof}, nil
+}
+
+// GetPositionProof returns the inclusion proof of the position of [trader] in [market] in the latest checkpoint
+func (api *OrderBookAPI) GetPositionProof(ctx context.Context, trader string, market int) (*PositionProofResponse, error) {
+	latest := api.checkpoints.Latest()
+	if latest == nil {
+		return nil, ErrNoCheckpoint
+	}
+	leaf, proof, err := latest.PositionProof(common.HexToAddress(trader), Market(market))
+	if err != nil {
+		return nil, err
+	}
+	return &PositionProofResponse{Checkpoint: *newCheckpointResponse(latest), Leaf: leaf, Proof: proof}, nil
+}
+
+func newCheckpointResponse(c *OrderBookCheckpoint) *CheckpointResponse {
+	return &CheckpointResponse{
+		BlockNumber: c.BlockNumber,
+		BlockHash:   c.BlockHash,
+		Root:        c.Root(),
+		MessageID:   c.MessageID,
+	}
+}
+
 // NewOrderBookState send a notification each time a new (header) block is appended to the chain.
 func (api *OrderBookAPI) NewOrderBookState(ctx context.Context) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -288,8 +398,10 @@ func getDepthForMarket(db LimitOrderDatabase, market Market) *MarketDepth {
 	// but because of our retry logic they might be retried every 100 blocks
 	// So, one could argue that is this not a super accurate representation of the order book
 	// BUT for the argument sake, we could also say that these retry orders can be treated as "fresh" orders
-	longOrders := db.GetLongOrders(market, nil /* lowerbound */, nil /* currentBlock */)
-	shortOrders := db.GetShortOrders(market, nil /* upperbound */, nil /* currentBlock */)
+	// expired orders that haven't been cancelled yet can't be matched, so they are left out
+	now := uint64(time.Now().Unix())
+	longOrders := db.GetLongOrders(market, nil /* lowerbound */, nil /* currentBlock */, now)
+	shortOrders := db.GetShortOrders(market, nil /* upperbound */, nil /* currentBlock */, now)
 	return &MarketDepth{
 		Market: market,
 		Longs:  aggregateOrdersByPrice(longOrders),
@@ -0,0 +1,97 @@
+package orderbook
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+
+	"github.com/ava-labs/subnet-evm/accounts"
+	"github.com/ava-labs/subnet-evm/accounts/external"
+	"github.com/ava-labs/subnet-evm/accounts/keystore"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ValidatorSigner signs the orderbook txs of the validator
+type ValidatorSigner interface {
+	Address() common.Address
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// privateKeySigner signs with a raw private key, as read from validator-private-key-file
+type privateKeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewPrivateKeySigner(hexKey string) (ValidatorSigner, error) {
+	if hexKey == "" {
+		return nil, errors.New("private key is not supplied")
+	}
+	key, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		return nil, err
+	}
+	return &privateKeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}, nil
+}
+
+func (signer *privateKeySigner) Address() common.Address {
+	return signer.address
+}
+
+func (signer *privateKeySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewLondonSigner(chainID), signer.key)
+}
+
+// accountSigner signs with an account of an encrypted keystore or an external signer
+type accountSigner struct {
+	backend interface {
+		SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	}
+	account accounts.Account
+}
+
+func (signer *accountSigner) Address() common.Address {
+	return signer.account.Address
+}
+
+func (signer *accountSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return signer.backend.SignTx(signer.account, tx, chainID)
+}
+
+// NewKeystoreSigner unlocks the encrypted json key in [keyFile] with [passphrase].
+// The decrypted key is only held in memory, the key on disk stays encrypted.
+func NewKeystoreSigner(keyFile string, passphrase string) (ValidatorSigner, error) {
+	keyFile, err := filepath.Abs(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	ks := keystore.NewKeyStore(filepath.Dir(keyFile), keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.Find(accounts.Account{URL: accounts.URL{Scheme: keystore.KeyStoreScheme, Path: keyFile}})
+	if err != nil {
+		return nil, fmt.Errorf("key %s not found in keystore: %w", keyFile, err)
+	}
+	if err := ks.Unlock(account, passphrase); err != nil {
+		return nil, fmt.Errorf("unable to unlock key %s: %w", keyFile, err)
+	}
+	return &accountSigner{backend: ks, account: account}, nil
+}
+
+// NewExternalSigner signs through a clef compatible signer listening at [endpoint] (IPC path or http url).
+// [address] may be left empty if the signer manages a single account.
+func NewExternalSigner(endpoint string, address common.Address) (ValidatorSigner, error) {
+	signer, err := external.NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to external signer %s: %w", endpoint, err)
+	}
+	signerAccounts := signer.Accounts()
+	for _, account := range signerAccounts {
+		if account.Address == address || (address == (common.Address{}) && len(signerAccounts) == 1) {
+			return &accountSigner{backend: signer, account: account}, nil
+		}
+	}
+	return nil, fmt.Errorf("account %s not found in external signer %s, available accounts: %d", address.String(), endpoint, len(signerAccounts))
+}
@@ -0,0 +1,118 @@
+package orderbook
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/accounts/keystore"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/rpc"
+	"github.com/ava-labs/subnet-evm/signer/core/apitypes"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatorSigners(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(321123)
+	tx := types.NewTransaction(1, OrderBookContractAddress, big.NewInt(0), 21000, big.NewInt(1), []byte{1, 2, 3})
+
+	assertSignedBy := func(t *testing.T, signer ValidatorSigner) {
+		assert.Equal(t, address, signer.Address())
+		signedTx, err := signer.SignTx(tx, chainID)
+		assert.Nil(t, err)
+		from, err := types.Sender(types.NewLondonSigner(chainID), signedTx)
+		assert.Nil(t, err)
+		assert.Equal(t, address, from)
+		assert.Equal(t, tx.Nonce(), signedTx.Nonce())
+		assert.Equal(t, tx.Data(), signedTx.Data())
+	}
+
+	t.Run("private key", func(t *testing.T) {
+		signer, err := NewPrivateKeySigner(common.Bytes2Hex(crypto.FromECDSA(key)))
+		assert.Nil(t, err)
+		assertSignedBy(t, signer)
+
+		_, err = NewPrivateKeySigner("")
+		assert.Error(t, err)
+	})
+	t.Run("keystore", func(t *testing.T) {
+		dir := t.TempDir()
+		ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+		account, err := ks.ImportECDSA(key, "passphrase")
+		assert.Nil(t, err)
+		// the key on disk is encrypted
+		keyJSON, _ := os.ReadFile(account.URL.Path)
+		assert.NotContains(t, string(keyJSON), common.Bytes2Hex(crypto.FromECDSA(key)))
+
+		signer, err := NewKeystoreSigner(account.URL.Path, "passphrase")
+		assert.Nil(t, err)
+		assertSignedBy(t, signer)
+
+		_, err = NewKeystoreSigner(account.URL.Path, "wrong passphrase")
+		assert.Error(t, err)
+		_, err = NewKeystoreSigner(filepath.Join(dir, "missing"), "passphrase")
+		assert.Error(t, err)
+	})
+	t.Run("external signer", func(t *testing.T) {
+		endpoint := newMockExternalSigner(t, key)
+
+		signer, err := NewExternalSigner(endpoint, address)
+		assert.Nil(t, err)
+		assertSignedBy(t, signer)
+
+		// the only account of the signer is used if no address is given
+		signer, err = NewExternalSigner(endpoint, common.Address{})
+		assert.Nil(t, err)
+		assertSignedBy(t, signer)
+
+		_, err = NewExternalSigner(endpoint, common.HexToAddress("0x1"))
+		assert.Error(t, err)
+	})
+}
+
+// mockExternalSigner implements the account_ namespace of clef with a single key
+type mockExternalSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (api *mockExternalSigner) Version() string {
+	return "6.0.0"
+}
+
+func (api *mockExternalSigner) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(api.key.PublicKey)}
+}
+
+func (api *mockExternalSigner) SignTransaction(args apitypes.SendTxArgs) (map[string]interface{}, error) {
+	signedTx, err := types.SignTx(args.ToTransaction(), types.NewLondonSigner((*big.Int)(args.ChainID)), api.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": signedTx}, nil
+}
+
+// newMockExternalSigner starts a clef compatible signer over http and returns its url
+func newMockExternalSigner(t *testing.T, key *ecdsa.PrivateKey) string {
+	server := rpc.NewServer(0)
+	if err := server.RegisterName("account", &mockExternalSigner{key: key}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return httpServer.URL
+}
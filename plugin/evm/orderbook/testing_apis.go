@@ -5,31 +5,51 @@ package orderbook
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
 
+	"github.com/ava-labs/subnet-evm/core"
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/core/types"
 	"github.com/ava-labs/subnet-evm/eth"
 	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 	"github.com/ava-labs/subnet-evm/rpc"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
 )
 
+var (
+	ErrNotValidator           = errors.New("the matching pipeline only runs on a validator")
+	ErrStateOverridesDisabled = errors.New("testing state overrides are not enabled, see testing-state-overrides-enabled")
+)
+
+// TestingAPI is only registered with testing-api-enabled. Besides reading the hubble slots, it seeds state for the
+// e2e tests of a local chain: with testing-state-overrides-enabled, the Set* methods queue storage writes that are
+// applied to the next block this node builds or processes (see core.TestingStateOverrides) and update the memory DB
+// right away. As blocks are not built without transactions, the next transaction sent to the chain carries them.
 type TestingAPI struct {
-	db            LimitOrderDatabase
-	backend       *eth.EthAPIBackend
-	configService IConfigService
+	db                  LimitOrderDatabase
+	backend             *eth.EthAPIBackend
+	configService       IConfigService
+	stateOverrides      *core.TestingStateOverrides
+	runMatchingPipeline func() (bool, error)
 }
 
-func NewTestingAPI(database LimitOrderDatabase, backend *eth.EthAPIBackend, configService IConfigService) *TestingAPI {
+// NewTestingAPI returns the testing API, the Set* methods return ErrStateOverridesDisabled if [stateOverrides] is nil
+func NewTestingAPI(database LimitOrderDatabase, backend *eth.EthAPIBackend, configService IConfigService, stateOverrides *core.TestingStateOverrides, runMatchingPipeline func() (bool, error)) *TestingAPI {
 	return &TestingAPI{
-		db:            database,
-		backend:       backend,
-		configService: configService,
+		db:                  database,
+		backend:             backend,
+		configService:       configService,
+		stateOverrides:      stateOverrides,
+		runMatchingPipeline: runMatchingPipeline,
 	}
 }
 
 func (api *TestingAPI) GetClearingHouseVars(ctx context.Context, trader common.Address) bibliophile.VariablesReadFromClearingHouseSlots {
-	stateDB, _, _ := api.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(getCurrentBlockNumber(api.backend)))
-	return bibliophile.GetClearingHouseVariables(stateDB, trader)
+	stateDB, header, _ := api.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(getCurrentBlockNumber(api.backend)))
+	return bibliophile.GetClearingHouseVariables(api.backend.ChainConfig(), stateDB, trader, header.Time)
 }
 
 func (api *TestingAPI) GetMarginAccountVars(ctx context.Context, collateralIdx *big.Int, traderAddress string) bibliophile.VariablesReadFromMarginAccountSlots {
@@ -38,8 +58,8 @@ func (api *TestingAPI) GetMarginAccountVars(ctx context.Context, collateralIdx *
 }
 
 func (api *TestingAPI) GetAMMVars(ctx context.Context, ammAddress string, ammIndex int, traderAddress string) bibliophile.VariablesReadFromAMMSlots {
-	stateDB, _, _ := api.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(getCurrentBlockNumber(api.backend)))
-	return bibliophile.GetAMMVariables(stateDB, common.HexToAddress(ammAddress), int64(ammIndex), common.HexToAddress(traderAddress))
+	stateDB, header, _ := api.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(getCurrentBlockNumber(api.backend)))
+	return bibliophile.GetAMMVariables(api.backend.ChainConfig(), stateDB, common.HexToAddress(ammAddress), int64(ammIndex), common.HexToAddress(traderAddress), header.Time)
 }
 
 func (api *TestingAPI) GetIOCOrdersVars(ctx context.Context, orderHash common.Hash) bibliophile.VariablesReadFromIOCOrdersSlots {
@@ -52,6 +72,103 @@ func (api *TestingAPI) GetOrderBookVars(ctx context.Context, traderAddress strin
 	return bibliophile.GetOrderBookVariables(stateDB, traderAddress, senderAddress, orderHash)
 }
 
+// SetOraclePrice sets the underlying price (6 decimals) of a market that reads its price from a TestOracle
+func (api *TestingAPI) SetOraclePrice(ctx context.Context, market int, price *big.Int) error {
+	if api.stateOverrides == nil {
+		return ErrStateOverridesDisabled
+	}
+	stateDB, header, err := api.stateAtMarket(ctx, market)
+	if err != nil {
+		return err
+	}
+	oracle, slot, ok := bibliophile.TestOraclePriceStorageSlot(api.backend.ChainConfig(), stateDB, int64(market), header.Time)
+	if !ok {
+		return fmt.Errorf("market %d does not use a test oracle", market)
+	}
+	api.stateOverrides.Add(core.StorageOverride{Address: oracle, Key: slot, Value: toStorageValue(price)})
+	return nil
+}
+
+// FastForwardFunding makes the funding payment of a market due, by setting its next funding time to the time of the
+// current block. It returns the new next funding time.
+func (api *TestingAPI) FastForwardFunding(ctx context.Context, market int) (uint64, error) {
+	if api.stateOverrides == nil {
+		return 0, ErrStateOverridesDisabled
+	}
+	stateDB, header, err := api.stateAtMarket(ctx, market)
+	if err != nil {
+		return 0, err
+	}
+	amm := bibliophile.GetMarkets(stateDB)[market]
+	api.stateOverrides.Add(core.StorageOverride{Address: amm, Key: common.BigToHash(big.NewInt(bibliophile.NEXT_FUNDING_TIME_SLOT)), Value: common.BigToHash(new(big.Int).SetUint64(header.Time))})
+	api.db.UpdateNextFundingTime(Market(market), header.Time)
+	return header.Time, nil
+}
+
+// SetMargin sets the hUSD margin of a trader. The memory DB is changed by the difference with its own margin, which
+// includes the margins set by earlier calls that are not in a block yet.
+func (api *TestingAPI) SetMargin(ctx context.Context, trader common.Address, margin *big.Int) error {
+	if api.stateOverrides == nil {
+		return ErrStateOverridesDisabled
+	}
+	current := big.NewInt(0)
+	if traderInfo := api.db.GetTraderInfo(trader); traderInfo != nil && traderInfo.Margin.Deposited[HUSD] != nil {
+		current = traderInfo.Margin.Deposited[HUSD]
+	}
+	api.stateOverrides.Add(core.StorageOverride{Address: common.HexToAddress(bibliophile.MARGIN_ACCOUNT_GENESIS_ADDRESS), Key: bibliophile.MarginStorageSlot(big.NewInt(0), trader), Value: toStorageValue(margin)})
+	api.db.UpdateMargin(trader, HUSD, new(big.Int).Sub(margin, current))
+	return nil
+}
+
+// SetPosition sets the position of a trader in a market. Its lastPremiumFraction is set to the cumulative premium
+// fraction of the market, so that no funding is owed on it. The open interest of the AMM is not changed.
+func (api *TestingAPI) SetPosition(ctx context.Context, trader common.Address, market int, size *big.Int, openNotional *big.Int) error {
+	if api.stateOverrides == nil {
+		return ErrStateOverridesDisabled
+	}
+	stateDB, _, err := api.stateAtMarket(ctx, market)
+	if err != nil {
+		return err
+	}
+	if openNotional.Sign() < 0 {
+		return fmt.Errorf("invalid openNotional %s", openNotional)
+	}
+	amm := bibliophile.GetMarkets(stateDB)[market]
+	cumulativePremiumFraction := bibliophile.GetCumulativePremiumFraction(stateDB, amm)
+	slot := bibliophile.PositionStorageSlot(trader).Big()
+	api.stateOverrides.Add(
+		core.StorageOverride{Address: amm, Key: common.BigToHash(slot), Value: toStorageValue(size)},
+		core.StorageOverride{Address: amm, Key: common.BigToHash(new(big.Int).Add(slot, big.NewInt(1))), Value: toStorageValue(openNotional)},
+		core.StorageOverride{Address: amm, Key: common.BigToHash(new(big.Int).Add(slot, big.NewInt(2))), Value: toStorageValue(cumulativePremiumFraction)},
+	)
+	api.db.UpdatePosition(trader, Market(market), size, openNotional, false)
+	api.db.UpdateLastPremiumFraction(Market(market), trader, cumulativePremiumFraction, cumulativePremiumFraction)
+	return nil
+}
+
+// RunMatchingPipeline runs the matching pipeline now instead of waiting for its ticker, and returns whether it created
+// orderbook txs (matches, liquidations or funding payments)
+func (api *TestingAPI) RunMatchingPipeline(ctx context.Context) (bool, error) {
+	return api.runMatchingPipeline()
+}
+
+// stateAtMarket returns the state and the header of the current block, after checking that [market] exists in it
+func (api *TestingAPI) stateAtMarket(ctx context.Context, market int) (*state.StateDB, *types.Header, error) {
+	stateDB, header, err := api.backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(getCurrentBlockNumber(api.backend)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if market < 0 || int64(market) >= bibliophile.GetActiveMarketsCount(stateDB) {
+		return nil, nil, fmt.Errorf("invalid market %d", market)
+	}
+	return stateDB, header, nil
+}
+
+// toStorageValue encodes [value] as an int256 storage word
+func toStorageValue(value *big.Int) common.Hash {
+	return common.BytesToHash(math.U256Bytes(new(big.Int).Set(value)))
+}
+
 func getCurrentBlockNumber(backend *eth.EthAPIBackend) uint64 {
 	return backend.CurrentHeader().Number.Uint64()
 }
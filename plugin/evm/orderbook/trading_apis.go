@@ -65,6 +65,12 @@ type OrderStatusResponse struct {
 	Type         string   `json:"type"`         // "LIMIT"
 	UpdateTime   int64    `json:"updateTime"`   // 1579276756075
 	Salt         *big.Int `json:"salt"`
+	// why the last execution of the order failed and when it will be retried, set while the order is in Execution_Failed
+	FailureCode   string `json:"failureCode,omitempty"`   // "INSUFFICIENT_MARGIN"
+	FailureReason string `json:"failureReason,omitempty"` // "CH: Below Minimum Allowable Margin"
+	RetryPolicy   string `json:"retryPolicy,omitempty"`   // "AFTER_ORACLE_MOVE"
+	// hash of the latest amendment of the order, orderId stays the hash the order was placed with
+	AmendedOrderHash string `json:"amendedOrderHash,omitempty"`
 }
 
 type TraderPosition struct {
@@ -91,6 +97,7 @@ var mapStatus = map[Status]string{
 	FulFilled:        "FILLED",
 	Cancelled:        "CANCELED",
 	Execution_Failed: "REJECTED",
+	Amended:          "NEW",
 }
 
 func (api *TradingAPI) GetTradingOrderBookDepth(ctx context.Context, market int8) TradingOrderBookDepthResponse {
@@ -155,6 +162,15 @@ func (api *TradingAPI) GetOrderStatus(ctx context.Context, orderId common.Hash)
 		UpdateTime:   updateTime,
 		Salt:         limitOrder.Salt,
 	}
+	if lastStatus := limitOrder.getOrderStatus(); lastStatus.Status == Execution_Failed {
+		failureCode := lastStatus.failureCode()
+		response.FailureCode = string(failureCode)
+		response.FailureReason = lastStatus.Info
+		response.RetryPolicy = failureCode.RetryPolicy().String()
+	}
+	if amendment := limitOrder.getLatestAmendment(); amendment != nil {
+		response.AmendedOrderHash = amendment.AmendedOrderHash.String()
+	}
 
 	return response, nil
 }
@@ -0,0 +1,258 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// TxPriorityPolicy decides the order in which the txs of a matching pipeline run are sent.
+// Only a prefix of them fits in the gas budget of a block, the rest are deferred.
+type TxPriorityPolicy string
+
+const (
+	// PriorityLiquidationsFirst sends funding payments, then liquidations, then cancellations and finally the matched orders, largest notional first
+	PriorityLiquidationsFirst TxPriorityPolicy = "liquidations-first"
+	// PrioritySequential sends the txs in the order in which the pipeline creates them
+	PrioritySequential TxPriorityPolicy = "sequential"
+)
+
+func (policy TxPriorityPolicy) Validate() error {
+	switch policy {
+	case PriorityLiquidationsFirst, PrioritySequential:
+		return nil
+	}
+	return fmt.Errorf("unknown orderbook tx priority policy %q", policy)
+}
+
+// ErrGasBudgetExhausted is returned by the LimitOrderTxProcessor when a tx doesn't fit in the orderbook gas budget of the block
+var ErrGasBudgetExhausted = errors.New("orderbook gas budget exhausted")
+
+// batchCutError is returned for the executions of a batch when only the first [sent] of them fit in the gas budget
+type batchCutError struct {
+	sent int
+}
+
+func (err *batchCutError) Error() string {
+	return fmt.Sprintf("%s after %d executions of the batch", ErrGasBudgetExhausted, err.sent)
+}
+
+func (err *batchCutError) Unwrap() error {
+	return ErrGasBudgetExhausted
+}
+
+type txKind uint8
+
+// in the order of PriorityLiquidationsFirst
+const (
+	fundingPaymentTx txKind = iota
+	liquidationTx
+	cancelTx
+	matchedOrdersTx
+)
+
+type queuedTx struct {
+	kind     txKind
+	notional *big.Int
+	send     func(lotp LimitOrderTxProcessor) error
+	// size is the number of executions in the tx, more than 1 for an executeBatch tx
+	size int
+
+	// set for liquidations and matches so that they can be batched
+	liquidation *LiquidationInstruction
+	match       *MatchInstruction
+}
+
+// txQueue is a LimitOrderTxProcessor that holds the txs of a matching pipeline run, so that they are sent to the underlying processor
+// in the order of the priority policy when the run is complete.
+// With batching, the liquidations and matches are sent in a single executeBatch tx instead.
+type txQueue struct {
+	lotp   LimitOrderTxProcessor
+	policy TxPriorityPolicy
+	batch  bool
+	txs    []queuedTx
+}
+
+func newTxQueue(lotp LimitOrderTxProcessor, policy TxPriorityPolicy, batch bool) *txQueue {
+	return &txQueue{lotp: lotp, policy: policy, batch: batch}
+}
+
+func (queue *txQueue) GetOrderBookTxsCount() uint64 {
+	return queue.lotp.GetOrderBookTxsCount()
+}
+
+func (queue *txQueue) PurgeOrderBookTxs() {
+	queue.txs = nil
+	queue.lotp.PurgeOrderBookTxs()
+}
+
+func (queue *txQueue) ExecuteMatchedOrdersTx(longOrder Order, shortOrder Order, fillAmount *big.Int) error {
+	queue.txs = append(queue.txs, queuedTx{
+		kind:     matchedOrdersTx,
+		notional: getMatchNotional(longOrder, shortOrder, fillAmount),
+		send: func(lotp LimitOrderTxProcessor) error {
+			return lotp.ExecuteMatchedOrdersTx(longOrder, shortOrder, fillAmount)
+		},
+		size:  1,
+		match: &MatchInstruction{LongOrder: longOrder, ShortOrder: shortOrder, FillAmount: fillAmount},
+	})
+	return nil
+}
+
+func (queue *txQueue) ExecuteFundingPaymentTx(market Market) error {
+	queue.push(fundingPaymentTx, func(lotp LimitOrderTxProcessor) error {
+		return lotp.ExecuteFundingPaymentTx(market)
+	})
+	return nil
+}
+
+func (queue *txQueue) ExecuteLiquidation(trader common.Address, matchedOrder Order, fillAmount *big.Int) error {
+	queue.txs = append(queue.txs, queuedTx{
+		kind: liquidationTx,
+		send: func(lotp LimitOrderTxProcessor) error {
+			return lotp.ExecuteLiquidation(trader, matchedOrder, fillAmount)
+		},
+		size:        1,
+		liquidation: &LiquidationInstruction{Trader: trader, Order: matchedOrder, FillAmount: fillAmount},
+	})
+	return nil
+}
+
+func (queue *txQueue) ExecuteLimitOrderCancel(orders []LimitOrder) error {
+	queue.push(cancelTx, func(lotp LimitOrderTxProcessor) error {
+		return lotp.ExecuteLimitOrderCancel(orders)
+	})
+	return nil
+}
+
+func (queue *txQueue) ExecuteGTTOrderCancel(orders []GTTOrder) error {
+	queue.push(cancelTx, func(lotp LimitOrderTxProcessor) error {
+		return lotp.ExecuteGTTOrderCancel(orders)
+	})
+	return nil
+}
+
+// ExecuteBatch is not queued, the queue does the batching itself
+func (queue *txQueue) ExecuteBatch(liquidations []LiquidationInstruction, matches []MatchInstruction) (int, error) {
+	return queue.lotp.ExecuteBatch(liquidations, matches)
+}
+
+func (queue *txQueue) UpdateMetrics(block *types.Block) {
+	queue.lotp.UpdateMetrics(block)
+}
+
+func (queue *txQueue) HandleBlockGasTooLow() {
+	queue.lotp.HandleBlockGasTooLow()
+}
+
+func (queue *txQueue) push(kind txKind, send func(lotp LimitOrderTxProcessor) error) {
+	queue.txs = append(queue.txs, queuedTx{kind: kind, send: send, size: 1})
+}
+
+// flush sends the queued txs in the order of the policy and stops at the first one that doesn't fit in the gas budget,
+// so that the block gets a prefix of the prioritized txs. The txs after it are dropped, the next run (for the next block)
+// creates them again from the order book. Txs that fail for any other reason are skipped.
+func (queue *txQueue) flush() {
+	if queue.policy == PriorityLiquidationsFirst {
+		sort.SliceStable(queue.txs, func(i, j int) bool {
+			if queue.txs[i].kind != queue.txs[j].kind {
+				return queue.txs[i].kind < queue.txs[j].kind
+			}
+			if queue.txs[i].kind == matchedOrdersTx {
+				return queue.txs[i].notional.Cmp(queue.txs[j].notional) > 0
+			}
+			return false
+		})
+	}
+
+	txs := queue.txs
+	if queue.batch {
+		txs = batchExecutions(txs)
+	}
+	for i, tx := range txs {
+		if err := tx.send(queue.lotp); errors.Is(err, ErrGasBudgetExhausted) {
+			deferred := 0
+			for _, tx := range txs[i:] {
+				deferred += tx.size
+			}
+			var cut *batchCutError
+			if errors.As(err, &cut) {
+				deferred -= cut.sent
+			}
+			log.Info("orderbook gas budget exhausted, deferring txs to the next block", "sent", i, "deferred", deferred)
+			orderBookTxsDeferredCounter.Inc(int64(deferred))
+			break
+		}
+	}
+	queue.txs = nil
+}
+
+// batchExecutions replaces the liquidations and matches in [txs] with a single executeBatch tx, at the position of the first of them.
+// The relative order of the executions is kept, except that the contract executes all the liquidations before the matches.
+// If the batch can't be sent for a reason other than the gas budget, for example when the orderbook contract doesn't implement
+// executeBatch, the executions are sent in their own txs instead.
+func batchExecutions(txs []queuedTx) []queuedTx {
+	batched := make([]queuedTx, 0, len(txs))
+	executions := []queuedTx{}
+	liquidations := []LiquidationInstruction{}
+	matches := []MatchInstruction{}
+	position := -1
+	for _, tx := range txs {
+		if tx.liquidation == nil && tx.match == nil {
+			batched = append(batched, tx)
+			continue
+		}
+		if position == -1 {
+			position = len(batched)
+			batched = append(batched, queuedTx{})
+		}
+		executions = append(executions, tx)
+		if tx.liquidation != nil {
+			liquidations = append(liquidations, *tx.liquidation)
+		} else {
+			matches = append(matches, *tx.match)
+		}
+	}
+	if position == -1 {
+		return batched
+	}
+
+	size := len(liquidations) + len(matches)
+	batched[position] = queuedTx{
+		kind: liquidationTx,
+		size: size,
+		send: func(lotp LimitOrderTxProcessor) error {
+			_, err := lotp.ExecuteBatch(liquidations, matches)
+			if err != nil && !errors.Is(err, ErrGasBudgetExhausted) {
+				log.Warn("executeBatch failed, sending the executions in separate txs", "err", err)
+				return sendSeparately(lotp, executions)
+			}
+			return err
+		},
+	}
+	return batched
+}
+
+// sendSeparately sends each of the [executions] in its own tx until the gas budget is exhausted
+func sendSeparately(lotp LimitOrderTxProcessor, executions []queuedTx) error {
+	for i, tx := range executions {
+		if err := tx.send(lotp); errors.Is(err, ErrGasBudgetExhausted) {
+			return &batchCutError{sent: i}
+		}
+	}
+	return nil
+}
+
+// getMatchNotional returns the notional of a match at the price of the order that was placed first
+func getMatchNotional(longOrder Order, shortOrder Order, fillAmount *big.Int) *big.Int {
+	price := longOrder.Price
+	if shortOrder.BlockNumber != nil && longOrder.BlockNumber != nil && shortOrder.BlockNumber.Cmp(longOrder.BlockNumber) < 0 {
+		price = shortOrder.Price
+	}
+	return new(big.Int).Mul(new(big.Int).Abs(fillAmount), price)
+}
@@ -0,0 +1,129 @@
+package orderbook
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTxQueueFlush(t *testing.T) {
+	trader := common.HexToAddress("0x710bf5f942331874dcbc7783319123679033b63b")
+	smallLong, smallShort := buildLongOrder(20, 1), buildShortOrder(20, -1)
+	bigLong, bigShort := buildLongOrder(20, 5), buildShortOrder(20, -5)
+	cancels := []LimitOrder{{}}
+
+	queueAll := func(queue *txQueue) {
+		queue.ExecuteMatchedOrdersTx(smallLong, smallShort, big.NewInt(1))
+		queue.ExecuteLimitOrderCancel(cancels)
+		queue.ExecuteMatchedOrdersTx(bigLong, bigShort, big.NewInt(5))
+		queue.ExecuteLiquidation(trader, bigLong, big.NewInt(2))
+		queue.ExecuteFundingPaymentTx(market)
+	}
+	calledMethods := func(lotp *MockLimitOrderTxProcessor) []string {
+		methods := []string{}
+		for _, call := range lotp.Calls {
+			methods = append(methods, call.Method)
+		}
+		return methods
+	}
+
+	t.Run("liquidations first policy", func(t *testing.T) {
+		lotp := NewMockLimitOrderTxProcessor()
+		lotp.On("ExecuteMatchedOrdersTx", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		lotp.On("ExecuteLimitOrderCancel", mock.Anything).Return(nil)
+		lotp.On("ExecuteLiquidation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		lotp.On("ExecuteFundingPaymentTx", mock.Anything).Return(nil)
+		queue := newTxQueue(lotp, PriorityLiquidationsFirst, false)
+		queueAll(queue)
+		lotp.AssertNotCalled(t, "ExecuteMatchedOrdersTx", mock.Anything, mock.Anything, mock.Anything)
+
+		queue.flush()
+		assert.Equal(t, []string{"ExecuteFundingPaymentTx", "ExecuteLiquidation", "ExecuteLimitOrderCancel", "ExecuteMatchedOrdersTx", "ExecuteMatchedOrdersTx"}, calledMethods(lotp))
+		// largest notional first
+		assert.Equal(t, big.NewInt(5), lotp.Calls[3].Arguments.Get(2))
+		assert.Equal(t, big.NewInt(1), lotp.Calls[4].Arguments.Get(2))
+	})
+	t.Run("sequential policy", func(t *testing.T) {
+		lotp := NewMockLimitOrderTxProcessor()
+		lotp.On("ExecuteMatchedOrdersTx", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		lotp.On("ExecuteLimitOrderCancel", mock.Anything).Return(nil)
+		lotp.On("ExecuteLiquidation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		lotp.On("ExecuteFundingPaymentTx", mock.Anything).Return(nil)
+		queue := newTxQueue(lotp, PrioritySequential, false)
+		queueAll(queue)
+
+		queue.flush()
+		assert.Equal(t, []string{"ExecuteMatchedOrdersTx", "ExecuteLimitOrderCancel", "ExecuteMatchedOrdersTx", "ExecuteLiquidation", "ExecuteFundingPaymentTx"}, calledMethods(lotp))
+	})
+	t.Run("txs after the gas budget is exhausted are deferred", func(t *testing.T) {
+		lotp := NewMockLimitOrderTxProcessor()
+		lotp.On("ExecuteFundingPaymentTx", mock.Anything).Return(nil)
+		lotp.On("ExecuteLiquidation", mock.Anything, mock.Anything, mock.Anything).Return(ErrGasBudgetExhausted)
+		queue := newTxQueue(lotp, PriorityLiquidationsFirst, false)
+		queueAll(queue)
+
+		queue.flush()
+		assert.Equal(t, []string{"ExecuteFundingPaymentTx", "ExecuteLiquidation"}, calledMethods(lotp))
+		assert.Empty(t, queue.txs)
+	})
+	t.Run("batch execution", func(t *testing.T) {
+		lotp := NewMockLimitOrderTxProcessor()
+		lotp.On("ExecuteFundingPaymentTx", mock.Anything).Return(nil)
+		lotp.On("ExecuteBatch", mock.Anything, mock.Anything).Return(3, nil)
+		lotp.On("ExecuteLimitOrderCancel", mock.Anything).Return(nil)
+		queue := newTxQueue(lotp, PriorityLiquidationsFirst, true)
+		queueAll(queue)
+
+		queue.flush()
+		assert.Equal(t, []string{"ExecuteFundingPaymentTx", "ExecuteBatch", "ExecuteLimitOrderCancel"}, calledMethods(lotp))
+		assert.Equal(t, []LiquidationInstruction{{Trader: trader, Order: bigLong, FillAmount: big.NewInt(2)}}, lotp.Calls[1].Arguments.Get(0))
+		assert.Equal(t, []MatchInstruction{
+			{LongOrder: bigLong, ShortOrder: bigShort, FillAmount: big.NewInt(5)},
+			{LongOrder: smallLong, ShortOrder: smallShort, FillAmount: big.NewInt(1)},
+		}, lotp.Calls[1].Arguments.Get(1))
+	})
+	t.Run("batch that doesn't fit at all is deferred", func(t *testing.T) {
+		lotp := NewMockLimitOrderTxProcessor()
+		lotp.On("ExecuteFundingPaymentTx", mock.Anything).Return(nil)
+		lotp.On("ExecuteBatch", mock.Anything, mock.Anything).Return(0, ErrGasBudgetExhausted)
+		queue := newTxQueue(lotp, PriorityLiquidationsFirst, true)
+		queueAll(queue)
+
+		queue.flush()
+		assert.Equal(t, []string{"ExecuteFundingPaymentTx", "ExecuteBatch"}, calledMethods(lotp))
+	})
+	t.Run("txs after a batch that was cut are deferred", func(t *testing.T) {
+		lotp := NewMockLimitOrderTxProcessor()
+		lotp.On("ExecuteFundingPaymentTx", mock.Anything).Return(nil)
+		lotp.On("ExecuteBatch", mock.Anything, mock.Anything).Return(1, &batchCutError{sent: 1})
+		queue := newTxQueue(lotp, PriorityLiquidationsFirst, true)
+		queueAll(queue)
+
+		queue.flush()
+		assert.Equal(t, []string{"ExecuteFundingPaymentTx", "ExecuteBatch"}, calledMethods(lotp))
+	})
+	t.Run("executions are sent separately when the batch fails", func(t *testing.T) {
+		lotp := NewMockLimitOrderTxProcessor()
+		lotp.On("ExecuteFundingPaymentTx", mock.Anything).Return(nil)
+		lotp.On("ExecuteBatch", mock.Anything, mock.Anything).Return(0, errors.New("execution reverted"))
+		lotp.On("ExecuteLiquidation", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		lotp.On("ExecuteMatchedOrdersTx", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		lotp.On("ExecuteMatchedOrdersTx", mock.Anything, mock.Anything, mock.Anything).Return(ErrGasBudgetExhausted)
+		queue := newTxQueue(lotp, PriorityLiquidationsFirst, true)
+		queueAll(queue)
+
+		queue.flush()
+		// the cancellation after the batch is deferred with the match that didn't fit
+		assert.Equal(t, []string{"ExecuteFundingPaymentTx", "ExecuteBatch", "ExecuteLiquidation", "ExecuteMatchedOrdersTx", "ExecuteMatchedOrdersTx"}, calledMethods(lotp))
+	})
+}
+
+func TestTxPriorityPolicyValidate(t *testing.T) {
+	assert.NoError(t, PriorityLiquidationsFirst.Validate())
+	assert.NoError(t, PrioritySequential.Validate())
+	assert.Error(t, TxPriorityPolicy("fifo").Validate())
+}
@@ -2,20 +2,26 @@ package orderbook
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
+	"sync"
 
 	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/consensus/dummy"
+	"github.com/ava-labs/subnet-evm/core"
 	"github.com/ava-labs/subnet-evm/core/txpool"
 	"github.com/ava-labs/subnet-evm/core/types"
 	"github.com/ava-labs/subnet-evm/eth"
+	"github.com/ava-labs/subnet-evm/internal/ethapi"
 	"github.com/ava-labs/subnet-evm/metrics"
 	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/abis"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/marginbridge"
+	"github.com/ava-labs/subnet-evm/rpc"
+	"github.com/ava-labs/subnet-evm/vmerrs"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -23,22 +29,59 @@ var OrderBookContractAddress = common.HexToAddress("0x03000000000000000000000000
 var MarginAccountContractAddress = common.HexToAddress("0x0300000000000000000000000000000000000001")
 var ClearingHouseContractAddress = common.HexToAddress("0x0300000000000000000000000000000000000002")
 var IOCOrderBookContractAddress = common.HexToAddress("0x635c5F96989a4226953FE6361f12B96c5d50289b")
+var GTTOrderBookContractAddress = common.HexToAddress("0x0300000000000000000000000000000000000006")
+var MarginBridgeContractAddress = marginbridge.ContractAddress
 
 // var IOCOrderBookContractAddress = common.HexToAddress("0x635c5F96989a4226953FE6361f12B96c5d50289b")
 
+const (
+	// orderBookTxGasLimit is the max gas limit of an orderbook tx, also used when its gas can't be estimated
+	orderBookTxGasLimit uint64 = 1500000
+)
+
 type LimitOrderTxProcessor interface {
 	GetOrderBookTxsCount() uint64
 	PurgeOrderBookTxs()
 	ExecuteMatchedOrdersTx(incomingOrder Order, matchedOrder Order, fillAmount *big.Int) error
-	ExecuteFundingPaymentTx() error
+	ExecuteFundingPaymentTx(market Market) error
 	ExecuteLiquidation(trader common.Address, matchedOrder Order, fillAmount *big.Int) error
 	UpdateMetrics(block *types.Block)
+	HandleBlockGasTooLow()
 	ExecuteLimitOrderCancel(orderIds []LimitOrder) error
+	ExecuteGTTOrderCancel(orders []GTTOrder) error
+	ExecuteBatch(liquidations []LiquidationInstruction, matches []MatchInstruction) (int, error)
+}
+
+// LiquidationInstruction is a liquidateAndExecuteOrder call in an executeBatch tx
+type LiquidationInstruction struct {
+	Trader     common.Address
+	Order      Order
+	FillAmount *big.Int
+}
+
+// MatchInstruction is an executeMatchedOrders call in an executeBatch tx
+type MatchInstruction struct {
+	LongOrder  Order
+	ShortOrder Order
+	FillAmount *big.Int
+}
+
+// liquidationOrder and matchedOrders are the abi encodings of IOrderBook.LiquidationOrder and IOrderBook.MatchedOrders
+type liquidationOrder struct {
+	Trader      common.Address
+	Order       []byte
+	ToLiquidate *big.Int
+}
+
+type matchedOrders struct {
+	Orders     [2][]byte
+	FillAmount *big.Int
 }
 
 type ValidatorTxFeeConfig struct {
-	baseFeeEstimate *big.Int
-	blockNumber     uint64
+	suggestedPrice *big.Int
+	blockGasCost   *big.Int
+	blockNumber    uint64
 }
 
 type limitOrderTxProcessor struct {
@@ -51,12 +94,22 @@ type limitOrderTxProcessor struct {
 	clearingHouseContractAddress common.Address
 	marginAccountContractAddress common.Address
 	backend                      *eth.EthAPIBackend
-	validatorAddress             common.Address
-	validatorPrivateKey          string
+	validatorKeys                *validatorKeys
 	validatorTxFeeConfig         ValidatorTxFeeConfig
+	feeStrategy                  *feeStrategy
+	// gasBudgetPercent is the percentage of the block gas limit that the orderbook txs may use
+	gasBudgetPercent uint64
+	// mu guards the gas budget, the state of the current run and validatorTxFeeConfig
+	mu                 sync.Mutex
+	remainingGasBudget uint64
+	runState           *runState
+	// preflightEnabled dry runs the txs before sending them, see preflight.go
+	preflightEnabled bool
+	jurorABI         abi.ABI
+	gttOrderBookABI  abi.ABI
 }
 
-func NewLimitOrderTxProcessor(txPool *txpool.TxPool, memoryDb LimitOrderDatabase, backend *eth.EthAPIBackend, validatorPrivateKey string) LimitOrderTxProcessor {
+func NewLimitOrderTxProcessor(txPool *txpool.TxPool, memoryDb LimitOrderDatabase, backend *eth.EthAPIBackend, signers []ValidatorSigner, gasBudgetPercent uint64, feeCaps TxFeeCaps, preflightEnabled bool) LimitOrderTxProcessor {
 	orderBookABI, err := abi.FromSolidityJson(string(abis.OrderBookAbi))
 	if err != nil {
 		panic(err)
@@ -72,12 +125,18 @@ func NewLimitOrderTxProcessor(txPool *txpool.TxPool, memoryDb LimitOrderDatabase
 		panic(err)
 	}
 
-	if validatorPrivateKey == "" {
-		panic("private key is not supplied")
+	jurorABI, err := abi.FromSolidityJson(string(abis.JurorAbi))
+	if err != nil {
+		panic(err)
 	}
-	validatorAddress, err := getAddressFromPrivateKey(validatorPrivateKey)
+
+	gttOrderBookABI, err := abi.FromSolidityJson(string(abis.GTTOrderBookAbi))
 	if err != nil {
-		panic(fmt.Sprint("unable to get address from private key with error", err.Error()))
+		panic(err)
+	}
+
+	if len(signers) == 0 {
+		panic("validator signer is not supplied")
 	}
 
 	lotp := &limitOrderTxProcessor{
@@ -90,9 +149,13 @@ func NewLimitOrderTxProcessor(txPool *txpool.TxPool, memoryDb LimitOrderDatabase
 		clearingHouseContractAddress: ClearingHouseContractAddress,
 		marginAccountContractAddress: MarginAccountContractAddress,
 		backend:                      backend,
-		validatorAddress:             validatorAddress,
-		validatorPrivateKey:          validatorPrivateKey,
-		validatorTxFeeConfig:         ValidatorTxFeeConfig{baseFeeEstimate: big.NewInt(0), blockNumber: 0},
+		validatorKeys:                newValidatorKeys(signers),
+		validatorTxFeeConfig:         ValidatorTxFeeConfig{blockNumber: 0},
+		feeStrategy:                  newFeeStrategy(feeCaps),
+		gasBudgetPercent:             gasBudgetPercent,
+		preflightEnabled:             preflightEnabled,
+		jurorABI:                     jurorABI,
+		gttOrderBookABI:              gttOrderBookABI,
 	}
 	return lotp
 }
@@ -103,15 +166,20 @@ func (lotp *limitOrderTxProcessor) ExecuteLiquidation(trader common.Address, mat
 		log.Error("EncodeLimitOrder failed in ExecuteLiquidation", "order", matchedOrder, "err", err)
 		return err
 	}
-	txHash, err := lotp.executeLocalTx(lotp.orderBookContractAddress, lotp.orderBookABI, "liquidateAndExecuteOrder", trader, orderBytes, fillAmount)
+	txHash, err := lotp.executeLocalTx(liquidationTx, lotp.orderBookContractAddress, lotp.orderBookABI, "liquidateAndExecuteOrder", trader, orderBytes, fillAmount)
+	var preflightErr *PreflightError
+	if errors.As(err, &preflightErr) && preflightErr.Failure.Code != FailureNotLiquidatable {
+		// the order isn't at fault if the trader can't be liquidated
+		lotp.markOrdersFailed(preflightErr.Failure, matchedOrder.Id)
+	}
 	log.Info("ExecuteLiquidation", "trader", trader, "matchedOrder", matchedOrder, "fillAmount", prettifyScaledBigInt(fillAmount, 18), "txHash", txHash.String(), "err", err)
 	// log.Info("ExecuteLiquidation", "trader", trader, "matchedOrder", matchedOrder, "fillAmount", prettifyScaledBigInt(fillAmount, 18), "orderBytes", hex.EncodeToString(orderBytes), "txHash", txHash.String(), "err", err)
 	return err
 }
 
-func (lotp *limitOrderTxProcessor) ExecuteFundingPaymentTx() error {
-	txHash, err := lotp.executeLocalTx(lotp.orderBookContractAddress, lotp.orderBookABI, "settleFunding")
-	log.Info("ExecuteFundingPaymentTx", "txHash", txHash.String(), "err", err)
+func (lotp *limitOrderTxProcessor) ExecuteFundingPaymentTx(market Market) error {
+	txHash, err := lotp.executeLocalTx(fundingPaymentTx, lotp.orderBookContractAddress, lotp.orderBookABI, "settleFundingForMarket", big.NewInt(int64(market)))
+	log.Info("ExecuteFundingPaymentTx", "market", market, "txHash", txHash.String(), "err", err)
 	return err
 }
 
@@ -129,91 +197,279 @@ func (lotp *limitOrderTxProcessor) ExecuteMatchedOrdersTx(longOrder Order, short
 		return err
 	}
 
-	txHash, err := lotp.executeLocalTx(lotp.orderBookContractAddress, lotp.orderBookABI, "executeMatchedOrders", orders, fillAmount)
+	txHash, err := lotp.executeLocalTx(matchedOrdersTx, lotp.orderBookContractAddress, lotp.orderBookABI, "executeMatchedOrders", orders, fillAmount)
+	var preflightErr *PreflightError
+	if errors.As(err, &preflightErr) {
+		failure, orderIds := lotp.faultyOrders(preflightErr.Failure, longOrder, shortOrder, [2][]byte{orders[0], orders[1]}, fillAmount)
+		lotp.markOrdersFailed(failure, orderIds...)
+	}
 	log.Info("ExecuteMatchedOrdersTx", "LongOrder", longOrder, "ShortOrder", shortOrder, "fillAmount", prettifyScaledBigInt(fillAmount, 18), "txHash", txHash.String(), "err", err)
 	return err
 }
 
 func (lotp *limitOrderTxProcessor) ExecuteLimitOrderCancel(orders []LimitOrder) error {
-	txHash, err := lotp.executeLocalTx(lotp.orderBookContractAddress, lotp.orderBookABI, "cancelOrders", orders)
+	txHash, err := lotp.executeLocalTx(cancelTx, lotp.orderBookContractAddress, lotp.orderBookABI, "cancelOrders", orders)
 	log.Info("ExecuteLimitOrderCancel", "orders", orders, "txHash", txHash.String(), "err", err)
 	return err
 }
 
-func (lotp *limitOrderTxProcessor) executeLocalTx(contract common.Address, contractABI abi.ABI, method string, args ...interface{}) (common.Hash, error) {
-	var txHash common.Hash
-	nonce := lotp.txPool.GetOrderBookTxNonce(common.HexToAddress(lotp.validatorAddress.Hex())) // admin address
+// ExecuteGTTOrderCancel cancels expired GTT orders, anyone may cancel an order once it has expired
+func (lotp *limitOrderTxProcessor) ExecuteGTTOrderCancel(orders []GTTOrder) error {
+	txHash, err := lotp.executeLocalTx(cancelTx, GTTOrderBookContractAddress, lotp.gttOrderBookABI, "cancelExpiredOrders", orders)
+	log.Info("ExecuteGTTOrderCancel", "orders", orders, "txHash", txHash.String(), "err", err)
+	return err
+}
 
+// ExecuteBatch sends the liquidations and the matches in a single executeBatch tx, liquidations first.
+// The orderbook emits OrderMatchingError/LiquidationError for the executions that fail instead of reverting the whole batch.
+// If the batch doesn't fit in the remaining gas budget, it is halved until it does.
+// It returns the number of executions included in the tx, with a batchCutError if the rest were left for the next block.
+func (lotp *limitOrderTxProcessor) ExecuteBatch(liquidations []LiquidationInstruction, matches []MatchInstruction) (int, error) {
+	liquidationArgs := make([]liquidationOrder, 0, len(liquidations))
+	for _, liquidation := range liquidations {
+		orderBytes, err := liquidation.Order.RawOrder.EncodeToABI()
+		if err != nil {
+			log.Error("EncodeLimitOrder failed in ExecuteBatch", "order", liquidation.Order, "err", err)
+			return 0, err
+		}
+		liquidationArgs = append(liquidationArgs, liquidationOrder{Trader: liquidation.Trader, Order: orderBytes, ToLiquidate: liquidation.FillAmount})
+	}
+	matchArgs := make([]matchedOrders, 0, len(matches))
+	for _, match := range matches {
+		longOrderBytes, err := match.LongOrder.RawOrder.EncodeToABI()
+		if err != nil {
+			log.Error("EncodeLimitOrder failed for longOrder in ExecuteBatch", "order", match.LongOrder, "err", err)
+			return 0, err
+		}
+		shortOrderBytes, err := match.ShortOrder.RawOrder.EncodeToABI()
+		if err != nil {
+			log.Error("EncodeLimitOrder failed for shortOrder in ExecuteBatch", "order", match.ShortOrder, "err", err)
+			return 0, err
+		}
+		matchArgs = append(matchArgs, matchedOrders{Orders: [2][]byte{longOrderBytes, shortOrderBytes}, FillAmount: match.FillAmount})
+	}
+
+	total := len(liquidationArgs) + len(matchArgs)
+	if total == 0 {
+		return 0, nil
+	}
+	key := lotp.validatorKeys.pick(lotp.backend.CurrentHeader().Number.Uint64())
+	lotp.mu.Lock()
+	defer lotp.mu.Unlock()
+	for size := total; size > 0; size /= 2 {
+		numLiquidations := size
+		if numLiquidations > len(liquidationArgs) {
+			numLiquidations = len(liquidationArgs)
+		}
+		data, err := lotp.orderBookABI.Pack("executeBatch", liquidationArgs[:numLiquidations], matchArgs[:size-numLiquidations])
+		if err != nil {
+			log.Error("abi.Pack failed", "method", "executeBatch", "err", err)
+			return 0, err
+		}
+		gas, revert, err := lotp.estimateGas("executeBatch", key.signer.Address(), lotp.orderBookContractAddress, data, lotp.remainingGasBudget)
+		if errors.Is(err, errGasExceedsCap) {
+			continue
+		}
+		if err != nil {
+			log.Error("ExecuteBatch - estimateGas failed", "liquidations", numLiquidations, "matches", size-numLiquidations, "err", err)
+			return 0, err
+		}
+		// the batch pays the fee of its most important execution
+		kind := matchedOrdersTx
+		if numLiquidations > 0 {
+			kind = liquidationTx
+		}
+		txHash, err := lotp.sendLocalTx(kind, key, lotp.orderBookContractAddress, data, gas)
+		log.Info("ExecuteBatch", "signer", key.signer.Address(), "liquidations", numLiquidations, "matches", size-numLiquidations, "deferred", total-size, "gas", gas, "txHash", txHash.String(), "err", err)
+		if err != nil {
+			revert()
+			return 0, err
+		}
+		if size < total {
+			return size, &batchCutError{sent: size}
+		}
+		return size, nil
+	}
+	return 0, ErrGasBudgetExhausted
+}
+
+func (lotp *limitOrderTxProcessor) executeLocalTx(kind txKind, contract common.Address, contractABI abi.ABI, method string, args ...interface{}) (common.Hash, error) {
 	data, err := contractABI.Pack(method, args...)
 	if err != nil {
 		log.Error("abi.Pack failed", "method", method, "args", args, "err", err)
-		return txHash, err
+		return common.Hash{}, err
 	}
-	key, err := crypto.HexToECDSA(lotp.validatorPrivateKey) // admin private key
+	key := lotp.validatorKeys.pick(lotp.backend.CurrentHeader().Number.Uint64())
+	lotp.mu.Lock()
+	defer lotp.mu.Unlock()
+	gas, revert, err := lotp.estimateGas(method, key.signer.Address(), contract, data, orderBookTxGasLimit)
 	if err != nil {
-		log.Error("HexToECDSA failed", "err", err)
-		return txHash, err
+		var preflightErr *PreflightError
+		if errors.As(err, &preflightErr) {
+			if lotp.preflightEnabled {
+				// the tx would fail on-chain
+				orderBookPreflightFailuresCounter.Inc(1)
+				return common.Hash{}, err
+			}
+			log.Warn("tx reverted in the simulation, sending it with the gas it used", "method", method, "gas", preflightErr.Gas, "err", err)
+		} else {
+			log.Warn("estimateGas failed, using the max gas limit", "method", method, "err", err)
+		}
+		gas, revert = gasOfFailedEstimate(err), func() {}
 	}
-	txFee := lotp.getTransactionFee()
-	tx := types.NewTransaction(nonce, contract, big.NewInt(0), 1500000, txFee, data)
-	signer := types.NewLondonSigner(lotp.backend.ChainConfig().ChainID)
-	signedTx, err := types.SignTx(tx, signer, key)
+	txHash, err := lotp.sendLocalTx(kind, key, contract, data, gas)
 	if err != nil {
-		log.Error("types.SignTx failed", "err", err)
+		revert()
+	}
+	return txHash, err
+}
+
+// sendLocalTx signs the tx and adds it to the orderbook txs of the pool, within the remaining gas budget
+// assumes that lotp.mu is held
+func (lotp *limitOrderTxProcessor) sendLocalTx(kind txKind, key *validatorKey, contract common.Address, data []byte, gas uint64) (common.Hash, error) {
+	var txHash common.Hash
+	if gas > lotp.remainingGasBudget {
+		return txHash, ErrGasBudgetExhausted
+	}
+	signerAddress := key.signer.Address()
+	nonce := lotp.txPool.GetOrderBookTxNonce(signerAddress) // every key has its own nonce
+	txFee := lotp.getTransactionFee(kind)
+	tx := types.NewTransaction(nonce, contract, big.NewInt(0), gas, txFee, data)
+	signedTx, err := key.signer.SignTx(tx, lotp.backend.ChainConfig().ChainID)
+	if err != nil {
+		log.Error("SignTx failed", "signer", signerAddress, "err", err)
 		return txHash, err
 	}
 	txHash = signedTx.Hash()
 	err = lotp.txPool.AddOrderBookTx(signedTx)
 	if err != nil {
-		log.Error("lop.txPool.AddOrderBookTx failed", "err", err, "tx", signedTx.Hash().String(), "nonce", nonce)
+		log.Error("lop.txPool.AddOrderBookTx failed", "err", err, "tx", signedTx.Hash().String(), "signer", signerAddress, "nonce", nonce)
 		return txHash, err
 	}
+	lotp.remainingGasBudget -= gas
 
 	return txHash, nil
 }
 
-func (lotp *limitOrderTxProcessor) getTransactionFee() *big.Int {
+// getTransactionFee returns the gas price of an orderbook tx of [kind] in the next block
+func (lotp *limitOrderTxProcessor) getTransactionFee(kind txKind) *big.Int {
 	latest := lotp.backend.CurrentHeader()
 	latestBlockNumber := latest.Number.Uint64()
 
-	// if the fee is already calculated for this block, then return it
-	if lotp.validatorTxFeeConfig.blockNumber == latestBlockNumber {
-		return lotp.validatorTxFeeConfig.baseFeeEstimate
+	// the suggested price and the block gas cost are only calculated once per block
+	if lotp.validatorTxFeeConfig.blockNumber != latestBlockNumber || lotp.validatorTxFeeConfig.suggestedPrice == nil {
+		suggestedPrice, err := lotp.backend.SuggestPrice(context.Background())
+		if err != nil {
+			log.Error("getTransactionFee - SuggestPrice failed", "err", err)
+			return big.NewInt(65_000000000) // hardcoded to 65 gwei
+		}
+
+		var blockGasCost *big.Int
+		feeConfig, _, err := lotp.backend.GetFeeConfigAt(latest)
+		if err != nil {
+			log.Error("getTransactionFee - GetFeeConfigAt failed", "err", err)
+			// if feeConfig can't be obtained, then pay for the block gas cost of the latest block
+			blockGasCost = big.NewInt(0)
+			if latest.BlockGasCost != nil {
+				blockGasCost.Set(latest.BlockGasCost)
+			}
+		} else {
+			// assuming pessimistically that the block is being produced within a second of the latest block
+			blockGasCost = dummy.EstimateNextBlockGasCost(feeConfig, latest, latest.Time+1)
+		}
+		lotp.validatorTxFeeConfig = ValidatorTxFeeConfig{suggestedPrice: suggestedPrice, blockGasCost: blockGasCost, blockNumber: latestBlockNumber}
 	}
+	return lotp.feeStrategy.fee(kind, lotp.validatorTxFeeConfig.suggestedPrice, lotp.validatorTxFeeConfig.blockGasCost)
+}
+
+// HandleBlockGasTooLow is called when a block with the orderbook txs failed to be built because they didn't pay for the block gas cost
+func (lotp *limitOrderTxProcessor) HandleBlockGasTooLow() {
+	lotp.feeStrategy.observeBlockGasTooLow()
+}
 
-	baseFeeEstimate, err := lotp.backend.SuggestPrice(context.Background())
+// estimateGas simulates the tx on the state of the run, which has the txs sent before it in the run, and returns the gas
+// it used with a 25% margin (the refunds are up to a fifth of the gas used), capped at [gasCap].
+// The tx stays applied to the state of the run, for the txs after it, unless the returned revert is called.
+// A tx that fails for another reason than the gas cap is returned as a PreflightError.
+// assumes that lotp.mu is held
+func (lotp *limitOrderTxProcessor) estimateGas(method string, from common.Address, contract common.Address, data []byte, gasCap uint64) (uint64, func(), error) {
+	if lotp.runState == nil {
+		return 0, nil, errors.New("the state of the run is not available")
+	}
+	result, revert, err := lotp.simulate(lotp.runState, from, contract, data, gasCap)
+	if errors.Is(err, core.ErrIntrinsicGas) {
+		return 0, nil, errGasExceedsCap
+	}
 	if err != nil {
-		log.Error("getBaseFeeEstimate - SuggestPrice failed", "err", err)
-		return big.NewInt(65_000000000) // hardcoded to 65 gwei
+		return 0, nil, err
 	}
-	// add 10%
-	baseFeeEstimate.Add(baseFeeEstimate, big.NewInt(0).Div(baseFeeEstimate, big.NewInt(10)))
+	gas := result.UsedGas + result.UsedGas/4
+	if gas > gasCap {
+		gas = gasCap
+	}
+	if result.Failed() {
+		revert()
+		if errors.Is(result.Err, vmerrs.ErrOutOfGas) {
+			return 0, nil, errGasExceedsCap
+		}
+		return 0, nil, &PreflightError{Method: method, Failure: NewFailure(revertReason(result)), Gas: gas}
+	}
+	return gas, revert, nil
+}
 
-	feeConfig, _, err := lotp.backend.GetFeeConfigAt(latest)
-	if err != nil {
-		log.Error("getBaseFeeEstimate - GetFeeConfigAt failed", "err", err)
-		// if feeConfig can't be obtained, then add another 10% to the baseFeeEstimate
-		baseFeeEstimate.Add(baseFeeEstimate, big.NewInt(0).Div(baseFeeEstimate, big.NewInt(10)))
-		return baseFeeEstimate
+// gasOfFailedEstimate returns the gas limit of a tx that is sent even though estimateGas failed with [err]. A tx that
+// reverted in the simulation reverts on-chain after about the same gas, so only that much of the gas budget is used by
+// it. The max gas limit is only used when the gas could not be estimated at all.
+func gasOfFailedEstimate(err error) uint64 {
+	var preflightErr *PreflightError
+	if errors.As(err, &preflightErr) {
+		return preflightErr.Gas
 	}
-	// assuming pessimistically that the block is being produced within a second of the latest block
-	// we calculate the block gas cost as the latest block gas cost + the block gas cost step
-	blockGasCost := big.NewInt(0).Add(latest.BlockGasCost, feeConfig.BlockGasCostStep)
+	return orderBookTxGasLimit
+}
 
-	// assuming a minimum gas usage of 200k for a tx, we calculate the tip such that the entire block has an effective tip above the threshold
-	// example calculation for blockGasCost = 10,000, baseFeeEstimate = 60 gwei, tx gas usage = 200,000
-	// tip = (10000 * 60 * 1e9) / 200000 = 3 gwei
-	tip := big.NewInt(0).Div(big.NewInt(0).Mul(blockGasCost, baseFeeEstimate), big.NewInt(200000))
+// PurgeOrderBookTxs also resets the gas budget, as the txs that are created from now on are for the next block
+func (lotp *limitOrderTxProcessor) PurgeOrderBookTxs() {
+	lotp.txPool.PurgeOrderBookTxs()
+	lotp.validatorKeys.refreshRegistrations(lotp.isValidator)
 
-	totalFee := baseFeeEstimate.Add(baseFeeEstimate, tip)
+	latest := lotp.backend.CurrentHeader()
+	gasLimit := latest.GasLimit
+	if feeConfig, _, err := lotp.backend.GetFeeConfigAt(latest); err == nil {
+		gasLimit = feeConfig.GasLimit.Uint64()
+	}
+	runState, err := lotp.newRunState()
+	if err != nil {
+		log.Error("PurgeOrderBookTxs - error in getting the state at the head", "err", err)
+	}
 
-	lotp.validatorTxFeeConfig.baseFeeEstimate = totalFee
-	lotp.validatorTxFeeConfig.blockNumber = latestBlockNumber
-	return totalFee
+	lotp.mu.Lock()
+	defer lotp.mu.Unlock()
+	lotp.remainingGasBudget = gasLimit * lotp.gasBudgetPercent / 100
+	lotp.runState = runState
+	lotp.txPool.SetOrderBookGasBudget(lotp.remainingGasBudget)
 }
 
-func (lotp *limitOrderTxProcessor) PurgeOrderBookTxs() {
-	lotp.txPool.PurgeOrderBookTxs()
+// isValidator reads OrderBook.isValidator at the last accepted block
+func (lotp *limitOrderTxProcessor) isValidator(address common.Address) (bool, error) {
+	data, err := lotp.orderBookABI.Pack("isValidator", address)
+	if err != nil {
+		return false, err
+	}
+	input := hexutil.Bytes(data)
+	args := ethapi.TransactionArgs{To: &lotp.orderBookContractAddress, Data: &input}
+	result, err := ethapi.DoCall(context.Background(), lotp.backend, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, lotp.backend.RPCEVMTimeout(), lotp.backend.RPCGasCap())
+	if err != nil {
+		return false, err
+	}
+	if result.Err != nil {
+		return false, result.Err
+	}
+	values, err := lotp.orderBookABI.Unpack("isValidator", result.Return())
+	if err != nil {
+		return false, err
+	}
+	return values[0].(bool), nil
 }
 
 func (lotp *limitOrderTxProcessor) GetOrderBookTxsCount() uint64 {
@@ -247,20 +503,6 @@ func getOrderBookContractCallMethod(tx *types.Transaction, orderBookABI abi.ABI,
 	}
 }
 
-func getAddressFromPrivateKey(key string) (common.Address, error) {
-	privateKey, err := crypto.HexToECDSA(key) // admin private key
-	if err != nil {
-		return common.Address{}, err
-	}
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return common.Address{}, errors.New("unable to get address from private key")
-	}
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
-	return address, nil
-}
-
 func (lotp *limitOrderTxProcessor) UpdateMetrics(block *types.Block) {
 	// defer func(start time.Time) { log.Info("limitOrderTxProcessor.UpdateMetrics", "time", time.Since(start)) }(time.Now())
 
@@ -281,6 +523,8 @@ func (lotp *limitOrderTxProcessor) UpdateMetrics(block *types.Block) {
 	timestamp := new(big.Int).SetUint64(block.Header().Time)
 	signer := types.MakeSigner(lotp.backend.ChainConfig(), bigblock, timestamp.Uint64())
 
+	// gas used by the orderbook txs of this validator, it is 0 if the block was built by another validator
+	orderBookGasUsed := uint64(0)
 	for i := 0; i < len(txs); i++ {
 		tx := txs[i]
 		receipt := receipts[i]
@@ -297,7 +541,9 @@ func (lotp *limitOrderTxProcessor) UpdateMetrics(block *types.Block) {
 			continue
 		}
 
-		if from == lotp.validatorAddress {
+		if key := lotp.validatorKeys.get(from); key != nil {
+			lotp.validatorKeys.recordReceipt(key, receipt.Status == 1, block.NumberU64())
+			orderBookGasUsed += receipt.GasUsed
 			if receipt.Status == 0 {
 				orderBookTransactionsFailureTotalCounter.Inc(1)
 			} else if receipt.Status == 1 {
@@ -335,6 +581,7 @@ func (lotp *limitOrderTxProcessor) UpdateMetrics(block *types.Block) {
 			metrics.GetOrRegisterHistogram(gasUsageMetric, nil, sampler).Update(int64(receipt.GasUsed))
 		}
 	}
+	lotp.feeStrategy.observeBlock(orderBookGasUsed)
 }
 
 func EncodeLimitOrder(order LimitOrder) ([]byte, error) {
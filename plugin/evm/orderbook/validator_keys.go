@@ -0,0 +1,109 @@
+package orderbook
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// a key is taken out of rotation when this many of its orderbook txs fail in a row...
+	maxConsecutiveKeyFailures = 3
+	// ...and put back after this many blocks
+	keyFailoverCooldownBlocks uint64 = 50
+)
+
+type validatorKey struct {
+	signer ValidatorSigner
+	// registered is true once the key is known to be registered as a validator in the orderbook
+	registered          bool
+	consecutiveFailures int
+	disabledUntil       uint64
+}
+
+func (key *validatorKey) isHealthy(blockNumber uint64) bool {
+	return key.registered && blockNumber >= key.disabledUntil
+}
+
+// validatorKeys shards the orderbook txs of the validator across its keys in round robin.
+// Every key has its own nonce, so a tx that is stuck or keeps failing for one key doesn't hold up the txs of the others.
+// The keys are picked by the matching pipeline while the receipts are recorded by UpdateMetrics in its own goroutine.
+type validatorKeys struct {
+	// mu guards the state of the keys and next
+	mu   sync.Mutex
+	keys []*validatorKey
+	next int
+}
+
+func newValidatorKeys(signers []ValidatorSigner) *validatorKeys {
+	keys := make([]*validatorKey, 0, len(signers))
+	for _, signer := range signers {
+		keys = append(keys, &validatorKey{signer: signer})
+	}
+	return &validatorKeys{keys: keys}
+}
+
+// refreshRegistrations checks the keys that are not known to be registered yet
+func (vk *validatorKeys) refreshRegistrations(isValidator func(address common.Address) (bool, error)) {
+	vk.mu.Lock()
+	defer vk.mu.Unlock()
+	for _, key := range vk.keys {
+		if key.registered {
+			continue
+		}
+		registered, err := isValidator(key.signer.Address())
+		if err != nil {
+			// the key is used as before, if it isn't a validator its txs will fail and it will be taken out of rotation
+			log.Warn("unable to check if the validator key is registered in the orderbook", "address", key.signer.Address(), "err", err)
+			registered = true
+		}
+		if !registered {
+			log.Warn("validator key is not registered in the orderbook, skipping it", "address", key.signer.Address())
+		}
+		key.registered = registered
+	}
+}
+
+// pick returns the key for the next tx. If no key is healthy, it falls back to all the keys rather than not sending any txs.
+func (vk *validatorKeys) pick(blockNumber uint64) *validatorKey {
+	vk.mu.Lock()
+	defer vk.mu.Unlock()
+	for i := 0; i < len(vk.keys); i++ {
+		key := vk.keys[(vk.next+i)%len(vk.keys)]
+		if key.isHealthy(blockNumber) {
+			vk.next = (vk.next + i + 1) % len(vk.keys)
+			return key
+		}
+	}
+	key := vk.keys[vk.next]
+	vk.next = (vk.next + 1) % len(vk.keys)
+	return key
+}
+
+// get returns the key with [address], nil if it isn't one of the validator's keys
+func (vk *validatorKeys) get(address common.Address) *validatorKey {
+	for _, key := range vk.keys {
+		if key.signer.Address() == address {
+			return key
+		}
+	}
+	return nil
+}
+
+// recordReceipt takes the key out of rotation if its txs keep failing
+func (vk *validatorKeys) recordReceipt(key *validatorKey, success bool, blockNumber uint64) {
+	vk.mu.Lock()
+	defer vk.mu.Unlock()
+	if success {
+		key.consecutiveFailures = 0
+		return
+	}
+	key.consecutiveFailures++
+	if key.consecutiveFailures >= maxConsecutiveKeyFailures && len(vk.keys) > 1 {
+		log.Error("validator key failed too many orderbook txs in a row, taking it out of rotation", "address", key.signer.Address(), "failures", key.consecutiveFailures, "until", blockNumber+keyFailoverCooldownBlocks)
+		validatorKeyFailoverCounter.Inc(1)
+		key.consecutiveFailures = 0
+		key.disabledUntil = blockNumber + keyFailoverCooldownBlocks
+	}
+}
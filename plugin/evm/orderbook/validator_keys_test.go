@@ -0,0 +1,100 @@
+package orderbook
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatorKeys(t *testing.T) {
+	newKeys := func(n int) (*validatorKeys, []common.Address) {
+		signers := []ValidatorSigner{}
+		addresses := []common.Address{}
+		for i := 0; i < n; i++ {
+			key, _ := crypto.GenerateKey()
+			signer, _ := NewPrivateKeySigner(common.Bytes2Hex(crypto.FromECDSA(key)))
+			signers = append(signers, signer)
+			addresses = append(addresses, signer.Address())
+		}
+		return newValidatorKeys(signers), addresses
+	}
+	allRegistered := func(address common.Address) (bool, error) { return true, nil }
+	pickN := func(vk *validatorKeys, n int, blockNumber uint64) []common.Address {
+		picked := []common.Address{}
+		for i := 0; i < n; i++ {
+			picked = append(picked, vk.pick(blockNumber).signer.Address())
+		}
+		return picked
+	}
+
+	t.Run("txs are sharded in round robin", func(t *testing.T) {
+		vk, addresses := newKeys(3)
+		vk.refreshRegistrations(allRegistered)
+		assert.Equal(t, []common.Address{addresses[0], addresses[1], addresses[2], addresses[0]}, pickN(vk, 4, 1))
+	})
+	t.Run("unregistered keys are skipped", func(t *testing.T) {
+		vk, addresses := newKeys(3)
+		vk.refreshRegistrations(func(address common.Address) (bool, error) { return address != addresses[1], nil })
+		assert.Equal(t, []common.Address{addresses[0], addresses[2], addresses[0]}, pickN(vk, 3, 1))
+
+		// registration is checked again until the key is registered
+		vk.refreshRegistrations(allRegistered)
+		assert.Equal(t, []common.Address{addresses[1], addresses[2], addresses[0]}, pickN(vk, 3, 1))
+	})
+	t.Run("keys are used if the registration can't be checked", func(t *testing.T) {
+		vk, addresses := newKeys(2)
+		vk.refreshRegistrations(func(address common.Address) (bool, error) { return false, errors.New("no state") })
+		assert.Equal(t, []common.Address{addresses[0], addresses[1]}, pickN(vk, 2, 1))
+	})
+	t.Run("failing key is taken out of rotation until the cooldown is over", func(t *testing.T) {
+		vk, addresses := newKeys(2)
+		vk.refreshRegistrations(allRegistered)
+		failing := vk.get(addresses[0])
+		for i := 0; i < maxConsecutiveKeyFailures-1; i++ {
+			vk.recordReceipt(failing, false, 10)
+		}
+		// a success resets the count
+		vk.recordReceipt(failing, true, 10)
+		assert.Equal(t, []common.Address{addresses[0], addresses[1]}, pickN(vk, 2, 11))
+
+		for i := 0; i < maxConsecutiveKeyFailures; i++ {
+			vk.recordReceipt(failing, false, 10)
+		}
+		assert.Equal(t, []common.Address{addresses[1], addresses[1]}, pickN(vk, 2, 11))
+		assert.Equal(t, addresses[0], vk.pick(10+keyFailoverCooldownBlocks).signer.Address())
+	})
+	t.Run("all keys are used when none is healthy", func(t *testing.T) {
+		vk, addresses := newKeys(2)
+		vk.refreshRegistrations(func(address common.Address) (bool, error) { return false, nil })
+		assert.Equal(t, []common.Address{addresses[0], addresses[1], addresses[0]}, pickN(vk, 3, 1))
+	})
+	t.Run("a single key is never taken out of rotation", func(t *testing.T) {
+		vk, addresses := newKeys(1)
+		vk.refreshRegistrations(allRegistered)
+		for i := 0; i < maxConsecutiveKeyFailures; i++ {
+			vk.recordReceipt(vk.get(addresses[0]), false, 10)
+		}
+		assert.True(t, vk.get(addresses[0]).isHealthy(11))
+		assert.Nil(t, vk.get(common.HexToAddress("0x1")))
+	})
+	t.Run("receipts are recorded while keys are picked", func(t *testing.T) {
+		vk, addresses := newKeys(2)
+		vk.refreshRegistrations(allRegistered)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		// like UpdateMetrics, in its own goroutine
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				vk.recordReceipt(vk.get(addresses[0]), false, 10)
+			}
+		}()
+		pickN(vk, 100, 11)
+		wg.Wait()
+		assert.False(t, vk.get(addresses[0]).isHealthy(11))
+	})
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/ava-labs/subnet-evm/peer"
 	"github.com/ava-labs/subnet-evm/plugin/evm/message"
 	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 	"github.com/ava-labs/subnet-evm/rpc"
 	statesyncclient "github.com/ava-labs/subnet-evm/sync/client"
 	"github.com/ava-labs/subnet-evm/sync/client/stats"
@@ -421,6 +422,11 @@ func (vm *VM) Initialize(
 		vm.chainConfig.UpgradeConfig = upgradeConfig
 	}
 
+	// the hubble precompiles select their behaviour by block timestamp from the hubbleVersions of the chain config
+	if err := bibliophile.VerifyChainConfigVersions(vm.chainConfig); err != nil {
+		return fmt.Errorf("invalid hubble versions: %w", err)
+	}
+
 	// create genesisHash after applying upgradeBytes in case
 	// upgradeBytes modifies genesis.
 	vm.genesisHash = vm.ethConfig.Genesis.ToBlock().Hash() // must create genesis hash before [vm.readLastAccepted]
@@ -504,6 +510,12 @@ func (vm *VM) initializeChain(lastAcceptedHash common.Hash, ethConfig ethconfig.
 	vm.blockChain = vm.eth.BlockChain()
 	vm.miner = vm.eth.Miner()
 
+	if err := verifyStorageLayouts(vm.chainConfig, vm.blockChain, vm.config.SkipStorageLayoutCheck); err != nil {
+		return err
+	}
+	if err := verifyStateUpgrades(vm.chainConfig, vm.blockChain); err != nil {
+		return err
+	}
 	vm.limitOrderProcesser = vm.NewLimitOrderProcesser()
 	vm.eth.Start()
 	return vm.initChainState(vm.blockChain.LastAcceptedBlock())
@@ -709,6 +721,7 @@ func (vm *VM) buildBlockWithContext(ctx context.Context, proposerVMBlockCtx *blo
 		if vm.txPool.GetOrderBookTxsCount() > 0 && strings.Contains(err.Error(), "BLOCK_GAS_TOO_LOW") {
 			// orderbook txs from the validator were part of the block that failed to be generated because of low block gas
 			orderbook.BuildBlockFailedWithLowBlockGasCounter.Inc(1)
+			vm.limitOrderProcesser.HandleBlockGasTooLow()
 			log.Error("buildBlock - GenerateBlock failed with low gas cost", "err", err, "orderbookTxsCount", vm.txPool.GetOrderBookTxsCount())
 		} else {
 			log.Error("buildBlock - GenerateBlock failed", "err", err)
@@ -843,7 +856,7 @@ func newHandler(name string, service interface{}, lockOption ...commonEng.LockOp
 }
 
 // CreateHandlers makes new http handlers that can handle API calls
-func (vm *VM) CreateHandlers(context.Context) (map[string]*commonEng.HTTPHandler, error) {
+func (vm *VM) CreateHandlers(ctx context.Context) (map[string]*commonEng.HTTPHandler, error) {
 	handler := rpc.NewServer(vm.config.APIMaxDuration.Duration)
 	enabledAPIs := vm.config.EthAPIs()
 	if err := attachEthService(handler, vm.eth.APIs(), enabledAPIs); err != nil {
@@ -880,7 +893,14 @@ func (vm *VM) CreateHandlers(context.Context) (map[string]*commonEng.HTTPHandler
 		}
 	}
 	if vm.config.TestingApiEnabled {
-		if err := handler.RegisterName("testing", vm.limitOrderProcesser.GetTestingAPI()); err != nil {
+		var stateOverrides *core.TestingStateOverrides
+		if vm.config.TestingStateOverridesEnabled {
+			if err := verifySingleValidator(ctx, vm.ctx.ValidatorState, vm.ctx.SubnetID); err != nil {
+				return nil, fmt.Errorf("cannot enable testing state overrides: %w", err)
+			}
+			stateOverrides = vm.blockChain.EnableTestingStateOverrides()
+		}
+		if err := handler.RegisterName("testing", vm.limitOrderProcesser.GetTestingAPI(stateOverrides)); err != nil {
 			return nil, err
 		}
 	}
@@ -1034,9 +1054,9 @@ func attachEthService(handler *rpc.Server, apis []rpc.API, names []string) error
 }
 
 func (vm *VM) NewLimitOrderProcesser() LimitOrderProcesser {
-	validatorPrivateKey, err := loadPrivateKeyFromFile(vm.config.ValidatorPrivateKeyFile)
+	validatorSigners, err := vm.newValidatorSigners()
 	if err != nil {
-		panic(fmt.Sprint("please specify correct path for validator-private-key-file in chain.json ", err))
+		panic(fmt.Sprint("unable to load the validator signer, please check the validator key config in chain.json ", err))
 	}
 	return NewLimitOrderProcesser(
 		vm.ctx,
@@ -1046,13 +1066,69 @@ func (vm *VM) NewLimitOrderProcesser() LimitOrderProcesser {
 		vm.eth.APIBackend,
 		vm.blockChain,
 		vm.hubbleDB,
-		validatorPrivateKey,
+		validatorSigners,
 		vm.config.IsValidator,
 		vm.config.TradingAPIEnabled,
+		vm.config.OrderBookSequencingCheckEnabled,
+		vm.config.OrderBookSequencingCheckReject,
+		vm.config.OrderBookGasBudgetPercent,
+		orderbook.TxPriorityPolicy(vm.config.OrderBookTxPriorityPolicy),
+		vm.config.OrderBookBatchExecutionEnabled,
+		orderbook.TxFeeCaps(vm.config.OrderBookTxFeeCaps),
+		vm.config.OrderBookPreflightEnabled,
+		vm.warpBackend,
+		vm.config.OrderBookCheckpointInterval,
 	)
 }
 
-func loadPrivateKeyFromFile(keyFile string) (string, error) {
+// newValidatorSigners returns the signers of the orderbook txs: the accounts of an external signer, encrypted keystores or,
+// if neither is configured, the plaintext validator-private-key-file(s)
+func (vm *VM) newValidatorSigners() ([]orderbook.ValidatorSigner, error) {
+	signers := []orderbook.ValidatorSigner{}
+	if vm.config.ValidatorExternalSigner != "" {
+		addresses := vm.config.ValidatorSignerAddresses
+		if vm.config.ValidatorSignerAddress != "" || len(addresses) == 0 {
+			// an empty address picks the only account of the signer
+			addresses = append([]string{vm.config.ValidatorSignerAddress}, addresses...)
+		}
+		for _, address := range addresses {
+			signer, err := orderbook.NewExternalSigner(vm.config.ValidatorExternalSigner, common.HexToAddress(address))
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, signer)
+		}
+		return signers, nil
+	}
+	if vm.config.ValidatorKeystoreFile != "" {
+		passphrase, err := loadSecretFromFile(vm.config.ValidatorKeystorePassphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, keyFile := range append([]string{vm.config.ValidatorKeystoreFile}, vm.config.ValidatorKeystoreFiles...) {
+			signer, err := orderbook.NewKeystoreSigner(keyFile, passphrase)
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, signer)
+		}
+		return signers, nil
+	}
+	for _, keyFile := range append([]string{vm.config.ValidatorPrivateKeyFile}, vm.config.ValidatorPrivateKeyFiles...) {
+		validatorPrivateKey, err := loadSecretFromFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := orderbook.NewPrivateKeySigner(validatorPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+func loadSecretFromFile(keyFile string) (string, error) {
 	key, err := ioutil.ReadFile(keyFile)
 	if err != nil {
 		return "", err
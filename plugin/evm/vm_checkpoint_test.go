@@ -0,0 +1,75 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook/checkpoint"
+	"github.com/ava-labs/subnet-evm/warp/aggregator"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderBookCheckpointSignedOnAccept(t *testing.T) {
+	require := require.New(t)
+	issuer, vm, _, _ := GenesisVM(t, true, genesisJSONSubnetEVM, `{"order-book-checkpoint-interval": 1}`, "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+	vm.ctx.ValidatorState = &validators.TestState{
+		GetCurrentHeightF: func(ctx context.Context) (uint64, error) {
+			return 10, nil
+		},
+		GetSubnetIDF: func(ctx context.Context, chainID ids.ID) (ids.ID, error) {
+			return vm.ctx.SubnetID, nil
+		},
+		GetValidatorSetF: func(ctx context.Context, height uint64, subnetID ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+			return map[ids.NodeID]*validators.GetValidatorOutput{
+				vm.ctx.NodeID: {NodeID: vm.ctx.NodeID, PublicKey: vm.ctx.PublicKey, Weight: 100},
+			}, nil
+		},
+	}
+
+	tx, err := types.SignTx(
+		types.NewTransaction(0, testEthAddrs[1], big.NewInt(1), 21000, big.NewInt(225*params.GWei), nil),
+		types.LatestSignerForChainID(vm.chainConfig.ChainID),
+		testKeys[0],
+	)
+	require.NoError(err)
+	require.NoError(vm.txPool.AddRemotesSync([]*types.Transaction{tx})[0])
+	vm.clock.Set(vm.clock.Time().Add(2 * time.Second))
+	<-issuer
+	blk, err := vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk.Verify(context.Background()))
+	require.NoError(vm.SetPreference(context.Background(), blk.ID()))
+	require.NoError(blk.Accept(context.Background()))
+	vm.blockChain.DrainAcceptorQueue()
+
+	api := vm.limitOrderProcesser.GetOrderBookAPI()
+	var latest *orderbook.CheckpointResponse
+	require.Eventually(func() bool {
+		latest, err = api.GetLatestCheckpoint(context.Background())
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(blk.Height(), latest.BlockNumber)
+	require.Equal(common.Hash(blk.ID()), latest.BlockHash)
+
+	// the checkpoint is aggregated like any warp message, and verifies against the validators of the chain
+	unsignedMessage, err := vm.warpBackend.GetMessage(latest.MessageID)
+	require.NoError(err)
+	signatureBackend := testSignatureBackend{vm.ctx.NodeID: vm.warpBackend}
+	aggregated, err := aggregator.NewAggregator(vm.ctx.SubnetID, vm.ctx.ValidatorState, signatureBackend).AggregateSignatures(context.Background(), unsignedMessage, params.WarpDefaultQuorumNumerator)
+	require.NoError(err)
+	signed, err := checkpoint.VerifyCheckpoint(context.Background(), aggregated.Message, vm.ctx.NetworkID, vm.ctx.ChainID, vm.ctx.ValidatorState, 10, params.WarpDefaultQuorumNumerator, params.WarpQuorumDenominator)
+	require.NoError(err)
+	require.Equal(latest.Root, signed.Root)
+	require.Equal(latest.BlockNumber, signed.BlockNumber)
+}
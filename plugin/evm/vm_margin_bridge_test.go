@@ -0,0 +1,315 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
+	"github.com/ava-labs/avalanchego/snow/choices"
+	commonEng "github.com/ava-labs/avalanchego/snow/engine/common"
+	"github.com/ava-labs/avalanchego/snow/engine/snowman/block"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/utils/crypto/bls"
+	"github.com/ava-labs/avalanchego/vms/components/chain"
+	avalancheWarp "github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	"github.com/ava-labs/subnet-evm/core"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/marginbridge"
+	subnetEVMUtils "github.com/ava-labs/subnet-evm/utils"
+	predicateutils "github.com/ava-labs/subnet-evm/utils/predicate"
+	warpBackend "github.com/ava-labs/subnet-evm/warp"
+	"github.com/ava-labs/subnet-evm/warp/aggregator"
+	warpPayload "github.com/ava-labs/subnet-evm/warp/payload"
+	"github.com/ava-labs/subnet-evm/x/warp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testWarpValidator is a validator of a chain, that signs the warp messages of the chain with its in-memory warp backend
+type testWarpValidator struct {
+	nodeID    ids.NodeID
+	publicKey *bls.PublicKey
+	backend   warpBackend.WarpBackend
+}
+
+func newTestWarpValidator(t *testing.T, networkID uint32, chainID ids.ID) *testWarpValidator {
+	secretKey, err := bls.NewSecretKey()
+	require.NoError(t, err)
+	snowCtx := snow.DefaultContextTest()
+	snowCtx.NetworkID = networkID
+	snowCtx.ChainID = chainID
+	snowCtx.WarpSigner = avalancheWarp.NewSigner(secretKey, networkID, chainID)
+	return &testWarpValidator{
+		nodeID:    ids.GenerateTestNodeID(),
+		publicKey: bls.PublicFromSecretKey(secretKey),
+		backend:   warpBackend.NewWarpBackend(snowCtx, memdb.New(), 100),
+	}
+}
+
+// testSignatureBackend serves the signatures of the validators from their warp backends, instead of requesting them over the network
+type testSignatureBackend map[ids.NodeID]warpBackend.WarpBackend
+
+func (b testSignatureBackend) FetchWarpSignature(ctx context.Context, nodeID ids.NodeID, unsignedWarpMessage *avalancheWarp.UnsignedMessage) (*bls.Signature, error) {
+	backend, ok := b[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("unknown validator %s", nodeID)
+	}
+	signature, err := backend.GetSignature(unsignedWarpMessage.ID())
+	if err != nil {
+		return nil, err
+	}
+	return bls.SignatureFromBytes(signature[:])
+}
+
+func validatorSet(members ...*testWarpValidator) map[ids.NodeID]*validators.GetValidatorOutput {
+	set := map[ids.NodeID]*validators.GetValidatorOutput{}
+	for _, validator := range members {
+		set[validator.nodeID] = &validators.GetValidatorOutput{NodeID: validator.nodeID, PublicKey: validator.publicKey, Weight: 50}
+	}
+	return set
+}
+
+func getHUSDMargin(vm *VM, trader common.Address) *big.Int {
+	margin := vm.limitOrderProcesser.(*limitOrderProcesser).memoryDb.GetOrderBookData().TraderMap[trader]
+	if margin == nil || margin.Margin.Deposited[orderbook.HUSD] == nil {
+		return big.NewInt(0)
+	}
+	return margin.Margin.Deposited[orderbook.HUSD]
+}
+
+var (
+	marginAccountStubCode = []byte{0x01}
+	husdStubCode          = []byte{0x02}
+)
+
+// marginBridgeGenesisJSON returns a genesis that enables the margin bridge with [husd] and [bridges], and deploys stubs
+// of the MarginAccount and hUSD
+func marginBridgeGenesisJSON(t *testing.T, husd common.Address, bridges []marginbridge.Bridge) string {
+	genesis := &core.Genesis{}
+	require.NoError(t, genesis.UnmarshalJSON([]byte(genesisJSONSubnetEVM)))
+	genesis.Config.GenesisPrecompiles = params.Precompiles{
+		warp.ConfigKey:         warp.NewDefaultConfig(subnetEVMUtils.NewUint64(0)),
+		marginbridge.ConfigKey: marginbridge.NewConfig(subnetEVMUtils.NewUint64(0), husd, bridges),
+	}
+	// the margin is credited in the storage of the MarginAccount, which would be cleared for an empty account
+	genesis.Alloc[orderbook.MarginAccountContractAddress] = core.GenesisAccount{Balance: common.Big0, Code: marginAccountStubCode, Nonce: 1}
+	genesis.Alloc[husd] = core.GenesisAccount{Balance: common.Big0, Code: husdStubCode, Nonce: 1}
+	return mustMarshal(t, genesis)
+}
+
+// withStubStorageLayouts makes the stubs of the MarginAccount and hUSD verify, with layouts that have the state
+// variables that the bibliophile reads at its slot constants, until the end of the test
+func withStubStorageLayouts(t *testing.T) {
+	stubLayout := func(contractName string, code []byte) *bibliophile.CompiledStorageLayout {
+		layout := &bibliophile.CompiledStorageLayout{Contract: contractName, CodeHashes: []common.Hash{crypto.Keccak256Hash(code)}}
+		for _, constant := range bibliophile.SlotConstants {
+			if constant.Contract == contractName {
+				layout.StorageLayout.Storage = append(layout.StorageLayout.Storage, contract.StorageVariable{Label: constant.Label, Slot: strconv.FormatInt(constant.Slot, 10), Offset: constant.Offset})
+			}
+		}
+		return layout
+	}
+	compiledStorageLayouts = func() ([]*bibliophile.CompiledStorageLayout, error) {
+		return []*bibliophile.CompiledStorageLayout{
+			stubLayout(bibliophile.MarginAccountContract, marginAccountStubCode),
+			stubLayout(bibliophile.HUSDContract, husdStubCode),
+		}, nil
+	}
+	t.Cleanup(func() { compiledStorageLayouts = bibliophile.CompiledStorageLayouts })
+}
+
+func TestMarginBridgeStorageLayouts(t *testing.T) {
+	husd := common.HexToAddress("0x0300000000000000000000000000000000000011")
+	genesisJSON := marginBridgeGenesisJSON(t, husd, nil)
+
+	t.Run("the bridge isn't enabled without the layouts of hUSD and the MarginAccount, even if the check is skipped", func(t *testing.T) {
+		vm := &VM{}
+		ctx, dbManager, genesisBytes, issuer, _ := setupGenesis(t, genesisJSON)
+		createValidatorPrivateKeyIfNotExists()
+		err := vm.Initialize(context.Background(), ctx, dbManager, genesisBytes, []byte{}, []byte(`{"skip-storage-layout-check": true}`), issuer, []*commonEng.Fx{}, &commonEng.SenderTest{T: t})
+		require.ErrorContains(t, err, "the margin bridge is enabled, and writes the storage of the MarginAccount: "+orderbook.MarginAccountContractAddress.Hex()+": no compiled storage layout for the code of MarginAccount")
+	})
+
+	t.Run("with the layouts", func(t *testing.T) {
+		withStubStorageLayouts(t)
+		_, vm, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+		require.NoError(t, vm.Shutdown(context.Background()))
+	})
+}
+
+func TestMarginBridgeDepositAndWithdrawal(t *testing.T) {
+	require := require.New(t)
+	sourceSubnetID := ids.GenerateTestID()
+	sourceChainID := ids.GenerateTestID()
+	sourceBridge := common.HexToAddress("0x0b1d9e")
+	trader := testEthAddrs[1]
+	husd := common.HexToAddress("0x0300000000000000000000000000000000000011")
+
+	genesisJSON := marginBridgeGenesisJSON(t, husd, []marginbridge.Bridge{{BlockchainID: common.Hash(sourceChainID), Address: sourceBridge}})
+	withStubStorageLayouts(t)
+	issuer, vm, _, _ := GenesisVM(t, true, genesisJSON, "", "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+
+	// the validators of the source chain sign the deposit once the bridge sends it
+	sourceValidators := []*testWarpValidator{
+		newTestWarpValidator(t, vm.ctx.NetworkID, sourceChainID),
+		newTestWarpValidator(t, vm.ctx.NetworkID, sourceChainID),
+	}
+	vm.ctx.ValidatorState = &validators.TestState{
+		GetCurrentHeightF: func(ctx context.Context) (uint64, error) {
+			return 10, nil
+		},
+		GetSubnetIDF: func(ctx context.Context, chainID ids.ID) (ids.ID, error) {
+			if chainID == sourceChainID {
+				return sourceSubnetID, nil
+			}
+			return vm.ctx.SubnetID, nil
+		},
+		GetValidatorSetF: func(ctx context.Context, height uint64, subnetID ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+			if subnetID == sourceSubnetID {
+				return validatorSet(sourceValidators...), nil
+			}
+			return map[ids.NodeID]*validators.GetValidatorOutput{
+				vm.ctx.NodeID: {NodeID: vm.ctx.NodeID, PublicKey: vm.ctx.PublicKey, Weight: 100},
+			}, nil
+		},
+	}
+
+	deposit, err := marginbridge.PackTransferPayload(marginbridge.TransferPayload{
+		ID:      common.HexToHash("0xd1"),
+		Account: trader,
+		Amount:  big.NewInt(5e6),
+	})
+	require.NoError(err)
+	addressedPayload, err := warpPayload.NewAddressedPayload(sourceBridge, common.Hash(vm.ctx.ChainID), marginbridge.ContractAddress, deposit)
+	require.NoError(err)
+	unsignedDeposit, err := avalancheWarp.NewUnsignedMessage(vm.ctx.NetworkID, sourceChainID, addressedPayload.Bytes())
+	require.NoError(err)
+	signatureBackend := testSignatureBackend{}
+	for _, validator := range sourceValidators {
+		require.NoError(validator.backend.AddMessage(unsignedDeposit))
+		signatureBackend[validator.nodeID] = validator.backend
+	}
+	aggregated, err := aggregator.NewAggregator(sourceSubnetID, vm.ctx.ValidatorState, signatureBackend).AggregateSignatures(context.Background(), unsignedDeposit, params.WarpDefaultQuorumNumerator)
+	require.NoError(err)
+	require.Equal(aggregated.TotalWeight, aggregated.SignatureWeight)
+
+	// deposit
+	{
+		depositInput, err := marginbridge.PackDepositFromWarp()
+		require.NoError(err)
+		depositTx, err := types.SignTx(
+			predicateutils.NewPredicateTx(
+				vm.chainConfig.ChainID,
+				0,
+				&marginbridge.ContractAddress,
+				1_000_000,
+				big.NewInt(225*params.GWei),
+				big.NewInt(params.GWei),
+				common.Big0,
+				depositInput,
+				types.AccessList{},
+				warp.ContractAddress,
+				aggregated.Message.Bytes(),
+			),
+			types.LatestSignerForChainID(vm.chainConfig.ChainID),
+			testKeys[0],
+		)
+		require.NoError(err)
+		require.NoError(vm.txPool.AddRemotesSync([]*types.Transaction{depositTx})[0])
+
+		proposerCtx := &block.Context{PChainHeight: 10}
+		vm.clock.Set(vm.clock.Time().Add(2 * time.Second))
+		<-issuer
+		blk, err := vm.BuildBlockWithContext(context.Background(), proposerCtx)
+		require.NoError(err)
+		require.NoError(blk.(block.WithVerifyContext).VerifyWithContext(context.Background(), proposerCtx))
+		require.Equal(choices.Processing, blk.Status())
+		require.NoError(vm.SetPreference(context.Background(), blk.ID()))
+		require.NoError(blk.Accept(context.Background()))
+		vm.blockChain.DrainAcceptorQueue()
+
+		ethBlock := blk.(*chain.BlockWrapper).Block.(*Block).ethBlock
+		receipts := vm.blockChain.GetReceiptsByHash(ethBlock.Hash())
+		require.Len(receipts, 1)
+		require.Equal(types.ReceiptStatusSuccessful, receipts[0].Status)
+
+		stateDB, err := vm.blockChain.State()
+		require.NoError(err)
+		require.Equal(big.NewInt(5e6), stateDB.GetState(orderbook.MarginAccountContractAddress, bibliophile.MarginStorageSlot(big.NewInt(0), trader)).Big())
+		require.Equal(big.NewInt(5e6), stateDB.GetState(husd, bibliophile.HUSDBalanceStorageSlot(orderbook.MarginAccountContractAddress)).Big())
+		require.Eventually(func() bool { return getHUSDMargin(vm, trader).Cmp(big.NewInt(5e6)) == 0 }, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// withdrawal
+	{
+		recipient := common.HexToAddress("0x0456")
+		withdrawInput, err := marginbridge.PackWithdrawToWarp(marginbridge.WithdrawToWarpInput{
+			DestinationChainID: common.Hash(sourceChainID),
+			Recipient:          recipient,
+			Amount:             big.NewInt(4e6),
+		})
+		require.NoError(err)
+		withdrawTx, err := types.SignTx(
+			types.NewTransaction(0, marginbridge.ContractAddress, common.Big0, 1_000_000, big.NewInt(225*params.GWei), withdrawInput),
+			types.LatestSignerForChainID(vm.chainConfig.ChainID),
+			testKeys[1],
+		)
+		require.NoError(err)
+		require.NoError(vm.txPool.AddRemotesSync([]*types.Transaction{withdrawTx})[0])
+
+		vm.clock.Set(vm.clock.Time().Add(2 * time.Second))
+		<-issuer
+		blk, err := vm.BuildBlock(context.Background())
+		require.NoError(err)
+		require.NoError(blk.Verify(context.Background()))
+		require.NoError(vm.SetPreference(context.Background(), blk.ID()))
+		require.NoError(blk.Accept(context.Background()))
+		vm.blockChain.DrainAcceptorQueue()
+
+		ethBlock := blk.(*chain.BlockWrapper).Block.(*Block).ethBlock
+		receipts := vm.blockChain.GetReceiptsByHash(ethBlock.Hash())
+		require.Len(receipts, 1)
+		require.Equal(types.ReceiptStatusSuccessful, receipts[0].Status)
+		require.Len(receipts[0].Logs, 3)
+		require.Equal(husd, receipts[0].Logs[0].Address)
+		require.Equal(warp.ContractAddress, receipts[0].Logs[1].Address)
+		require.Equal(marginbridge.ContractAddress, receipts[0].Logs[2].Address)
+		stateDB, err := vm.blockChain.State()
+		require.NoError(err)
+		require.Equal(big.NewInt(1e6), stateDB.GetState(husd, bibliophile.HUSDBalanceStorageSlot(orderbook.MarginAccountContractAddress)).Big())
+
+		// the withdrawal is signed by the validators of this chain once accepted, and verifies on the source chain
+		unsignedWithdrawal, err := avalancheWarp.ParseUnsignedMessage(receipts[0].Logs[1].Data)
+		require.NoError(err)
+		signatureBackend := testSignatureBackend{vm.ctx.NodeID: vm.warpBackend}
+		aggregated, err := aggregator.NewAggregator(vm.ctx.SubnetID, vm.ctx.ValidatorState, signatureBackend).AggregateSignatures(context.Background(), unsignedWithdrawal, params.WarpDefaultQuorumNumerator)
+		require.NoError(err)
+		require.NoError(aggregated.Message.Signature.Verify(context.Background(), &aggregated.Message.UnsignedMessage, vm.ctx.NetworkID, vm.ctx.ValidatorState, 10, params.WarpDefaultQuorumNumerator, params.WarpQuorumDenominator))
+
+		addressedPayload, err := warpPayload.ParseAddressedPayload(unsignedWithdrawal.Payload)
+		require.NoError(err)
+		require.Equal(marginbridge.ContractAddress, addressedPayload.SourceAddress)
+		require.Equal(common.Hash(sourceChainID), addressedPayload.DestinationChainID)
+		require.Equal(sourceBridge, addressedPayload.DestinationAddress)
+		withdrawal, err := marginbridge.UnpackTransferPayload(addressedPayload.Payload)
+		require.NoError(err)
+		require.Equal(recipient, withdrawal.Account)
+		require.Equal(big.NewInt(4e6), withdrawal.Amount)
+
+		require.Eventually(func() bool { return getHUSDMargin(vm, trader).Cmp(big.NewInt(1e6)) == 0 }, 5*time.Second, 10*time.Millisecond)
+	}
+}
@@ -0,0 +1,117 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/stretchr/testify/require"
+)
+
+const testingAPIConfig = `{"testing-api-enabled": true, "testing-state-overrides-enabled": true, "pruning-enabled": false, "skip-storage-layout-check": true}`
+
+func TestTestingAPISetMargin(t *testing.T) {
+	require := require.New(t)
+	// the storage of an account without code is dropped at the end of the block
+	genesis := strings.Replace(genesisJSONSubnetEVM, `"alloc":{`, `"alloc":{"`+bibliophile.MARGIN_ACCOUNT_GENESIS_ADDRESS+`": {"balance":"0x0","code":"0x00"}, `, 1)
+	issuer, vm, _, _ := GenesisVM(t, true, genesis, testingAPIConfig, "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+
+	api := vm.limitOrderProcesser.GetTestingAPI(vm.blockChain.EnableTestingStateOverrides())
+	trader := testEthAddrs[1]
+	require.NoError(api.SetMargin(context.Background(), trader, big.NewInt(4e8)))
+	require.NoError(api.SetMargin(context.Background(), trader, big.NewInt(1e9)))
+	// the memory DB is updated right away, the state with the next block
+	require.Equal(big.NewInt(1e9), getHUSDMargin(vm, trader))
+	stateDB, err := vm.blockChain.State()
+	require.NoError(err)
+	require.Equal(0, bibliophile.GetNormalizedMargin(stateDB, trader).Sign())
+
+	tx, err := types.SignTx(
+		types.NewTransaction(0, testEthAddrs[1], big.NewInt(1), 21000, big.NewInt(225*params.GWei), nil),
+		types.LatestSignerForChainID(vm.chainConfig.ChainID),
+		testKeys[0],
+	)
+	require.NoError(err)
+	require.NoError(vm.txPool.AddRemotesSync([]*types.Transaction{tx})[0])
+	vm.clock.Set(vm.clock.Time().Add(2 * time.Second))
+	<-issuer
+	blk, err := vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk.Verify(context.Background()))
+	require.NoError(vm.SetPreference(context.Background(), blk.ID()))
+	require.NoError(blk.Accept(context.Background()))
+	vm.blockChain.DrainAcceptorQueue()
+
+	stateDB, err = vm.blockChain.State()
+	require.NoError(err)
+	require.Equal(big.NewInt(1e9), bibliophile.GetNormalizedMargin(stateDB, trader))
+
+	// the margin is changed by the difference with the memory DB
+	require.NoError(api.SetMargin(context.Background(), trader, big.NewInt(4e8)))
+	require.Equal(big.NewInt(4e8), getHUSDMargin(vm, trader))
+}
+
+func TestTestingAPIValidatesMarket(t *testing.T) {
+	require := require.New(t)
+	_, vm, _, _ := GenesisVM(t, true, genesisJSONSubnetEVM, testingAPIConfig, "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+
+	api := vm.limitOrderProcesser.GetTestingAPI(vm.blockChain.EnableTestingStateOverrides())
+	require.ErrorContains(api.SetPosition(context.Background(), testEthAddrs[1], 0, big.NewInt(1e18), big.NewInt(1e9)), "invalid market 0")
+	_, err := api.FastForwardFunding(context.Background(), 0)
+	require.ErrorContains(err, "invalid market 0")
+	require.ErrorContains(api.SetOraclePrice(context.Background(), -1, big.NewInt(1e6)), "invalid market -1")
+
+	_, err = api.RunMatchingPipeline(context.Background())
+	require.ErrorIs(err, orderbook.ErrNotValidator)
+}
+
+func TestTestingAPIStateOverridesDisabled(t *testing.T) {
+	require := require.New(t)
+	_, vm, _, _ := GenesisVM(t, true, genesisJSONSubnetEVM, `{"testing-api-enabled": true}`, "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+
+	api := vm.limitOrderProcesser.GetTestingAPI(nil)
+	require.ErrorIs(api.SetMargin(context.Background(), testEthAddrs[1], big.NewInt(1e9)), orderbook.ErrStateOverridesDisabled)
+	require.ErrorIs(api.SetPosition(context.Background(), testEthAddrs[1], 0, big.NewInt(1e18), big.NewInt(1e9)), orderbook.ErrStateOverridesDisabled)
+	_, err := api.FastForwardFunding(context.Background(), 0)
+	require.ErrorIs(err, orderbook.ErrStateOverridesDisabled)
+	require.ErrorIs(api.SetOraclePrice(context.Background(), 0, big.NewInt(1e6)), orderbook.ErrStateOverridesDisabled)
+	require.Nil(vm.blockChain.TestingStateOverrides())
+}
+
+func TestVerifySingleValidator(t *testing.T) {
+	subnetID := ids.GenerateTestID()
+	validatorState := func(count int) validators.State {
+		return &validators.TestState{
+			GetCurrentHeightF: func(context.Context) (uint64, error) { return 10, nil },
+			GetValidatorSetF: func(_ context.Context, height uint64, id ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+				require.Equal(t, uint64(10), height)
+				require.Equal(t, subnetID, id)
+				validatorSet := map[ids.NodeID]*validators.GetValidatorOutput{}
+				for i := 0; i < count; i++ {
+					nodeID := ids.GenerateTestNodeID()
+					validatorSet[nodeID] = &validators.GetValidatorOutput{NodeID: nodeID, Weight: 1}
+				}
+				return validatorSet, nil
+			},
+		}
+	}
+	require.NoError(t, verifySingleValidator(context.Background(), validatorState(1), subnetID))
+	require.ErrorContains(t, verifySingleValidator(context.Background(), validatorState(5), subnetID), "the subnet has 5 validators, only a single validator is supported")
+}
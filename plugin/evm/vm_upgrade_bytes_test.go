@@ -19,6 +19,7 @@ import (
 	"github.com/ava-labs/subnet-evm/core/types"
 	"github.com/ava-labs/subnet-evm/metrics"
 	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 	"github.com/ava-labs/subnet-evm/precompile/contracts/txallowlist"
 	"github.com/ava-labs/subnet-evm/utils"
 	"github.com/ava-labs/subnet-evm/vmerrs"
@@ -365,3 +366,57 @@ func TestVMStateUpgrade(t *testing.T) {
 	require.Equal(t, state.GetNonce(newAccount), uint64(1)) // Nonce should be set to 1 when code is set if nonce was 0
 	require.Equal(t, state.GetState(newAccount, storageKey), newAccountUpgrade.Storage[storageKey])
 }
+
+func TestVMHubbleStateUpgradeVerified(t *testing.T) {
+	clearingHouse := common.HexToAddress(bibliophile.CLEARING_HOUSE_GENESIS_ADDRESS)
+	amm := common.Address{42}
+	upgradeConfig := params.UpgradeConfig{
+		StateUpgrades: []params.StateUpgrade{{
+			BlockTimestamp: utils.NewUint64(10),
+			StateUpgradeAccounts: map[common.Address]params.StateUpgradeAccount{
+				amm: {Code: []byte{0x1}},
+			},
+			Hubble: &params.HubbleUpgrade{AddMarkets: []params.HubbleMarket{{AMM: amm, HubbleMarketConfig: params.HubbleMarketConfig{
+				Oracle:             &common.Address{43},
+				MinSizeRequirement: (*math.HexOrDecimal256)(big.NewInt(1e16)),
+			}}}},
+		}},
+	}
+
+	t.Run("an upgrade that doesn't apply to the last accepted state fails the startup", func(t *testing.T) {
+		upgradeBytesJSON := mustMarshal(t, upgradeConfig)
+		vm := &VM{}
+		ctx, dbManager, genesisBytes, issuer, _ := setupGenesis(t, genesisJSONSubnetEVM)
+		createValidatorPrivateKeyIfNotExists()
+		err := vm.Initialize(context.Background(), ctx, dbManager, genesisBytes, []byte(upgradeBytesJSON), []byte{}, issuer, []*commonEng.Fx{}, &commonEng.SenderTest{T: t})
+		require.ErrorContains(t, err, "StateUpgrade[0] does not apply to the last accepted state: hubble: clearing house "+clearingHouse.Hex()+" is not deployed")
+	})
+
+	t.Run("the accounts of the upgrade are applied before it is verified", func(t *testing.T) {
+		upgradeConfig.StateUpgrades[0].StateUpgradeAccounts[clearingHouse] = params.StateUpgradeAccount{Code: []byte{0x1}}
+		_, vm, _, _ := GenesisVM(t, true, genesisJSONSubnetEVM, "", mustMarshal(t, upgradeConfig))
+		require.NoError(t, vm.Shutdown(context.Background()))
+	})
+}
+
+func TestVMStorageLayoutCheck(t *testing.T) {
+	genesis := &core.Genesis{}
+	require.NoError(t, genesis.UnmarshalJSON([]byte(genesisJSONSubnetEVM)))
+	clearingHouse := common.HexToAddress(bibliophile.CLEARING_HOUSE_GENESIS_ADDRESS)
+	// code that no storage layout was compiled to
+	genesis.Alloc[clearingHouse] = core.GenesisAccount{Balance: common.Big0, Code: []byte{0x01}, Nonce: 1}
+	genesisStr := mustMarshal(t, genesis)
+
+	t.Run("a hubble contract without a compiled storage layout fails the startup", func(t *testing.T) {
+		vm := &VM{}
+		ctx, dbManager, genesisBytes, issuer, _ := setupGenesis(t, genesisStr)
+		createValidatorPrivateKeyIfNotExists()
+		err := vm.Initialize(context.Background(), ctx, dbManager, genesisBytes, []byte{}, []byte{}, issuer, []*commonEng.Fx{}, &commonEng.SenderTest{T: t})
+		require.ErrorContains(t, err, "storage layout check failed, set skip-storage-layout-check to start anyway: no compiled storage layout for the code of the hubble contracts at ["+clearingHouse.Hex()+"]")
+	})
+
+	t.Run("the check can be skipped", func(t *testing.T) {
+		_, vm, _, _ := GenesisVM(t, true, genesisStr, `{"skip-storage-layout-check": true}`, "")
+		require.NoError(t, vm.Shutdown(context.Background()))
+	})
+}
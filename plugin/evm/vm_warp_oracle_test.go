@@ -0,0 +1,162 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/engine/snowman/block"
+	"github.com/ava-labs/avalanchego/snow/validators"
+	"github.com/ava-labs/avalanchego/vms/components/chain"
+	avalancheWarp "github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	"github.com/ava-labs/subnet-evm/core"
+	"github.com/ava-labs/subnet-evm/core/types"
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/warporacle"
+	subnetEVMUtils "github.com/ava-labs/subnet-evm/utils"
+	predicateutils "github.com/ava-labs/subnet-evm/utils/predicate"
+	"github.com/ava-labs/subnet-evm/warp/aggregator"
+	warpPayload "github.com/ava-labs/subnet-evm/warp/payload"
+	"github.com/ava-labs/subnet-evm/x/warp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarpOraclePriceDelivery(t *testing.T) {
+	require := require.New(t)
+	sourceSubnetID := ids.GenerateTestID()
+	sourceChainID := ids.GenerateTestID()
+	publisher := common.HexToAddress("0x0b1d9e")
+	amm := common.HexToAddress("0x0a00")
+	underlying := common.HexToAddress("0x0a55e7")
+
+	genesis := &core.Genesis{}
+	require.NoError(genesis.UnmarshalJSON([]byte(genesisJSONSubnetEVM)))
+	genesis.Config.GenesisPrecompiles = params.Precompiles{
+		warp.ConfigKey:       warp.NewDefaultConfig(subnetEVMUtils.NewUint64(0)),
+		warporacle.ConfigKey: warporacle.NewConfig(subnetEVMUtils.NewUint64(0), []warporacle.Publisher{{BlockchainID: common.Hash(sourceChainID), Address: publisher}}, 3600),
+		// the oracle type readers are used from V3
+		bibliophile.ConfigKey: bibliophile.NewConfig(subnetEVMUtils.NewUint64(0), []bibliophile.VersionUpgrade{{BlockTimestamp: 0, Version: bibliophile.V3}}),
+	}
+	// a market whose AMM reads its underlying price from the warporacle precompile
+	marketsSlot := crypto.Keccak256Hash(common.BigToHash(big.NewInt(bibliophile.AMMS_SLOT)).Bytes())
+	genesis.Alloc[orderbook.ClearingHouseContractAddress] = core.GenesisAccount{Balance: common.Big0, Code: []byte{0x01}, Nonce: 1, Storage: map[common.Hash]common.Hash{
+		common.BigToHash(big.NewInt(bibliophile.AMMS_SLOT)): common.BigToHash(big.NewInt(1)),
+		marketsSlot: amm.Hash(),
+	}}
+	genesis.Alloc[amm] = core.GenesisAccount{Balance: common.Big0, Code: []byte{0x01}, Nonce: 1, Storage: map[common.Hash]common.Hash{
+		common.BigToHash(big.NewInt(bibliophile.ORACLE_SLOT)):           warporacle.ContractAddress.Hash(),
+		common.BigToHash(big.NewInt(bibliophile.UNDERLYING_ASSET_SLOT)): underlying.Hash(),
+	}}
+	genesisJSON, err := genesis.MarshalJSON()
+	require.NoError(err)
+	// the ClearingHouse and AMM stubs have no compiled storage layout
+	issuer, vm, _, _ := GenesisVM(t, true, string(genesisJSON), `{"skip-storage-layout-check": true}`, "")
+	defer func() {
+		require.NoError(vm.Shutdown(context.Background()))
+	}()
+
+	// the validators of the oracle chain sign the prices its publisher sends
+	sourceValidators := []*testWarpValidator{
+		newTestWarpValidator(t, vm.ctx.NetworkID, sourceChainID),
+		newTestWarpValidator(t, vm.ctx.NetworkID, sourceChainID),
+	}
+	vm.ctx.ValidatorState = &validators.TestState{
+		GetCurrentHeightF: func(ctx context.Context) (uint64, error) {
+			return 10, nil
+		},
+		GetSubnetIDF: func(ctx context.Context, chainID ids.ID) (ids.ID, error) {
+			if chainID == sourceChainID {
+				return sourceSubnetID, nil
+			}
+			return vm.ctx.SubnetID, nil
+		},
+		GetValidatorSetF: func(ctx context.Context, height uint64, subnetID ids.ID) (map[ids.NodeID]*validators.GetValidatorOutput, error) {
+			if subnetID == sourceSubnetID {
+				return validatorSet(sourceValidators...), nil
+			}
+			return map[ids.NodeID]*validators.GetValidatorOutput{
+				vm.ctx.NodeID: {NodeID: vm.ctx.NodeID, PublicKey: vm.ctx.PublicKey, Weight: 100},
+			}, nil
+		},
+	}
+
+	// publishPrice returns the warp message of the price, signed by [signers]
+	publishPrice := func(round uint64, signers ...*testWarpValidator) (*aggregator.AggregateSignatureResult, error) {
+		oraclePrice, err := warpPayload.NewOraclePrice(underlying, 1800e6, uint64(vm.clock.Time().Unix()), round)
+		require.NoError(err)
+		addressedPayload, err := warpPayload.NewAddressedPayload(publisher, common.Hash(vm.ctx.ChainID), warporacle.ContractAddress, oraclePrice.Bytes())
+		require.NoError(err)
+		unsignedPrice, err := avalancheWarp.NewUnsignedMessage(vm.ctx.NetworkID, sourceChainID, addressedPayload.Bytes())
+		require.NoError(err)
+		signatureBackend := testSignatureBackend{}
+		for _, validator := range signers {
+			require.NoError(validator.backend.AddMessage(unsignedPrice))
+			signatureBackend[validator.nodeID] = validator.backend
+		}
+		return aggregator.NewAggregator(sourceSubnetID, vm.ctx.ValidatorState, signatureBackend).AggregateSignatures(context.Background(), unsignedPrice, params.WarpDefaultQuorumNumerator)
+	}
+	deliverPrice := func(nonce uint64, message *avalancheWarp.Message) *types.Receipt {
+		input, err := warporacle.PackDeliverPrice()
+		require.NoError(err)
+		tx, err := types.SignTx(
+			predicateutils.NewPredicateTx(
+				vm.chainConfig.ChainID,
+				nonce,
+				&warporacle.ContractAddress,
+				1_000_000,
+				big.NewInt(225*params.GWei),
+				big.NewInt(params.GWei),
+				common.Big0,
+				input,
+				types.AccessList{},
+				warp.ContractAddress,
+				message.Bytes(),
+			),
+			types.LatestSignerForChainID(vm.chainConfig.ChainID),
+			testKeys[0],
+		)
+		require.NoError(err)
+		require.NoError(vm.txPool.AddRemotesSync([]*types.Transaction{tx})[0])
+
+		proposerCtx := &block.Context{PChainHeight: 10}
+		vm.clock.Set(vm.clock.Time().Add(2 * time.Second))
+		<-issuer
+		blk, err := vm.BuildBlockWithContext(context.Background(), proposerCtx)
+		require.NoError(err)
+		require.NoError(blk.(block.WithVerifyContext).VerifyWithContext(context.Background(), proposerCtx))
+		require.NoError(vm.SetPreference(context.Background(), blk.ID()))
+		require.NoError(blk.Accept(context.Background()))
+		vm.blockChain.DrainAcceptorQueue()
+
+		ethBlock := blk.(*chain.BlockWrapper).Block.(*Block).ethBlock
+		receipts := vm.blockChain.GetReceiptsByHash(ethBlock.Hash())
+		require.Len(receipts, 1)
+		return receipts[0]
+	}
+
+	// a single validator holds half of the weight, which is short of the quorum
+	_, err = publishPrice(1, sourceValidators[0])
+	require.ErrorContains(err, "failed to aggregate signature")
+
+	aggregated, err := publishPrice(1, sourceValidators...)
+	require.NoError(err)
+	require.Equal(aggregated.TotalWeight, aggregated.SignatureWeight)
+	receipt := deliverPrice(0, aggregated.Message)
+	require.Equal(types.ReceiptStatusSuccessful, receipt.Status)
+	require.Len(receipt.Logs, 1)
+	require.Equal(warporacle.ContractAddress, receipt.Logs[0].Address)
+
+	stateDB, err := vm.blockChain.State()
+	require.NoError(err)
+	require.Equal([]*big.Int{big.NewInt(1800e6)}, bibliophile.GetUnderlyingPrices(stateDB, bibliophile.GetVersion(vm.chainConfig, stateDB, vm.blockChain.CurrentBlock().Time)))
+
+	// a relayed round is not delivered again
+	receipt = deliverPrice(1, aggregated.Message)
+	require.Equal(types.ReceiptStatusFailed, receipt.Status)
+}
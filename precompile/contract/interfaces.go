@@ -27,6 +27,8 @@ type ChainConfig interface {
 	GetFeeConfig() commontype.FeeConfig
 	// AllowedFeeRecipients returns true if fee recipients are allowed in the genesis.
 	AllowedFeeRecipients() bool
+	// GetHubbleVersions returns the schedule of the behaviour versions of the hubble precompiles in the chain config.
+	GetHubbleVersions() []commontype.HubbleVersion
 }
 
 // StateDB is the interface for accessing EVM state
@@ -60,6 +62,7 @@ type AccessibleState interface {
 	GetStateDB() StateDB
 	GetBlockContext() BlockContext
 	GetSnowContext() *snow.Context
+	GetChainConfig() ChainConfig
 }
 
 // BlockContext defines an interface that provides information to a stateful precompile about the
@@ -36,13 +36,15 @@ type mockAccessibleState struct {
 	state        StateDB
 	blockContext *mockBlockContext
 	snowContext  *snow.Context
+	chainConfig  ChainConfig
 }
 
-func NewMockAccessibleState(state StateDB, blockContext *mockBlockContext, snowContext *snow.Context) *mockAccessibleState {
+func NewMockAccessibleState(state StateDB, blockContext *mockBlockContext, snowContext *snow.Context, chainConfig ChainConfig) *mockAccessibleState {
 	return &mockAccessibleState{
 		state:        state,
 		blockContext: blockContext,
 		snowContext:  snowContext,
+		chainConfig:  chainConfig,
 	}
 }
 
@@ -52,13 +54,17 @@ func (m *mockAccessibleState) GetBlockContext() BlockContext { return m.blockCon
 
 func (m *mockAccessibleState) GetSnowContext() *snow.Context { return m.snowContext }
 
+func (m *mockAccessibleState) GetChainConfig() ChainConfig { return m.chainConfig }
+
 type mockChainState struct {
 	feeConfig            commontype.FeeConfig
 	allowedFeeRecipients bool
+	hubbleVersions       []commontype.HubbleVersion
 }
 
-func (m *mockChainState) GetFeeConfig() commontype.FeeConfig { return m.feeConfig }
-func (m *mockChainState) AllowedFeeRecipients() bool         { return m.allowedFeeRecipients }
+func (m *mockChainState) GetFeeConfig() commontype.FeeConfig            { return m.feeConfig }
+func (m *mockChainState) AllowedFeeRecipients() bool                    { return m.allowedFeeRecipients }
+func (m *mockChainState) GetHubbleVersions() []commontype.HubbleVersion { return m.hubbleVersions }
 
 func NewMockChainState(feeConfig commontype.FeeConfig, allowedFeeRecipients bool) *mockChainState {
 	return &mockChainState{
@@ -66,3 +72,11 @@ func NewMockChainState(feeConfig commontype.FeeConfig, allowedFeeRecipients bool
 		allowedFeeRecipients: allowedFeeRecipients,
 	}
 }
+
+// NewMockChainStateWithHubbleVersions returns a chain config with the schedule [hubbleVersions] of the hubble precompiles
+func NewMockChainStateWithHubbleVersions(hubbleVersions []commontype.HubbleVersion) *mockChainState {
+	return &mockChainState{
+		feeConfig:      commontype.ValidTestFeeConfig,
+		hubbleVersions: hubbleVersions,
+	}
+}
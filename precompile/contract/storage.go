@@ -0,0 +1,127 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package contract
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// StorageLayout is the storage layout of a contract, as solc outputs it with --storage-layout
+// (the storageLayout of a contract in the standard JSON output).
+type StorageLayout struct {
+	Storage []StorageVariable       `json:"storage"`
+	Types   map[string]*StorageType `json:"types"`
+}
+
+// StorageVariable is a state variable of a contract, or a member of a struct
+type StorageVariable struct {
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"`
+	Slot     string `json:"slot"`
+	Type     string `json:"type"`
+}
+
+// StorageType is the type of a state variable in a StorageLayout
+type StorageType struct {
+	Encoding      string            `json:"encoding"`
+	Label         string            `json:"label"`
+	NumberOfBytes string            `json:"numberOfBytes"`
+	Key           string            `json:"key,omitempty"`
+	Value         string            `json:"value,omitempty"`
+	Base          string            `json:"base,omitempty"`
+	Members       []StorageVariable `json:"members,omitempty"`
+}
+
+// Helpers to read the state variables of a Solidity contract from its storage, following the Solidity storage layout.
+// They are used by the storage readers that precompilegen generates from a solc storage layout.
+
+// StorageSlot returns the hash of the slot number [slot]
+func StorageSlot(slot int64) common.Hash {
+	return common.BigToHash(big.NewInt(slot))
+}
+
+// AddToSlot returns the slot [n] slots after [slot], as used for struct members and array elements
+func AddToSlot(slot common.Hash, n *big.Int) common.Hash {
+	if n.Sign() == 0 {
+		return slot
+	}
+	sum := new(big.Int).Add(slot.Big(), n)
+	return common.BigToHash(math.U256(sum))
+}
+
+// MappingSlot returns the slot of the value of [key] in the mapping at [slot]. [key] must be encoded with one of the *Key functions.
+func MappingSlot(key []byte, slot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(key, slot[:])
+}
+
+// ArrayDataSlot returns the first slot of the elements of the dynamic array at [slot], whose length is stored at [slot]
+func ArrayDataSlot(slot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(slot[:])
+}
+
+// AddressKey encodes [key] as the key of a mapping
+func AddressKey(key common.Address) []byte {
+	return common.LeftPadBytes(key.Bytes(), common.HashLength)
+}
+
+// BoolKey encodes [key] as the key of a mapping
+func BoolKey(key bool) []byte {
+	if key {
+		return common.LeftPadBytes([]byte{1}, common.HashLength)
+	}
+	return make([]byte, common.HashLength)
+}
+
+// IntKey encodes a signed or unsigned integer [key] as the key of a mapping, negative numbers in two's complement
+func IntKey(key *big.Int) []byte {
+	return math.U256Bytes(new(big.Int).Set(key))
+}
+
+// FixedBytesKey encodes a bytes1 to bytes32 [key] as the key of a mapping
+func FixedBytesKey(key common.Hash) []byte {
+	return key[:]
+}
+
+// ReadStorage reads the [size] bytes at the byte [offset] of [slot], where [offset] counts from the lower order end
+// of the slot, as solc packs the variables
+func ReadStorage(stateDB StateDB, address common.Address, slot common.Hash, offset int, size int) []byte {
+	value := stateDB.GetState(address, slot)
+	return value[common.HashLength-offset-size : common.HashLength-offset]
+}
+
+// StorageUint decodes an unsigned integer or an enum read with ReadStorage
+func StorageUint(value []byte) *big.Int {
+	return new(big.Int).SetBytes(value)
+}
+
+// StorageInt decodes a signed integer read with ReadStorage from its two's complement
+func StorageInt(value []byte) *big.Int {
+	n := new(big.Int).SetBytes(value)
+	if len(value) > 0 && value[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(value)*8)))
+	}
+	return n
+}
+
+// StorageAddress decodes an address or a contract read with ReadStorage
+func StorageAddress(value []byte) common.Address {
+	return common.BytesToAddress(value)
+}
+
+// StorageBool decodes a bool read with ReadStorage
+func StorageBool(value []byte) bool {
+	return value[len(value)-1] != 0
+}
+
+// StorageFixedBytes decodes a bytes1 to bytes32 read with ReadStorage, left aligned like the key of FixedBytesKey
+func StorageFixedBytes(value []byte) common.Hash {
+	var fixedBytes common.Hash
+	copy(fixedBytes[:], value)
+	return fixedBytes
+}
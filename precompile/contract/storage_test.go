@@ -0,0 +1,60 @@
+// (c) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package contract_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMappingSlot(t *testing.T) {
+	trader := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+
+	// mapping(uint => mapping(address => int256)) at slot 10, as margin in MarginAccount
+	expected := crypto.Keccak256(common.LeftPadBytes(big.NewInt(0).Bytes(), 32), common.LeftPadBytes(big.NewInt(10).Bytes(), 32))
+	expected = crypto.Keccak256(common.LeftPadBytes(trader.Bytes(), 32), expected)
+	slot := contract.MappingSlot(contract.AddressKey(trader), contract.MappingSlot(contract.IntKey(big.NewInt(0)), contract.StorageSlot(10)))
+	require.Equal(t, common.BytesToHash(expected), slot)
+
+	// the third member of a struct in a mapping(bytes32 => struct)
+	orderHash := common.HexToHash("0x1234")
+	base := new(big.Int).SetBytes(crypto.Keccak256(orderHash[:], common.LeftPadBytes(big.NewInt(53).Bytes(), 32)))
+	expected = common.BigToHash(new(big.Int).Add(base, big.NewInt(2))).Bytes()
+	slot = contract.AddToSlot(contract.MappingSlot(contract.FixedBytesKey(orderHash), contract.StorageSlot(53)), big.NewInt(2))
+	require.Equal(t, common.BytesToHash(expected), slot)
+
+	// negative keys are encoded in two's complement
+	require.Equal(t, common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff").Bytes(), contract.IntKey(big.NewInt(-1)))
+	require.Equal(t, common.LeftPadBytes([]byte{1}, 32), contract.BoolKey(true))
+}
+
+func TestReadStorage(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	address := common.HexToAddress("0x0300000000000000000000000000000000000000")
+	slot := contract.StorageSlot(3)
+
+	// an address at offset 0 and an int8 and a bool packed after it
+	owner := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	var value common.Hash
+	copy(value[12:], owner.Bytes())
+	value[11] = 0xfe // int8(-2)
+	value[10] = 1    // true
+	stateDB.SetState(address, slot, value)
+
+	require.Equal(t, owner, contract.StorageAddress(contract.ReadStorage(stateDB, address, slot, 0, 20)))
+	require.Equal(t, big.NewInt(-2), contract.StorageInt(contract.ReadStorage(stateDB, address, slot, 20, 1)))
+	require.Equal(t, big.NewInt(0xfe), contract.StorageUint(contract.ReadStorage(stateDB, address, slot, 20, 1)))
+	require.True(t, contract.StorageBool(contract.ReadStorage(stateDB, address, slot, 21, 1)))
+	require.False(t, contract.StorageBool(contract.ReadStorage(stateDB, address, slot, 22, 1)))
+
+	// bytes4 are left aligned in the decoded hash
+	stateDB.SetState(address, contract.StorageSlot(4), common.BigToHash(big.NewInt(0x12345678)))
+	require.Equal(t, common.HexToHash("0x1234567800000000000000000000000000000000000000000000000000000000"), contract.StorageFixedBytes(contract.ReadStorage(stateDB, address, contract.StorageSlot(4), 0, 4)))
+}
@@ -17,6 +17,8 @@ const (
 	MIN_SIZE_REQUIREMENT_SLOT       int64 = 9
 	ORACLE_SLOT                     int64 = 10
 	UNDERLYING_ASSET_SLOT           int64 = 11
+	NEXT_FUNDING_TIME_SLOT          int64 = 14
+	FUNDING_PERIOD_SLOT             int64 = 15
 	MAX_LIQUIDATION_PRICE_SPREAD    int64 = 17
 	RED_STONE_ADAPTER_SLOT          int64 = 21
 	RED_STONE_FEED_ID_SLOT          int64 = 22
@@ -26,11 +28,6 @@ const (
 	TEST_ORACLE_PRICES_MAPPING_SLOT int64 = 53
 )
 
-var (
-	// Date and time (GMT): riday, 9 June 2023 14:40:00
-	V2ActivationDate *big.Int = new(big.Int).SetInt64(1686321600)
-)
-
 // AMM State
 func getLastPrice(stateDB contract.StateDB, market common.Address) *big.Int {
 	return stateDB.GetState(market, common.BigToHash(big.NewInt(MARK_PRICE_TWAP_DATA_SLOT))).Big()
@@ -64,6 +61,18 @@ func GetMinSizeRequirement(stateDB contract.StateDB, marketID int64) *big.Int {
 	return fromTwosComplement(stateDB.GetState(market, common.BigToHash(big.NewInt(MIN_SIZE_REQUIREMENT_SLOT))).Bytes())
 }
 
+// GetNextFundingTime returns the timestamp at which funding can next be settled for a given market
+func GetNextFundingTime(stateDB contract.StateDB, marketID int64) *big.Int {
+	market := getMarketAddressFromMarketID(marketID, stateDB)
+	return stateDB.GetState(market, common.BigToHash(big.NewInt(NEXT_FUNDING_TIME_SLOT))).Big()
+}
+
+// GetFundingPeriod returns the funding interval (in seconds) for a given market
+func GetFundingPeriod(stateDB contract.StateDB, marketID int64) *big.Int {
+	market := getMarketAddressFromMarketID(marketID, stateDB)
+	return stateDB.GetState(market, common.BigToHash(big.NewInt(FUNDING_PERIOD_SLOT))).Big()
+}
+
 func getOracleAddress(stateDB contract.StateDB, market common.Address) common.Address {
 	return common.BytesToAddress(stateDB.GetState(market, common.BigToHash(big.NewInt(ORACLE_SLOT))).Bytes())
 }
@@ -72,9 +81,9 @@ func getUnderlyingAssetAddress(stateDB contract.StateDB, market common.Address)
 	return common.BytesToAddress(stateDB.GetState(market, common.BigToHash(big.NewInt(UNDERLYING_ASSET_SLOT))).Bytes())
 }
 
-func getUnderlyingPriceForMarket(stateDB contract.StateDB, marketID int64) *big.Int {
+func getUnderlyingPriceForMarket(stateDB contract.StateDB, marketID int64, version Version) *big.Int {
 	market := getMarketAddressFromMarketID(marketID, stateDB)
-	return getUnderlyingPrice(stateDB, market)
+	return getUnderlyingPrice(stateDB, market, version)
 }
 
 func getRedStoneAdapterAddress(stateDB contract.StateDB, market common.Address) common.Address {
@@ -85,35 +94,8 @@ func getRedStoneFeedId(stateDB contract.StateDB, market common.Address) common.H
 	return stateDB.GetState(market, common.BigToHash(big.NewInt(RED_STONE_FEED_ID_SLOT)))
 }
 
-func getUnderlyingPrice(stateDB contract.StateDB, market common.Address) *big.Int {
-	redStoneAdapter := getRedStoneAdapterAddress(stateDB, market)
-	if redStoneAdapter.Hash().Big().Sign() != 0 {
-		feedId := getRedStoneFeedId(stateDB, market)
-		// first we check the feedId, if it is set, it should imply we are using a redstone oracle
-		// log.Info("red-stone-feed-id", "feedId", feedId.String())
-		if feedId.Big().Sign() != 0 {
-			// redstone oracle is configured for this market
-			redstonePrice := getRedStonePrice(stateDB, redStoneAdapter, feedId)
-			// log.Info("redstone-price", "amm", market, "price", redstonePrice)
-			return redstonePrice
-		}
-		/* else {
-			// just log the red stone price, for testing before deployment
-			var feedId common.Hash
-			if strings.EqualFold(market.String(), "0xa72b463C21dA61cCc86069cFab82e9e8491152a0") { // eth amm
-				feedId = common.HexToHash("0x4554480000000000000000000000000000000000000000000000000000000000")
-			} else if strings.EqualFold(market.String(), "0xd80e57dB448b0692C396B890eE9c791D7386dAdC") { // avax amm
-				feedId = common.HexToHash("0x4156415800000000000000000000000000000000000000000000000000000000")
-			}
-			// redstonePrice := getRedStonePrice(stateDB, redStoneAdapter, feedId)
-			// log.Info("log-only-redstone-price", "amm", market, "price", redstonePrice)
-		} */
-	}
-	// red stone oracle is not enabled for this market, we use the default TestOracle
-	oracle := getOracleAddress(stateDB, market)
-	underlying := getUnderlyingAssetAddress(stateDB, market)
-	slot := crypto.Keccak256(append(common.LeftPadBytes(underlying.Bytes(), 32), common.LeftPadBytes(big.NewInt(TEST_ORACLE_PRICES_MAPPING_SLOT).Bytes(), 32)...))
-	return fromTwosComplement(stateDB.GetState(oracle, common.BytesToHash(slot)).Bytes())
+func getUnderlyingPrice(stateDB contract.StateDB, market common.Address, version Version) *big.Int {
+	return getOraclePrice(stateDB, market, version).Price
 }
 
 // Trader State
@@ -122,6 +104,12 @@ func positionsStorageSlot(trader *common.Address) *big.Int {
 	return new(big.Int).SetBytes(crypto.Keccak256(append(common.LeftPadBytes(trader.Bytes(), 32), common.LeftPadBytes(big.NewInt(VAR_POSITIONS_SLOT).Bytes(), 32)...)))
 }
 
+// PositionStorageSlot returns the slot of positions[trader] in the storage of an AMM. The size of the position is at
+// the slot, its openNotional and lastPremiumFraction at the next two.
+func PositionStorageSlot(trader common.Address) common.Hash {
+	return common.BigToHash(positionsStorageSlot(&trader))
+}
+
 func getSize(stateDB contract.StateDB, market common.Address, trader *common.Address) *big.Int {
 	return fromTwosComplement(stateDB.GetState(market, common.BigToHash(positionsStorageSlot(trader))).Bytes())
 }
@@ -141,7 +129,7 @@ func getPendingFundingPayment(stateDB contract.StateDB, market common.Address, t
 	return divide1e18(new(big.Int).Mul(new(big.Int).Sub(cumulativePremiumFraction, GetLastPremiumFraction(stateDB, market, trader)), getSize(stateDB, market, trader)))
 }
 
-func getOptimalPnl(stateDB contract.StateDB, market common.Address, oraclePrice *big.Int, lastPrice *big.Int, trader *common.Address, margin *big.Int, marginMode MarginMode, blockTimestamp *big.Int) (notionalPosition *big.Int, uPnL *big.Int) {
+func getOptimalPnl(stateDB contract.StateDB, market common.Address, oraclePrice *big.Int, lastPrice *big.Int, trader *common.Address, margin *big.Int, marginMode MarginMode, version Version) (notionalPosition *big.Int, uPnL *big.Int) {
 	size := getSize(stateDB, market, trader)
 	if size.Sign() == 0 {
 		return big.NewInt(0), big.NewInt(0)
@@ -154,7 +142,7 @@ func getOptimalPnl(stateDB contract.StateDB, market common.Address, oraclePrice
 		openNotional,
 		size,
 		margin,
-		blockTimestamp,
+		version,
 	)
 
 	// based on oracle price
@@ -163,7 +151,7 @@ func getOptimalPnl(stateDB contract.StateDB, market common.Address, oraclePrice
 		openNotional,
 		size,
 		margin,
-		blockTimestamp,
+		version,
 	)
 
 	if (marginMode == Maintenance_Margin && oracleBasedMF.Cmp(lastPriceBasedMF) == 1) || // for liquidations
@@ -173,18 +161,52 @@ func getOptimalPnl(stateDB contract.StateDB, market common.Address, oraclePrice
 	return notionalPosition, unrealizedPnl
 }
 
-func getPositionMetadata(price *big.Int, openNotional *big.Int, size *big.Int, margin *big.Int, blockTimestamp *big.Int) (notionalPos *big.Int, uPnl *big.Int, marginFraction *big.Int) {
-	notionalPos = divide1e18(new(big.Int).Mul(price, new(big.Int).Abs(size)))
+func getPositionMetadata(price *big.Int, openNotional *big.Int, size *big.Int, margin *big.Int, version Version) (notionalPos *big.Int, uPnl *big.Int, marginFraction *big.Int) {
+	notionalPos, uPnl = getNotionalPositionAndUnrealizedPnl(price, openNotional, size)
 	if notionalPos.Sign() == 0 {
 		return big.NewInt(0), big.NewInt(0), big.NewInt(0)
 	}
+	marginFraction = new(big.Int).Div(_multiply1e6(new(big.Int).Add(margin, uPnl), version), notionalPos)
+	return notionalPos, uPnl, marginFraction
+}
+
+// GetPositionMetadata returns the notional position, unrealized pnl and margin fraction of a position at [price].
+// The validator uses it for its in-memory margin computations so that they match what is read from storage here
+func GetPositionMetadata(price *big.Int, openNotional *big.Int, size *big.Int, margin *big.Int) (notionalPos *big.Int, uPnl *big.Int, marginFraction *big.Int) {
+	notionalPos, uPnl = getNotionalPositionAndUnrealizedPnl(price, openNotional, size)
+	if notionalPos.Sign() == 0 {
+		return big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	}
+	return notionalPos, uPnl, CalcMarginFraction(new(big.Int).Add(margin, uPnl), notionalPos)
+}
+
+func getNotionalPositionAndUnrealizedPnl(price *big.Int, openNotional *big.Int, size *big.Int) (notionalPos *big.Int, uPnl *big.Int) {
+	notionalPos = GetNotionalPosition(price, size)
+	if notionalPos.Sign() == 0 {
+		return notionalPos, big.NewInt(0)
+	}
 	if size.Sign() == 1 {
 		uPnl = new(big.Int).Sub(notionalPos, openNotional)
 	} else {
 		uPnl = new(big.Int).Sub(openNotional, notionalPos)
 	}
-	marginFraction = new(big.Int).Div(_multiply1e6(new(big.Int).Add(margin, uPnl), blockTimestamp), notionalPos)
-	return notionalPos, uPnl, marginFraction
+	return notionalPos, uPnl
+}
+
+// GetNotionalPosition returns |size| * price, scaled down to the price precision
+func GetNotionalPosition(price *big.Int, size *big.Int) *big.Int {
+	return divide1e18(new(big.Int).Mul(price, new(big.Int).Abs(size)))
+}
+
+// GetLiquidationThreshold returns the max size of a position that can be liquidated in one go, with the same sign as [size].
+// It is max(|size| * maxLiquidationRatio, minSizeRequirement)
+func GetLiquidationThreshold(maxLiquidationRatio *big.Int, minSizeRequirement *big.Int, size *big.Int) *big.Int {
+	maxLiquidationSize := divide1e6(new(big.Int).Mul(new(big.Int).Abs(size), maxLiquidationRatio))
+	liquidationThreshold := maxLiquidationSize
+	if minSizeRequirement.Cmp(maxLiquidationSize) == 1 {
+		liquidationThreshold = new(big.Int).Set(minSizeRequirement)
+	}
+	return liquidationThreshold.Mul(liquidationThreshold, big.NewInt(int64(size.Sign()))) // same sign as size
 }
 
 // Common Utils
@@ -200,8 +222,8 @@ func divide1e6(number *big.Int) *big.Int {
 	return big.NewInt(0).Div(number, _1e6)
 }
 
-func _multiply1e6(number *big.Int, blockTimestamp *big.Int) *big.Int {
-	if blockTimestamp.Cmp(V2ActivationDate) == 1 {
+func _multiply1e6(number *big.Int, version Version) *big.Int {
+	if version >= V2 {
 		return multiply1e6(number)
 	}
 	return multiply1e6v1(number)
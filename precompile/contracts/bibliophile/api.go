@@ -19,7 +19,7 @@ type VariablesReadFromClearingHouseSlots struct {
 	PositionSizes      []*big.Int       `json:"position_sizes"`
 }
 
-func GetClearingHouseVariables(stateDB contract.StateDB, trader common.Address) VariablesReadFromClearingHouseSlots {
+func GetClearingHouseVariables(chainConfig contract.ChainConfig, stateDB contract.StateDB, trader common.Address, blockTimestamp uint64) VariablesReadFromClearingHouseSlots {
 	maintenanceMargin := GetMaintenanceMargin(stateDB)
 	minAllowableMargin := GetMinAllowableMargin(stateDB)
 	amms := GetMarkets(stateDB)
@@ -28,10 +28,10 @@ func GetClearingHouseVariables(stateDB contract.StateDB, trader common.Address)
 		Trader:                 trader,
 		IncludeFundingPayments: false,
 		Mode:                   0,
-	}, big.NewInt(0))
+	}, GetVersion(chainConfig, stateDB, blockTimestamp))
 	totalFunding := GetTotalFunding(stateDB, &trader)
-	positionSizes := getPosSizes(stateDB, &trader)
-	underlyingPrices := GetUnderlyingPrices(stateDB)
+	positionSizes := GetPositionSizes(stateDB, &trader)
+	underlyingPrices := GetUnderlyingPrices(stateDB, GetVersion(chainConfig, stateDB, blockTimestamp))
 
 	return VariablesReadFromClearingHouseSlots{
 		MaintenanceMargin:  maintenanceMargin,
@@ -73,6 +73,11 @@ type VariablesReadFromAMMSlots struct {
 	MaxLiquidationPriceSpread *big.Int       `json:"max_liquidation_price_spread"`
 	RedStoneAdapterAddress    common.Address `json:"red_stone_adapter_address"`
 	RedStoneFeedId            common.Hash    `json:"red_stone_feed_id"`
+	NextFundingTime           *big.Int       `json:"next_funding_time"`
+	FundingPeriod             *big.Int       `json:"funding_period"`
+	OracleType                OracleType     `json:"oracle_type"`
+	MaxOraclePriceAge         *big.Int       `json:"max_oracle_price_age"`
+	OraclePriceUpdatedAt      uint64         `json:"oracle_price_updated_at"`
 	Position                  Position       `json:"position"`
 }
 
@@ -83,7 +88,8 @@ type Position struct {
 	LiquidationThreshold *big.Int `json:"liquidation_threshold"`
 }
 
-func GetAMMVariables(stateDB contract.StateDB, ammAddress common.Address, ammIndex int64, trader common.Address) VariablesReadFromAMMSlots {
+func GetAMMVariables(chainConfig contract.ChainConfig, stateDB contract.StateDB, ammAddress common.Address, ammIndex int64, trader common.Address, blockTimestamp uint64) VariablesReadFromAMMSlots {
+	version := GetVersion(chainConfig, stateDB, blockTimestamp)
 	lastPrice := getLastPrice(stateDB, ammAddress)
 	position := Position{
 		Size:                getSize(stateDB, ammAddress, &trader),
@@ -97,10 +103,15 @@ func GetAMMVariables(stateDB contract.StateDB, ammAddress common.Address, ammInd
 	minSizeRequirement := GetMinSizeRequirement(stateDB, ammIndex)
 	oracleAddress := getOracleAddress(stateDB, ammAddress)
 	underlyingAssetAddress := getUnderlyingAssetAddress(stateDB, ammAddress)
-	underlyingPriceForMarket := getUnderlyingPriceForMarket(stateDB, ammIndex)
-	underlyingPrice := getUnderlyingPrice(stateDB, ammAddress)
+	underlyingPriceForMarket := getUnderlyingPriceForMarket(stateDB, ammIndex, version)
+	underlyingPrice := getUnderlyingPrice(stateDB, ammAddress, version)
 	redStoneAdapterAddress := getRedStoneAdapterAddress(stateDB, ammAddress)
 	redStoneFeedId := getRedStoneFeedId(stateDB, ammAddress)
+	nextFundingTime := GetNextFundingTime(stateDB, ammIndex)
+	fundingPeriod := GetFundingPeriod(stateDB, ammIndex)
+	oracleType := getOracleType(stateDB, ammAddress)
+	maxOraclePriceAge := GetMaxOraclePriceAge(stateDB, ammIndex)
+	oraclePriceUpdatedAt := getOraclePrice(stateDB, ammAddress, version).UpdatedAt
 	return VariablesReadFromAMMSlots{
 		LastPrice:                 lastPrice,
 		CumulativePremiumFraction: cumulativePremiumFraction,
@@ -114,6 +125,11 @@ func GetAMMVariables(stateDB contract.StateDB, ammAddress common.Address, ammInd
 		MaxLiquidationPriceSpread: maxLiquidationPriceSpread,
 		RedStoneAdapterAddress:    redStoneAdapterAddress,
 		RedStoneFeedId:            redStoneFeedId,
+		NextFundingTime:           nextFundingTime,
+		FundingPeriod:             fundingPeriod,
+		OracleType:                oracleType,
+		MaxOraclePriceAge:         maxOraclePriceAge,
+		OraclePriceUpdatedAt:      oraclePriceUpdatedAt,
 		Position:                  position,
 	}
 }
@@ -145,6 +161,14 @@ func GetIOCOrdersVariables(stateDB contract.StateDB, orderHash common.Hash) Vari
 	}
 }
 
+func GetGTTOrderDetails(stateDB contract.StateDB, orderHash common.Hash) OrderDetails {
+	return OrderDetails{
+		BlockPlaced:  gttGetBlockPlaced(stateDB, orderHash),
+		FilledAmount: gttGetOrderFilledAmount(stateDB, orderHash),
+		OrderStatus:  gttGetOrderStatus(stateDB, orderHash),
+	}
+}
+
 type VariablesReadFromOrderbookSlots struct {
 	OrderDetails      OrderDetails `json:"order_details"`
 	IsTradingAuthoriy bool         `json:"is_trading_authority"`
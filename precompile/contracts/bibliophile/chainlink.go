@@ -0,0 +1,52 @@
+package bibliophile
+
+import (
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Storage layout of a chainlink style aggregator, i.e. the values returned by latestRoundData()
+const (
+	CHAINLINK_DECIMALS_SLOT        int64 = 0 // uint8 decimals
+	CHAINLINK_LATEST_ROUND_ID_SLOT int64 = 1 // uint80 latestRoundId
+	CHAINLINK_ROUNDS_MAPPING_SLOT  int64 = 2 // mapping(uint80 => Round{int256 answer; uint256 startedAt; uint256 updatedAt; uint80 answeredInRound})
+)
+
+// chainlinkOracleReader reads prices from a chainlink style aggregator
+type chainlinkOracleReader struct{}
+
+func (r *chainlinkOracleReader) ReadPrice(stateDB contract.StateDB, market common.Address) OraclePrice {
+	aggregator := getOracleAdapterAddress(stateDB, market)
+	decimals := getChainlinkDecimals(stateDB, aggregator)
+	latestRoundId := getChainlinkLatestRoundId(stateDB, aggregator)
+	roundSlot := chainlinkRoundStorageSlot(latestRoundId)
+	updatedAt := stateDB.GetState(aggregator, common.BigToHash(new(big.Int).Add(roundSlot, big.NewInt(2)))).Big()
+	price := OraclePrice{
+		Price:     normaliseToPrecision6(getChainlinkAnswer(stateDB, aggregator, latestRoundId), decimals),
+		UpdatedAt: updatedAt.Uint64(),
+	}
+	if latestRoundId.Sign() == 1 {
+		price.PreviousPrice = normaliseToPrecision6(getChainlinkAnswer(stateDB, aggregator, new(big.Int).Sub(latestRoundId, big.NewInt(1))), decimals)
+	}
+	return price
+}
+
+func getChainlinkAnswer(stateDB contract.StateDB, aggregator common.Address, roundId *big.Int) *big.Int {
+	return fromTwosComplement(stateDB.GetState(aggregator, common.BigToHash(chainlinkRoundStorageSlot(roundId))).Bytes())
+}
+
+func getChainlinkDecimals(stateDB contract.StateDB, aggregator common.Address) int64 {
+	return stateDB.GetState(aggregator, common.BigToHash(big.NewInt(CHAINLINK_DECIMALS_SLOT))).Big().Int64()
+}
+
+func getChainlinkLatestRoundId(stateDB contract.StateDB, aggregator common.Address) *big.Int {
+	return stateDB.GetState(aggregator, common.BigToHash(big.NewInt(CHAINLINK_LATEST_ROUND_ID_SLOT))).Big()
+}
+
+func chainlinkRoundStorageSlot(roundId *big.Int) *big.Int {
+	return new(big.Int).SetBytes(crypto.Keccak256(append(common.LeftPadBytes(roundId.Bytes(), 32), common.LeftPadBytes(big.NewInt(CHAINLINK_ROUNDS_MAPPING_SLOT).Bytes(), 32)...)))
+}
@@ -1,6 +1,7 @@
 package bibliophile
 
 import (
+	"math"
 	"math/big"
 
 	"github.com/ava-labs/subnet-evm/precompile/contract"
@@ -34,6 +35,11 @@ func marketsStorageSlot() *big.Int {
 	return new(big.Int).SetBytes(crypto.Keccak256(common.LeftPadBytes(big.NewInt(AMMS_SLOT).Bytes(), 32)))
 }
 
+// MarketStorageSlot returns the slot of the ClearingHouse that stores the AMM of the market at [index]
+func MarketStorageSlot(index int64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(marketsStorageSlot(), big.NewInt(index)))
+}
+
 func GetActiveMarketsCount(stateDB contract.StateDB) int64 {
 	rawVal := stateDB.GetState(common.HexToAddress(CLEARING_HOUSE_GENESIS_ADDRESS), common.BytesToHash(common.LeftPadBytes(big.NewInt(AMMS_SLOT).Bytes(), 32)))
 	return new(big.Int).SetBytes(rawVal.Bytes()).Int64()
@@ -51,25 +57,55 @@ func GetMarkets(stateDB contract.StateDB) []common.Address {
 	return markets
 }
 
-func GetNotionalPositionAndMargin(stateDB contract.StateDB, input *GetNotionalPositionAndMarginInput, blockTimestamp *big.Int) GetNotionalPositionAndMarginOutput {
+func GetNotionalPositionAndMargin(stateDB contract.StateDB, input *GetNotionalPositionAndMarginInput, version Version) GetNotionalPositionAndMarginOutput {
+	return getNotionalPositionAndMarginWithPrices(stateDB, input, version, underlyingPriceReader(version))
+}
+
+// GetNotionalPositionAndMarginFromTestOracle is GetNotionalPositionAndMargin with the underlying prices read from the
+// TestOracle of each market, whatever oracle the market is configured with. The hubblebibliophile precompile never
+// read any other oracle, so its output must not change.
+func GetNotionalPositionAndMarginFromTestOracle(stateDB contract.StateDB, input *GetNotionalPositionAndMarginInput, version Version) GetNotionalPositionAndMarginOutput {
+	return getNotionalPositionAndMarginWithPrices(stateDB, input, version, getTestOraclePrice)
+}
+
+func getNotionalPositionAndMarginWithPrices(stateDB contract.StateDB, input *GetNotionalPositionAndMarginInput, version Version, underlyingPrice func(contract.StateDB, common.Address) *big.Int) GetNotionalPositionAndMarginOutput {
 	margin := GetNormalizedMargin(stateDB, input.Trader)
 	if input.IncludeFundingPayments {
 		margin.Sub(margin, GetTotalFunding(stateDB, &input.Trader))
 	}
-	notionalPosition, unrealizedPnl := GetTotalNotionalPositionAndUnrealizedPnl(stateDB, &input.Trader, margin, GetMarginMode(input.Mode), blockTimestamp)
+	notionalPosition, unrealizedPnl := getTotalNotionalPositionAndUnrealizedPnl(stateDB, &input.Trader, margin, GetMarginMode(input.Mode), version, underlyingPrice)
 	return GetNotionalPositionAndMarginOutput{
 		NotionalPosition: notionalPosition,
 		Margin:           new(big.Int).Add(margin, unrealizedPnl),
 	}
 }
 
-func GetTotalNotionalPositionAndUnrealizedPnl(stateDB contract.StateDB, trader *common.Address, margin *big.Int, marginMode MarginMode, blockTimeStamp *big.Int) (*big.Int, *big.Int) {
+// CalcMarginFraction returns margin / notionalPosition in 1e6 precision; a trader without any position has an infinite margin fraction
+func CalcMarginFraction(margin *big.Int, notionalPosition *big.Int) *big.Int {
+	if notionalPosition.Sign() == 0 {
+		return big.NewInt(math.MaxInt64)
+	}
+	return new(big.Int).Div(multiply1e6(margin), notionalPosition)
+}
+
+func GetTotalNotionalPositionAndUnrealizedPnl(stateDB contract.StateDB, trader *common.Address, margin *big.Int, marginMode MarginMode, version Version) (*big.Int, *big.Int) {
+	return getTotalNotionalPositionAndUnrealizedPnl(stateDB, trader, margin, marginMode, version, underlyingPriceReader(version))
+}
+
+// underlyingPriceReader returns getUnderlyingPrice for the given [version]
+func underlyingPriceReader(version Version) func(contract.StateDB, common.Address) *big.Int {
+	return func(stateDB contract.StateDB, market common.Address) *big.Int {
+		return getUnderlyingPrice(stateDB, market, version)
+	}
+}
+
+func getTotalNotionalPositionAndUnrealizedPnl(stateDB contract.StateDB, trader *common.Address, margin *big.Int, marginMode MarginMode, version Version, underlyingPrice func(contract.StateDB, common.Address) *big.Int) (*big.Int, *big.Int) {
 	notionalPosition := big.NewInt(0)
 	unrealizedPnl := big.NewInt(0)
 	for _, market := range GetMarkets(stateDB) {
 		lastPrice := getLastPrice(stateDB, market)
-		oraclePrice := getUnderlyingPrice(stateDB, market)
-		_notionalPosition, _unrealizedPnl := getOptimalPnl(stateDB, market, oraclePrice, lastPrice, trader, margin, marginMode, blockTimeStamp)
+		oraclePrice := underlyingPrice(stateDB, market)
+		_notionalPosition, _unrealizedPnl := getOptimalPnl(stateDB, market, oraclePrice, lastPrice, trader, margin, marginMode, version)
 		notionalPosition.Add(notionalPosition, _notionalPosition)
 		unrealizedPnl.Add(unrealizedPnl, _unrealizedPnl)
 	}
@@ -94,15 +130,16 @@ func GetMinAllowableMargin(stateDB contract.StateDB) *big.Int {
 	return new(big.Int).SetBytes(stateDB.GetState(common.HexToAddress(CLEARING_HOUSE_GENESIS_ADDRESS), common.BytesToHash(common.LeftPadBytes(big.NewInt(MIN_ALLOWABLE_MARGIN_SLOT).Bytes(), 32))).Bytes())
 }
 
-func GetUnderlyingPrices(stateDB contract.StateDB) []*big.Int {
+func GetUnderlyingPrices(stateDB contract.StateDB, version Version) []*big.Int {
 	underlyingPrices := make([]*big.Int, 0)
 	for _, market := range GetMarkets(stateDB) {
-		underlyingPrices = append(underlyingPrices, getUnderlyingPrice(stateDB, market))
+		underlyingPrices = append(underlyingPrices, getUnderlyingPrice(stateDB, market, version))
 	}
 	return underlyingPrices
 }
 
-func getPosSizes(stateDB contract.StateDB, trader *common.Address) []*big.Int {
+// GetPositionSizes returns the position size of the trader in every market
+func GetPositionSizes(stateDB contract.StateDB, trader *common.Address) []*big.Int {
 	positionSizes := make([]*big.Int, 0)
 	for _, market := range GetMarkets(stateDB) {
 		positionSizes = append(positionSizes, getSize(stateDB, market, trader))
@@ -110,13 +147,13 @@ func getPosSizes(stateDB contract.StateDB, trader *common.Address) []*big.Int {
 	return positionSizes
 }
 
-func _getPositionSizesAndUpperBoundsForMarkets(stateDB contract.StateDB, trader *common.Address) GetPositionSizesAndUpperBoundsForMarketsOutput {
+func _getPositionSizesAndUpperBoundsForMarkets(stateDB contract.StateDB, trader *common.Address, version Version) GetPositionSizesAndUpperBoundsForMarketsOutput {
 	markets := GetMarkets(stateDB)
 	positionSizes := make([]*big.Int, len(markets))
 	upperBounds := make([]*big.Int, len(markets))
 	for i, market := range markets {
 		positionSizes[i] = getSize(stateDB, market, trader)
-		oraclePrice := getUnderlyingPrice(stateDB, market)
+		oraclePrice := getUnderlyingPrice(stateDB, market, version)
 		spreadLimit := GetMaxOraclePriceSpread(stateDB, int64(i))
 		upperBounds[i], _ = calculateBounds(spreadLimit, oraclePrice)
 	}
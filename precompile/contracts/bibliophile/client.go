@@ -14,6 +14,12 @@ type BibliophileClient interface {
 	DetermineFillPrice(marketId int64, longOrderPrice, shortOrderPrice, blockPlaced0, blockPlaced1 *big.Int) (*ValidateOrdersAndDetermineFillPriceOutput, error)
 	DetermineLiquidationFillPrice(marketId int64, baseAssetQuantity, price *big.Int) (*big.Int, error)
 
+	// Margin
+	GetNotionalPositionAndMargin(trader common.Address, includeFundingPayments bool, mode uint8) (*big.Int, *big.Int)
+	GetTotalFunding(trader *common.Address) *big.Int
+	GetMaintenanceMargin() *big.Int
+	GetMaxLiquidationRatio(marketId int64) *big.Int
+
 	// Misc
 	IsTradingAuthority(senderOrSigner, trader common.Address) bool
 
@@ -21,6 +27,8 @@ type BibliophileClient interface {
 	GetBlockPlaced(orderHash [32]byte) *big.Int
 	GetOrderFilledAmount(orderHash [32]byte) *big.Int
 	GetOrderStatus(orderHash [32]byte) int64
+	GetOrderAmendedFrom(orderHash [32]byte) [32]byte
+	GetOrderAmendedTo(orderHash [32]byte) [32]byte
 
 	// IOC Order
 	IOC_GetBlockPlaced(orderHash [32]byte) *big.Int
@@ -28,6 +36,11 @@ type BibliophileClient interface {
 	IOC_GetOrderStatus(orderHash [32]byte) int64
 	IOC_GetExpirationCap() *big.Int
 
+	// GTT Order
+	GTT_GetBlockPlaced(orderHash [32]byte) *big.Int
+	GTT_GetOrderFilledAmount(orderHash [32]byte) *big.Int
+	GTT_GetOrderStatus(orderHash [32]byte) int64
+
 	GetAccessibleState() contract.AccessibleState
 }
 
@@ -46,6 +59,11 @@ func (b *bibliophileClient) GetAccessibleState() contract.AccessibleState {
 	return b.accessibleState
 }
 
+// getVersion returns the version of the bibliophile logic for the block being executed
+func (b *bibliophileClient) getVersion() Version {
+	return GetVersion(b.accessibleState.GetChainConfig(), b.accessibleState.GetStateDB(), b.accessibleState.GetBlockContext().Timestamp())
+}
+
 func (b *bibliophileClient) GetSize(market common.Address, trader *common.Address) *big.Int {
 	return getSize(b.accessibleState.GetStateDB(), market, trader)
 }
@@ -59,11 +77,34 @@ func (b *bibliophileClient) GetMarketAddressFromMarketID(marketID int64) common.
 }
 
 func (b *bibliophileClient) DetermineFillPrice(marketId int64, longOrderPrice, shortOrderPrice, blockPlaced0, blockPlaced1 *big.Int) (*ValidateOrdersAndDetermineFillPriceOutput, error) {
-	return DetermineFillPrice(b.accessibleState.GetStateDB(), marketId, longOrderPrice, shortOrderPrice, blockPlaced0, blockPlaced1)
+	if err := CheckOracleCircuitBreaker(b.accessibleState.GetChainConfig(), b.accessibleState.GetStateDB(), marketId, b.accessibleState.GetBlockContext().Timestamp()); err != nil {
+		return nil, err
+	}
+	return DetermineFillPrice(b.accessibleState.GetStateDB(), marketId, longOrderPrice, shortOrderPrice, blockPlaced0, blockPlaced1, b.getVersion())
 }
 
 func (b *bibliophileClient) DetermineLiquidationFillPrice(marketId int64, baseAssetQuantity, price *big.Int) (*big.Int, error) {
-	return DetermineLiquidationFillPrice(b.accessibleState.GetStateDB(), marketId, baseAssetQuantity, price)
+	if err := CheckOracleCircuitBreaker(b.accessibleState.GetChainConfig(), b.accessibleState.GetStateDB(), marketId, b.accessibleState.GetBlockContext().Timestamp()); err != nil {
+		return nil, err
+	}
+	return DetermineLiquidationFillPrice(b.accessibleState.GetStateDB(), marketId, baseAssetQuantity, price, b.getVersion())
+}
+
+func (b *bibliophileClient) GetNotionalPositionAndMargin(trader common.Address, includeFundingPayments bool, mode uint8) (*big.Int, *big.Int) {
+	output := GetNotionalPositionAndMargin(b.accessibleState.GetStateDB(), &GetNotionalPositionAndMarginInput{Trader: trader, IncludeFundingPayments: includeFundingPayments, Mode: mode}, b.getVersion())
+	return output.NotionalPosition, output.Margin
+}
+
+func (b *bibliophileClient) GetTotalFunding(trader *common.Address) *big.Int {
+	return GetTotalFunding(b.accessibleState.GetStateDB(), trader)
+}
+
+func (b *bibliophileClient) GetMaintenanceMargin() *big.Int {
+	return GetMaintenanceMargin(b.accessibleState.GetStateDB())
+}
+
+func (b *bibliophileClient) GetMaxLiquidationRatio(marketId int64) *big.Int {
+	return GetMaxLiquidationRatio(b.accessibleState.GetStateDB(), marketId)
 }
 
 func (b *bibliophileClient) GetBlockPlaced(orderHash [32]byte) *big.Int {
@@ -78,6 +119,22 @@ func (b *bibliophileClient) GetOrderStatus(orderHash [32]byte) int64 {
 	return getOrderStatus(b.accessibleState.GetStateDB(), orderHash)
 }
 
+// GetOrderAmendedFrom returns the hash that the amended order with [orderHash] was placed with, the zero hash before V4
+func (b *bibliophileClient) GetOrderAmendedFrom(orderHash [32]byte) [32]byte {
+	if b.getVersion() < V4 {
+		return [32]byte{}
+	}
+	return getOrderAmendedFrom(b.accessibleState.GetStateDB(), orderHash)
+}
+
+// GetOrderAmendedTo returns the hash of the latest amendment of the order placed with [orderHash], the zero hash before V4
+func (b *bibliophileClient) GetOrderAmendedTo(orderHash [32]byte) [32]byte {
+	if b.getVersion() < V4 {
+		return [32]byte{}
+	}
+	return getOrderAmendedTo(b.accessibleState.GetStateDB(), orderHash)
+}
+
 func (b *bibliophileClient) IOC_GetBlockPlaced(orderHash [32]byte) *big.Int {
 	return iocGetBlockPlaced(b.accessibleState.GetStateDB(), orderHash)
 }
@@ -97,3 +154,15 @@ func (b *bibliophileClient) IsTradingAuthority(trader, senderOrSigner common.Add
 func (b *bibliophileClient) IOC_GetExpirationCap() *big.Int {
 	return iocGetExpirationCap(b.accessibleState.GetStateDB())
 }
+
+func (b *bibliophileClient) GTT_GetBlockPlaced(orderHash [32]byte) *big.Int {
+	return gttGetBlockPlaced(b.accessibleState.GetStateDB(), orderHash)
+}
+
+func (b *bibliophileClient) GTT_GetOrderFilledAmount(orderHash [32]byte) *big.Int {
+	return gttGetOrderFilledAmount(b.accessibleState.GetStateDB(), orderHash)
+}
+
+func (b *bibliophileClient) GTT_GetOrderStatus(orderHash [32]byte) int64 {
+	return gttGetOrderStatus(b.accessibleState.GetStateDB(), orderHash)
+}
@@ -66,6 +66,48 @@ func (mr *MockBibliophileClientMockRecorder) DetermineLiquidationFillPrice(marke
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetermineLiquidationFillPrice", reflect.TypeOf((*MockBibliophileClient)(nil).DetermineLiquidationFillPrice), marketId, baseAssetQuantity, price)
 }
 
+// GTT_GetBlockPlaced mocks base method.
+func (m *MockBibliophileClient) GTT_GetBlockPlaced(orderHash [32]byte) *big.Int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GTT_GetBlockPlaced", orderHash)
+	ret0, _ := ret[0].(*big.Int)
+	return ret0
+}
+
+// GTT_GetBlockPlaced indicates an expected call of GTT_GetBlockPlaced.
+func (mr *MockBibliophileClientMockRecorder) GTT_GetBlockPlaced(orderHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GTT_GetBlockPlaced", reflect.TypeOf((*MockBibliophileClient)(nil).GTT_GetBlockPlaced), orderHash)
+}
+
+// GTT_GetOrderFilledAmount mocks base method.
+func (m *MockBibliophileClient) GTT_GetOrderFilledAmount(orderHash [32]byte) *big.Int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GTT_GetOrderFilledAmount", orderHash)
+	ret0, _ := ret[0].(*big.Int)
+	return ret0
+}
+
+// GTT_GetOrderFilledAmount indicates an expected call of GTT_GetOrderFilledAmount.
+func (mr *MockBibliophileClientMockRecorder) GTT_GetOrderFilledAmount(orderHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GTT_GetOrderFilledAmount", reflect.TypeOf((*MockBibliophileClient)(nil).GTT_GetOrderFilledAmount), orderHash)
+}
+
+// GTT_GetOrderStatus mocks base method.
+func (m *MockBibliophileClient) GTT_GetOrderStatus(orderHash [32]byte) int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GTT_GetOrderStatus", orderHash)
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// GTT_GetOrderStatus indicates an expected call of GTT_GetOrderStatus.
+func (mr *MockBibliophileClientMockRecorder) GTT_GetOrderStatus(orderHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GTT_GetOrderStatus", reflect.TypeOf((*MockBibliophileClient)(nil).GTT_GetOrderStatus), orderHash)
+}
+
 // GetAccessibleState mocks base method.
 func (m *MockBibliophileClient) GetAccessibleState() contract.AccessibleState {
 	m.ctrl.T.Helper()
@@ -94,6 +136,20 @@ func (mr *MockBibliophileClientMockRecorder) GetBlockPlaced(orderHash interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockPlaced", reflect.TypeOf((*MockBibliophileClient)(nil).GetBlockPlaced), orderHash)
 }
 
+// GetMaintenanceMargin mocks base method.
+func (m *MockBibliophileClient) GetMaintenanceMargin() *big.Int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMaintenanceMargin")
+	ret0, _ := ret[0].(*big.Int)
+	return ret0
+}
+
+// GetMaintenanceMargin indicates an expected call of GetMaintenanceMargin.
+func (mr *MockBibliophileClientMockRecorder) GetMaintenanceMargin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMaintenanceMargin", reflect.TypeOf((*MockBibliophileClient)(nil).GetMaintenanceMargin))
+}
+
 // GetMarketAddressFromMarketID mocks base method.
 func (m *MockBibliophileClient) GetMarketAddressFromMarketID(marketId int64) common.Address {
 	m.ctrl.T.Helper()
@@ -108,6 +164,20 @@ func (mr *MockBibliophileClientMockRecorder) GetMarketAddressFromMarketID(market
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMarketAddressFromMarketID", reflect.TypeOf((*MockBibliophileClient)(nil).GetMarketAddressFromMarketID), marketId)
 }
 
+// GetMaxLiquidationRatio mocks base method.
+func (m *MockBibliophileClient) GetMaxLiquidationRatio(marketId int64) *big.Int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMaxLiquidationRatio", marketId)
+	ret0, _ := ret[0].(*big.Int)
+	return ret0
+}
+
+// GetMaxLiquidationRatio indicates an expected call of GetMaxLiquidationRatio.
+func (mr *MockBibliophileClientMockRecorder) GetMaxLiquidationRatio(marketId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMaxLiquidationRatio", reflect.TypeOf((*MockBibliophileClient)(nil).GetMaxLiquidationRatio), marketId)
+}
+
 // GetMinSizeRequirement mocks base method.
 func (m *MockBibliophileClient) GetMinSizeRequirement(marketId int64) *big.Int {
 	m.ctrl.T.Helper()
@@ -122,6 +192,49 @@ func (mr *MockBibliophileClientMockRecorder) GetMinSizeRequirement(marketId inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMinSizeRequirement", reflect.TypeOf((*MockBibliophileClient)(nil).GetMinSizeRequirement), marketId)
 }
 
+// GetNotionalPositionAndMargin mocks base method.
+func (m *MockBibliophileClient) GetNotionalPositionAndMargin(trader common.Address, includeFundingPayments bool, mode uint8) (*big.Int, *big.Int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotionalPositionAndMargin", trader, includeFundingPayments, mode)
+	ret0, _ := ret[0].(*big.Int)
+	ret1, _ := ret[1].(*big.Int)
+	return ret0, ret1
+}
+
+// GetNotionalPositionAndMargin indicates an expected call of GetNotionalPositionAndMargin.
+func (mr *MockBibliophileClientMockRecorder) GetNotionalPositionAndMargin(trader, includeFundingPayments, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotionalPositionAndMargin", reflect.TypeOf((*MockBibliophileClient)(nil).GetNotionalPositionAndMargin), trader, includeFundingPayments, mode)
+}
+
+// GetOrderAmendedFrom mocks base method.
+func (m *MockBibliophileClient) GetOrderAmendedFrom(orderHash [32]byte) [32]byte {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderAmendedFrom", orderHash)
+	ret0, _ := ret[0].([32]byte)
+	return ret0
+}
+
+// GetOrderAmendedFrom indicates an expected call of GetOrderAmendedFrom.
+func (mr *MockBibliophileClientMockRecorder) GetOrderAmendedFrom(orderHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderAmendedFrom", reflect.TypeOf((*MockBibliophileClient)(nil).GetOrderAmendedFrom), orderHash)
+}
+
+// GetOrderAmendedTo mocks base method.
+func (m *MockBibliophileClient) GetOrderAmendedTo(orderHash [32]byte) [32]byte {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderAmendedTo", orderHash)
+	ret0, _ := ret[0].([32]byte)
+	return ret0
+}
+
+// GetOrderAmendedTo indicates an expected call of GetOrderAmendedTo.
+func (mr *MockBibliophileClientMockRecorder) GetOrderAmendedTo(orderHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderAmendedTo", reflect.TypeOf((*MockBibliophileClient)(nil).GetOrderAmendedTo), orderHash)
+}
+
 // GetOrderFilledAmount mocks base method.
 func (m *MockBibliophileClient) GetOrderFilledAmount(orderHash [32]byte) *big.Int {
 	m.ctrl.T.Helper()
@@ -164,6 +277,20 @@ func (mr *MockBibliophileClientMockRecorder) GetSize(market, trader interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSize", reflect.TypeOf((*MockBibliophileClient)(nil).GetSize), market, trader)
 }
 
+// GetTotalFunding mocks base method.
+func (m *MockBibliophileClient) GetTotalFunding(trader *common.Address) *big.Int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotalFunding", trader)
+	ret0, _ := ret[0].(*big.Int)
+	return ret0
+}
+
+// GetTotalFunding indicates an expected call of GetTotalFunding.
+func (mr *MockBibliophileClientMockRecorder) GetTotalFunding(trader interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalFunding", reflect.TypeOf((*MockBibliophileClient)(nil).GetTotalFunding), trader)
+}
+
 // IOC_GetBlockPlaced mocks base method.
 func (m *MockBibliophileClient) IOC_GetBlockPlaced(orderHash [32]byte) *big.Int {
 	m.ctrl.T.Helper()
@@ -5,6 +5,8 @@
 package bibliophile
 
 import (
+	"fmt"
+
 	"github.com/ava-labs/subnet-evm/precompile/precompileconfig"
 )
 
@@ -15,14 +17,18 @@ var _ precompileconfig.Config = &Config{}
 type Config struct {
 	precompileconfig.Upgrade
 	// CUSTOM CODE STARTS HERE
-	// Add your own custom fields for Config here
+	// Versions schedules the behaviour versions of the bibliophile logic by block timestamp.
+	// It is written to the precompile storage on activation. The blocks before its first version use the hubbleVersions
+	// of the chain config, see GetVersion.
+	Versions []VersionUpgrade `json:"versions,omitempty"`
 }
 
 // NewConfig returns a config for a network upgrade at [blockTimestamp] that enables
-// HubbleBibliophile.
-func NewConfig(blockTimestamp *uint64) *Config {
+// HubbleBibliophile with the given version schedule.
+func NewConfig(blockTimestamp *uint64, versions []VersionUpgrade) *Config {
 	return &Config{
-		Upgrade: precompileconfig.Upgrade{BlockTimestamp: blockTimestamp},
+		Upgrade:  precompileconfig.Upgrade{BlockTimestamp: blockTimestamp},
+		Versions: versions,
 	}
 }
 
@@ -44,8 +50,14 @@ func (*Config) Key() string { return ConfigKey }
 // Verify tries to verify Config and returns an error accordingly.
 func (c *Config) Verify() error {
 	// CUSTOM CODE STARTS HERE
-	// Add your own custom verify code for Config here
-	// and return an error accordingly
+	for i, version := range c.Versions {
+		if !version.Version.IsValid() {
+			return fmt.Errorf("invalid version %d at index %d", version.Version, i)
+		}
+		if i > 0 && version.BlockTimestamp <= c.Versions[i-1].BlockTimestamp {
+			return fmt.Errorf("version block timestamps must be increasing: %d <= %d at index %d", version.BlockTimestamp, c.Versions[i-1].BlockTimestamp, i)
+		}
+	}
 	return nil
 }
 
@@ -60,5 +72,13 @@ func (c *Config) Equal(s precompileconfig.Config) bool {
 	// modify this boolean accordingly with your custom Config, to check if [other] and the current [c] are equal
 	// if Config contains only Upgrade you can skip modifying it.
 	equals := c.Upgrade.Equal(&other.Upgrade)
-	return equals
+	if !equals || len(c.Versions) != len(other.Versions) {
+		return false
+	}
+	for i := range c.Versions {
+		if c.Versions[i] != other.Versions[i] {
+			return false
+		}
+	}
+	return true
 }
@@ -16,15 +16,22 @@ import (
 func TestVerify(t *testing.T) {
 	tests := map[string]testutils.ConfigVerifyTest{
 		"valid config": {
-			Config:        NewConfig(utils.NewUint64(3)),
+			Config:        NewConfig(utils.NewUint64(3), nil),
 			ExpectedError: "",
 		},
 		// CUSTOM CODE STARTS HERE
-		// Add your own Verify tests here, e.g.:
-		// "your custom test name": {
-		// 	Config: NewConfig(utils.NewUint64(3),),
-		// 	ExpectedError: ErrYourCustomError.Error(),
-		// },
+		"valid versions": {
+			Config:        NewConfig(utils.NewUint64(3), []VersionUpgrade{{BlockTimestamp: 0, Version: V1}, {BlockTimestamp: 10, Version: V2}}),
+			ExpectedError: "",
+		},
+		"invalid version": {
+			Config:        NewConfig(utils.NewUint64(3), []VersionUpgrade{{BlockTimestamp: 0, Version: LatestVersion + 1}}),
+			ExpectedError: "invalid version",
+		},
+		"versions not in order": {
+			Config:        NewConfig(utils.NewUint64(3), []VersionUpgrade{{BlockTimestamp: 10, Version: V1}, {BlockTimestamp: 10, Version: V2}}),
+			ExpectedError: "version block timestamps must be increasing",
+		},
 	}
 	// Run verify tests.
 	testutils.RunVerifyTests(t, tests)
@@ -34,27 +41,36 @@ func TestVerify(t *testing.T) {
 func TestEqual(t *testing.T) {
 	tests := map[string]testutils.ConfigEqualTest{
 		"non-nil config and nil other": {
-			Config:   NewConfig(utils.NewUint64(3)),
+			Config:   NewConfig(utils.NewUint64(3), nil),
 			Other:    nil,
 			Expected: false,
 		},
 		"different type": {
-			Config:   NewConfig(utils.NewUint64(3)),
+			Config:   NewConfig(utils.NewUint64(3), nil),
 			Other:    precompileconfig.NewNoopStatefulPrecompileConfig(),
 			Expected: false,
 		},
 		"different timestamp": {
-			Config:   NewConfig(utils.NewUint64(3)),
-			Other:    NewConfig(utils.NewUint64(4)),
+			Config:   NewConfig(utils.NewUint64(3), nil),
+			Other:    NewConfig(utils.NewUint64(4), nil),
 			Expected: false,
 		},
 		"same config": {
-			Config:   NewConfig(utils.NewUint64(3)),
-			Other:    NewConfig(utils.NewUint64(3)),
+			Config:   NewConfig(utils.NewUint64(3), nil),
+			Other:    NewConfig(utils.NewUint64(3), nil),
 			Expected: true,
 		},
 		// CUSTOM CODE STARTS HERE
-		// Add your own Equal tests here
+		"different versions": {
+			Config:   NewConfig(utils.NewUint64(3), []VersionUpgrade{{BlockTimestamp: 0, Version: V2}}),
+			Other:    NewConfig(utils.NewUint64(3), []VersionUpgrade{{BlockTimestamp: 1, Version: V2}}),
+			Expected: false,
+		},
+		"same versions": {
+			Config:   NewConfig(utils.NewUint64(3), []VersionUpgrade{{BlockTimestamp: 0, Version: V2}}),
+			Other:    NewConfig(utils.NewUint64(3), []VersionUpgrade{{BlockTimestamp: 0, Version: V2}}),
+			Expected: true,
+		},
 	}
 	// Run equal tests.
 	testutils.RunEqualTests(t, tests)
@@ -53,8 +53,9 @@ var (
 // Some logic changes may result in usedGas which will result in error during replay of blocks while syncing.
 // Some logic changes may result in different state wihch will result in error in state during replay of blocks while syncing.
 // We should track these logic change which can cause changes specified above; as releases in comments below
+// Releases that change the output are tracked as a Version (see version.go) and scheduled by block timestamp in the config
 
-// Release 1
+// Release 1 - V2
 // in amm.go multiply1e6 is diving by 1e6(v1). Change was to fix this to multiply by 1e6(v2).
 // This caused different marginFration and thus different output which cause issue while replay of blocks.
 
@@ -134,7 +135,7 @@ func getNotionalPositionAndMargin(accessibleState contract.AccessibleState, call
 	}
 
 	// CUSTOM CODE STARTS HERE
-	output := GetNotionalPositionAndMargin(accessibleState.GetStateDB(), &inputStruct, new(big.Int).SetUint64(accessibleState.GetBlockContext().Timestamp()))
+	output := GetNotionalPositionAndMargin(accessibleState.GetStateDB(), &inputStruct, GetVersion(accessibleState.GetChainConfig(), accessibleState.GetStateDB(), accessibleState.GetBlockContext().Timestamp()))
 	packedOutput, err := PackGetNotionalPositionAndMarginOutput(output)
 	if err != nil {
 		return nil, remainingGas, err
@@ -181,7 +182,7 @@ func getPositionSizes(accessibleState contract.AccessibleState, caller common.Ad
 	}
 
 	// CUSTOM CODE STARTS HERE
-	output := getPosSizes(accessibleState.GetStateDB(), &inputStruct)
+	output := GetPositionSizes(accessibleState.GetStateDB(), &inputStruct)
 	packedOutput, err := PackGetPositionSizesOutput(output)
 	if err != nil {
 		return nil, remainingGas, err
@@ -224,7 +225,7 @@ func validateLiquidationOrderAndDetermineFillPrice(accessibleState contract.Acce
 	}
 
 	// CUSTOM CODE STARTS HERE
-	output, err := ValidateLiquidationOrderAndDetermineFillPrice(accessibleState.GetStateDB(), &inputStruct)
+	output, err := ValidateLiquidationOrderAndDetermineFillPrice(accessibleState.GetStateDB(), &inputStruct, GetVersion(accessibleState.GetChainConfig(), accessibleState.GetStateDB(), accessibleState.GetBlockContext().Timestamp()))
 	if err != nil {
 		return nil, remainingGas, err
 	}
@@ -274,7 +275,7 @@ func validateOrdersAndDetermineFillPrice(accessibleState contract.AccessibleStat
 	}
 
 	// CUSTOM CODE STARTS HERE
-	output, err := ValidateOrdersAndDetermineFillPrice(accessibleState.GetStateDB(), &inputStruct)
+	output, err := ValidateOrdersAndDetermineFillPrice(accessibleState.GetStateDB(), &inputStruct, GetVersion(accessibleState.GetChainConfig(), accessibleState.GetStateDB(), accessibleState.GetBlockContext().Timestamp()))
 	if err != nil {
 		return nil, remainingGas, err
 	}
@@ -360,7 +361,7 @@ func getPositionSizesAndUpperBoundsForMarkets(accessibleState contract.Accessibl
 	}
 
 	// CUSTOM CODE STARTS HERE
-	output := _getPositionSizesAndUpperBoundsForMarkets(accessibleState.GetStateDB(), &inputStruct) // CUSTOM CODE FOR AN OUTPUT
+	output := _getPositionSizesAndUpperBoundsForMarkets(accessibleState.GetStateDB(), &inputStruct, GetVersion(accessibleState.GetChainConfig(), accessibleState.GetStateDB(), accessibleState.GetBlockContext().Timestamp())) // CUSTOM CODE FOR AN OUTPUT
 	packedOutput, err := PackGetPositionSizesAndUpperBoundsForMarketsOutput(output)
 	if err != nil {
 		return nil, remainingGas, err
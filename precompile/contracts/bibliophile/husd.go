@@ -0,0 +1,27 @@
+package bibliophile
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// hUSD is the margin collateral, an ERC20PresetMinterPauserUpgradeable. The margin bridge mints and burns it for the
+// margin that it credits and debits in the MarginAccount, at the slots of the ERC20 storage that follows the storage
+// of the upgradeable access control contracts. The node only enables the bridge once the layout of the deployed hUSD
+// verifies these slots.
+const (
+	HUSD_BALANCES_SLOT     int64 = 201 // mapping(address account => uint256 balance)
+	HUSD_TOTAL_SUPPLY_SLOT int64 = 203
+)
+
+// HUSDBalanceStorageSlot returns the slot of the hUSD balance of [account]
+func HUSDBalanceStorageSlot(account common.Address) common.Hash {
+	return crypto.Keccak256Hash(append(common.LeftPadBytes(account.Bytes(), 32), common.LeftPadBytes(big.NewInt(HUSD_BALANCES_SLOT).Bytes(), 32)...))
+}
+
+// HUSDTotalSupplyStorageSlot returns the slot of the total supply of hUSD
+func HUSDTotalSupplyStorageSlot() common.Hash {
+	return common.BigToHash(big.NewInt(HUSD_TOTAL_SUPPLY_SLOT))
+}
@@ -12,6 +12,7 @@ import (
 const (
 	MARGIN_ACCOUNT_GENESIS_ADDRESS        = "0x0300000000000000000000000000000000000001"
 	VAR_MARGIN_MAPPING_STORAGE_SLOT int64 = 10
+	VAR_RESERVED_MARGIN_SLOT        int64 = 11 // mapping(address trader => uint reservedMargin), the margin held by open orders
 )
 
 func GetNormalizedMargin(stateDB contract.StateDB, trader common.Address) *big.Int {
@@ -21,7 +22,30 @@ func GetNormalizedMargin(stateDB contract.StateDB, trader common.Address) *big.I
 }
 
 func getMargin(stateDB contract.StateDB, collateralIdx *big.Int, trader common.Address) *big.Int {
+	return fromTwosComplement(stateDB.GetState(common.HexToAddress(MARGIN_ACCOUNT_GENESIS_ADDRESS), MarginStorageSlot(collateralIdx, trader)).Bytes())
+}
+
+// MarginStorageSlot returns the slot of margin[collateralIdx][trader] in the storage of the MarginAccount
+func MarginStorageSlot(collateralIdx *big.Int, trader common.Address) common.Hash {
 	marginStorageSlot := crypto.Keccak256(append(common.LeftPadBytes(collateralIdx.Bytes(), 32), common.LeftPadBytes(big.NewInt(VAR_MARGIN_MAPPING_STORAGE_SLOT).Bytes(), 32)...))
-	marginStorageSlot = crypto.Keccak256(append(common.LeftPadBytes(trader.Bytes(), 32), marginStorageSlot...))
-	return fromTwosComplement(stateDB.GetState(common.HexToAddress(MARGIN_ACCOUNT_GENESIS_ADDRESS), common.BytesToHash(marginStorageSlot)).Bytes())
+	return crypto.Keccak256Hash(append(common.LeftPadBytes(trader.Bytes(), 32), marginStorageSlot...))
+}
+
+// GetReservedMargin returns the margin that the open orders of [trader] hold in the MarginAccount
+func GetReservedMargin(stateDB contract.StateDB, trader common.Address) *big.Int {
+	slot := crypto.Keccak256Hash(append(common.LeftPadBytes(trader.Bytes(), 32), common.LeftPadBytes(big.NewInt(VAR_RESERVED_MARGIN_SLOT).Bytes(), 32)...))
+	return stateDB.GetState(common.HexToAddress(MARGIN_ACCOUNT_GENESIS_ADDRESS), slot).Big()
+}
+
+// GetAvailableMargin returns the margin of [trader] that is neither used by its positions at the min allowable margin
+// nor reserved by its open orders, as MarginAccount.getAvailableMargin computes it
+func GetAvailableMargin(stateDB contract.StateDB, trader common.Address, version Version) *big.Int {
+	output := GetNotionalPositionAndMargin(stateDB, &GetNotionalPositionAndMarginInput{
+		Trader:                 trader,
+		IncludeFundingPayments: true,
+		Mode:                   uint8(Min_Allowable_Margin),
+	}, version)
+	utilizedMargin := divide1e6(new(big.Int).Mul(output.NotionalPosition, GetMinAllowableMargin(stateDB)))
+	availableMargin := new(big.Int).Sub(output.Margin, utilizedMargin)
+	return availableMargin.Sub(availableMargin, GetReservedMargin(stateDB, trader))
 }
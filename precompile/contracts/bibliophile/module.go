@@ -59,5 +59,6 @@ func (*configurator) Configure(chainConfig contract.ChainConfig, cfg precompilec
 		return fmt.Errorf("incorrect config %T: %v", config, config)
 	}
 	// CUSTOM CODE STARTS HERE
+	setVersions(state, config.Versions)
 	return nil
 }
@@ -0,0 +1,244 @@
+package bibliophile
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/warporacle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// OracleType identifies the storage layout of the oracle an AMM reads its underlying price from.
+// It is read from the oracle config of the AMM, see ORACLE_CONFIGS_SLOT.
+type OracleType uint8
+
+const (
+	// UnsetOracle is for AMMs that were listed without an oracle type.
+	// The oracle is then detected from the AMM: RedStone if an adapter and feed id are configured, the warporacle
+	// precompile if it is set as the oracle, TestOracle otherwise
+	UnsetOracle OracleType = iota
+	TestOracle
+	RedStoneOracle
+	ChainlinkOracle
+	PythOracle
+	// WarpOracle is the warporacle precompile, which keeps the prices delivered over warp from an oracle chain
+	WarpOracle
+)
+
+const (
+	// ORACLE_CONFIGS_SLOT is the slot (in the storage of the bibliophile precompile) of the
+	// mapping(address amm => OracleConfig{uint8 oracleType; uint256 maxPriceAge; uint256 maxPriceDeviation}).
+	// The AMM contracts don't keep these, they are set with the hubble state upgrades.
+	ORACLE_CONFIGS_SLOT int64 = 1
+)
+
+var (
+	ErrStaleOraclePrice     = errors.New("OB_stale_oracle_price")
+	ErrOraclePriceDeviation = errors.New("OB_oracle_price_deviation_too_high")
+)
+
+// OraclePrice is the normalised (6 decimals) price read from an oracle
+type OraclePrice struct {
+	Price *big.Int
+	// Confidence interval around the price, nil if the oracle does not publish one
+	Confidence *big.Int
+	// UpdatedAt is the unix timestamp (in seconds) of the price; 0 if the oracle does not publish one
+	UpdatedAt uint64
+	// PreviousPrice is the price of the previous round (or the ema price for pyth), nil if the oracle does not keep one
+	PreviousPrice *big.Int
+}
+
+// OracleReader reads the underlying price for an AMM directly from the oracle's storage
+type OracleReader interface {
+	ReadPrice(stateDB contract.StateDB, market common.Address) OraclePrice
+}
+
+// oracleReaders is read by consensus code, it is only ever read after init
+var oracleReaders = map[OracleType]OracleReader{
+	TestOracle:      &testOracleReader{},
+	RedStoneOracle:  &redStoneOracleReader{},
+	ChainlinkOracle: &chainlinkOracleReader{},
+	PythOracle:      &pythOracleReader{},
+	WarpOracle:      &warpOracleReader{},
+}
+
+func oracleConfigStorageSlot(market common.Address) *big.Int {
+	return new(big.Int).SetBytes(crypto.Keccak256(append(common.LeftPadBytes(market.Bytes(), 32), common.LeftPadBytes(big.NewInt(ORACLE_CONFIGS_SLOT).Bytes(), 32)...)))
+}
+
+// OracleTypeStorageSlot returns the slot of the bibliophile precompile that keeps the oracle type of the [market]
+func OracleTypeStorageSlot(market common.Address) common.Hash {
+	return common.BigToHash(oracleConfigStorageSlot(market))
+}
+
+// MaxOraclePriceAgeStorageSlot returns the slot of the bibliophile precompile that keeps the max oracle price age of the [market]
+func MaxOraclePriceAgeStorageSlot(market common.Address) common.Hash {
+	return common.BigToHash(new(big.Int).Add(oracleConfigStorageSlot(market), big.NewInt(1)))
+}
+
+// MaxOraclePriceDeviationStorageSlot returns the slot of the bibliophile precompile that keeps the max oracle price deviation of the [market]
+func MaxOraclePriceDeviationStorageSlot(market common.Address) common.Hash {
+	return common.BigToHash(new(big.Int).Add(oracleConfigStorageSlot(market), big.NewInt(2)))
+}
+
+func getOracleType(stateDB contract.StateDB, market common.Address) OracleType {
+	return OracleType(stateDB.GetState(ContractAddress, OracleTypeStorageSlot(market)).Big().Uint64())
+}
+
+// GetMaxOraclePriceAge returns the max age (in seconds) of the oracle price for a given market; 0 means staleness is not checked
+func GetMaxOraclePriceAge(stateDB contract.StateDB, marketID int64) *big.Int {
+	market := getMarketAddressFromMarketID(marketID, stateDB)
+	return stateDB.GetState(ContractAddress, MaxOraclePriceAgeStorageSlot(market)).Big()
+}
+
+// GetMaxOraclePriceDeviation returns the max allowed change (1e6 precision) of the oracle price from the previous block for a given market; 0 means deviation is not checked
+func GetMaxOraclePriceDeviation(stateDB contract.StateDB, marketID int64) *big.Int {
+	market := getMarketAddressFromMarketID(marketID, stateDB)
+	return stateDB.GetState(ContractAddress, MaxOraclePriceDeviationStorageSlot(market)).Big()
+}
+
+// getOracleAdapterAddress returns the address of the oracle adapter for RedStone, Chainlink and Pyth style oracles
+func getOracleAdapterAddress(stateDB contract.StateDB, market common.Address) common.Address {
+	return getRedStoneAdapterAddress(stateDB, market)
+}
+
+// getOracleFeedId returns the feed id (RedStone) or price id (Pyth) of the market in the oracle adapter
+func getOracleFeedId(stateDB contract.StateDB, market common.Address) common.Hash {
+	return getRedStoneFeedId(stateDB, market)
+}
+
+func getOracleReader(stateDB contract.StateDB, market common.Address, version Version) OracleReader {
+	if version < V3 {
+		// before V3 the price was read from RedStone if an adapter and feed id are configured, TestOracle otherwise
+		if isRedStoneConfigured(stateDB, market) {
+			return &redStoneOracleReader{}
+		}
+		return &testOracleReader{}
+	}
+	oracleType := getOracleType(stateDB, market)
+	if oracleType == UnsetOracle {
+		oracleType = TestOracle
+		// first we check the feedId, if it is set, it should imply we are using a redstone oracle
+		if isRedStoneConfigured(stateDB, market) {
+			oracleType = RedStoneOracle
+		} else if getOracleAddress(stateDB, market) == warporacle.ContractAddress {
+			// the warporacle precompile is set directly as the oracle of the AMM
+			oracleType = WarpOracle
+		}
+	}
+	reader, ok := oracleReaders[oracleType]
+	if !ok {
+		// unknown oracle type, fall back to the default TestOracle
+		reader = oracleReaders[TestOracle]
+	}
+	return reader
+}
+
+func isRedStoneConfigured(stateDB contract.StateDB, market common.Address) bool {
+	return getRedStoneAdapterAddress(stateDB, market).Hash().Big().Sign() != 0 && getRedStoneFeedId(stateDB, market).Big().Sign() != 0
+}
+
+func getOraclePrice(stateDB contract.StateDB, market common.Address, version Version) OraclePrice {
+	return getOracleReader(stateDB, market, version).ReadPrice(stateDB, market)
+}
+
+// IsOraclePriceStale returns true if the oracle price of the market is older than the max age configured for it.
+// Staleness is only checked from V3.
+func IsOraclePriceStale(chainConfig contract.ChainConfig, stateDB contract.StateDB, marketID int64, blockTimestamp uint64) bool {
+	version := GetVersion(chainConfig, stateDB, blockTimestamp)
+	if version < V3 {
+		return false
+	}
+	maxAge := GetMaxOraclePriceAge(stateDB, marketID)
+	if maxAge.Sign() == 0 {
+		return false
+	}
+	market := getMarketAddressFromMarketID(marketID, stateDB)
+	return isStale(getOraclePrice(stateDB, market, version).UpdatedAt, maxAge.Uint64(), blockTimestamp)
+}
+
+// CheckOracleCircuitBreaker returns an error if matching and liquidations must be halted for the market,
+// because its oracle price is either stale or has moved more than the allowed deviation since the previous block.
+// [blockTimestamp] is the timestamp of the block being executed (or built). The circuit breaker is only enforced from V3.
+func CheckOracleCircuitBreaker(chainConfig contract.ChainConfig, stateDB contract.StateDB, marketID int64, blockTimestamp uint64) error {
+	version := GetVersion(chainConfig, stateDB, blockTimestamp)
+	if version < V3 {
+		return nil
+	}
+	market := getMarketAddressFromMarketID(marketID, stateDB)
+	price := getOraclePrice(stateDB, market, version)
+	if isStale(price.UpdatedAt, GetMaxOraclePriceAge(stateDB, marketID).Uint64(), blockTimestamp) {
+		return ErrStaleOraclePrice
+	}
+	if exceedsMaxDeviation(price.Price, previousBlockPrice(price, blockTimestamp), GetMaxOraclePriceDeviation(stateDB, marketID)) {
+		return ErrOraclePriceDeviation
+	}
+	return nil
+}
+
+// previousBlockPrice returns the oracle price as of the previous block. A price updated before [blockTimestamp] was
+// already there in the previous block, otherwise it was updated in this block over the previous round.
+func previousBlockPrice(price OraclePrice, blockTimestamp uint64) *big.Int {
+	if price.UpdatedAt != 0 && price.UpdatedAt < blockTimestamp {
+		return price.Price
+	}
+	return price.PreviousPrice
+}
+
+func exceedsMaxDeviation(price, previousPrice, maxDeviation *big.Int) bool {
+	if maxDeviation.Sign() == 0 {
+		return false
+	}
+	if previousPrice == nil || previousPrice.Sign() <= 0 {
+		// the deviation can't be shown to be within the limit without a previous price
+		return true
+	}
+	// |price - previousPrice| / previousPrice > maxDeviation / 1e6
+	deviation := new(big.Int).Mul(new(big.Int).Abs(new(big.Int).Sub(price, previousPrice)), _1e6)
+	return deviation.Cmp(new(big.Int).Mul(previousPrice, maxDeviation)) == 1
+}
+
+func isStale(updatedAt, maxAge, blockTimestamp uint64) bool {
+	if maxAge == 0 {
+		// staleness is not checked
+		return false
+	}
+	// a price without a timestamp (never updated, or from an oracle that does not publish one) can't be shown to be fresh
+	return updatedAt == 0 || updatedAt+maxAge < blockTimestamp
+}
+
+// testOracleReader reads prices from the TestOracle contract that is set in the AMM's oracle slot
+type testOracleReader struct{}
+
+func (r *testOracleReader) ReadPrice(stateDB contract.StateDB, market common.Address) OraclePrice {
+	return OraclePrice{Price: getTestOraclePrice(stateDB, market)}
+}
+
+func getTestOraclePrice(stateDB contract.StateDB, market common.Address) *big.Int {
+	oracle := getOracleAddress(stateDB, market)
+	return fromTwosComplement(stateDB.GetState(oracle, testOraclePriceSlot(getUnderlyingAssetAddress(stateDB, market))).Bytes())
+}
+
+// TestOraclePriceStorageSlot returns the TestOracle of the market and the slot of its storage that keeps the underlying
+// price. It returns false if the market doesn't read its price from a TestOracle at [blockTimestamp].
+func TestOraclePriceStorageSlot(chainConfig contract.ChainConfig, stateDB contract.StateDB, marketID int64, blockTimestamp uint64) (common.Address, common.Hash, bool) {
+	market := getMarketAddressFromMarketID(marketID, stateDB)
+	if _, ok := getOracleReader(stateDB, market, GetVersion(chainConfig, stateDB, blockTimestamp)).(*testOracleReader); !ok {
+		return common.Address{}, common.Hash{}, false
+	}
+	return getOracleAddress(stateDB, market), testOraclePriceSlot(getUnderlyingAssetAddress(stateDB, market)), true
+}
+
+func testOraclePriceSlot(underlying common.Address) common.Hash {
+	return crypto.Keccak256Hash(append(common.LeftPadBytes(underlying.Bytes(), 32), common.LeftPadBytes(big.NewInt(TEST_ORACLE_PRICES_MAPPING_SLOT).Bytes(), 32)...))
+}
+
+// normaliseToPrecision6 converts [value] with [decimals] decimals to 6 decimals
+func normaliseToPrecision6(value *big.Int, decimals int64) *big.Int {
+	if decimals >= 6 {
+		return new(big.Int).Div(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(decimals-6), nil))
+	}
+	return new(big.Int).Mul(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(6-decimals), nil))
+}
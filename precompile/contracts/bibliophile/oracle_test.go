@@ -0,0 +1,220 @@
+package bibliophile
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/warporacle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testAMM     = common.HexToAddress("0xa72b463C21dA61cCc86069cFab82e9e8491152a0")
+	testAdapter = common.HexToAddress("0x0000000000000000000000000000000000000a11")
+	testFeedId  = common.HexToHash("0x4554480000000000000000000000000000000000000000000000000000000000")
+	// the versions are set in the precompile storage by setupMarket
+	testChainConfig = contract.NewMockChainStateWithHubbleVersions(nil)
+)
+
+func setupMarket(stateDB contract.StateDB, oracleType OracleType) {
+	setVersions(stateDB, []VersionUpgrade{{BlockTimestamp: 0, Version: V3}})
+	clearingHouse := common.HexToAddress(CLEARING_HOUSE_GENESIS_ADDRESS)
+	stateDB.SetState(clearingHouse, common.BigToHash(big.NewInt(AMMS_SLOT)), common.BigToHash(big.NewInt(1)))
+	stateDB.SetState(clearingHouse, common.BigToHash(marketsStorageSlot()), testAMM.Hash())
+	stateDB.SetState(ContractAddress, OracleTypeStorageSlot(testAMM), common.BigToHash(big.NewInt(int64(oracleType))))
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(RED_STONE_ADAPTER_SLOT)), testAdapter.Hash())
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(RED_STONE_FEED_ID_SLOT)), testFeedId)
+}
+
+func TestTestOracleReader(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setupMarket(stateDB, UnsetOracle)
+	// no redstone feed id => TestOracle
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(RED_STONE_FEED_ID_SLOT)), common.Hash{})
+	oracle := common.HexToAddress("0x0000000000000000000000000000000000000b22")
+	underlying := common.HexToAddress("0x0000000000000000000000000000000000000c33")
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(ORACLE_SLOT)), oracle.Hash())
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(UNDERLYING_ASSET_SLOT)), underlying.Hash())
+	slot := crypto.Keccak256(append(common.LeftPadBytes(underlying.Bytes(), 32), common.LeftPadBytes(big.NewInt(TEST_ORACLE_PRICES_MAPPING_SLOT).Bytes(), 32)...))
+	stateDB.SetState(oracle, common.BytesToHash(slot), common.BigToHash(big.NewInt(1800e6)))
+
+	assert.Equal(t, big.NewInt(1800e6), getUnderlyingPrice(stateDB, testAMM, V3))
+	assert.False(t, IsOraclePriceStale(testChainConfig, stateDB, 0, 1e10))
+}
+
+func TestRedStoneOracleReader(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setupMarket(stateDB, UnsetOracle) // redstone is detected from the adapter and feed id
+	roundId := big.NewInt(5)
+	stateDB.SetState(testAdapter, RED_STONE_LATEST_ROUND_ID_STORAGE_LOCATION, common.BigToHash(roundId))
+	slot := common.BytesToHash(crypto.Keccak256(append(append(testFeedId.Bytes(), common.LeftPadBytes(roundId.Bytes(), 32)...), RED_STONE_VALUES_MAPPING_STORAGE_LOCATION.Bytes()...)))
+	stateDB.SetState(testAdapter, slot, common.BigToHash(big.NewInt(1800e8)))
+	dataTimestamp := new(big.Int).Lsh(big.NewInt(1_700_000_000_000), 128) // milliseconds
+	stateDB.SetState(testAdapter, RED_STONE_LATEST_UPDATE_TIMESTAMPS_STORAGE_LOCATION, common.BigToHash(new(big.Int).Add(dataTimestamp, big.NewInt(1_700_000_005))))
+
+	price := getOraclePrice(stateDB, testAMM, V3)
+	assert.Equal(t, big.NewInt(1800e6), price.Price)
+	assert.Equal(t, uint64(1_700_000_000), price.UpdatedAt)
+}
+
+func TestChainlinkOracleReader(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setupMarket(stateDB, ChainlinkOracle)
+	roundId := big.NewInt(7)
+	stateDB.SetState(testAdapter, common.BigToHash(big.NewInt(CHAINLINK_DECIMALS_SLOT)), common.BigToHash(big.NewInt(8)))
+	stateDB.SetState(testAdapter, common.BigToHash(big.NewInt(CHAINLINK_LATEST_ROUND_ID_SLOT)), common.BigToHash(roundId))
+	roundSlot := chainlinkRoundStorageSlot(roundId)
+	stateDB.SetState(testAdapter, common.BigToHash(roundSlot), common.BigToHash(big.NewInt(1800e8)))
+	stateDB.SetState(testAdapter, common.BigToHash(new(big.Int).Add(roundSlot, big.NewInt(2))), common.BigToHash(big.NewInt(1_700_000_000)))
+
+	price := getOraclePrice(stateDB, testAMM, V3)
+	assert.Equal(t, big.NewInt(1800e6), price.Price)
+	assert.Equal(t, uint64(1_700_000_000), price.UpdatedAt)
+}
+
+func TestPythOracleReader(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setupMarket(stateDB, PythOracle)
+	// price = 180000000000 * 10^-8, conf = 50000000 * 10^-8, publishTime = 1700000000
+	packed := make([]byte, 32)
+	copy(packed[4:12], common.LeftPadBytes(big.NewInt(5e7).Bytes(), 8))
+	copy(packed[12:20], common.LeftPadBytes(big.NewInt(1800e8).Bytes(), 8))
+	expo := int32(-8)
+	copy(packed[20:24], common.LeftPadBytes(big.NewInt(int64(uint32(expo))).Bytes(), 4))
+	copy(packed[24:32], common.LeftPadBytes(big.NewInt(1_700_000_000).Bytes(), 8))
+	slot := crypto.Keccak256(append(testFeedId.Bytes(), common.LeftPadBytes(big.NewInt(PYTH_PRICE_INFO_MAPPING_SLOT).Bytes(), 32)...))
+	stateDB.SetState(testAdapter, common.BytesToHash(slot), common.BytesToHash(packed))
+
+	price := getOraclePrice(stateDB, testAMM, V3)
+	assert.Equal(t, big.NewInt(1800e6), price.Price)
+	assert.Equal(t, big.NewInt(5e5), price.Confidence)
+	assert.Equal(t, uint64(1_700_000_000), price.UpdatedAt)
+}
+
+func TestWarpOracleReader(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setupMarket(stateDB, UnsetOracle)
+	// no redstone feed id and the warporacle precompile as the oracle => WarpOracle
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(RED_STONE_FEED_ID_SLOT)), common.Hash{})
+	underlying := common.HexToAddress("0x0000000000000000000000000000000000000c33")
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(ORACLE_SLOT)), warporacle.ContractAddress.Hash())
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(UNDERLYING_ASSET_SLOT)), underlying.Hash())
+	assert.IsType(t, &warpOracleReader{}, getOracleReader(stateDB, testAMM, V3))
+
+	// no price has been delivered yet
+	assert.Equal(t, 0, getOraclePrice(stateDB, testAMM, V3).Price.Sign())
+
+	feedSlot := contract.MappingSlot(contract.AddressKey(underlying), contract.StorageSlot(warporacle.FEEDS_SLOT))
+	observation := func(price, timestamp int64) common.Hash {
+		return common.BigToHash(new(big.Int).Or(new(big.Int).Lsh(big.NewInt(timestamp), 192), big.NewInt(price)))
+	}
+	stateDB.SetState(warporacle.ContractAddress, contract.AddToSlot(feedSlot, big.NewInt(1)), common.BigToHash(big.NewInt(2)))
+	stateDB.SetState(warporacle.ContractAddress, contract.AddToSlot(feedSlot, big.NewInt(2)), observation(1700e6, 1_699_999_940))
+	stateDB.SetState(warporacle.ContractAddress, contract.AddToSlot(feedSlot, big.NewInt(3)), observation(1800e6, 1_700_000_000))
+
+	price := getOraclePrice(stateDB, testAMM, V3)
+	assert.Equal(t, big.NewInt(1800e6), price.Price)
+	assert.Equal(t, big.NewInt(1700e6), price.PreviousPrice)
+	assert.Equal(t, uint64(1_700_000_000), price.UpdatedAt)
+}
+
+func TestIsOraclePriceStale(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setupMarket(stateDB, ChainlinkOracle)
+	roundSlot := chainlinkRoundStorageSlot(big.NewInt(0))
+	stateDB.SetState(testAdapter, common.BigToHash(new(big.Int).Add(roundSlot, big.NewInt(2))), common.BigToHash(big.NewInt(1_700_000_000)))
+
+	t.Run("when max age is not set", func(t *testing.T) {
+		assert.False(t, IsOraclePriceStale(testChainConfig, stateDB, 0, 1_800_000_000))
+	})
+	t.Run("when max age is set", func(t *testing.T) {
+		stateDB.SetState(ContractAddress, MaxOraclePriceAgeStorageSlot(testAMM), common.BigToHash(big.NewInt(60)))
+		assert.False(t, IsOraclePriceStale(testChainConfig, stateDB, 0, 1_700_000_060))
+		assert.True(t, IsOraclePriceStale(testChainConfig, stateDB, 0, 1_700_000_061))
+	})
+	t.Run("when the oracle has no timestamp", func(t *testing.T) {
+		stateDB.SetState(testAdapter, common.BigToHash(new(big.Int).Add(roundSlot, big.NewInt(2))), common.Hash{})
+		assert.True(t, IsOraclePriceStale(testChainConfig, stateDB, 0, 1_700_000_000))
+	})
+	t.Run("before V3", func(t *testing.T) {
+		setVersions(stateDB, []VersionUpgrade{{BlockTimestamp: 0, Version: V2}})
+		assert.False(t, IsOraclePriceStale(testChainConfig, stateDB, 0, 1_800_000_000))
+	})
+}
+
+func TestOracleReaderBeforeV3(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	// the oracle type is ignored, the price is read from RedStone since an adapter and feed id are configured
+	setupMarket(stateDB, ChainlinkOracle)
+	assert.IsType(t, &redStoneOracleReader{}, getOracleReader(stateDB, testAMM, V2))
+	assert.IsType(t, &chainlinkOracleReader{}, getOracleReader(stateDB, testAMM, V3))
+
+	// and from the TestOracle otherwise, even if it is the warporacle precompile
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(RED_STONE_FEED_ID_SLOT)), common.Hash{})
+	stateDB.SetState(ContractAddress, OracleTypeStorageSlot(testAMM), common.Hash{})
+	stateDB.SetState(testAMM, common.BigToHash(big.NewInt(ORACLE_SLOT)), warporacle.ContractAddress.Hash())
+	assert.IsType(t, &testOracleReader{}, getOracleReader(stateDB, testAMM, V2))
+	assert.IsType(t, &warpOracleReader{}, getOracleReader(stateDB, testAMM, V3))
+}
+
+func TestCheckOracleCircuitBreaker(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setupMarket(stateDB, ChainlinkOracle)
+	stateDB.SetState(testAdapter, common.BigToHash(big.NewInt(CHAINLINK_DECIMALS_SLOT)), common.BigToHash(big.NewInt(6)))
+	stateDB.SetState(testAdapter, common.BigToHash(big.NewInt(CHAINLINK_LATEST_ROUND_ID_SLOT)), common.BigToHash(big.NewInt(2)))
+	stateDB.SetState(testAdapter, common.BigToHash(chainlinkRoundStorageSlot(big.NewInt(1))), common.BigToHash(big.NewInt(1000e6)))
+	roundSlot := chainlinkRoundStorageSlot(big.NewInt(2))
+	stateDB.SetState(testAdapter, common.BigToHash(roundSlot), common.BigToHash(big.NewInt(1100e6))) // +10%
+	stateDB.SetState(testAdapter, common.BigToHash(new(big.Int).Add(roundSlot, big.NewInt(2))), common.BigToHash(big.NewInt(1_700_000_000)))
+
+	t.Run("when no limits are set", func(t *testing.T) {
+		assert.Nil(t, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_800_000_000))
+	})
+	t.Run("when price moved less than max deviation", func(t *testing.T) {
+		stateDB.SetState(ContractAddress, MaxOraclePriceDeviationStorageSlot(testAMM), common.BigToHash(big.NewInt(1e5))) // 10%
+		assert.Nil(t, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_700_000_000))
+	})
+	t.Run("when price moved more than max deviation", func(t *testing.T) {
+		stateDB.SetState(ContractAddress, MaxOraclePriceDeviationStorageSlot(testAMM), common.BigToHash(big.NewInt(5e4))) // 5%
+		assert.Equal(t, ErrOraclePriceDeviation, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_700_000_000))
+	})
+	t.Run("when price moved in an earlier block", func(t *testing.T) {
+		// the price was the same in the previous block
+		assert.Nil(t, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_700_000_001))
+	})
+	t.Run("when price is stale", func(t *testing.T) {
+		stateDB.SetState(ContractAddress, MaxOraclePriceAgeStorageSlot(testAMM), common.BigToHash(big.NewInt(60)))
+		assert.Equal(t, ErrStaleOraclePrice, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_700_000_061))
+	})
+	t.Run("when there is no previous round", func(t *testing.T) {
+		stateDB.SetState(ContractAddress, MaxOraclePriceAgeStorageSlot(testAMM), common.Hash{})
+		stateDB.SetState(testAdapter, common.BigToHash(chainlinkRoundStorageSlot(big.NewInt(1))), common.Hash{})
+		assert.Equal(t, ErrOraclePriceDeviation, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_700_000_000))
+	})
+	t.Run("before V3", func(t *testing.T) {
+		setVersions(stateDB, []VersionUpgrade{{BlockTimestamp: 0, Version: V2}})
+		assert.Nil(t, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_700_000_000))
+	})
+}
+
+func TestCheckOracleCircuitBreakerWithRedStone(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setupMarket(stateDB, RedStoneOracle)
+	setRound := func(roundId int64, price int64) {
+		slot := common.BytesToHash(crypto.Keccak256(append(append(testFeedId.Bytes(), common.LeftPadBytes(big.NewInt(roundId).Bytes(), 32)...), RED_STONE_VALUES_MAPPING_STORAGE_LOCATION.Bytes()...)))
+		stateDB.SetState(testAdapter, slot, common.BigToHash(big.NewInt(price)))
+		stateDB.SetState(testAdapter, RED_STONE_LATEST_ROUND_ID_STORAGE_LOCATION, common.BigToHash(big.NewInt(roundId)))
+	}
+	setRound(1, 1000e8)
+	// a max deviation of 10%, the price moved by +8%
+	stateDB.SetState(ContractAddress, MaxOraclePriceDeviationStorageSlot(testAMM), common.BigToHash(big.NewInt(1e5)))
+	setRound(2, 1080e8)
+	assert.Nil(t, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_700_000_000))
+
+	setRound(3, 900e8) // -16.7%
+	assert.Equal(t, ErrOraclePriceDeviation, CheckOracleCircuitBreaker(testChainConfig, stateDB, 0, 1_700_000_000))
+}
@@ -15,6 +15,9 @@ const (
 	ORDERBOOK_GENESIS_ADDRESS       = "0x0300000000000000000000000000000000000000"
 	ORDER_INFO_SLOT           int64 = 53
 	IS_TRADING_AUTHORITY_SLOT int64 = 61
+	// an amended order keeps its order info under the hash it was placed with, these are the links between the two hashes
+	ORDER_AMENDED_FROM_SLOT int64 = 62 // mapping(bytes32 amendedOrderHash => bytes32 orderHash)
+	ORDER_AMENDED_TO_SLOT   int64 = 63 // mapping(bytes32 orderHash => bytes32 amendedOrderHash), the latest amendment only
 )
 
 var (
@@ -48,6 +51,18 @@ func orderInfoMappingStorageSlot(orderHash [32]byte) *big.Int {
 	return new(big.Int).SetBytes(crypto.Keccak256(append(orderHash[:], common.LeftPadBytes(big.NewInt(ORDER_INFO_SLOT).Bytes(), 32)...)))
 }
 
+func getOrderAmendedFrom(stateDB contract.StateDB, orderHash [32]byte) [32]byte {
+	return stateDB.GetState(common.HexToAddress(ORDERBOOK_GENESIS_ADDRESS), bytes32MappingStorageSlot(orderHash, ORDER_AMENDED_FROM_SLOT))
+}
+
+func getOrderAmendedTo(stateDB contract.StateDB, orderHash [32]byte) [32]byte {
+	return stateDB.GetState(common.HexToAddress(ORDERBOOK_GENESIS_ADDRESS), bytes32MappingStorageSlot(orderHash, ORDER_AMENDED_TO_SLOT))
+}
+
+func bytes32MappingStorageSlot(key [32]byte, slot int64) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(append(key[:], common.LeftPadBytes(big.NewInt(slot).Bytes(), 32)...)))
+}
+
 func IsTradingAuthority(stateDB contract.StateDB, trader, senderOrSigner common.Address) bool {
 	tradingAuthorityMappingSlot := crypto.Keccak256(append(common.LeftPadBytes(trader.Bytes(), 32), common.LeftPadBytes(big.NewInt(IS_TRADING_AUTHORITY_SLOT).Bytes(), 32)...))
 	tradingAuthorityMappingSlot = crypto.Keccak256(append(common.LeftPadBytes(senderOrSigner.Bytes(), 32), tradingAuthorityMappingSlot...))
@@ -56,7 +71,7 @@ func IsTradingAuthority(stateDB contract.StateDB, trader, senderOrSigner common.
 
 // Business Logic
 
-func ValidateOrdersAndDetermineFillPrice(stateDB contract.StateDB, inputStruct *ValidateOrdersAndDetermineFillPriceInput) (*ValidateOrdersAndDetermineFillPriceOutput, error) {
+func ValidateOrdersAndDetermineFillPrice(stateDB contract.StateDB, inputStruct *ValidateOrdersAndDetermineFillPriceInput, version Version) (*ValidateOrdersAndDetermineFillPriceOutput, error) {
 	longOrder := inputStruct.Orders[0]
 	shortOrder := inputStruct.Orders[1]
 
@@ -86,12 +101,12 @@ func ValidateOrdersAndDetermineFillPrice(stateDB contract.StateDB, inputStruct *
 	if new(big.Int).Mod(inputStruct.FillAmount, minSize).Cmp(big.NewInt(0)) != 0 {
 		return nil, ErrNotMultiple
 	}
-	return DetermineFillPrice(stateDB, longOrder.AmmIndex.Int64(), longOrder.Price, shortOrder.Price, blockPlaced0, blockPlaced1)
+	return DetermineFillPrice(stateDB, longOrder.AmmIndex.Int64(), longOrder.Price, shortOrder.Price, blockPlaced0, blockPlaced1, version)
 }
 
-func DetermineFillPrice(stateDB contract.StateDB, marketId int64, longOrderPrice, shortOrderPrice, blockPlaced0, blockPlaced1 *big.Int) (*ValidateOrdersAndDetermineFillPriceOutput, error) {
+func DetermineFillPrice(stateDB contract.StateDB, marketId int64, longOrderPrice, shortOrderPrice, blockPlaced0, blockPlaced1 *big.Int, version Version) (*ValidateOrdersAndDetermineFillPriceOutput, error) {
 	market := getMarketAddressFromMarketID(marketId, stateDB)
-	oraclePrice := getUnderlyingPrice(stateDB, market)
+	oraclePrice := getUnderlyingPrice(stateDB, market, version)
 	spreadLimit := GetMaxOraclePriceSpread(stateDB, marketId)
 	return determineFillPrice(oraclePrice, spreadLimit, longOrderPrice, shortOrderPrice, blockPlaced0, blockPlaced1)
 }
@@ -120,22 +135,22 @@ func determineFillPrice(oraclePrice, spreadLimit, longOrderPrice, shortOrderPric
 	return &output, nil
 }
 
-func ValidateLiquidationOrderAndDetermineFillPrice(stateDB contract.StateDB, inputStruct *ValidateLiquidationOrderAndDetermineFillPriceInput) (*big.Int, error) {
+func ValidateLiquidationOrderAndDetermineFillPrice(stateDB contract.StateDB, inputStruct *ValidateLiquidationOrderAndDetermineFillPriceInput, version Version) (*big.Int, error) {
 	order := inputStruct.Order
 	minSize := GetMinSizeRequirement(stateDB, order.AmmIndex.Int64())
 	if new(big.Int).Mod(inputStruct.FillAmount, minSize).Cmp(big.NewInt(0)) != 0 {
 		return nil, ErrNotMultiple
 	}
-	return DetermineLiquidationFillPrice(stateDB, order.AmmIndex.Int64(), order.BaseAssetQuantity, order.Price)
+	return DetermineLiquidationFillPrice(stateDB, order.AmmIndex.Int64(), order.BaseAssetQuantity, order.Price, version)
 }
 
-func DetermineLiquidationFillPrice(stateDB contract.StateDB, marketId int64, baseAssetQuantity, price *big.Int) (*big.Int, error) {
+func DetermineLiquidationFillPrice(stateDB contract.StateDB, marketId int64, baseAssetQuantity, price *big.Int, version Version) (*big.Int, error) {
 	isLongOrder := true
 	if baseAssetQuantity.Sign() < 0 {
 		isLongOrder = false
 	}
 	market := getMarketAddressFromMarketID(marketId, stateDB)
-	oraclePrice := getUnderlyingPrice(stateDB, market)
+	oraclePrice := getUnderlyingPrice(stateDB, market, version)
 	liquidationSpreadLimit := GetMaxLiquidationPriceSpread(stateDB, marketId)
 	liqUpperBound, liqLowerBound := calculateBounds(liquidationSpreadLimit, oraclePrice)
 
@@ -163,15 +178,15 @@ func determineLiquidationFillPrice(isLongOrder bool, price, liqUpperBound, liqLo
 
 // Helper functions
 
-func GetAcceptableBounds(stateDB contract.StateDB, marketID int64) (upperBound, lowerBound *big.Int) {
+func GetAcceptableBounds(stateDB contract.StateDB, marketID int64, version Version) (upperBound, lowerBound *big.Int) {
 	spreadLimit := GetMaxOraclePriceSpread(stateDB, marketID)
-	oraclePrice := getUnderlyingPriceForMarket(stateDB, marketID)
+	oraclePrice := getUnderlyingPriceForMarket(stateDB, marketID, version)
 	return calculateBounds(spreadLimit, oraclePrice)
 }
 
-func GetAcceptableBoundsForLiquidation(stateDB contract.StateDB, marketID int64) (upperBound, lowerBound *big.Int) {
+func GetAcceptableBoundsForLiquidation(stateDB contract.StateDB, marketID int64, version Version) (upperBound, lowerBound *big.Int) {
 	spreadLimit := GetMaxLiquidationPriceSpread(stateDB, marketID)
-	oraclePrice := getUnderlyingPriceForMarket(stateDB, marketID)
+	oraclePrice := getUnderlyingPriceForMarket(stateDB, marketID, version)
 	return calculateBounds(spreadLimit, oraclePrice)
 }
 
@@ -0,0 +1,51 @@
+package bibliophile
+
+import (
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// mapping(bytes32 priceId => PriceInfo{uint64 publishTime; int32 expo; int64 price; uint64 conf; int64 emaPrice; uint64 emaConf})
+	PYTH_PRICE_INFO_MAPPING_SLOT int64 = 0
+)
+
+// pythOracleReader reads prices from a pyth style oracle. The price id is read from the feed id slot of the AMM
+type pythOracleReader struct{}
+
+func (r *pythOracleReader) ReadPrice(stateDB contract.StateDB, market common.Address) OraclePrice {
+	oracle := getOracleAdapterAddress(stateDB, market)
+	priceId := getOracleFeedId(stateDB, market)
+	slot := new(big.Int).SetBytes(crypto.Keccak256(append(priceId.Bytes(), common.LeftPadBytes(big.NewInt(PYTH_PRICE_INFO_MAPPING_SLOT).Bytes(), 32)...)))
+	packed := stateDB.GetState(oracle, common.BigToHash(slot))
+	price := decodePythPriceInfo(packed)
+	// the second slot packs emaPrice (bytes 24-31) and emaConf (bytes 16-23); the ema price is the reference for deviation checks
+	emaPacked := stateDB.GetState(oracle, common.BigToHash(new(big.Int).Add(slot, big.NewInt(1))))
+	if emaPrice := fromTwosComplement(emaPacked[24:32]); emaPrice.Sign() != 0 {
+		price.PreviousPrice = normaliseToPrecision6(emaPrice, -pythExpo(packed))
+	}
+	return price
+}
+
+func pythExpo(packed common.Hash) int64 {
+	return int64(int32(new(big.Int).SetBytes(packed[20:24]).Uint64()))
+}
+
+// decodePythPriceInfo decodes the first slot of PriceInfo, where solidity packs the fields from the lowest order bytes:
+// publishTime (bytes 24-31), expo (bytes 20-23), price (bytes 12-19), conf (bytes 4-11)
+func decodePythPriceInfo(packed common.Hash) OraclePrice {
+	publishTime := new(big.Int).SetBytes(packed[24:32]).Uint64()
+	expo := pythExpo(packed)
+	price := fromTwosComplement(packed[12:20])
+	conf := new(big.Int).SetBytes(packed[4:12])
+	// price = price * 10^expo, expo is usually negative
+	return OraclePrice{
+		Price:      normaliseToPrecision6(price, -expo),
+		Confidence: normaliseToPrecision6(conf, -expo),
+		UpdatedAt:  publishTime,
+	}
+}
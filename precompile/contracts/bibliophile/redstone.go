@@ -10,16 +10,45 @@ import (
 )
 
 var (
-	RED_STONE_VALUES_MAPPING_STORAGE_LOCATION  = common.HexToHash("0x4dd0c77efa6f6d590c97573d8c70b714546e7311202ff7c11c484cc841d91bfc") // keccak256("RedStone.oracleValuesMapping");
-	RED_STONE_LATEST_ROUND_ID_STORAGE_LOCATION = common.HexToHash("0xc68d7f1ee07d8668991a8951e720010c9d44c2f11c06b5cac61fbc4083263938") // keccak256("RedStone.latestRoundId");
+	RED_STONE_VALUES_MAPPING_STORAGE_LOCATION           = common.HexToHash("0x4dd0c77efa6f6d590c97573d8c70b714546e7311202ff7c11c484cc841d91bfc") // keccak256("RedStone.oracleValuesMapping");
+	RED_STONE_LATEST_ROUND_ID_STORAGE_LOCATION          = common.HexToHash("0xc68d7f1ee07d8668991a8951e720010c9d44c2f11c06b5cac61fbc4083263938") // keccak256("RedStone.latestRoundId");
+	RED_STONE_LATEST_UPDATE_TIMESTAMPS_STORAGE_LOCATION = common.HexToHash("0x3d01e4d77237ea0f771f1786da4d4ff757fcba6a92933aa53b1dcef2d6bd6fe2") // keccak256("RedStone.lastUpdateTimestamp");
 )
 
-func getRedStonePrice(stateDB contract.StateDB, adapterAddress common.Address, redStoneFeedId common.Hash) *big.Int {
+// redStoneOracleReader reads prices from a RedStone price feeds adapter
+type redStoneOracleReader struct{}
+
+func (r *redStoneOracleReader) ReadPrice(stateDB contract.StateDB, market common.Address) OraclePrice {
+	adapterAddress := getOracleAdapterAddress(stateDB, market)
+	feedId := getOracleFeedId(stateDB, market)
+	price := OraclePrice{
+		Price:     getRedStonePrice(stateDB, adapterAddress, feedId),
+		UpdatedAt: getRedStoneDataTimestamp(stateDB, adapterAddress),
+	}
 	latestRoundId := getlatestRoundId(stateDB, adapterAddress)
-	slot := common.BytesToHash(crypto.Keccak256(append(append(redStoneFeedId.Bytes(), common.LeftPadBytes(latestRoundId.Bytes(), 32)...), RED_STONE_VALUES_MAPPING_STORAGE_LOCATION.Bytes()...)))
+	if latestRoundId.Cmp(big.NewInt(1)) == 1 {
+		price.PreviousPrice = getRedStonePriceForRound(stateDB, adapterAddress, feedId, new(big.Int).Sub(latestRoundId, big.NewInt(1)))
+	}
+	return price
+}
+
+func getRedStonePrice(stateDB contract.StateDB, adapterAddress common.Address, redStoneFeedId common.Hash) *big.Int {
+	return getRedStonePriceForRound(stateDB, adapterAddress, redStoneFeedId, getlatestRoundId(stateDB, adapterAddress))
+}
+
+func getRedStonePriceForRound(stateDB contract.StateDB, adapterAddress common.Address, redStoneFeedId common.Hash, roundId *big.Int) *big.Int {
+	slot := common.BytesToHash(crypto.Keccak256(append(append(redStoneFeedId.Bytes(), common.LeftPadBytes(roundId.Bytes(), 32)...), RED_STONE_VALUES_MAPPING_STORAGE_LOCATION.Bytes()...)))
 	return new(big.Int).Div(fromTwosComplement(stateDB.GetState(adapterAddress, slot).Bytes()), big.NewInt(100)) // we use 6 decimals precision everywhere
 }
 
 func getlatestRoundId(stateDB contract.StateDB, adapterAddress common.Address) *big.Int {
 	return fromTwosComplement(stateDB.GetState(adapterAddress, RED_STONE_LATEST_ROUND_ID_STORAGE_LOCATION).Bytes())
 }
+
+// getRedStoneDataTimestamp returns the timestamp (in seconds) of the data in the latest update.
+// The adapter packs the data timestamp (in milliseconds) in the higher 128 bits and the block timestamp in the lower 128 bits
+func getRedStoneDataTimestamp(stateDB contract.StateDB, adapterAddress common.Address) uint64 {
+	timestamps := stateDB.GetState(adapterAddress, RED_STONE_LATEST_UPDATE_TIMESTAMPS_STORAGE_LOCATION).Big()
+	dataTimestamp := new(big.Int).Rsh(timestamps, 128)
+	return new(big.Int).Div(dataTimestamp, big.NewInt(1000)).Uint64()
+}
@@ -0,0 +1,379 @@
+package bibliophile
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SlotConstant is a slot constant of the bibliophile, and the state variable of the contract that it reads.
+// The precompiles read the storage of the hubble contracts directly, so their storage layout must not shift.
+type SlotConstant struct {
+	Contract string
+	Constant string
+	Label    string
+	Slot     int64
+	Offset   int
+	// Members are the struct members read at a slot (and byte offset) from the slot of the value, e.g. of a mapping of structs
+	Members []SlotConstantMember
+}
+
+// SlotConstantMember is a struct member read relative to the slot of a SlotConstant
+type SlotConstantMember struct {
+	Label  string
+	Slot   int64
+	Offset int
+}
+
+// Names of the contracts in SlotConstants and of the compiled storage layouts
+const (
+	OrderBookContract           = "OrderBook"
+	IOCOrderBookContract        = "IOCOrderBook"
+	GTTOrderBookContract        = "GTTOrderBook"
+	ClearingHouseContract       = "ClearingHouse"
+	MarginAccountContract       = "MarginAccount"
+	HUSDContract                = "HUSD"
+	AMMContract                 = "AMM"
+	TestOracleContract          = "TestOracle"
+	ChainlinkAggregatorContract = "ChainlinkAggregator"
+	PythContract                = "Pyth"
+)
+
+var orderInfoMembers = []SlotConstantMember{
+	{Label: "blockPlaced", Slot: 0},
+	{Label: "filledAmount", Slot: 1},
+	{Label: "reservedMargin", Slot: 2},
+	{Label: "status", Slot: 3},
+}
+
+var limitOrderInfoMembers = []SlotConstantMember{
+	{Label: "blockPlaced", Slot: 0},
+	{Label: "filledAmount", Slot: 1},
+	{Label: "status", Slot: 2},
+}
+
+// SlotConstants are all the slot constants that the bibliophile (and through it, hubblebibliophile and juror) reads
+// the hubble contracts with. VERSIONS_SLOT is the storage of the precompile itself and the RedStone adapter is read at
+// fixed storage locations, so they are not part of any solc storage layout.
+var SlotConstants = []SlotConstant{
+	{Contract: OrderBookContract, Constant: "ORDER_INFO_SLOT", Label: "orderInfo", Slot: ORDER_INFO_SLOT, Members: orderInfoMembers},
+	{Contract: OrderBookContract, Constant: "IS_TRADING_AUTHORITY_SLOT", Label: "isTradingAuthority", Slot: IS_TRADING_AUTHORITY_SLOT},
+	{Contract: OrderBookContract, Constant: "ORDER_AMENDED_FROM_SLOT", Label: "orderAmendedFrom", Slot: ORDER_AMENDED_FROM_SLOT},
+	{Contract: OrderBookContract, Constant: "ORDER_AMENDED_TO_SLOT", Label: "orderAmendedTo", Slot: ORDER_AMENDED_TO_SLOT},
+
+	{Contract: IOCOrderBookContract, Constant: "IOC_ORDER_INFO_SLOT", Label: "orderInfo", Slot: IOC_ORDER_INFO_SLOT, Members: limitOrderInfoMembers},
+	{Contract: IOCOrderBookContract, Constant: "IOC_EXPIRATION_CAP_SLOT", Label: "expirationCap", Slot: IOC_EXPIRATION_CAP_SLOT},
+
+	{Contract: GTTOrderBookContract, Constant: "GTT_ORDER_INFO_SLOT", Label: "orderInfo", Slot: GTT_ORDER_INFO_SLOT, Members: limitOrderInfoMembers},
+
+	{Contract: ClearingHouseContract, Constant: "MAINTENANCE_MARGIN_SLOT", Label: "maintenanceMargin", Slot: MAINTENANCE_MARGIN_SLOT},
+	{Contract: ClearingHouseContract, Constant: "MIN_ALLOWABLE_MARGIN_SLOT", Label: "minAllowableMargin", Slot: MIN_ALLOWABLE_MARGIN_SLOT},
+	{Contract: ClearingHouseContract, Constant: "AMMS_SLOT", Label: "amms", Slot: AMMS_SLOT},
+
+	{Contract: MarginAccountContract, Constant: "VAR_MARGIN_MAPPING_STORAGE_SLOT", Label: "margin", Slot: VAR_MARGIN_MAPPING_STORAGE_SLOT},
+	{Contract: MarginAccountContract, Constant: "VAR_RESERVED_MARGIN_SLOT", Label: "reservedMargin", Slot: VAR_RESERVED_MARGIN_SLOT},
+
+	{Contract: HUSDContract, Constant: "HUSD_BALANCES_SLOT", Label: "_balances", Slot: HUSD_BALANCES_SLOT},
+	{Contract: HUSDContract, Constant: "HUSD_TOTAL_SUPPLY_SLOT", Label: "_totalSupply", Slot: HUSD_TOTAL_SUPPLY_SLOT},
+
+	{Contract: AMMContract, Constant: "MARK_PRICE_TWAP_DATA_SLOT", Label: "markPriceTwapData", Slot: MARK_PRICE_TWAP_DATA_SLOT, Members: []SlotConstantMember{
+		{Label: "lastPrice", Slot: 0},
+	}},
+	{Contract: AMMContract, Constant: "VAR_POSITIONS_SLOT", Label: "positions", Slot: VAR_POSITIONS_SLOT, Members: []SlotConstantMember{
+		{Label: "size", Slot: 0},
+		{Label: "openNotional", Slot: 1},
+		{Label: "lastPremiumFraction", Slot: 2},
+	}},
+	{Contract: AMMContract, Constant: "VAR_CUMULATIVE_PREMIUM_FRACTION", Label: "cumulativePremiumFraction", Slot: VAR_CUMULATIVE_PREMIUM_FRACTION},
+	{Contract: AMMContract, Constant: "MAX_ORACLE_SPREAD_RATIO_SLOT", Label: "maxOracleSpreadRatio", Slot: MAX_ORACLE_SPREAD_RATIO_SLOT},
+	{Contract: AMMContract, Constant: "MAX_LIQUIDATION_RATIO_SLOT", Label: "maxLiquidationRatio", Slot: MAX_LIQUIDATION_RATIO_SLOT},
+	{Contract: AMMContract, Constant: "MIN_SIZE_REQUIREMENT_SLOT", Label: "minSizeRequirement", Slot: MIN_SIZE_REQUIREMENT_SLOT},
+	{Contract: AMMContract, Constant: "ORACLE_SLOT", Label: "oracle", Slot: ORACLE_SLOT},
+	{Contract: AMMContract, Constant: "UNDERLYING_ASSET_SLOT", Label: "underlyingAsset", Slot: UNDERLYING_ASSET_SLOT},
+	{Contract: AMMContract, Constant: "NEXT_FUNDING_TIME_SLOT", Label: "nextFundingTime", Slot: NEXT_FUNDING_TIME_SLOT},
+	{Contract: AMMContract, Constant: "FUNDING_PERIOD_SLOT", Label: "fundingPeriod", Slot: FUNDING_PERIOD_SLOT},
+	{Contract: AMMContract, Constant: "MAX_LIQUIDATION_PRICE_SPREAD", Label: "maxLiquidationPriceSpread", Slot: MAX_LIQUIDATION_PRICE_SPREAD},
+	{Contract: AMMContract, Constant: "RED_STONE_ADAPTER_SLOT", Label: "redStoneAdapter", Slot: RED_STONE_ADAPTER_SLOT},
+	{Contract: AMMContract, Constant: "RED_STONE_FEED_ID_SLOT", Label: "redStoneFeedId", Slot: RED_STONE_FEED_ID_SLOT},
+
+	{Contract: TestOracleContract, Constant: "TEST_ORACLE_PRICES_MAPPING_SLOT", Label: "prices", Slot: TEST_ORACLE_PRICES_MAPPING_SLOT},
+
+	{Contract: ChainlinkAggregatorContract, Constant: "CHAINLINK_DECIMALS_SLOT", Label: "decimals", Slot: CHAINLINK_DECIMALS_SLOT},
+	{Contract: ChainlinkAggregatorContract, Constant: "CHAINLINK_LATEST_ROUND_ID_SLOT", Label: "latestRoundId", Slot: CHAINLINK_LATEST_ROUND_ID_SLOT},
+	{Contract: ChainlinkAggregatorContract, Constant: "CHAINLINK_ROUNDS_MAPPING_SLOT", Label: "rounds", Slot: CHAINLINK_ROUNDS_MAPPING_SLOT, Members: []SlotConstantMember{
+		{Label: "answer", Slot: 0},
+		{Label: "startedAt", Slot: 1},
+		{Label: "updatedAt", Slot: 2},
+		{Label: "answeredInRound", Slot: 3},
+	}},
+
+	{Contract: PythContract, Constant: "PYTH_PRICE_INFO_MAPPING_SLOT", Label: "latestPriceInfo", Slot: PYTH_PRICE_INFO_MAPPING_SLOT, Members: []SlotConstantMember{
+		{Label: "publishTime", Slot: 0, Offset: 0},
+		{Label: "expo", Slot: 0, Offset: 8},
+		{Label: "price", Slot: 0, Offset: 12},
+		{Label: "conf", Slot: 0, Offset: 20},
+		{Label: "emaPrice", Slot: 1, Offset: 0},
+		{Label: "emaConf", Slot: 1, Offset: 8},
+	}},
+}
+
+// VerifyStorageLayout checks the slot constants of [contractName] against its solc storage layout, and returns an
+// error listing every state variable that moved.
+func VerifyStorageLayout(contractName string, layout *contract.StorageLayout) error {
+	var mismatches []string
+	found := false
+	for _, constant := range SlotConstants {
+		if constant.Contract != contractName {
+			continue
+		}
+		found = true
+		mismatches = append(mismatches, verifySlotConstant(constant, layout)...)
+	}
+	if !found {
+		return fmt.Errorf("no slot constants for contract %s", contractName)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("storage layout of %s does not match the bibliophile: %s", contractName, strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+func verifySlotConstant(constant SlotConstant, layout *contract.StorageLayout) []string {
+	variable, ok := findStorageVariable(layout.Storage, constant.Label)
+	if !ok {
+		return []string{fmt.Sprintf("%s: %s is not in the layout", constant.Constant, constant.Label)}
+	}
+	var mismatches []string
+	if mismatch := compareSlot(constant.Constant, constant.Label, variable, constant.Slot, constant.Offset); mismatch != "" {
+		mismatches = append(mismatches, mismatch)
+	}
+	if len(constant.Members) == 0 {
+		return mismatches
+	}
+	members, err := structMembers(layout, variable.Type)
+	if err != nil {
+		return append(mismatches, fmt.Sprintf("%s: %s: %v", constant.Constant, constant.Label, err))
+	}
+	for _, expected := range constant.Members {
+		label := constant.Label + "." + expected.Label
+		member, ok := findStorageVariable(members, expected.Label)
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s is not in the layout", constant.Constant, label))
+			continue
+		}
+		if mismatch := compareSlot(constant.Constant, label, member, expected.Slot, expected.Offset); mismatch != "" {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches
+}
+
+func compareSlot(constant string, label string, variable contract.StorageVariable, slot int64, offset int) string {
+	layoutSlot, err := strconv.ParseInt(variable.Slot, 10, 64)
+	if err != nil {
+		return fmt.Sprintf("%s: invalid slot %q of %s", constant, variable.Slot, label)
+	}
+	if layoutSlot != slot || variable.Offset != offset {
+		return fmt.Sprintf("%s: %s is at slot %d offset %d, expected slot %d offset %d", constant, label, layoutSlot, variable.Offset, slot, offset)
+	}
+	return ""
+}
+
+func findStorageVariable(variables []contract.StorageVariable, label string) (contract.StorageVariable, bool) {
+	for _, variable := range variables {
+		if variable.Label == label {
+			return variable, true
+		}
+	}
+	return contract.StorageVariable{}, false
+}
+
+// structMembers returns the members of the struct that [typeID] holds, through mapping values and array elements
+func structMembers(layout *contract.StorageLayout, typeID string) ([]contract.StorageVariable, error) {
+	for {
+		storageType, ok := layout.Types[typeID]
+		if !ok {
+			return nil, fmt.Errorf("type %s is not in the layout", typeID)
+		}
+		switch {
+		case len(storageType.Members) > 0:
+			return storageType.Members, nil
+		case storageType.Value != "":
+			typeID = storageType.Value
+		case storageType.Base != "":
+			typeID = storageType.Base
+		default:
+			return nil, fmt.Errorf("%s is not a struct", storageType.Label)
+		}
+	}
+}
+
+// CompiledStorageLayout is the solc storage layout of a compiled hubble contract, with the hashes of the deployed
+// (runtime) code that it was compiled to, so that a node can match it with the contracts on chain.
+type CompiledStorageLayout struct {
+	Contract      string                 `json:"contract"`
+	CodeHashes    []common.Hash          `json:"codeHashes"`
+	StorageLayout contract.StorageLayout `json:"storageLayout"`
+}
+
+//go:embed layouts
+var compiledStorageLayouts embed.FS
+
+// CompiledStorageLayouts returns the storage layouts in layouts/, see layouts/README.md on how to export them
+func CompiledStorageLayouts() ([]*CompiledStorageLayout, error) {
+	entries, err := compiledStorageLayouts.ReadDir("layouts")
+	if err != nil {
+		return nil, err
+	}
+	var layouts []*CompiledStorageLayout
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := compiledStorageLayouts.ReadFile(path.Join("layouts", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		layout := &CompiledStorageLayout{}
+		if err := json.Unmarshal(data, layout); err != nil {
+			return nil, fmt.Errorf("failed to parse storage layout %s: %w", entry.Name(), err)
+		}
+		layouts = append(layouts, layout)
+	}
+	return layouts, nil
+}
+
+// CodeHashReader is the state that the storage layouts of the deployed contracts are verified against
+type CodeHashReader interface {
+	contract.StateDB
+	GetCodeHash(common.Address) common.Hash
+}
+
+// EIP-1967 implementation slot of the proxies that the hubble contracts are deployed behind,
+// bytes32(uint256(keccak256('eip1967.proxy.implementation')) - 1)
+var eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// VerifyDeployedStorageLayouts matches the code of the hubble contracts in [stateDB] (of the implementation, for
+// proxies) with [layouts], and verifies the layout of each one that it recognises. It returns an error if a deployed
+// contract's layout does not match the slot constants, and the addresses whose code is not one of [layouts], which
+// can't be verified.
+func VerifyDeployedStorageLayouts(stateDB CodeHashReader, layouts []*CompiledStorageLayout) ([]common.Address, error) {
+	byCodeHash := layoutsByCodeHash(layouts)
+	deployed := deployedContracts(stateDB)
+	addresses := make([]common.Address, 0, len(deployed))
+	for address := range deployed {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Hex() < addresses[j].Hex() })
+
+	var (
+		unverified []common.Address
+		mismatches []string
+	)
+	for _, address := range addresses {
+		if _, ok := implementationCodeHash(stateDB, address); !ok {
+			// not deployed on this chain
+			continue
+		}
+		verified, err := verifyDeployedStorageLayout(stateDB, byCodeHash, address, deployed[address])
+		if err != nil {
+			mismatches = append(mismatches, err.Error())
+		} else if !verified {
+			unverified = append(unverified, address)
+		}
+	}
+	if len(mismatches) > 0 {
+		return unverified, fmt.Errorf("deployed contracts do not match the bibliophile: %s", strings.Join(mismatches, "; "))
+	}
+	return unverified, nil
+}
+
+// VerifyDeployedStorageLayout verifies the layout of the [contractName] contract at [address] in [stateDB] like
+// VerifyDeployedStorageLayouts, but also fails if there is no code at [address] or its code is not one of [layouts].
+func VerifyDeployedStorageLayout(stateDB CodeHashReader, layouts []*CompiledStorageLayout, address common.Address, contractName string) error {
+	if _, ok := implementationCodeHash(stateDB, address); !ok {
+		return fmt.Errorf("%s: %s is not deployed", address.Hex(), contractName)
+	}
+	verified, err := verifyDeployedStorageLayout(stateDB, layoutsByCodeHash(layouts), address, contractName)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return fmt.Errorf("%s: no compiled storage layout for the code of %s", address.Hex(), contractName)
+	}
+	return nil
+}
+
+// verifyDeployedStorageLayout verifies the layout of the [contractName] contract at [address] with the layout that its
+// code was compiled to, and returns false if there is none
+func verifyDeployedStorageLayout(stateDB CodeHashReader, byCodeHash map[common.Hash]*CompiledStorageLayout, address common.Address, contractName string) (bool, error) {
+	codeHash, _ := implementationCodeHash(stateDB, address)
+	layout, ok := byCodeHash[codeHash]
+	if !ok {
+		return false, nil
+	}
+	if layout.Contract != contractName {
+		return true, fmt.Errorf("%s: code is %s, expected %s", address.Hex(), layout.Contract, contractName)
+	}
+	if err := VerifyStorageLayout(contractName, &layout.StorageLayout); err != nil {
+		return true, fmt.Errorf("%s: %w", address.Hex(), err)
+	}
+	return true, nil
+}
+
+func layoutsByCodeHash(layouts []*CompiledStorageLayout) map[common.Hash]*CompiledStorageLayout {
+	byCodeHash := map[common.Hash]*CompiledStorageLayout{}
+	for _, layout := range layouts {
+		for _, codeHash := range layout.CodeHashes {
+			byCodeHash[codeHash] = layout
+		}
+	}
+	return byCodeHash
+}
+
+// deployedContracts returns the hubble contracts that the bibliophile reads, by address
+func deployedContracts(stateDB contract.StateDB) map[common.Address]string {
+	deployed := map[common.Address]string{
+		common.HexToAddress(ORDERBOOK_GENESIS_ADDRESS):      OrderBookContract,
+		common.HexToAddress(MARGIN_ACCOUNT_GENESIS_ADDRESS): MarginAccountContract,
+		common.HexToAddress(CLEARING_HOUSE_GENESIS_ADDRESS): ClearingHouseContract,
+		common.HexToAddress(IOC_ORDERBOOK_ADDRESS):          IOCOrderBookContract,
+		common.HexToAddress(GTT_ORDERBOOK_ADDRESS):          GTTOrderBookContract,
+	}
+	for _, market := range GetMarkets(stateDB) {
+		deployed[market] = AMMContract
+		switch getOracleReader(stateDB, market, LatestVersion).(type) {
+		case *testOracleReader:
+			deployed[getOracleAddress(stateDB, market)] = TestOracleContract
+		case *chainlinkOracleReader:
+			deployed[getOracleAdapterAddress(stateDB, market)] = ChainlinkAggregatorContract
+		case *pythOracleReader:
+			deployed[getOracleAdapterAddress(stateDB, market)] = PythContract
+		}
+	}
+	delete(deployed, common.Address{})
+	return deployed
+}
+
+// implementationCodeHash returns the code hash of the implementation of the proxy at [address], or of [address]
+// itself if it is not a proxy. It returns false if there is no code.
+func implementationCodeHash(stateDB CodeHashReader, address common.Address) (common.Hash, bool) {
+	if implementation := common.BytesToAddress(stateDB.GetState(address, eip1967ImplementationSlot).Bytes()); implementation != (common.Address{}) {
+		address = implementation
+	}
+	codeHash := stateDB.GetCodeHash(address)
+	if codeHash == (common.Hash{}) || codeHash == emptyCodeHash {
+		return common.Hash{}, false
+	}
+	return codeHash, true
+}
@@ -0,0 +1,169 @@
+package bibliophile
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestStorageLayout(t *testing.T) *contract.StorageLayout {
+	data, err := os.ReadFile("testdata/orderbook_storage_layout.json")
+	require.NoError(t, err)
+	layout := &contract.StorageLayout{}
+	require.NoError(t, json.Unmarshal(data, layout))
+	return layout
+}
+
+// TestSlotConstantsAreComplete makes sure that a new slot constant is added to SlotConstants, so that it is verified
+func TestSlotConstantsAreComplete(t *testing.T) {
+	registered := map[string]bool{}
+	for _, constant := range SlotConstants {
+		registered[constant.Constant] = true
+	}
+	// storage of the precompile itself
+	registered["VERSIONS_SLOT"] = true
+	registered["ORACLE_CONFIGS_SLOT"] = true
+
+	packages, err := parser.ParseDir(token.NewFileSet(), ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	require.NoError(t, err)
+	for _, file := range packages["bibliophile"].Files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				if ident, ok := valueSpec.Type.(*ast.Ident); !ok || ident.Name != "int64" {
+					continue
+				}
+				for _, name := range valueSpec.Names {
+					require.True(t, registered[name.Name], "%s is not in SlotConstants", name.Name)
+				}
+			}
+		}
+	}
+}
+
+// TestCompiledStorageLayouts verifies the layouts in layouts/. It is skipped until they include the contracts whose
+// storage the precompiles read for matching, liquidations and margin, and hUSD which the margin bridge writes, since
+// the node refuses to start on a chain where those are deployed without a layout.
+func TestCompiledStorageLayouts(t *testing.T) {
+	layouts, err := CompiledStorageLayouts()
+	require.NoError(t, err)
+	compiled := map[string]bool{}
+	for _, layout := range layouts {
+		require.NotEmpty(t, layout.CodeHashes, layout.Contract)
+		require.NoError(t, VerifyStorageLayout(layout.Contract, &layout.StorageLayout))
+		compiled[layout.Contract] = true
+	}
+	var missing []string
+	for _, contractName := range []string{OrderBookContract, GTTOrderBookContract, ClearingHouseContract, MarginAccountContract, AMMContract, HUSDContract} {
+		if !compiled[contractName] {
+			missing = append(missing, contractName)
+		}
+	}
+	if len(missing) > 0 {
+		t.Skipf("no storage layout of %s in layouts/, see layouts/README.md on how to export them", strings.Join(missing, ", "))
+	}
+}
+
+func TestVerifyStorageLayout(t *testing.T) {
+	t.Run("matching layout", func(t *testing.T) {
+		require.NoError(t, VerifyStorageLayout(OrderBookContract, loadTestStorageLayout(t)))
+	})
+
+	t.Run("shifted variable", func(t *testing.T) {
+		layout := loadTestStorageLayout(t)
+		// a new state variable before orderInfo
+		for i := range layout.Storage {
+			if layout.Storage[i].Label == "orderInfo" || layout.Storage[i].Label == "isTradingAuthority" {
+				layout.Storage[i].Slot = "54"
+			}
+		}
+		err := VerifyStorageLayout(OrderBookContract, layout)
+		require.ErrorContains(t, err, "ORDER_INFO_SLOT: orderInfo is at slot 54 offset 0, expected slot 53 offset 0")
+		require.ErrorContains(t, err, "IS_TRADING_AUTHORITY_SLOT: isTradingAuthority is at slot 54 offset 0, expected slot 61 offset 0")
+	})
+
+	t.Run("reordered struct member", func(t *testing.T) {
+		layout := loadTestStorageLayout(t)
+		members := layout.Types["t_struct(OrderInfo)8_storage"].Members
+		members[2].Slot, members[3].Slot = "3", "2"
+		err := VerifyStorageLayout(OrderBookContract, layout)
+		require.ErrorContains(t, err, "ORDER_INFO_SLOT: orderInfo.status is at slot 2 offset 0, expected slot 3 offset 0")
+	})
+
+	t.Run("removed variable", func(t *testing.T) {
+		layout := loadTestStorageLayout(t)
+		layout.Storage = layout.Storage[:len(layout.Storage)-1]
+		require.ErrorContains(t, VerifyStorageLayout(OrderBookContract, layout), "ORDER_AMENDED_TO_SLOT: orderAmendedTo is not in the layout")
+	})
+
+	t.Run("unknown contract", func(t *testing.T) {
+		require.ErrorContains(t, VerifyStorageLayout("Unknown", loadTestStorageLayout(t)), "no slot constants for contract Unknown")
+	})
+}
+
+func TestVerifyDeployedStorageLayouts(t *testing.T) {
+	orderBook := common.HexToAddress(ORDERBOOK_GENESIS_ADDRESS)
+	implementation := common.HexToAddress("0x0000000000000000000000000000000000000d44")
+	iocOrderBook := common.HexToAddress(IOC_ORDERBOOK_ADDRESS)
+	orderBookCode := []byte{0x60, 0x80, 0x60, 0x40}
+
+	setup := func(t *testing.T) CodeHashReader {
+		stateDB := state.NewTestStateDB(t).(*state.StateDB)
+		// the order book is a proxy, and the IOC order book has code that no layout was compiled to
+		stateDB.SetCode(orderBook, []byte{0xfe})
+		stateDB.SetState(orderBook, eip1967ImplementationSlot, common.BytesToHash(implementation.Bytes()))
+		stateDB.SetCode(implementation, orderBookCode)
+		stateDB.SetCode(iocOrderBook, []byte{0x00})
+		return stateDB
+	}
+	compiled := func(t *testing.T, contractName string) *CompiledStorageLayout {
+		return &CompiledStorageLayout{
+			Contract:      contractName,
+			CodeHashes:    []common.Hash{crypto.Keccak256Hash(orderBookCode)},
+			StorageLayout: *loadTestStorageLayout(t),
+		}
+	}
+
+	t.Run("matching layout", func(t *testing.T) {
+		unverified, err := VerifyDeployedStorageLayouts(setup(t), []*CompiledStorageLayout{compiled(t, OrderBookContract)})
+		require.NoError(t, err)
+		require.Equal(t, []common.Address{iocOrderBook}, unverified)
+	})
+
+	t.Run("shifted layout", func(t *testing.T) {
+		layout := compiled(t, OrderBookContract)
+		layout.StorageLayout.Storage[5].Slot = "54"
+		_, err := VerifyDeployedStorageLayouts(setup(t), []*CompiledStorageLayout{layout})
+		require.ErrorContains(t, err, orderBook.Hex()+": storage layout of OrderBook does not match the bibliophile: ORDER_INFO_SLOT")
+	})
+
+	t.Run("other contract", func(t *testing.T) {
+		_, err := VerifyDeployedStorageLayouts(setup(t), []*CompiledStorageLayout{compiled(t, GTTOrderBookContract)})
+		require.ErrorContains(t, err, orderBook.Hex()+": code is GTTOrderBook, expected OrderBook")
+	})
+
+	t.Run("single contract", func(t *testing.T) {
+		stateDB := setup(t)
+		layouts := []*CompiledStorageLayout{compiled(t, OrderBookContract)}
+		require.NoError(t, VerifyDeployedStorageLayout(stateDB, layouts, orderBook, OrderBookContract))
+		require.EqualError(t, VerifyDeployedStorageLayout(stateDB, layouts, iocOrderBook, IOCOrderBookContract), iocOrderBook.Hex()+": no compiled storage layout for the code of IOCOrderBook")
+		gttOrderBook := common.HexToAddress(GTT_ORDERBOOK_ADDRESS)
+		require.EqualError(t, VerifyDeployedStorageLayout(stateDB, layouts, gttOrderBook, GTTOrderBookContract), gttOrderBook.Hex()+": GTTOrderBook is not deployed")
+	})
+}
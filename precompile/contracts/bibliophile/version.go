@@ -0,0 +1,121 @@
+package bibliophile
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Version is a behaviour of the bibliophile logic that changes the output of a precompile call.
+// Such changes would result in a different state (or gas used) when replaying historical blocks while syncing,
+// so every behaviour is kept around and selected by block timestamp from the schedule in Config.Versions.
+type Version uint8
+
+const (
+	// V1 is the behaviour at launch: _multiply1e6 divided by 1e6 when computing margin fractions
+	V1 Version = iota + 1
+	// V2 fixes _multiply1e6 to multiply by 1e6
+	V2
+	// V3 reads the underlying prices with the reader of the oracle type configured for the market (see
+	// ORACLE_CONFIGS_SLOT) and halts matching and liquidations in markets whose oracle price is stale or deviates
+	// too much. Before V3 the price is read from RedStone if an adapter and feed id are configured, TestOracle otherwise.
+	V3
+	// V4 executes amended limit orders: an order is matched with the hash of its latest amendment and keeps its info
+	// under the hash it was placed with (see ORDER_AMENDED_FROM_SLOT and ORDER_AMENDED_TO_SLOT). Before V4 the
+	// amendment links are not read, an order is only executed with the hash it was placed with.
+	V4
+
+	LatestVersion = V4
+)
+
+const (
+	// VERSIONS_SLOT is the slot (in the storage of the bibliophile precompile) of the VersionUpgrade[] schedule.
+	// Each item is packed into a single slot as (blockTimestamp << 8 | version), so that it can also be set with a state upgrade.
+	VERSIONS_SLOT int64 = 0
+)
+
+// VersionUpgrade activates [Version] for blocks with timestamp >= [BlockTimestamp]
+type VersionUpgrade struct {
+	BlockTimestamp uint64  `json:"blockTimestamp"`
+	Version        Version `json:"version"`
+}
+
+// DefaultVersion is used when no schedule covers a block, i.e. on chains that configure neither Config.Versions nor
+// the hubbleVersions of their upgrade config
+const DefaultVersion = V2
+
+// VerifyChainConfigVersions checks that the hubbleVersions of the chain config are versions of this node
+func VerifyChainConfigVersions(chainConfig contract.ChainConfig) error {
+	for i, version := range chainConfig.GetHubbleVersions() {
+		if !Version(version.Version).IsValid() {
+			return fmt.Errorf("invalid version %d at index %d", version.Version, i)
+		}
+	}
+	return nil
+}
+
+func (v Version) IsValid() bool {
+	return v >= V1 && v <= LatestVersion
+}
+
+// GetVersion returns the version of the bibliophile logic to be used for a block with [blockTimestamp]. The schedule in
+// the precompile storage takes precedence, the hubbleVersions of [chainConfig] cover the blocks before it.
+func GetVersion(chainConfig contract.ChainConfig, stateDB contract.StateDB, blockTimestamp uint64) Version {
+	if version, ok := getActiveVersion(getVersions(stateDB), blockTimestamp); ok {
+		return version
+	}
+	if version, ok := getActiveVersion(getChainConfigVersions(chainConfig), blockTimestamp); ok {
+		return version
+	}
+	return DefaultVersion
+}
+
+func getChainConfigVersions(chainConfig contract.ChainConfig) []VersionUpgrade {
+	hubbleVersions := chainConfig.GetHubbleVersions()
+	versions := make([]VersionUpgrade, len(hubbleVersions))
+	for i, version := range hubbleVersions {
+		versions[i] = VersionUpgrade{BlockTimestamp: version.BlockTimestamp, Version: Version(version.Version)}
+	}
+	return versions
+}
+
+func getActiveVersion(versions []VersionUpgrade, blockTimestamp uint64) (Version, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].BlockTimestamp <= blockTimestamp {
+			return versions[i].Version, true
+		}
+	}
+	return 0, false
+}
+
+func versionsStorageSlot() *big.Int {
+	return new(big.Int).SetBytes(crypto.Keccak256(common.LeftPadBytes(big.NewInt(VERSIONS_SLOT).Bytes(), 32)))
+}
+
+func getVersions(stateDB contract.StateDB) []VersionUpgrade {
+	count := stateDB.GetState(ContractAddress, common.BigToHash(big.NewInt(VERSIONS_SLOT))).Big().Int64()
+	versions := make([]VersionUpgrade, count)
+	baseStorageSlot := versionsStorageSlot()
+	for i := int64(0); i < count; i++ {
+		packed := stateDB.GetState(ContractAddress, common.BigToHash(new(big.Int).Add(baseStorageSlot, big.NewInt(i)))).Big()
+		versions[i] = VersionUpgrade{
+			BlockTimestamp: new(big.Int).Rsh(packed, 8).Uint64(),
+			Version:        Version(packed.Uint64() & 0xff),
+		}
+	}
+	return versions
+}
+
+func setVersions(stateDB contract.StateDB, versions []VersionUpgrade) {
+	stateDB.SetState(ContractAddress, common.BigToHash(big.NewInt(VERSIONS_SLOT)), common.BigToHash(big.NewInt(int64(len(versions)))))
+	baseStorageSlot := versionsStorageSlot()
+	for i, version := range versions {
+		packed := new(big.Int).Lsh(new(big.Int).SetUint64(version.BlockTimestamp), 8)
+		packed.Or(packed, big.NewInt(int64(version.Version)))
+		stateDB.SetState(ContractAddress, common.BigToHash(new(big.Int).Add(baseStorageSlot, big.NewInt(int64(i)))), common.BigToHash(packed))
+	}
+}
@@ -0,0 +1,70 @@
+package bibliophile
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/commontype"
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetVersion(t *testing.T) {
+	noSchedule := contract.NewMockChainStateWithHubbleVersions(nil)
+	chainConfig := contract.NewMockChainStateWithHubbleVersions([]commontype.HubbleVersion{{BlockTimestamp: 0, Version: uint8(V1)}, {BlockTimestamp: 1686321601, Version: uint8(V2)}})
+
+	t.Run("default version when no schedule is configured", func(t *testing.T) {
+		stateDB := state.NewTestStateDB(t)
+		assert.Equal(t, DefaultVersion, GetVersion(noSchedule, stateDB, 0))
+		assert.Equal(t, DefaultVersion, GetVersion(noSchedule, stateDB, 1686321601))
+	})
+	t.Run("schedule from the chain config", func(t *testing.T) {
+		stateDB := state.NewTestStateDB(t)
+		assert.Equal(t, V1, GetVersion(chainConfig, stateDB, 0))
+		assert.Equal(t, V1, GetVersion(chainConfig, stateDB, 1686321600))
+		assert.Equal(t, V2, GetVersion(chainConfig, stateDB, 1686321601))
+	})
+	t.Run("schedule from the precompile config", func(t *testing.T) {
+		stateDB := state.NewTestStateDB(t)
+		versions := []VersionUpgrade{{BlockTimestamp: 1686321700, Version: V1}, {BlockTimestamp: 1686321800, Version: V2}}
+		require.NoError(t, Module.Configurator.Configure(nil, NewConfig(utils.NewUint64(50), versions), stateDB, nil))
+		assert.Equal(t, versions, getVersions(stateDB))
+		assert.Equal(t, V1, GetVersion(chainConfig, stateDB, 1686321700))
+		assert.Equal(t, V1, GetVersion(chainConfig, stateDB, 1686321799))
+		assert.Equal(t, V2, GetVersion(chainConfig, stateDB, 1686321800))
+		// falls back to the schedule of the chain config before the first version
+		assert.Equal(t, V2, GetVersion(chainConfig, stateDB, 1686321699))
+		assert.Equal(t, V1, GetVersion(chainConfig, stateDB, 1686321600))
+	})
+	t.Run("invalid version in the chain config", func(t *testing.T) {
+		require.NoError(t, VerifyChainConfigVersions(chainConfig))
+		invalid := contract.NewMockChainStateWithHubbleVersions([]commontype.HubbleVersion{{BlockTimestamp: 0, Version: uint8(LatestVersion + 1)}})
+		require.ErrorContains(t, VerifyChainConfigVersions(invalid), "invalid version")
+	})
+}
+
+func TestOrderAmendmentsFromV4(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	orderbook := common.HexToAddress(ORDERBOOK_GENESIS_ADDRESS)
+	placedHash := common.HexToHash("0x01")
+	amendedHash := common.HexToHash("0x02")
+	stateDB.SetState(orderbook, bytes32MappingStorageSlot(amendedHash, ORDER_AMENDED_FROM_SLOT), placedHash)
+	stateDB.SetState(orderbook, bytes32MappingStorageSlot(placedHash, ORDER_AMENDED_TO_SLOT), amendedHash)
+	setVersions(stateDB, []VersionUpgrade{{BlockTimestamp: 0, Version: V3}, {BlockTimestamp: 100, Version: V4}})
+	chainConfig := contract.NewMockChainStateWithHubbleVersions(nil)
+
+	t.Run("the amendments are not read before V4", func(t *testing.T) {
+		client := NewBibliophileClient(contract.NewMockAccessibleState(stateDB, contract.NewMockBlockContext(big.NewInt(1), 99), nil, chainConfig))
+		assert.Equal(t, [32]byte{}, client.GetOrderAmendedFrom(amendedHash))
+		assert.Equal(t, [32]byte{}, client.GetOrderAmendedTo(placedHash))
+	})
+	t.Run("the amendments are read from V4", func(t *testing.T) {
+		client := NewBibliophileClient(contract.NewMockAccessibleState(stateDB, contract.NewMockBlockContext(big.NewInt(1), 100), nil, chainConfig))
+		assert.Equal(t, [32]byte(placedHash), client.GetOrderAmendedFrom(amendedHash))
+		assert.Equal(t, [32]byte(amendedHash), client.GetOrderAmendedTo(placedHash))
+	})
+}
@@ -0,0 +1,27 @@
+package bibliophile
+
+import (
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/warporacle"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// warpOracleReader reads the prices delivered over warp to the warporacle precompile, which is set in the AMM's oracle
+// slot. The prices are keyed by the underlying asset of the AMM.
+type warpOracleReader struct{}
+
+func (r *warpOracleReader) ReadPrice(stateDB contract.StateDB, market common.Address) OraclePrice {
+	observations := warporacle.GetLatestObservations(stateDB, getUnderlyingAssetAddress(stateDB, market), 2)
+	if len(observations) == 0 {
+		return OraclePrice{Price: common.Big0}
+	}
+	price := OraclePrice{
+		Price:     observations[0].Price,
+		UpdatedAt: observations[0].Timestamp,
+	}
+	if len(observations) > 1 {
+		price.PreviousPrice = observations[1].Price
+	}
+	return price
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/ava-labs/subnet-evm/accounts/abi"
 	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 
 	_ "embed"
 
@@ -51,9 +52,8 @@ var (
 // Some logic changes may result in different state wihch will result in error in state during replay of blocks while syncing.
 // We should track these logic change which can cause changes specified above; as releases in comments below
 
-// Release 1
-// in amm.go multiply1e6 is diving by 1e6(v1). Change was to fix this to multiply by 1e6(v2).
-// This caused different marginFration and thus different output which cause issue while replay of blocks.
+// The logic is shared with the bibliophile precompile, releases are tracked as bibliophile.Version.
+// The version is selected with the schedule in the bibliophile config, so this precompile behaves the same as bibliophile for a given block.
 
 type GetNotionalPositionAndMarginInput struct {
 	Trader                 common.Address
@@ -102,8 +102,9 @@ func getNotionalPositionAndMargin(accessibleState contract.AccessibleState, call
 	}
 
 	// CUSTOM CODE STARTS HERE
-	output := GetNotionalPositionAndMargin(accessibleState.GetStateDB(), &inputStruct, new(big.Int).SetUint64(accessibleState.GetBlockContext().Timestamp()))
-	packedOutput, err := PackGetNotionalPositionAndMarginOutput(output)
+	stateDB := accessibleState.GetStateDB()
+	output := bibliophile.GetNotionalPositionAndMarginFromTestOracle(stateDB, (*bibliophile.GetNotionalPositionAndMarginInput)(&inputStruct), bibliophile.GetVersion(accessibleState.GetChainConfig(), stateDB, accessibleState.GetBlockContext().Timestamp()))
+	packedOutput, err := PackGetNotionalPositionAndMarginOutput(GetNotionalPositionAndMarginOutput(output))
 	if err != nil {
 		return nil, remainingGas, err
 	}
@@ -149,7 +150,7 @@ func getPositionSizes(accessibleState contract.AccessibleState, caller common.Ad
 	}
 
 	// CUSTOM CODE STARTS HERE
-	output := getPosSizes(accessibleState.GetStateDB(), &inputStruct)
+	output := bibliophile.GetPositionSizes(accessibleState.GetStateDB(), &inputStruct)
 	packedOutput, err := PackGetPositionSizesOutput(output)
 	if err != nil {
 		return nil, remainingGas, err
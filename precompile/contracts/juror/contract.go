@@ -26,8 +26,16 @@ const (
 	// Generally, you should not set gas costs very low as this may cause your network to be vulnerable to DoS attacks.
 	// There are some predefined gas costs in contract/utils.go that you can use.
 	ValidateLiquidationOrderAndDetermineFillPriceGasCost uint64 = 69 /* SET A GAS COST HERE */
+	ValidateMatchedOrdersGasCost                         uint64 = 69 /* SET A GAS COST HERE */
 	ValidateOrdersAndDetermineFillPriceGasCost           uint64 = 69 /* SET A GAS COST HERE */
 	ValidatePlaceIOCOrdersGasCost                        uint64 = 69 /* SET A GAS COST HERE */
+
+	// ValidateLiquidationGasCost reads the market, the position size, the margin, the maintenance margin, the min size
+	// and the max liquidation ratio. ValidateLiquidationGasCostPerMarket is charged on top for every market, since the
+	// margin fraction of the trader reads the oracle price, the last price, the position (size and open notional) and
+	// the premium fractions of each market.
+	ValidateLiquidationGasCost          uint64 = 6 * contract.ReadGasCostPerSlot
+	ValidateLiquidationGasCostPerMarket uint64 = 6 * contract.ReadGasCostPerSlot
 )
 
 // CUSTOM CODE STARTS HERE
@@ -70,6 +78,12 @@ type IImmediateOrCancelOrdersOrder struct {
 	ReduceOnly        bool
 }
 
+type ValidateLiquidationInput struct {
+	Trader            common.Address
+	AmmIndex          *big.Int
+	LiquidationAmount *big.Int
+}
+
 type ValidateLiquidationOrderAndDetermineFillPriceInput struct {
 	Data              []byte
 	LiquidationAmount *big.Int
@@ -83,6 +97,15 @@ type ValidateLiquidationOrderAndDetermineFillPriceOutput struct {
 	FillAmount   *big.Int
 }
 
+type ValidateMatchedOrdersOutput struct {
+	Err           string
+	Element       uint8
+	Instructions  [2]IClearingHouseInstruction
+	OrderTypes    [2]uint8
+	EncodedOrders [2][]byte
+	FillPrice     *big.Int
+}
+
 type ValidateOrdersAndDetermineFillPriceInput struct {
 	Data       [2][]byte
 	FillAmount *big.Int
@@ -100,6 +123,50 @@ type ValidatePlaceIOCOrdersInput struct {
 	Sender common.Address
 }
 
+// UnpackValidateLiquidationInput attempts to unpack [input] as ValidateLiquidationInput
+// assumes that [input] does not include selector (omits first 4 func signature bytes)
+func UnpackValidateLiquidationInput(input []byte) (ValidateLiquidationInput, error) {
+	inputStruct := ValidateLiquidationInput{}
+	err := JurorABI.UnpackInputIntoInterface(&inputStruct, "validateLiquidation", input)
+
+	return inputStruct, err
+}
+
+// PackValidateLiquidation packs [inputStruct] of type ValidateLiquidationInput into the appropriate arguments for validateLiquidation.
+func PackValidateLiquidation(inputStruct ValidateLiquidationInput) ([]byte, error) {
+	return JurorABI.Pack("validateLiquidation", inputStruct.Trader, inputStruct.AmmIndex, inputStruct.LiquidationAmount)
+}
+
+func validateLiquidation(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, ValidateLiquidationGasCost); err != nil {
+		return nil, 0, err
+	}
+	marketsCount := uint64(bibliophile.GetActiveMarketsCount(accessibleState.GetStateDB()))
+	if remainingGas, err = contract.DeductGas(remainingGas, marketsCount*ValidateLiquidationGasCostPerMarket); err != nil {
+		return nil, 0, err
+	}
+	// attempts to unpack [input] into the arguments to the ValidateLiquidationInput.
+	// Assumes that [input] does not include selector
+	// You can use unpacked [inputStruct] variable in your code
+	inputStruct, err := UnpackValidateLiquidationInput(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	// CUSTOM CODE STARTS HERE
+	bibliophile := bibliophile.NewBibliophileClient(accessibleState)
+	if err := ValidateLiquidation(bibliophile, &inputStruct); err != nil {
+		log.Error("validateLiquidation", "error", err, "inputStruct", inputStruct, "block", accessibleState.GetBlockContext().Number())
+		return nil, remainingGas, err
+	}
+
+	// this function does not return an output, leave this one as is
+	packedOutput := []byte{}
+
+	// Return the packed output and the remaining gas
+	return packedOutput, remainingGas, nil
+}
+
 // UnpackValidateLiquidationOrderAndDetermineFillPriceInput attempts to unpack [input] as ValidateLiquidationOrderAndDetermineFillPriceInput
 // assumes that [input] does not include selector (omits first 4 func signature bytes)
 func UnpackValidateLiquidationOrderAndDetermineFillPriceInput(input []byte) (ValidateLiquidationOrderAndDetermineFillPriceInput, error) {
@@ -153,6 +220,57 @@ func validateLiquidationOrderAndDetermineFillPrice(accessibleState contract.Acce
 	return packedOutput, remainingGas, nil
 }
 
+// UnpackValidateMatchedOrdersInput attempts to unpack [input] as ValidateOrdersAndDetermineFillPriceInput
+// assumes that [input] does not include selector (omits first 4 func signature bytes)
+func UnpackValidateMatchedOrdersInput(input []byte) (ValidateOrdersAndDetermineFillPriceInput, error) {
+	inputStruct := ValidateOrdersAndDetermineFillPriceInput{}
+	err := JurorABI.UnpackInputIntoInterface(&inputStruct, "validateMatchedOrders", input)
+
+	return inputStruct, err
+}
+
+// PackValidateMatchedOrders packs [inputStruct] of type ValidateOrdersAndDetermineFillPriceInput into the appropriate arguments for validateMatchedOrders.
+func PackValidateMatchedOrders(inputStruct ValidateOrdersAndDetermineFillPriceInput) ([]byte, error) {
+	return JurorABI.Pack("validateMatchedOrders", inputStruct.Data, inputStruct.FillAmount)
+}
+
+// PackValidateMatchedOrdersOutput attempts to pack given [outputStruct] of type ValidateMatchedOrdersOutput
+// to conform the ABI outputs.
+func PackValidateMatchedOrdersOutput(outputStruct ValidateMatchedOrdersOutput) ([]byte, error) {
+	return JurorABI.PackOutput("validateMatchedOrders",
+		outputStruct.Err,
+		outputStruct.Element,
+		outputStruct.Instructions,
+		outputStruct.OrderTypes,
+		outputStruct.EncodedOrders,
+		outputStruct.FillPrice,
+	)
+}
+
+func validateMatchedOrders(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, ValidateMatchedOrdersGasCost); err != nil {
+		return nil, 0, err
+	}
+	// attempts to unpack [input] into the arguments to the ValidateOrdersAndDetermineFillPriceInput.
+	// Assumes that [input] does not include selector
+	// You can use unpacked [inputStruct] variable in your code
+	inputStruct, err := UnpackValidateMatchedOrdersInput(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	// CUSTOM CODE STARTS HERE
+	bibliophile := bibliophile.NewBibliophileClient(accessibleState)
+	output := ValidateMatchedOrders(bibliophile, &inputStruct)
+	packedOutput, err := PackValidateMatchedOrdersOutput(*output)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	// Return the packed output and the remaining gas
+	return packedOutput, remainingGas, nil
+}
+
 // UnpackValidateOrdersAndDetermineFillPriceInput attempts to unpack [input] as ValidateOrdersAndDetermineFillPriceInput
 // assumes that [input] does not include selector (omits first 4 func signature bytes)
 func UnpackValidateOrdersAndDetermineFillPriceInput(input []byte) (ValidateOrdersAndDetermineFillPriceInput, error) {
@@ -263,7 +381,9 @@ func createJurorPrecompile() contract.StatefulPrecompiledContract {
 	var functions []*contract.StatefulPrecompileFunction
 
 	abiFunctionMap := map[string]contract.RunStatefulPrecompileFunc{
+		"validateLiquidation":                           validateLiquidation,
 		"validateLiquidationOrderAndDetermineFillPrice": validateLiquidationOrderAndDetermineFillPrice,
+		"validateMatchedOrders":                         validateMatchedOrders,
 		"validateOrdersAndDetermineFillPrice":           validateOrdersAndDetermineFillPrice,
 		"validatePlaceIOCOrders":                        validatePlaceIOCOrders,
 	}
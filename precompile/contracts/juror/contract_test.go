@@ -14,6 +14,7 @@ import (
 
 	"github.com/ava-labs/subnet-evm/core/state"
 	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
 	"github.com/ava-labs/subnet-evm/precompile/testutils"
 	"github.com/ava-labs/subnet-evm/vmerrs"
 	"github.com/ethereum/go-ethereum/common"
@@ -32,6 +33,40 @@ import (
 // tests for specific cases.
 func TestRun(t *testing.T) {
 	tests := map[string]testutils.PrecompileTest{
+		"insufficient gas for validateLiquidation should fail": {
+			Caller: common.Address{1},
+			InputFn: func(t testing.TB) []byte {
+				testInput := ValidateLiquidationInput{
+					AmmIndex:          big.NewInt(0),
+					LiquidationAmount: big.NewInt(1),
+				}
+				input, err := PackValidateLiquidation(testInput)
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas: ValidateLiquidationGasCost - 1,
+			ReadOnly:    false,
+			ExpectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
+		"insufficient gas for the markets in validateLiquidation should fail": {
+			Caller: common.Address{1},
+			InputFn: func(t testing.TB) []byte {
+				testInput := ValidateLiquidationInput{
+					AmmIndex:          big.NewInt(0),
+					LiquidationAmount: big.NewInt(1),
+				}
+				input, err := PackValidateLiquidation(testInput)
+				require.NoError(t, err)
+				return input
+			},
+			BeforeHook: func(t testing.TB, state contract.StateDB) {
+				// 2 markets are listed
+				state.SetState(common.HexToAddress(b.CLEARING_HOUSE_GENESIS_ADDRESS), common.BigToHash(big.NewInt(b.AMMS_SLOT)), common.BigToHash(big.NewInt(2)))
+			},
+			SuppliedGas: ValidateLiquidationGasCost + ValidateLiquidationGasCostPerMarket,
+			ReadOnly:    false,
+			ExpectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
 		"insufficient gas for validateLiquidationOrderAndDetermineFillPrice should fail": {
 			Caller: common.Address{1},
 			InputFn: func(t testing.TB) []byte {
@@ -46,6 +81,46 @@ func TestRun(t *testing.T) {
 			ReadOnly:    false,
 			ExpectedErr: vmerrs.ErrOutOfGas.Error(),
 		},
+		"insufficient gas for validateMatchedOrders should fail": {
+			Caller: common.Address{1},
+			InputFn: func(t testing.TB) []byte {
+				testInput := ValidateOrdersAndDetermineFillPriceInput{
+					FillAmount: big.NewInt(1),
+				}
+				input, err := PackValidateMatchedOrders(testInput)
+				require.NoError(t, err)
+				return input
+			},
+			SuppliedGas: ValidateMatchedOrdersGasCost - 1,
+			ReadOnly:    false,
+			ExpectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
+		"validateMatchedOrders returns the error instead of reverting": {
+			Caller: common.Address{1},
+			InputFn: func(t testing.TB) []byte {
+				testInput := ValidateOrdersAndDetermineFillPriceInput{
+					FillAmount: big.NewInt(0),
+				}
+				input, err := PackValidateMatchedOrders(testInput)
+				require.NoError(t, err)
+				return input
+			},
+			ExpectedRes: func() []byte {
+				output, err := PackValidateMatchedOrdersOutput(ValidateMatchedOrdersOutput{
+					Err:           ErrInvalidFillAmount.Error(),
+					Element:       uint8(Generic),
+					Instructions:  [2]IClearingHouseInstruction{{AmmIndex: big.NewInt(0)}, {AmmIndex: big.NewInt(0)}},
+					EncodedOrders: [2][]byte{{}, {}},
+					FillPrice:     big.NewInt(0),
+				})
+				if err != nil {
+					panic(err)
+				}
+				return output
+			}(),
+			SuppliedGas: ValidateMatchedOrdersGasCost,
+			ReadOnly:    true,
+		},
 		"insufficient gas for validateOrdersAndDetermineFillPrice should fail": {
 			Caller: common.Address{1},
 			InputFn: func(t testing.TB) []byte {
@@ -382,6 +457,8 @@ func TestValidateExecuteLimitOrder(t *testing.T) {
 		assert.Nil(t, err)
 
 		blockPlaced := big.NewInt(42)
+		mockBibliophile.EXPECT().GetOrderAmendedFrom(orderHash).Return([32]byte{}).Times(1)
+		mockBibliophile.EXPECT().GetOrderAmendedTo(orderHash).Return([32]byte{}).Times(1)
 		mockBibliophile.EXPECT().GetOrderFilledAmount(orderHash).Return(filledAmount).Times(1)
 		mockBibliophile.EXPECT().GetOrderStatus(orderHash).Return(int64(1)).Times(1)                                 // placed
 		mockBibliophile.EXPECT().GetBlockPlaced(orderHash).Return(blockPlaced).Times(1)                              // placed
@@ -398,6 +475,121 @@ func TestValidateExecuteLimitOrder(t *testing.T) {
 			OrderHash:         orderHash,
 		}, m)
 	})
+
+	t.Run("amended order is validated against the order info of the placed order", func(t *testing.T) {
+		placedHash, err := GetLimitOrderHash(order)
+		assert.Nil(t, err)
+		amendedOrder := *order
+		amendedOrder.Price = big.NewInt(21)
+		amendedHash, err := GetLimitOrderHash(&amendedOrder)
+		assert.Nil(t, err)
+
+		blockPlaced := big.NewInt(43)
+		mockBibliophile.EXPECT().GetOrderAmendedFrom(amendedHash).Return(placedHash).Times(1)
+		mockBibliophile.EXPECT().GetOrderAmendedTo(placedHash).Return(amendedHash).Times(1)
+		mockBibliophile.EXPECT().GetOrderFilledAmount(placedHash).Return(filledAmount).Times(1)
+		mockBibliophile.EXPECT().GetOrderStatus(placedHash).Return(int64(1)).Times(1)
+		mockBibliophile.EXPECT().GetBlockPlaced(placedHash).Return(blockPlaced).Times(1)
+		mockBibliophile.EXPECT().GetMarketAddressFromMarketID(order.AmmIndex.Int64()).Return(marketAddress).Times(1)
+
+		m, err := validateExecuteLimitOrder(mockBibliophile, &amendedOrder, Long, fillAmount)
+		assert.Nil(t, err)
+		assertMetadataEquality(t, &Metadata{
+			AmmIndex:          new(big.Int).Set(order.AmmIndex),
+			Trader:            trader,
+			BaseAssetQuantity: new(big.Int).Set(order.BaseAssetQuantity),
+			BlockPlaced:       blockPlaced,
+			Price:             big.NewInt(21),
+			OrderHash:         placedHash,
+		}, m)
+
+		// the order as placed can't be executed anymore
+		mockBibliophile.EXPECT().GetOrderAmendedFrom(placedHash).Return([32]byte{}).Times(1)
+		mockBibliophile.EXPECT().GetOrderAmendedTo(placedHash).Return(amendedHash).Times(1)
+		_, err = validateExecuteLimitOrder(mockBibliophile, order, Long, fillAmount)
+		assert.Equal(t, ErrOrderAmended, err)
+	})
+}
+
+func TestValidateLiquidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBibliophile := b.NewMockBibliophileClient(ctrl)
+	marketAddress := common.HexToAddress("0xa72b463C21dA61cCc86069cFab82e9e8491152a0")
+	trader := common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC")
+	minSize := big.NewInt(1e17)
+	size := big.NewInt(-23e17)              // short 2.3
+	maintenanceMargin := big.NewInt(1e5)    // 10%
+	maxLiquidationRatio := big.NewInt(25e4) // 25%, i.e. threshold = 0.575 which is rounded down to 0.5
+	notionalPosition := big.NewInt(4000e6)
+	input := &ValidateLiquidationInput{Trader: trader, AmmIndex: big.NewInt(0), LiquidationAmount: big.NewInt(5e17)}
+
+	t.Run("invalid market", func(t *testing.T) {
+		mockBibliophile.EXPECT().GetMarketAddressFromMarketID(int64(0)).Return(common.Address{}).Times(1)
+		assert.Equal(t, ErrInvalidMarket, ValidateLiquidation(mockBibliophile, input))
+	})
+
+	t.Run("no position", func(t *testing.T) {
+		mockBibliophile.EXPECT().GetMarketAddressFromMarketID(int64(0)).Return(marketAddress).Times(1)
+		mockBibliophile.EXPECT().GetSize(marketAddress, &trader).Return(big.NewInt(0)).Times(1)
+		assert.Equal(t, ErrNoPosition, ValidateLiquidation(mockBibliophile, input))
+	})
+
+	setupPosition := func(margin *big.Int) {
+		mockBibliophile.EXPECT().GetMarketAddressFromMarketID(int64(0)).Return(marketAddress).Times(1)
+		mockBibliophile.EXPECT().GetSize(marketAddress, &trader).Return(size).Times(1)
+		mockBibliophile.EXPECT().GetNotionalPositionAndMargin(trader, true, uint8(b.Maintenance_Margin)).Return(notionalPosition, margin).Times(1)
+		mockBibliophile.EXPECT().GetMaintenanceMargin().Return(maintenanceMargin).Times(1)
+	}
+
+	t.Run("margin fraction == maintenance margin", func(t *testing.T) {
+		setupPosition(big.NewInt(400e6))
+		assert.Equal(t, ErrNotLiquidatable, ValidateLiquidation(mockBibliophile, input))
+	})
+
+	t.Run("liquidatable", func(t *testing.T) {
+		liquidatableMargin := big.NewInt(399e6)
+		t.Run("amount is 0", func(t *testing.T) {
+			setupPosition(liquidatableMargin)
+			input := &ValidateLiquidationInput{Trader: trader, AmmIndex: big.NewInt(0), LiquidationAmount: big.NewInt(0)}
+			assert.Equal(t, ErrInvalidFillAmount, ValidateLiquidation(mockBibliophile, input))
+		})
+		t.Run("min size is not set", func(t *testing.T) {
+			setupPosition(liquidatableMargin)
+			mockBibliophile.EXPECT().GetMinSizeRequirement(int64(0)).Return(big.NewInt(0)).Times(1)
+			assert.Equal(t, ErrMinSizeNotSet, ValidateLiquidation(mockBibliophile, input))
+		})
+		t.Run("amount is not a multiple of min size", func(t *testing.T) {
+			setupPosition(liquidatableMargin)
+			mockBibliophile.EXPECT().GetMinSizeRequirement(int64(0)).Return(minSize).Times(1)
+			input := &ValidateLiquidationInput{Trader: trader, AmmIndex: big.NewInt(0), LiquidationAmount: big.NewInt(15e16)}
+			assert.Equal(t, ErrNotMultiple, ValidateLiquidation(mockBibliophile, input))
+		})
+		t.Run("amount exceeds threshold", func(t *testing.T) {
+			setupPosition(liquidatableMargin)
+			mockBibliophile.EXPECT().GetMinSizeRequirement(int64(0)).Return(minSize).Times(1)
+			mockBibliophile.EXPECT().GetMaxLiquidationRatio(int64(0)).Return(maxLiquidationRatio).Times(1)
+			input := &ValidateLiquidationInput{Trader: trader, AmmIndex: big.NewInt(0), LiquidationAmount: big.NewInt(6e17)}
+			assert.Equal(t, ErrLiquidationAmountExceeded, ValidateLiquidation(mockBibliophile, input))
+		})
+		t.Run("amount is within threshold", func(t *testing.T) {
+			setupPosition(liquidatableMargin)
+			mockBibliophile.EXPECT().GetMinSizeRequirement(int64(0)).Return(minSize).Times(1)
+			mockBibliophile.EXPECT().GetMaxLiquidationRatio(int64(0)).Return(maxLiquidationRatio).Times(1)
+			assert.Nil(t, ValidateLiquidation(mockBibliophile, input))
+		})
+		t.Run("threshold is capped at the position size", func(t *testing.T) {
+			mockBibliophile.EXPECT().GetMarketAddressFromMarketID(int64(0)).Return(marketAddress).Times(1)
+			mockBibliophile.EXPECT().GetSize(marketAddress, &trader).Return(big.NewInt(1e17)).Times(1)
+			mockBibliophile.EXPECT().GetNotionalPositionAndMargin(trader, true, uint8(b.Maintenance_Margin)).Return(big.NewInt(200e6), big.NewInt(1e6)).Times(1)
+			mockBibliophile.EXPECT().GetMaintenanceMargin().Return(maintenanceMargin).Times(1)
+			mockBibliophile.EXPECT().GetMinSizeRequirement(int64(0)).Return(minSize).Times(1)
+			mockBibliophile.EXPECT().GetMaxLiquidationRatio(int64(0)).Return(maxLiquidationRatio).Times(1)
+			input := &ValidateLiquidationInput{Trader: trader, AmmIndex: big.NewInt(0), LiquidationAmount: big.NewInt(2e17)}
+			assert.Equal(t, ErrLiquidationAmountExceeded, ValidateLiquidation(mockBibliophile, input))
+		})
+	})
 }
 
 func assertMetadataEquality(t *testing.T, expected, actual *Metadata) {
@@ -409,6 +601,27 @@ func assertMetadataEquality(t *testing.T, expected, actual *Metadata) {
 	assert.Equal(t, expected.OrderHash, actual.OrderHash)
 }
 
+func TestValidateMatchedOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBibliophile := b.NewMockBibliophileClient(ctrl)
+
+	t.Run("invalid fill amount is not attributed to an order", func(t *testing.T) {
+		output := ValidateMatchedOrders(mockBibliophile, &ValidateOrdersAndDetermineFillPriceInput{Data: [2][]byte{{}, {}}, FillAmount: big.NewInt(0)})
+		assert.Equal(t, ErrInvalidFillAmount.Error(), output.Err)
+		assert.Equal(t, uint8(Generic), output.Element)
+	})
+	t.Run("undecodable long order is attributed to order 0", func(t *testing.T) {
+		output := ValidateMatchedOrders(mockBibliophile, &ValidateOrdersAndDetermineFillPriceInput{Data: [2][]byte{{1}, {}}, FillAmount: big.NewInt(1)})
+		assert.NotEmpty(t, output.Err)
+		assert.Equal(t, uint8(Order0), output.Element)
+		// same error as the reverting version
+		_, err := ValidateOrdersAndDetermineFillPrice(mockBibliophile, &ValidateOrdersAndDetermineFillPriceInput{Data: [2][]byte{{1}, {}}, FillAmount: big.NewInt(1)})
+		assert.EqualError(t, err, output.Err)
+	})
+}
+
 func TestDecodeIOCOrder(t *testing.T) {
 	t.Run("long order", func(t *testing.T) {
 		order := &orderbook.IOCOrder{
@@ -487,6 +700,112 @@ func assertIOCOrderEquality(t *testing.T, expected, actual *orderbook.IOCOrder)
 	assertLimitOrderEquality(t, expected.LimitOrder, actual.LimitOrder)
 }
 
+func TestDecodeGTTOrder(t *testing.T) {
+	order := &orderbook.GTTOrder{
+		OrderType: uint8(GTT),
+		ExpireAt:  big.NewInt(1688994854),
+		LimitOrder: orderbook.LimitOrder{
+			AmmIndex:          big.NewInt(0),
+			Trader:            common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+			BaseAssetQuantity: big.NewInt(-5000000000000000000),
+			Price:             big.NewInt(1000000000),
+			Salt:              big.NewInt(1688994806105),
+			ReduceOnly:        true,
+		},
+	}
+	b, err := order.EncodeToABI()
+	assert.Nil(t, err)
+
+	decodeStep, err := decodeTypeAndEncodedOrder(b)
+	assert.Nil(t, err)
+	assert.Equal(t, GTT, decodeStep.OrderType)
+
+	result, err := orderbook.DecodeGTTOrder(decodeStep.EncodedOrder)
+	assert.Nil(t, err)
+	assert.Equal(t, order.OrderType, result.OrderType)
+	assert.Equal(t, order.ExpireAt.Int64(), result.ExpireAt.Int64())
+	assertLimitOrderEquality(t, order.LimitOrder, result.LimitOrder)
+
+	// same fields as an IOC order, but signed for the GTT orderbook
+	gttHash, err := getGTTOrderHash(order)
+	assert.Nil(t, err)
+	iocHash, err := getIOCOrderHash(&orderbook.IOCOrder{OrderType: order.OrderType, ExpireAt: order.ExpireAt, LimitOrder: order.LimitOrder})
+	assert.Nil(t, err)
+	assert.NotEqual(t, iocHash, gttHash)
+}
+
+func TestValidateExecuteGTTOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBibliophile := b.NewMockBibliophileClient(ctrl)
+	marketAddress := common.HexToAddress("0xa72b463C21dA61cCc86069cFab82e9e8491152a0")
+	trader := common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC")
+	order := &orderbook.GTTOrder{
+		OrderType: uint8(GTT),
+		ExpireAt:  big.NewInt(1000),
+		LimitOrder: orderbook.LimitOrder{
+			AmmIndex:          big.NewInt(534),
+			Trader:            trader,
+			BaseAssetQuantity: big.NewInt(10),
+			Price:             big.NewInt(20),
+			Salt:              big.NewInt(1),
+			ReduceOnly:        false,
+		},
+	}
+	orderHash, err := getGTTOrderHash(order)
+	assert.Nil(t, err)
+	atTimestamp := func(timestamp uint64) {
+		accessibleState := contract.NewMockAccessibleState(nil, contract.NewMockBlockContext(big.NewInt(1), timestamp), nil, nil)
+		mockBibliophile.EXPECT().GetAccessibleState().Return(accessibleState).Times(1)
+	}
+
+	t.Run("fills before expiry", func(t *testing.T) {
+		atTimestamp(1000)
+		blockPlaced := big.NewInt(42)
+		mockBibliophile.EXPECT().GTT_GetOrderFilledAmount(orderHash).Return(big.NewInt(5)).Times(1)
+		mockBibliophile.EXPECT().GTT_GetOrderStatus(orderHash).Return(int64(1)).Times(1) // placed
+		mockBibliophile.EXPECT().GTT_GetBlockPlaced(orderHash).Return(blockPlaced).Times(1)
+		mockBibliophile.EXPECT().GetMarketAddressFromMarketID(order.AmmIndex.Int64()).Return(marketAddress).Times(1)
+
+		m, err := validateExecuteGTTOrder(mockBibliophile, order, Long, big.NewInt(5))
+		assert.Nil(t, err)
+		assertMetadataEquality(t, &Metadata{
+			AmmIndex:          new(big.Int).Set(order.AmmIndex),
+			Trader:            trader,
+			BaseAssetQuantity: new(big.Int).Set(order.BaseAssetQuantity),
+			BlockPlaced:       blockPlaced,
+			Price:             new(big.Int).Set(order.Price),
+			OrderHash:         orderHash,
+		}, m)
+	})
+	t.Run("rejects expired order", func(t *testing.T) {
+		atTimestamp(1001)
+		_, err := validateExecuteGTTOrder(mockBibliophile, order, Long, big.NewInt(5))
+		assert.Equal(t, ErrGTTExpired, err)
+	})
+	t.Run("doesn't wrap an expiry beyond uint64 around", func(t *testing.T) {
+		atTimestamp(1001)
+		farOrder := *order
+		farOrder.ExpireAt = new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(500))
+		farOrderHash, err := getGTTOrderHash(&farOrder)
+		assert.Nil(t, err)
+		mockBibliophile.EXPECT().GTT_GetOrderFilledAmount(farOrderHash).Return(big.NewInt(0)).Times(1)
+		mockBibliophile.EXPECT().GTT_GetOrderStatus(farOrderHash).Return(int64(1)).Times(1) // placed
+		mockBibliophile.EXPECT().GTT_GetBlockPlaced(farOrderHash).Return(big.NewInt(42)).Times(1)
+		mockBibliophile.EXPECT().GetMarketAddressFromMarketID(order.AmmIndex.Int64()).Return(marketAddress).Times(1)
+
+		_, err = validateExecuteGTTOrder(mockBibliophile, &farOrder, Long, big.NewInt(5))
+		assert.Nil(t, err)
+	})
+	t.Run("rejects order of another type", func(t *testing.T) {
+		iocOrder := *order
+		iocOrder.OrderType = uint8(IOC)
+		_, err := validateExecuteGTTOrder(mockBibliophile, &iocOrder, Long, big.NewInt(5))
+		assert.NotNil(t, err)
+	})
+}
+
 // @todo
 func TestValidatePlaceIOCOrders(t *testing.T) {
 }
@@ -63,6 +63,32 @@ func getIOCOrderHash(o *orderbook.IOCOrder) (hash common.Hash, err error) {
 	return EncodeForSigning(typedData)
 }
 
+func getGTTOrderHash(o *orderbook.GTTOrder) (hash common.Hash, err error) {
+	message := map[string]interface{}{
+		"orderType":         strconv.FormatUint(uint64(o.OrderType), 10),
+		"expireAt":          o.ExpireAt.String(),
+		"ammIndex":          o.AmmIndex.String(),
+		"trader":            o.Trader.String(),
+		"baseAssetQuantity": o.BaseAssetQuantity.String(),
+		"price":             o.Price.String(),
+		"salt":              o.Salt.String(),
+		"reduceOnly":        o.ReduceOnly,
+	}
+	domain := apitypes.TypedDataDomain{
+		Name:              "Hubble",
+		Version:           "2.0",
+		ChainId:           math.NewHexOrDecimal256(321123), // @todo chain id from config
+		VerifyingContract: common.HexToAddress(bibliophile.GTT_ORDERBOOK_ADDRESS).String(),
+	}
+	typedData := apitypes.TypedData{
+		Types:       Eip712OrderTypes,
+		PrimaryType: "GTTOrder",
+		Domain:      domain,
+		Message:     message,
+	}
+	return EncodeForSigning(typedData)
+}
+
 // EncodeForSigning - Encoding the typed data
 func EncodeForSigning(typedData apitypes.TypedData) (hash common.Hash, err error) {
 	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
@@ -157,4 +183,38 @@ var Eip712OrderTypes = apitypes.Types{
 			Type: "bool",
 		},
 	},
+	"GTTOrder": {
+		{
+			Name: "orderType",
+			Type: "uint8",
+		},
+		{
+			Name: "expireAt",
+			Type: "uint256",
+		},
+		{
+			Name: "ammIndex",
+			Type: "uint256",
+		},
+		{
+			Name: "trader",
+			Type: "address",
+		},
+		{
+			Name: "baseAssetQuantity",
+			Type: "int256",
+		},
+		{
+			Name: "price",
+			Type: "uint256",
+		},
+		{
+			Name: "salt",
+			Type: "uint256",
+		},
+		{
+			Name: "reduceOnly",
+			Type: "bool",
+		},
+	},
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/ava-labs/subnet-evm/accounts/abi"
 	"github.com/ava-labs/subnet-evm/plugin/evm/orderbook"
 	b "github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/ava-labs/subnet-evm/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -18,6 +19,7 @@ type OrderType uint8
 const (
 	Limit OrderType = iota
 	IOC
+	GTT
 )
 
 type DecodeStep struct {
@@ -66,52 +68,100 @@ var (
 	ErrTooHigh                  = errors.New("OB_short_order_price_too_high")
 	ErrOverFill                 = errors.New("overfill")
 	ErrReduceOnlyAmountExceeded = errors.New("not reducing pos")
+
+	ErrGTTExpired = errors.New("gtt expired")
+
+	ErrOrderAmended = errors.New("order amended")
+
+	ErrInvalidMarket             = errors.New("invalid market")
+	ErrNoPosition                = errors.New("no position")
+	ErrNotLiquidatable           = errors.New("trader is not liquidatable")
+	ErrLiquidationAmountExceeded = errors.New("liquidation amount exceeds threshold")
+	ErrMinSizeNotSet             = errors.New("min size requirement is not set")
+)
+
+// BadElement is the element of a match that failed validation
+type BadElement uint8
+
+// has to be exact same as IJuror.BadElement
+const (
+	Order0 BadElement = iota
+	Order1
+	Generic
+	NoError
 )
 
 // Business Logic
 func ValidateOrdersAndDetermineFillPrice(bibliophile b.BibliophileClient, inputStruct *ValidateOrdersAndDetermineFillPriceInput) (*ValidateOrdersAndDetermineFillPriceOutput, error) {
+	output, _, err := validateMatch(bibliophile, inputStruct)
+	return output, err
+}
+
+// ValidateMatchedOrders is the non-reverting version of ValidateOrdersAndDetermineFillPrice used by the orderbook when executing a batch of matches.
+// It reports which order failed validation, so that the orderbook can emit OrderMatchingError for it and move on to the next match.
+func ValidateMatchedOrders(bibliophile b.BibliophileClient, inputStruct *ValidateOrdersAndDetermineFillPriceInput) *ValidateMatchedOrdersOutput {
+	output, element, err := validateMatch(bibliophile, inputStruct)
+	if err != nil {
+		return &ValidateMatchedOrdersOutput{
+			Err:           err.Error(),
+			Element:       uint8(element),
+			Instructions:  [2]IClearingHouseInstruction{{AmmIndex: big.NewInt(0)}, {AmmIndex: big.NewInt(0)}},
+			EncodedOrders: [2][]byte{{}, {}},
+			FillPrice:     big.NewInt(0),
+		}
+	}
+	return &ValidateMatchedOrdersOutput{
+		Element:       uint8(NoError),
+		Instructions:  output.Instructions,
+		OrderTypes:    output.OrderTypes,
+		EncodedOrders: output.EncodedOrders,
+		FillPrice:     output.FillPrice,
+	}
+}
+
+func validateMatch(bibliophile b.BibliophileClient, inputStruct *ValidateOrdersAndDetermineFillPriceInput) (*ValidateOrdersAndDetermineFillPriceOutput, BadElement, error) {
 	if len(inputStruct.Data) != 2 {
-		return nil, ErrTwoOrders
+		return nil, Generic, ErrTwoOrders
 	}
 
 	if inputStruct.FillAmount.Sign() <= 0 {
-		return nil, ErrInvalidFillAmount
+		return nil, Generic, ErrInvalidFillAmount
 	}
 
 	decodeStep0, err := decodeTypeAndEncodedOrder(inputStruct.Data[0])
 	if err != nil {
-		return nil, err
+		return nil, Order0, err
 	}
 	m0, err := validateOrder(bibliophile, decodeStep0.OrderType, decodeStep0.EncodedOrder, Long, inputStruct.FillAmount)
 	if err != nil {
-		return nil, err
+		return nil, Order0, err
 	}
 
 	decodeStep1, err := decodeTypeAndEncodedOrder(inputStruct.Data[1])
 	if err != nil {
-		return nil, err
+		return nil, Order1, err
 	}
 	m1, err := validateOrder(bibliophile, decodeStep1.OrderType, decodeStep1.EncodedOrder, Short, new(big.Int).Neg(inputStruct.FillAmount))
 	if err != nil {
-		return nil, err
+		return nil, Order1, err
 	}
 
 	if m0.AmmIndex.Cmp(m1.AmmIndex) != 0 {
-		return nil, ErrNotSameAMM
+		return nil, Generic, ErrNotSameAMM
 	}
 
 	if m0.Price.Cmp(m1.Price) < 0 {
-		return nil, ErrNoMatch
+		return nil, Generic, ErrNoMatch
 	}
 
 	minSize := bibliophile.GetMinSizeRequirement(m0.AmmIndex.Int64())
 	if new(big.Int).Mod(inputStruct.FillAmount, minSize).Cmp(big.NewInt(0)) != 0 {
-		return nil, ErrNotMultiple
+		return nil, Generic, ErrNotMultiple
 	}
 
 	fillPriceAndModes, err := bibliophile.DetermineFillPrice(m0.AmmIndex.Int64(), m0.Price, m1.Price, m0.BlockPlaced, m1.BlockPlaced)
 	if err != nil {
-		return nil, err
+		return nil, Generic, err
 	}
 
 	output := &ValidateOrdersAndDetermineFillPriceOutput{
@@ -136,7 +186,7 @@ func ValidateOrdersAndDetermineFillPrice(bibliophile b.BibliophileClient, inputS
 		},
 		FillPrice: fillPriceAndModes.FillPrice,
 	}
-	return output, nil
+	return output, NoError, nil
 }
 
 func ValidateLiquidationOrderAndDetermineFillPrice(bibliophile b.BibliophileClient, inputStruct *ValidateLiquidationOrderAndDetermineFillPriceInput) (*ValidateLiquidationOrderAndDetermineFillPriceOutput, error) {
@@ -183,6 +233,45 @@ func ValidateLiquidationOrderAndDetermineFillPrice(bibliophile b.BibliophileClie
 	return output, nil
 }
 
+// ValidateLiquidation returns nil if [inputStruct.LiquidationAmount] of the trader's position in the market can be liquidated.
+// The margin fraction is computed the same way the validator computes it in orderbook.calcMarginFraction
+func ValidateLiquidation(bibliophile b.BibliophileClient, inputStruct *ValidateLiquidationInput) error {
+	marketId := inputStruct.AmmIndex.Int64()
+	market := bibliophile.GetMarketAddressFromMarketID(marketId)
+	if market == (common.Address{}) {
+		return ErrInvalidMarket
+	}
+	size := bibliophile.GetSize(market, &inputStruct.Trader)
+	if size.Sign() == 0 {
+		return ErrNoPosition
+	}
+
+	notionalPosition, margin := bibliophile.GetNotionalPositionAndMargin(inputStruct.Trader, true /* includeFundingPayments */, uint8(b.Maintenance_Margin))
+	marginFraction := b.CalcMarginFraction(margin, notionalPosition)
+	if marginFraction.Cmp(bibliophile.GetMaintenanceMargin()) >= 0 {
+		return ErrNotLiquidatable
+	}
+
+	liquidationAmount := inputStruct.LiquidationAmount
+	if liquidationAmount.Sign() <= 0 {
+		return ErrInvalidFillAmount
+	}
+	minSize := bibliophile.GetMinSizeRequirement(marketId)
+	if minSize.Sign() <= 0 {
+		return ErrMinSizeNotSet
+	}
+	if new(big.Int).Mod(liquidationAmount, minSize).Sign() != 0 {
+		return ErrNotMultiple
+	}
+	threshold := new(big.Int).Abs(b.GetLiquidationThreshold(bibliophile.GetMaxLiquidationRatio(marketId), minSize, size))
+	threshold.Sub(threshold, new(big.Int).Mod(threshold, minSize)) // round down to a multiple of minSize
+	threshold = utils.BigIntMin(threshold, new(big.Int).Abs(size))
+	if liquidationAmount.Cmp(threshold) == 1 {
+		return ErrLiquidationAmountExceeded
+	}
+	return nil
+}
+
 func decodeTypeAndEncodedOrder(data []byte) (*DecodeStep, error) {
 	orderType, _ := abi.NewType("uint8", "uint8", nil)
 	orderBytesType, _ := abi.NewType("bytes", "bytes", nil)
@@ -211,6 +300,13 @@ func validateOrder(bibliophile b.BibliophileClient, orderType OrderType, encoded
 		}
 		return validateExecuteIOCOrder(bibliophile, order, side, fillAmount)
 	}
+	if orderType == GTT {
+		order, err := orderbook.DecodeGTTOrder(encodedOrder)
+		if err != nil {
+			return nil, err
+		}
+		return validateExecuteGTTOrder(bibliophile, order, side, fillAmount)
+	}
 	return nil, errors.New("invalid order type")
 }
 
@@ -221,6 +317,10 @@ func validateExecuteLimitOrder(bibliophile b.BibliophileClient, order *orderbook
 	if err != nil {
 		return nil, err
 	}
+	orderHash, err = resolveAmendedOrderHash(bibliophile, orderHash)
+	if err != nil {
+		return nil, err
+	}
 	if err := validateLimitOrderLike(bibliophile, order, bibliophile.GetOrderFilledAmount(orderHash), OrderStatus(bibliophile.GetOrderStatus(orderHash)), side, fillAmount); err != nil {
 		return nil, err
 	}
@@ -234,6 +334,24 @@ func validateExecuteLimitOrder(bibliophile b.BibliophileClient, order *orderbook
 	}, nil
 }
 
+// resolveAmendedOrderHash returns the hash that the orderbook keeps the info of the order with [orderHash] under.
+// An amended order is kept under the hash it was originally placed with, which is also the hash it is matched with.
+// Only the latest amendment of an order can be executed, the order as placed or as amended before can't.
+func resolveAmendedOrderHash(bibliophile b.BibliophileClient, orderHash common.Hash) (common.Hash, error) {
+	placedHash := common.Hash(bibliophile.GetOrderAmendedFrom(orderHash))
+	if placedHash == (common.Hash{}) {
+		placedHash = orderHash
+	}
+	latestHash := common.Hash(bibliophile.GetOrderAmendedTo(placedHash))
+	if latestHash == (common.Hash{}) {
+		latestHash = placedHash
+	}
+	if latestHash != orderHash {
+		return common.Hash{}, ErrOrderAmended
+	}
+	return placedHash, nil
+}
+
 func validateLimitOrderLike(bibliophile b.BibliophileClient, order *orderbook.LimitOrder, filledAmount *big.Int, status OrderStatus, side Side, fillAmount *big.Int) error {
 	if status != Placed {
 		return ErrInvalidOrder
@@ -375,3 +493,31 @@ func validateExecuteIOCOrder(bibliophile b.BibliophileClient, order *orderbook.I
 		OrderHash:         orderHash,
 	}, nil
 }
+
+// GTT Orders
+
+func validateExecuteGTTOrder(bibliophile b.BibliophileClient, order *orderbook.GTTOrder, side Side, fillAmount *big.Int) (metadata *Metadata, err error) {
+	if OrderType(order.OrderType) != GTT {
+		return nil, errors.New("not gtt order")
+	}
+	// an expired order is not filled even if the validators haven't cancelled it yet. expireAt is compared as a big
+	// int, Uint64 would wrap an expiry beyond uint64 around to the past
+	if order.ExpireAt.Cmp(new(big.Int).SetUint64(bibliophile.GetAccessibleState().GetBlockContext().Timestamp())) < 0 {
+		return nil, ErrGTTExpired
+	}
+	orderHash, err := getGTTOrderHash(order)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLimitOrderLike(bibliophile, &order.LimitOrder, bibliophile.GTT_GetOrderFilledAmount(orderHash), OrderStatus(bibliophile.GTT_GetOrderStatus(orderHash)), side, fillAmount); err != nil {
+		return nil, err
+	}
+	return &Metadata{
+		AmmIndex:          order.AmmIndex,
+		Trader:            order.Trader,
+		BaseAssetQuantity: order.BaseAssetQuantity,
+		BlockPlaced:       bibliophile.GTT_GetBlockPlaced(orderHash),
+		Price:             order.Price,
+		OrderHash:         orderHash,
+	}, nil
+}
@@ -0,0 +1,100 @@
+// Code generated
+// This file is a generated precompile contract config with stubbed abstract functions.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package marginbridge
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/subnet-evm/precompile/precompileconfig"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var _ precompileconfig.Config = &Config{}
+
+var errNoBlockchainID = errors.New("bridge blockchainID is not set")
+
+// Config implements the precompileconfig.Config interface and
+// adds specific configuration for MarginBridge.
+type Config struct {
+	precompileconfig.Upgrade
+	// CUSTOM CODE STARTS HERE
+	// HUSD is the margin collateral, which the precompile mints to the MarginAccount for deposits and burns from it
+	// for withdrawals, so that the bridged margin is backed like the margin added to the MarginAccount. It is kept
+	// by a later upgrade that doesn't set it. The node doesn't start with the bridge enabled unless the storage layouts
+	// of the deployed hUSD and MarginAccount verify (see bibliophile/layouts), since it writes their storage directly.
+	HUSD common.Address `json:"hUSD,omitempty"`
+	// Bridges are the contracts on other chains that lock collateral to deposit it as margin on this chain, and release
+	// it on withdrawals. Deposits are only accepted from, and withdrawals are only sent to, these contracts.
+	// A later upgrade replaces the bridge of a chain, and removes it with the zero address.
+	Bridges []Bridge `json:"bridges,omitempty"`
+}
+
+// Bridge is the bridge contract at [Address] on the chain [BlockchainID]
+type Bridge struct {
+	BlockchainID common.Hash    `json:"blockchainID"`
+	Address      common.Address `json:"address"`
+}
+
+// NewConfig returns a config for a network upgrade at [blockTimestamp] that enables
+// MarginBridge.
+func NewConfig(blockTimestamp *uint64, husd common.Address, bridges []Bridge) *Config {
+	return &Config{
+		Upgrade: precompileconfig.Upgrade{BlockTimestamp: blockTimestamp},
+		HUSD:    husd,
+		Bridges: bridges,
+	}
+}
+
+// NewDisableConfig returns config for a network upgrade at [blockTimestamp]
+// that disables MarginBridge.
+func NewDisableConfig(blockTimestamp *uint64) *Config {
+	return &Config{
+		Upgrade: precompileconfig.Upgrade{
+			BlockTimestamp: blockTimestamp,
+			Disable:        true,
+		},
+	}
+}
+
+// Key returns the key for the MarginBridge precompileconfig.
+// This should be the same key as used in the precompile module.
+func (*Config) Key() string { return ConfigKey }
+
+// Verify tries to verify Config and returns an error accordingly.
+func (c *Config) Verify() error {
+	// CUSTOM CODE STARTS HERE
+	chains := make(map[common.Hash]bool, len(c.Bridges))
+	for _, bridge := range c.Bridges {
+		if bridge.BlockchainID == (common.Hash{}) {
+			return errNoBlockchainID
+		}
+		if chains[bridge.BlockchainID] {
+			return fmt.Errorf("duplicate bridge for blockchainID %s", bridge.BlockchainID.Hex())
+		}
+		chains[bridge.BlockchainID] = true
+	}
+	return nil
+}
+
+// Equal returns true if [s] is a [*Config] and it has been configured identical to [c].
+func (c *Config) Equal(s precompileconfig.Config) bool {
+	// typecast before comparison
+	other, ok := (s).(*Config)
+	if !ok {
+		return false
+	}
+	// CUSTOM CODE STARTS HERE
+	equals := c.Upgrade.Equal(&other.Upgrade)
+	if !equals || c.HUSD != other.HUSD || len(c.Bridges) != len(other.Bridges) {
+		return false
+	}
+	for i, bridge := range c.Bridges {
+		if bridge != other.Bridges[i] {
+			return false
+		}
+	}
+	return true
+}
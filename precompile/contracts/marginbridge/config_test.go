@@ -0,0 +1,94 @@
+// Code generated
+// This file is a generated precompile config test with the skeleton of test functions.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package marginbridge
+
+import (
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/precompile/precompileconfig"
+	"github.com/ava-labs/subnet-evm/precompile/testutils"
+	"github.com/ava-labs/subnet-evm/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var testHUSD = common.HexToAddress("0x1000")
+
+var testBridges = []Bridge{
+	{BlockchainID: common.HexToHash("0x01"), Address: common.HexToAddress("0x1001")},
+	{BlockchainID: common.HexToHash("0x02"), Address: common.HexToAddress("0x1002")},
+}
+
+// TestVerify tests the verification of Config.
+func TestVerify(t *testing.T) {
+	tests := map[string]testutils.ConfigVerifyTest{
+		"valid config": {
+			Config:        NewConfig(utils.NewUint64(3), testHUSD, testBridges),
+			ExpectedError: "",
+		},
+		// CUSTOM CODE STARTS HERE
+		"no bridges": {
+			Config:        NewConfig(utils.NewUint64(3), testHUSD, nil),
+			ExpectedError: "",
+		},
+		"removed bridge": {
+			Config:        NewConfig(utils.NewUint64(3), testHUSD, []Bridge{{BlockchainID: common.HexToHash("0x01")}}),
+			ExpectedError: "",
+		},
+		"no blockchainID": {
+			Config:        NewConfig(utils.NewUint64(3), testHUSD, []Bridge{{Address: common.HexToAddress("0x1001")}}),
+			ExpectedError: errNoBlockchainID.Error(),
+		},
+		"duplicate blockchainID": {
+			Config:        NewConfig(utils.NewUint64(3), testHUSD, []Bridge{testBridges[0], {BlockchainID: testBridges[0].BlockchainID, Address: common.HexToAddress("0x1003")}}),
+			ExpectedError: "duplicate bridge for blockchainID",
+		},
+	}
+	// Run verify tests.
+	testutils.RunVerifyTests(t, tests)
+}
+
+// TestEqual tests the equality of Config with other precompile configs.
+func TestEqual(t *testing.T) {
+	tests := map[string]testutils.ConfigEqualTest{
+		"non-nil config and nil other": {
+			Config:   NewConfig(utils.NewUint64(3), testHUSD, testBridges),
+			Other:    nil,
+			Expected: false,
+		},
+		"different type": {
+			Config:   NewConfig(utils.NewUint64(3), testHUSD, testBridges),
+			Other:    precompileconfig.NewNoopStatefulPrecompileConfig(),
+			Expected: false,
+		},
+		"different timestamp": {
+			Config:   NewConfig(utils.NewUint64(3), testHUSD, testBridges),
+			Other:    NewConfig(utils.NewUint64(4), testHUSD, testBridges),
+			Expected: false,
+		},
+		"same config": {
+			Config:   NewConfig(utils.NewUint64(3), testHUSD, testBridges),
+			Other:    NewConfig(utils.NewUint64(3), testHUSD, testBridges),
+			Expected: true,
+		},
+		// CUSTOM CODE STARTS HERE
+		"different hUSD": {
+			Config:   NewConfig(utils.NewUint64(3), testHUSD, testBridges),
+			Other:    NewConfig(utils.NewUint64(3), common.HexToAddress("0x1003"), testBridges),
+			Expected: false,
+		},
+		"different bridges": {
+			Config:   NewConfig(utils.NewUint64(3), testHUSD, testBridges),
+			Other:    NewConfig(utils.NewUint64(3), testHUSD, testBridges[:1]),
+			Expected: false,
+		},
+		"different bridge address": {
+			Config:   NewConfig(utils.NewUint64(3), testHUSD, testBridges[:1]),
+			Other:    NewConfig(utils.NewUint64(3), testHUSD, []Bridge{{BlockchainID: testBridges[0].BlockchainID, Address: common.HexToAddress("0x1003")}}),
+			Expected: false,
+		},
+	}
+	// Run equal tests.
+	testutils.RunEqualTests(t, tests)
+}
@@ -0,0 +1,445 @@
+// Code generated
+// This file is a generated precompile contract config with stubbed abstract functions.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package marginbridge
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/ava-labs/subnet-evm/vmerrs"
+	"github.com/ava-labs/subnet-evm/x/warp"
+
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// DepositFromWarpGasCost reads the bridge, the processed flag and the margin, writes the last two, mints the hUSD
+	// and emits MarginDeposited. The bytes of the warp message in the predicate are charged on top with
+	// warp.GasCostPerWarpMessageBytes.
+	DepositFromWarpGasCost uint64 = warp.GetVerifiedWarpMessageBaseCost + 3*contract.ReadGasCostPerSlot + 2*contract.WriteGasCostPerSlot + husdTransferGasCost + marginEventGasCost
+	// WithdrawToWarpGasCost checks the available margin of the trader, writes the margin and the nonce, burns the hUSD,
+	// sends the warp message and emits MarginWithdrawn.
+	WithdrawToWarpGasCost     uint64 = warp.SendWarpMessageGasCost + transferPayloadSize*warp.SendWarpMessageGasCostPerByte + marginRequirementGasCost + 4*contract.ReadGasCostPerSlot + 2*contract.WriteGasCostPerSlot + husdTransferGasCost + marginEventGasCost + params.LogDataGas*common.HashLength
+	IsDepositProcessedGasCost uint64 = contract.ReadGasCostPerSlot
+
+	// marginRequirementGasCost is the cost of computing the notional position and margin of a trader, as the bibliophile charges it
+	marginRequirementGasCost uint64 = bibliophile.GetNotionalPositionAndMarginGasCost
+	// marginEventGasCost is a log with 2 indexed topics (besides the event id) and 2 words of data
+	marginEventGasCost uint64 = params.LogGas + 3*params.LogTopicGas + 2*common.HashLength*params.LogDataGas
+	// husdTransferGasCost reads hUSD, reads and writes the balance of the MarginAccount and the total supply, and emits Transfer
+	husdTransferGasCost uint64 = 3*contract.ReadGasCostPerSlot + 2*contract.WriteGasCostPerSlot + params.LogGas + 3*params.LogTopicGas + common.HashLength*params.LogDataGas
+	transferPayloadSize uint64 = 3 * common.HashLength
+)
+
+// Storage of the precompile
+const (
+	// WITHDRAWAL_NONCE_SLOT is the number of withdrawals sent, which derives the id of the next withdrawal
+	WITHDRAWAL_NONCE_SLOT int64 = 0
+	// BRIDGES_SLOT is mapping(bytes32 blockchainID => address bridge)
+	BRIDGES_SLOT int64 = 1
+	// PROCESSED_DEPOSITS_SLOT is mapping(bytes32 sourceChainID => mapping(bytes32 depositID => bool processed))
+	PROCESSED_DEPOSITS_SLOT int64 = 2
+	// HUSD_SLOT is the address of hUSD, from the config
+	HUSD_SLOT int64 = 3
+)
+
+// HUSD is the collateral that is bridged
+var HUSD = big.NewInt(0)
+
+var (
+	errInvalidDestination = errors.New("warp message is not addressed to the margin bridge of this chain")
+	errUnknownBridge      = errors.New("warp message is not sent by the bridge of the source chain")
+	errNoBridge           = errors.New("no bridge for the destination chain")
+	errDepositProcessed   = errors.New("deposit is already processed")
+	errInvalidAmount      = errors.New("amount must be positive")
+	errInvalidRecipient   = errors.New("recipient is not set")
+	errInsufficientMargin = errors.New("insufficient margin")
+	errMarginRequirement  = errors.New("withdrawal exceeds the available margin")
+	errNoHUSD             = errors.New("hUSD is not configured")
+	errInsufficientHUSD   = errors.New("margin account holds less hUSD than withdrawn")
+)
+
+// erc20TransferEventID is the id of Transfer(address indexed from, address indexed to, uint256 value)
+var erc20TransferEventID = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// Singleton StatefulPrecompiledContract and signatures.
+var (
+
+	// MarginBridgeRawABI contains the raw ABI of MarginBridge contract.
+	//go:embed contract.abi
+	MarginBridgeRawABI string
+
+	MarginBridgeABI = contract.ParseABI(MarginBridgeRawABI)
+
+	MarginBridgePrecompile = createMarginBridgePrecompile()
+
+	transferPayloadArguments = abi.Arguments{
+		{Name: "id", Type: mustNewType("bytes32")},
+		{Name: "account", Type: mustNewType("address")},
+		{Name: "amount", Type: mustNewType("uint256")},
+	}
+)
+
+func mustNewType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+// TransferPayload is the payload of the warp messages between the margin bridge and the bridge contracts on other chains,
+// abi encoded as (bytes32 id, address account, uint256 amount).
+// A deposit credits [Amount] of HUSD to the margin of [Account], and is processed only once per [ID] and source chain.
+// A withdrawal releases [Amount] to [Account] on the destination chain.
+type TransferPayload struct {
+	ID      common.Hash
+	Account common.Address
+	Amount  *big.Int
+}
+
+// PackTransferPayload abi encodes [payload]
+func PackTransferPayload(payload TransferPayload) ([]byte, error) {
+	return transferPayloadArguments.Pack(payload.ID, payload.Account, payload.Amount)
+}
+
+// UnpackTransferPayload decodes an abi encoded TransferPayload
+func UnpackTransferPayload(data []byte) (TransferPayload, error) {
+	values, err := transferPayloadArguments.Unpack(data)
+	if err != nil {
+		return TransferPayload{}, err
+	}
+	return TransferPayload{
+		ID:      values[0].([32]byte),
+		Account: values[1].(common.Address),
+		Amount:  values[2].(*big.Int),
+	}, nil
+}
+
+type IsDepositProcessedInput struct {
+	SourceChainID common.Hash
+	DepositID     common.Hash
+}
+
+type WithdrawToWarpInput struct {
+	DestinationChainID common.Hash
+	Recipient          common.Address
+	Amount             *big.Int
+}
+
+// PackDepositFromWarp packs the include selector (first 4 func signature bytes).
+// This function is mostly used for tests.
+func PackDepositFromWarp() ([]byte, error) {
+	return MarginBridgeABI.Pack("depositFromWarp")
+}
+
+// depositFromWarp credits the margin of a trader with the deposit in the warp message of the transaction predicate,
+// and mints the hUSD of the deposit to the MarginAccount which holds the collateral of the margin
+func depositFromWarp(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, DepositFromWarpGasCost); err != nil {
+		return nil, 0, err
+	}
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+	stateDB := accessibleState.GetStateDB()
+	predicateBytes, exists := stateDB.GetPredicateStorageSlots(warp.ContractAddress)
+	if !exists {
+		return nil, remainingGas, errors.New("no warp message in the transaction")
+	}
+	msgBytesGas, overflow := math.SafeMul(warp.GasCostPerWarpMessageBytes, uint64(len(predicateBytes)))
+	if overflow {
+		return nil, 0, vmerrs.ErrOutOfGas
+	}
+	if remainingGas, err = contract.DeductGas(remainingGas, msgBytesGas); err != nil {
+		return nil, 0, err
+	}
+
+	warpMessage, err := warp.ParseVerifiedWarpMessage(predicateBytes)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	if warpMessage.DestinationChainID != common.Hash(accessibleState.GetSnowContext().ChainID) || warpMessage.DestinationAddress != ContractAddress {
+		return nil, remainingGas, errInvalidDestination
+	}
+	if bridge := getBridge(stateDB, warpMessage.OriginChainID); bridge == (common.Address{}) || bridge != warpMessage.OriginSenderAddress {
+		return nil, remainingGas, errUnknownBridge
+	}
+	deposit, err := UnpackTransferPayload(warpMessage.Payload)
+	if err != nil {
+		return nil, remainingGas, fmt.Errorf("invalid deposit: %w", err)
+	}
+	if deposit.Amount.Sign() <= 0 {
+		return nil, remainingGas, errInvalidAmount
+	}
+	if isDepositProcessed(stateDB, warpMessage.OriginChainID, deposit.ID) {
+		return nil, remainingGas, errDepositProcessed
+	}
+
+	husd := getHUSD(stateDB)
+	if husd == (common.Address{}) {
+		return nil, remainingGas, errNoHUSD
+	}
+
+	setDepositProcessed(stateDB, warpMessage.OriginChainID, deposit.ID)
+	margin := getMargin(stateDB, deposit.Account)
+	setMargin(stateDB, deposit.Account, margin.Add(margin, deposit.Amount))
+	mintHUSD(accessibleState, husd, deposit.Amount)
+
+	topics, data, err := MarginBridgeABI.PackEvent("MarginDeposited", deposit.Account, warpMessage.OriginChainID, deposit.ID, deposit.Amount)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	stateDB.AddLog(ContractAddress, topics, data, accessibleState.GetBlockContext().Number().Uint64())
+	return []byte{}, remainingGas, nil
+}
+
+// UnpackIsDepositProcessedInput attempts to unpack [input] as IsDepositProcessedInput
+// assumes that [input] does not include selector (omits first 4 func signature bytes)
+func UnpackIsDepositProcessedInput(input []byte) (IsDepositProcessedInput, error) {
+	inputStruct := IsDepositProcessedInput{}
+	err := MarginBridgeABI.UnpackInputIntoInterface(&inputStruct, "isDepositProcessed", input)
+
+	return inputStruct, err
+}
+
+// PackIsDepositProcessed packs [inputStruct] of type IsDepositProcessedInput into the appropriate arguments for isDepositProcessed.
+func PackIsDepositProcessed(inputStruct IsDepositProcessedInput) ([]byte, error) {
+	return MarginBridgeABI.Pack("isDepositProcessed", inputStruct.SourceChainID, inputStruct.DepositID)
+}
+
+// PackIsDepositProcessedOutput attempts to pack given processed of type bool
+// to conform the ABI outputs.
+func PackIsDepositProcessedOutput(processed bool) ([]byte, error) {
+	return MarginBridgeABI.PackOutput("isDepositProcessed", processed)
+}
+
+func isDepositProcessedFunc(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, IsDepositProcessedGasCost); err != nil {
+		return nil, 0, err
+	}
+	inputStruct, err := UnpackIsDepositProcessedInput(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	packedOutput, err := PackIsDepositProcessedOutput(isDepositProcessed(accessibleState.GetStateDB(), inputStruct.SourceChainID, inputStruct.DepositID))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return packedOutput, remainingGas, nil
+}
+
+// UnpackWithdrawToWarpInput attempts to unpack [input] as WithdrawToWarpInput
+// assumes that [input] does not include selector (omits first 4 func signature bytes)
+func UnpackWithdrawToWarpInput(input []byte) (WithdrawToWarpInput, error) {
+	inputStruct := WithdrawToWarpInput{}
+	err := MarginBridgeABI.UnpackInputIntoInterface(&inputStruct, "withdrawToWarp", input)
+
+	return inputStruct, err
+}
+
+// PackWithdrawToWarp packs [inputStruct] of type WithdrawToWarpInput into the appropriate arguments for withdrawToWarp.
+func PackWithdrawToWarp(inputStruct WithdrawToWarpInput) ([]byte, error) {
+	return MarginBridgeABI.Pack("withdrawToWarp", inputStruct.DestinationChainID, inputStruct.Recipient, inputStruct.Amount)
+}
+
+// PackWithdrawToWarpOutput attempts to pack given withdrawalID of type common.Hash
+// to conform the ABI outputs.
+func PackWithdrawToWarpOutput(withdrawalID common.Hash) ([]byte, error) {
+	return MarginBridgeABI.PackOutput("withdrawToWarp", withdrawalID)
+}
+
+// withdrawToWarp debits the margin of the caller, burns its hUSD from the MarginAccount and sends a warp message to
+// the bridge of the destination chain, which releases the amount to the recipient.
+// Only the HUSD margin can be withdrawn, and only out of the available margin, like MarginAccount.removeMargin: the
+// margin reserved by open orders and used by positions is held back.
+func withdrawToWarp(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, WithdrawToWarpGasCost); err != nil {
+		return nil, 0, err
+	}
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+	inputStruct, err := UnpackWithdrawToWarpInput(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	if inputStruct.Amount.Sign() <= 0 {
+		return nil, remainingGas, errInvalidAmount
+	}
+	if inputStruct.Recipient == (common.Address{}) {
+		return nil, remainingGas, errInvalidRecipient
+	}
+	stateDB := accessibleState.GetStateDB()
+	bridge := getBridge(stateDB, inputStruct.DestinationChainID)
+	if bridge == (common.Address{}) {
+		return nil, remainingGas, errNoBridge
+	}
+	if err := validateWithdrawal(stateDB, caller, inputStruct.Amount, bibliophile.GetVersion(accessibleState.GetChainConfig(), stateDB, accessibleState.GetBlockContext().Timestamp())); err != nil {
+		return nil, remainingGas, err
+	}
+	husd := getHUSD(stateDB)
+	if husd == (common.Address{}) {
+		return nil, remainingGas, errNoHUSD
+	}
+	if err := burnHUSD(accessibleState, husd, inputStruct.Amount); err != nil {
+		return nil, remainingGas, err
+	}
+
+	margin := getMargin(stateDB, caller)
+	setMargin(stateDB, caller, margin.Sub(margin, inputStruct.Amount))
+	withdrawalID := nextWithdrawalID(stateDB, inputStruct.DestinationChainID)
+	payload, err := PackTransferPayload(TransferPayload{ID: withdrawalID, Account: inputStruct.Recipient, Amount: inputStruct.Amount})
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	if err := warp.AddSendWarpMessageLog(accessibleState, ContractAddress, inputStruct.DestinationChainID, bridge, payload); err != nil {
+		return nil, remainingGas, err
+	}
+
+	topics, data, err := MarginBridgeABI.PackEvent("MarginWithdrawn", caller, inputStruct.DestinationChainID, withdrawalID, inputStruct.Recipient, inputStruct.Amount)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	stateDB.AddLog(ContractAddress, topics, data, accessibleState.GetBlockContext().Number().Uint64())
+
+	packedOutput, err := PackWithdrawToWarpOutput(withdrawalID)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return packedOutput, remainingGas, nil
+}
+
+// validateWithdrawal checks that [trader] has [amount] of HUSD margin, and [amount] of available margin: the margin
+// left after the min allowable margin of its positions and the margin reserved by its open orders
+func validateWithdrawal(stateDB contract.StateDB, trader common.Address, amount *big.Int, version bibliophile.Version) error {
+	if getMargin(stateDB, trader).Cmp(amount) < 0 {
+		return errInsufficientMargin
+	}
+	if bibliophile.GetAvailableMargin(stateDB, trader, version).Cmp(amount) < 0 {
+		return errMarginRequirement
+	}
+	return nil
+}
+
+func getHUSD(stateDB contract.StateDB) common.Address {
+	value := stateDB.GetState(ContractAddress, contract.StorageSlot(HUSD_SLOT))
+	return contract.StorageAddress(value[:])
+}
+
+func setHUSD(stateDB contract.StateDB, husd common.Address) {
+	stateDB.SetState(ContractAddress, contract.StorageSlot(HUSD_SLOT), common.BytesToHash(husd.Bytes()))
+}
+
+// mintHUSD mints [amount] of [husd] to the MarginAccount, like a deposit of the collateral of the margin would
+// transfer it there
+func mintHUSD(accessibleState contract.AccessibleState, husd common.Address, amount *big.Int) {
+	stateDB := accessibleState.GetStateDB()
+	marginAccount := common.HexToAddress(bibliophile.MARGIN_ACCOUNT_GENESIS_ADDRESS)
+	balance := stateDB.GetState(husd, bibliophile.HUSDBalanceStorageSlot(marginAccount)).Big()
+	stateDB.SetState(husd, bibliophile.HUSDBalanceStorageSlot(marginAccount), common.BigToHash(balance.Add(balance, amount)))
+	totalSupply := stateDB.GetState(husd, bibliophile.HUSDTotalSupplyStorageSlot()).Big()
+	stateDB.SetState(husd, bibliophile.HUSDTotalSupplyStorageSlot(), common.BigToHash(totalSupply.Add(totalSupply, amount)))
+	addTransferLog(accessibleState, husd, common.Address{}, marginAccount, amount)
+}
+
+// burnHUSD burns [amount] of [husd] from the MarginAccount, it fails if the MarginAccount holds less
+func burnHUSD(accessibleState contract.AccessibleState, husd common.Address, amount *big.Int) error {
+	stateDB := accessibleState.GetStateDB()
+	marginAccount := common.HexToAddress(bibliophile.MARGIN_ACCOUNT_GENESIS_ADDRESS)
+	balance := stateDB.GetState(husd, bibliophile.HUSDBalanceStorageSlot(marginAccount)).Big()
+	totalSupply := stateDB.GetState(husd, bibliophile.HUSDTotalSupplyStorageSlot()).Big()
+	if balance.Cmp(amount) < 0 || totalSupply.Cmp(amount) < 0 {
+		return errInsufficientHUSD
+	}
+	stateDB.SetState(husd, bibliophile.HUSDBalanceStorageSlot(marginAccount), common.BigToHash(balance.Sub(balance, amount)))
+	stateDB.SetState(husd, bibliophile.HUSDTotalSupplyStorageSlot(), common.BigToHash(totalSupply.Sub(totalSupply, amount)))
+	addTransferLog(accessibleState, husd, marginAccount, common.Address{}, amount)
+	return nil
+}
+
+func addTransferLog(accessibleState contract.AccessibleState, husd common.Address, from common.Address, to common.Address, amount *big.Int) {
+	topics := []common.Hash{erc20TransferEventID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())}
+	accessibleState.GetStateDB().AddLog(husd, topics, common.BigToHash(amount).Bytes(), accessibleState.GetBlockContext().Number().Uint64())
+}
+
+func getMargin(stateDB contract.StateDB, trader common.Address) *big.Int {
+	value := stateDB.GetState(common.HexToAddress(bibliophile.MARGIN_ACCOUNT_GENESIS_ADDRESS), bibliophile.MarginStorageSlot(HUSD, trader))
+	return contract.StorageInt(value[:])
+}
+
+func setMargin(stateDB contract.StateDB, trader common.Address, margin *big.Int) {
+	stateDB.SetState(common.HexToAddress(bibliophile.MARGIN_ACCOUNT_GENESIS_ADDRESS), bibliophile.MarginStorageSlot(HUSD, trader), common.BytesToHash(math.U256Bytes(margin)))
+}
+
+func getBridge(stateDB contract.StateDB, blockchainID common.Hash) common.Address {
+	value := stateDB.GetState(ContractAddress, bridgeSlot(blockchainID))
+	return contract.StorageAddress(value[:])
+}
+
+func setBridge(stateDB contract.StateDB, blockchainID common.Hash, bridge common.Address) {
+	stateDB.SetState(ContractAddress, bridgeSlot(blockchainID), common.BytesToHash(bridge.Bytes()))
+}
+
+func bridgeSlot(blockchainID common.Hash) common.Hash {
+	return contract.MappingSlot(contract.FixedBytesKey(blockchainID), contract.StorageSlot(BRIDGES_SLOT))
+}
+
+func isDepositProcessed(stateDB contract.StateDB, sourceChainID common.Hash, depositID common.Hash) bool {
+	value := stateDB.GetState(ContractAddress, processedDepositSlot(sourceChainID, depositID))
+	return contract.StorageBool(value[:])
+}
+
+func setDepositProcessed(stateDB contract.StateDB, sourceChainID common.Hash, depositID common.Hash) {
+	stateDB.SetState(ContractAddress, processedDepositSlot(sourceChainID, depositID), common.BigToHash(big.NewInt(1)))
+}
+
+func processedDepositSlot(sourceChainID common.Hash, depositID common.Hash) common.Hash {
+	slot := contract.MappingSlot(contract.FixedBytesKey(sourceChainID), contract.StorageSlot(PROCESSED_DEPOSITS_SLOT))
+	return contract.MappingSlot(contract.FixedBytesKey(depositID), slot)
+}
+
+// nextWithdrawalID increments the withdrawal nonce and returns keccak256(destinationChainID, nonce), which is unique
+// across the withdrawals of this chain
+func nextWithdrawalID(stateDB contract.StateDB, destinationChainID common.Hash) common.Hash {
+	nonce := stateDB.GetState(ContractAddress, contract.StorageSlot(WITHDRAWAL_NONCE_SLOT)).Big()
+	nonce.Add(nonce, big.NewInt(1))
+	stateDB.SetState(ContractAddress, contract.StorageSlot(WITHDRAWAL_NONCE_SLOT), common.BigToHash(nonce))
+	return crypto.Keccak256Hash(destinationChainID[:], common.BigToHash(nonce).Bytes())
+}
+
+// createMarginBridgePrecompile returns a StatefulPrecompiledContract with getters and setters for the precompile.
+func createMarginBridgePrecompile() contract.StatefulPrecompiledContract {
+	var functions []*contract.StatefulPrecompileFunction
+
+	abiFunctionMap := map[string]contract.RunStatefulPrecompileFunc{
+		"depositFromWarp":    depositFromWarp,
+		"isDepositProcessed": isDepositProcessedFunc,
+		"withdrawToWarp":     withdrawToWarp,
+	}
+
+	for name, function := range abiFunctionMap {
+		method, ok := MarginBridgeABI.Methods[name]
+		if !ok {
+			panic(fmt.Errorf("given method (%s) does not exist in the ABI", name))
+		}
+		functions = append(functions, contract.NewStatefulPrecompileFunction(method.ID, function))
+	}
+	// Construct the contract with no fallback function.
+	statefulContract, err := contract.NewStatefulPrecompileContract(nil, functions)
+	if err != nil {
+		panic(err)
+	}
+	return statefulContract
+}
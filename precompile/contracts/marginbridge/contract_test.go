@@ -0,0 +1,393 @@
+// Code generated
+// This file is a generated precompile contract test with the skeleton of test functions.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package marginbridge
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
+	avalancheWarp "github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	"github.com/ava-labs/subnet-evm/commontype"
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/ava-labs/subnet-evm/precompile/testutils"
+	"github.com/ava-labs/subnet-evm/utils"
+	predicateutils "github.com/ava-labs/subnet-evm/utils/predicate"
+	"github.com/ava-labs/subnet-evm/vmerrs"
+	warpPayload "github.com/ava-labs/subnet-evm/warp/payload"
+	"github.com/ava-labs/subnet-evm/x/warp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	trader        = common.HexToAddress("0x0123")
+	sourceChainID = ids.GenerateTestID()
+	sourceBridge  = common.HexToAddress("0x456789")
+	depositID     = common.HexToHash("0xd1")
+	husd          = common.HexToAddress("0x0300000000000000000000000000000000000011")
+	marginAccount = common.HexToAddress(bibliophile.MARGIN_ACCOUNT_GENESIS_ADDRESS)
+)
+
+func getHUSDBalance(state contract.StateDB, account common.Address) *big.Int {
+	return state.GetState(husd, bibliophile.HUSDBalanceStorageSlot(account)).Big()
+}
+
+func getHUSDTotalSupply(state contract.StateDB) *big.Int {
+	return state.GetState(husd, bibliophile.HUSDTotalSupplyStorageSlot()).Big()
+}
+
+// withHUSD sets the hUSD held by the MarginAccount, as the collateral of [balance] of margin
+func withHUSD(state contract.StateDB, balance int64) {
+	state.SetState(husd, bibliophile.HUSDBalanceStorageSlot(marginAccount), common.BigToHash(big.NewInt(balance)))
+	state.SetState(husd, bibliophile.HUSDTotalSupplyStorageSlot(), common.BigToHash(big.NewInt(balance)))
+}
+
+// packDepositPredicate returns the predicate of a transaction that delivers the deposit [payload], sent by [sender]
+// on the source chain to [destinationChainID]
+func packDepositPredicate(t testing.TB, sender common.Address, destinationChainID ids.ID, payload TransferPayload) []byte {
+	payloadBytes, err := PackTransferPayload(payload)
+	require.NoError(t, err)
+	addressedPayload, err := warpPayload.NewAddressedPayload(sender, common.Hash(destinationChainID), ContractAddress, payloadBytes)
+	require.NoError(t, err)
+	unsignedWarpMsg, err := avalancheWarp.NewUnsignedMessage(snow.DefaultContextTest().NetworkID, sourceChainID, addressedPayload.Bytes())
+	require.NoError(t, err)
+	// the signature is verified by the predicate before execution, so it is left empty
+	warpMessage, err := avalancheWarp.NewMessage(unsignedWarpMsg, &avalancheWarp.BitSetSignature{})
+	require.NoError(t, err)
+	return predicateutils.PackPredicate(warpMessage.Bytes())
+}
+
+func TestTransferPayload(t *testing.T) {
+	payload := TransferPayload{ID: depositID, Account: trader, Amount: big.NewInt(5e6)}
+	packed, err := PackTransferPayload(payload)
+	require.NoError(t, err)
+	require.Len(t, packed, int(transferPayloadSize))
+	unpacked, err := UnpackTransferPayload(packed)
+	require.NoError(t, err)
+	require.Equal(t, payload, unpacked)
+
+	_, err = UnpackTransferPayload(packed[:64])
+	require.Error(t, err)
+}
+
+func TestDepositFromWarp(t *testing.T) {
+	destinationChainID := snow.DefaultContextTest().ChainID
+	config := NewConfig(utils.NewUint64(0), husd, []Bridge{{BlockchainID: common.Hash(sourceChainID), Address: sourceBridge}})
+	deposit := TransferPayload{ID: depositID, Account: trader, Amount: big.NewInt(5e6)}
+	predicate := packDepositPredicate(t, sourceBridge, destinationChainID, deposit)
+	gas := DepositFromWarpGasCost + warp.GasCostPerWarpMessageBytes*uint64(len(predicate))
+	input, err := PackDepositFromWarp()
+	require.NoError(t, err)
+
+	withPredicate := func(predicate []byte) func(t testing.TB, state contract.StateDB) {
+		return func(t testing.TB, state contract.StateDB) {
+			state.SetPredicateStorageSlots(warp.ContractAddress, predicate)
+		}
+	}
+
+	tests := map[string]testutils.PrecompileTest{
+		"deposit success": {
+			Caller: trader,
+			Input:  input,
+			Config: config,
+			BeforeHook: func(t testing.TB, state contract.StateDB) {
+				withPredicate(predicate)(t, state)
+				setMargin(state, trader, big.NewInt(1e6))
+				withHUSD(state, 1e6)
+			},
+			SuppliedGas: gas,
+			ExpectedRes: []byte{},
+			AfterHook: func(t testing.TB, state contract.StateDB) {
+				require.Equal(t, big.NewInt(6e6), getMargin(state, trader))
+				require.True(t, isDepositProcessed(state, common.Hash(sourceChainID), depositID))
+				// the deposit is minted to the MarginAccount
+				require.Equal(t, big.NewInt(6e6), getHUSDBalance(state, marginAccount))
+				require.Equal(t, big.NewInt(6e6), getHUSDTotalSupply(state))
+
+				logsData := state.GetLogData()
+				require.Len(t, logsData, 2)
+				require.Equal(t, common.BigToHash(big.NewInt(5e6)).Bytes(), logsData[0])
+				event, err := MarginBridgeABI.Unpack("MarginDeposited", logsData[1])
+				require.NoError(t, err)
+				require.Equal(t, []interface{}{[32]byte(depositID), big.NewInt(5e6)}, event)
+			},
+		},
+		"deposit readOnly": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(predicate),
+			SuppliedGas: DepositFromWarpGasCost,
+			ReadOnly:    true,
+			ExpectedErr: vmerrs.ErrWriteProtection.Error(),
+		},
+		"deposit insufficient gas": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(predicate),
+			SuppliedGas: gas - 1,
+			ExpectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
+		"deposit without a warp message": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			SuppliedGas: DepositFromWarpGasCost,
+			ExpectedErr: "no warp message in the transaction",
+		},
+		"deposit from an unknown sender": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(packDepositPredicate(t, trader, destinationChainID, deposit)),
+			SuppliedGas: gas,
+			ExpectedErr: errUnknownBridge.Error(),
+		},
+		"deposit without a bridge": {
+			Caller:      trader,
+			Input:       input,
+			BeforeHook:  withPredicate(predicate),
+			SuppliedGas: gas,
+			ExpectedErr: errUnknownBridge.Error(),
+		},
+		"deposit to another chain": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(packDepositPredicate(t, sourceBridge, ids.GenerateTestID(), deposit)),
+			SuppliedGas: gas,
+			ExpectedErr: errInvalidDestination.Error(),
+		},
+		"deposit of zero": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(packDepositPredicate(t, sourceBridge, destinationChainID, TransferPayload{ID: depositID, Account: trader, Amount: big.NewInt(0)})),
+			SuppliedGas: gas,
+			ExpectedErr: errInvalidAmount.Error(),
+		},
+		"deposit without hUSD": {
+			Caller:      trader,
+			Input:       input,
+			Config:      NewConfig(utils.NewUint64(0), common.Address{}, config.Bridges),
+			BeforeHook:  withPredicate(predicate),
+			SuppliedGas: gas,
+			ExpectedErr: errNoHUSD.Error(),
+		},
+		"deposit already processed": {
+			Caller: trader,
+			Input:  input,
+			Config: config,
+			BeforeHook: func(t testing.TB, state contract.StateDB) {
+				withPredicate(predicate)(t, state)
+				setDepositProcessed(state, common.Hash(sourceChainID), depositID)
+			},
+			SuppliedGas: gas,
+			ExpectedErr: errDepositProcessed.Error(),
+		},
+	}
+
+	testutils.RunPrecompileTests(t, Module, state.NewTestStateDB, tests)
+}
+
+func TestIsDepositProcessed(t *testing.T) {
+	input, err := PackIsDepositProcessed(IsDepositProcessedInput{SourceChainID: common.Hash(sourceChainID), DepositID: depositID})
+	require.NoError(t, err)
+	processed, err := PackIsDepositProcessedOutput(true)
+	require.NoError(t, err)
+	notProcessed, err := PackIsDepositProcessedOutput(false)
+	require.NoError(t, err)
+
+	tests := map[string]testutils.PrecompileTest{
+		"processed deposit": {
+			Caller: trader,
+			Input:  input,
+			BeforeHook: func(t testing.TB, state contract.StateDB) {
+				setDepositProcessed(state, common.Hash(sourceChainID), depositID)
+			},
+			SuppliedGas: IsDepositProcessedGasCost,
+			ReadOnly:    true,
+			ExpectedRes: processed,
+		},
+		"deposit of another chain": {
+			Caller: trader,
+			Input:  input,
+			BeforeHook: func(t testing.TB, state contract.StateDB) {
+				setDepositProcessed(state, common.HexToHash("0x01"), depositID)
+			},
+			SuppliedGas: IsDepositProcessedGasCost,
+			ReadOnly:    true,
+			ExpectedRes: notProcessed,
+		},
+	}
+
+	testutils.RunPrecompileTests(t, Module, state.NewTestStateDB, tests)
+}
+
+func TestWithdrawToWarp(t *testing.T) {
+	destinationChainID := common.Hash(sourceChainID)
+	recipient := common.HexToAddress("0x0456")
+	config := NewConfig(utils.NewUint64(0), husd, []Bridge{{BlockchainID: destinationChainID, Address: sourceBridge}})
+	withdrawal := WithdrawToWarpInput{DestinationChainID: destinationChainID, Recipient: recipient, Amount: big.NewInt(4e6)}
+	input, err := PackWithdrawToWarp(withdrawal)
+	require.NoError(t, err)
+	withMargin := func(margin int64) func(t testing.TB, state contract.StateDB) {
+		return func(t testing.TB, state contract.StateDB) {
+			setMargin(state, trader, big.NewInt(margin))
+			withHUSD(state, margin)
+		}
+	}
+	reservedMarginSlot := crypto.Keccak256Hash(common.LeftPadBytes(trader.Bytes(), 32), common.LeftPadBytes(big.NewInt(bibliophile.VAR_RESERVED_MARGIN_SLOT).Bytes(), 32))
+	pack := func(input WithdrawToWarpInput) func(t testing.TB) []byte {
+		return func(t testing.TB) []byte {
+			packed, err := PackWithdrawToWarp(input)
+			require.NoError(t, err)
+			return packed
+		}
+	}
+	withdrawalID := nextWithdrawalID(state.NewTestStateDB(t), destinationChainID)
+	output, err := PackWithdrawToWarpOutput(withdrawalID)
+	require.NoError(t, err)
+
+	tests := map[string]testutils.PrecompileTest{
+		"withdraw success": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withMargin(5e6),
+			SuppliedGas: WithdrawToWarpGasCost,
+			ExpectedRes: output,
+			AfterHook: func(t testing.TB, state contract.StateDB) {
+				require.Equal(t, big.NewInt(1e6), getMargin(state, trader))
+				// the withdrawal is burned from the MarginAccount
+				require.Equal(t, big.NewInt(1e6), getHUSDBalance(state, marginAccount))
+				require.Equal(t, big.NewInt(1e6), getHUSDTotalSupply(state))
+
+				logsData := state.GetLogData()
+				require.Len(t, logsData, 3)
+				require.Equal(t, common.BigToHash(big.NewInt(4e6)).Bytes(), logsData[0])
+				unsignedWarpMsg, err := avalancheWarp.ParseUnsignedMessage(logsData[1])
+				require.NoError(t, err)
+				require.Equal(t, snow.DefaultContextTest().ChainID, unsignedWarpMsg.SourceChainID)
+				addressedPayload, err := warpPayload.ParseAddressedPayload(unsignedWarpMsg.Payload)
+				require.NoError(t, err)
+				require.Equal(t, ContractAddress, addressedPayload.SourceAddress)
+				require.Equal(t, destinationChainID, addressedPayload.DestinationChainID)
+				require.Equal(t, sourceBridge, addressedPayload.DestinationAddress)
+				payload, err := UnpackTransferPayload(addressedPayload.Payload)
+				require.NoError(t, err)
+				require.Equal(t, TransferPayload{ID: withdrawalID, Account: recipient, Amount: big.NewInt(4e6)}, payload)
+
+				event, err := MarginBridgeABI.Unpack("MarginWithdrawn", logsData[2])
+				require.NoError(t, err)
+				require.Equal(t, []interface{}{[32]byte(withdrawalID), recipient, big.NewInt(4e6)}, event)
+			},
+		},
+		"withdraw readOnly": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withMargin(5e6),
+			SuppliedGas: WithdrawToWarpGasCost,
+			ReadOnly:    true,
+			ExpectedErr: vmerrs.ErrWriteProtection.Error(),
+		},
+		"withdraw insufficient gas": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withMargin(5e6),
+			SuppliedGas: WithdrawToWarpGasCost - 1,
+			ExpectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
+		"withdraw more than the margin": {
+			Caller:      trader,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withMargin(3e6),
+			SuppliedGas: WithdrawToWarpGasCost,
+			ExpectedErr: errInsufficientMargin.Error(),
+		},
+		"withdraw the margin reserved by open orders": {
+			Caller: trader,
+			Input:  input,
+			Config: config,
+			BeforeHook: func(t testing.TB, state contract.StateDB) {
+				withMargin(5e6)(t, state)
+				state.SetState(marginAccount, reservedMarginSlot, common.BigToHash(big.NewInt(2e6)))
+			},
+			SuppliedGas: WithdrawToWarpGasCost,
+			ExpectedErr: errMarginRequirement.Error(),
+		},
+		"withdraw more hUSD than the margin account holds": {
+			Caller: trader,
+			Input:  input,
+			Config: config,
+			BeforeHook: func(t testing.TB, state contract.StateDB) {
+				withMargin(5e6)(t, state)
+				withHUSD(state, 3e6)
+			},
+			SuppliedGas: WithdrawToWarpGasCost,
+			ExpectedErr: errInsufficientHUSD.Error(),
+		},
+		"withdraw without hUSD": {
+			Caller:      trader,
+			Input:       input,
+			Config:      NewConfig(utils.NewUint64(0), common.Address{}, config.Bridges),
+			BeforeHook:  withMargin(5e6),
+			SuppliedGas: WithdrawToWarpGasCost,
+			ExpectedErr: errNoHUSD.Error(),
+		},
+		"withdraw to a chain without a bridge": {
+			Caller:      trader,
+			InputFn:     pack(WithdrawToWarpInput{DestinationChainID: common.HexToHash("0x01"), Recipient: recipient, Amount: big.NewInt(4e6)}),
+			Config:      config,
+			BeforeHook:  withMargin(5e6),
+			SuppliedGas: WithdrawToWarpGasCost,
+			ExpectedErr: errNoBridge.Error(),
+		},
+		"withdraw to no recipient": {
+			Caller:      trader,
+			InputFn:     pack(WithdrawToWarpInput{DestinationChainID: destinationChainID, Amount: big.NewInt(4e6)}),
+			Config:      config,
+			BeforeHook:  withMargin(5e6),
+			SuppliedGas: WithdrawToWarpGasCost,
+			ExpectedErr: errInvalidRecipient.Error(),
+		},
+		"withdraw zero": {
+			Caller:      trader,
+			InputFn:     pack(WithdrawToWarpInput{DestinationChainID: destinationChainID, Recipient: recipient, Amount: big.NewInt(0)}),
+			Config:      config,
+			BeforeHook:  withMargin(5e6),
+			SuppliedGas: WithdrawToWarpGasCost,
+			ExpectedErr: errInvalidAmount.Error(),
+		},
+	}
+
+	testutils.RunPrecompileTests(t, Module, state.NewTestStateDB, tests)
+}
+
+func TestConfigureBridges(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	chainConfig := contract.NewMockChainState(commontype.ValidTestFeeConfig, false)
+	blockContext := contract.NewMockBlockContext(big.NewInt(0), 0)
+
+	require.NoError(t, Module.Configure(chainConfig, NewConfig(utils.NewUint64(0), husd, testBridges), stateDB, blockContext))
+	require.Equal(t, husd, getHUSD(stateDB))
+	require.Equal(t, testBridges[0].Address, getBridge(stateDB, testBridges[0].BlockchainID))
+	require.Equal(t, testBridges[1].Address, getBridge(stateDB, testBridges[1].BlockchainID))
+
+	// a later upgrade removes a bridge with the zero address, and keeps the others
+	require.NoError(t, Module.Configure(chainConfig, NewConfig(utils.NewUint64(1), common.Address{}, []Bridge{{BlockchainID: testBridges[0].BlockchainID}}), stateDB, blockContext))
+	require.Equal(t, husd, getHUSD(stateDB))
+	require.Equal(t, common.Address{}, getBridge(stateDB, testBridges[0].BlockchainID))
+	require.Equal(t, testBridges[1].Address, getBridge(stateDB, testBridges[1].BlockchainID))
+}
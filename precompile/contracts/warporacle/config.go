@@ -0,0 +1,98 @@
+// Code generated
+// This file is a generated precompile contract config with stubbed abstract functions.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package warporacle
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/subnet-evm/precompile/precompileconfig"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var _ precompileconfig.Config = &Config{}
+
+var errNoBlockchainID = errors.New("publisher blockchainID is not set")
+
+// Config implements the precompileconfig.Config interface and
+// adds specific configuration for WarpOracle.
+type Config struct {
+	precompileconfig.Upgrade
+	// CUSTOM CODE STARTS HERE
+	// Publishers are the contracts on oracle chains whose prices are trusted. A price is delivered in a warp message,
+	// so it is only accepted once a quorum of the validators of the oracle chain signed it (see the quorum of the warp config).
+	// A later upgrade replaces the publisher of a chain, and removes it with the zero address.
+	Publishers []Publisher `json:"publishers,omitempty"`
+	// MaxPriceAge (in seconds) rejects the delivery of prices that are older than it at the time of the block; 0 disables the check.
+	// Every upgrade sets it. Prices that age after being delivered are caught by the max oracle price age of the market.
+	MaxPriceAge uint64 `json:"maxPriceAge,omitempty"`
+}
+
+// Publisher is the oracle publisher contract at [Address] on the chain [BlockchainID]
+type Publisher struct {
+	BlockchainID common.Hash    `json:"blockchainID"`
+	Address      common.Address `json:"address"`
+}
+
+// NewConfig returns a config for a network upgrade at [blockTimestamp] that enables
+// WarpOracle.
+func NewConfig(blockTimestamp *uint64, publishers []Publisher, maxPriceAge uint64) *Config {
+	return &Config{
+		Upgrade:     precompileconfig.Upgrade{BlockTimestamp: blockTimestamp},
+		Publishers:  publishers,
+		MaxPriceAge: maxPriceAge,
+	}
+}
+
+// NewDisableConfig returns config for a network upgrade at [blockTimestamp]
+// that disables WarpOracle.
+func NewDisableConfig(blockTimestamp *uint64) *Config {
+	return &Config{
+		Upgrade: precompileconfig.Upgrade{
+			BlockTimestamp: blockTimestamp,
+			Disable:        true,
+		},
+	}
+}
+
+// Key returns the key for the WarpOracle precompileconfig.
+// This should be the same key as used in the precompile module.
+func (*Config) Key() string { return ConfigKey }
+
+// Verify tries to verify Config and returns an error accordingly.
+func (c *Config) Verify() error {
+	// CUSTOM CODE STARTS HERE
+	chains := make(map[common.Hash]bool, len(c.Publishers))
+	for _, publisher := range c.Publishers {
+		if publisher.BlockchainID == (common.Hash{}) {
+			return errNoBlockchainID
+		}
+		if chains[publisher.BlockchainID] {
+			return fmt.Errorf("duplicate publisher for blockchainID %s", publisher.BlockchainID.Hex())
+		}
+		chains[publisher.BlockchainID] = true
+	}
+	return nil
+}
+
+// Equal returns true if [s] is a [*Config] and it has been configured identical to [c].
+func (c *Config) Equal(s precompileconfig.Config) bool {
+	// typecast before comparison
+	other, ok := (s).(*Config)
+	if !ok {
+		return false
+	}
+	// CUSTOM CODE STARTS HERE
+	equals := c.Upgrade.Equal(&other.Upgrade) && c.MaxPriceAge == other.MaxPriceAge
+	if !equals || len(c.Publishers) != len(other.Publishers) {
+		return false
+	}
+	for i, publisher := range c.Publishers {
+		if publisher != other.Publishers[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+// Code generated
+// This file is a generated precompile config test with the skeleton of test functions.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package warporacle
+
+import (
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/precompile/precompileconfig"
+	"github.com/ava-labs/subnet-evm/precompile/testutils"
+	"github.com/ava-labs/subnet-evm/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var testPublishers = []Publisher{
+	{BlockchainID: common.HexToHash("0x01"), Address: common.HexToAddress("0x1001")},
+	{BlockchainID: common.HexToHash("0x02"), Address: common.HexToAddress("0x1002")},
+}
+
+// TestVerify tests the verification of Config.
+func TestVerify(t *testing.T) {
+	tests := map[string]testutils.ConfigVerifyTest{
+		"valid config": {
+			Config:        NewConfig(utils.NewUint64(3), testPublishers, 60),
+			ExpectedError: "",
+		},
+		// CUSTOM CODE STARTS HERE
+		"no publishers": {
+			Config:        NewConfig(utils.NewUint64(3), nil, 0),
+			ExpectedError: "",
+		},
+		"removed publisher": {
+			Config:        NewConfig(utils.NewUint64(3), []Publisher{{BlockchainID: common.HexToHash("0x01")}}, 60),
+			ExpectedError: "",
+		},
+		"no blockchainID": {
+			Config:        NewConfig(utils.NewUint64(3), []Publisher{{Address: common.HexToAddress("0x1001")}}, 60),
+			ExpectedError: errNoBlockchainID.Error(),
+		},
+		"duplicate blockchainID": {
+			Config:        NewConfig(utils.NewUint64(3), []Publisher{testPublishers[0], {BlockchainID: testPublishers[0].BlockchainID, Address: common.HexToAddress("0x1003")}}, 60),
+			ExpectedError: "duplicate publisher for blockchainID",
+		},
+	}
+	// Run verify tests.
+	testutils.RunVerifyTests(t, tests)
+}
+
+// TestEqual tests the equality of Config with other precompile configs.
+func TestEqual(t *testing.T) {
+	tests := map[string]testutils.ConfigEqualTest{
+		"non-nil config and nil other": {
+			Config:   NewConfig(utils.NewUint64(3), testPublishers, 60),
+			Other:    nil,
+			Expected: false,
+		},
+		"different type": {
+			Config:   NewConfig(utils.NewUint64(3), testPublishers, 60),
+			Other:    precompileconfig.NewNoopStatefulPrecompileConfig(),
+			Expected: false,
+		},
+		"different timestamp": {
+			Config:   NewConfig(utils.NewUint64(3), testPublishers, 60),
+			Other:    NewConfig(utils.NewUint64(4), testPublishers, 60),
+			Expected: false,
+		},
+		"same config": {
+			Config:   NewConfig(utils.NewUint64(3), testPublishers, 60),
+			Other:    NewConfig(utils.NewUint64(3), testPublishers, 60),
+			Expected: true,
+		},
+		// CUSTOM CODE STARTS HERE
+		"different publishers": {
+			Config:   NewConfig(utils.NewUint64(3), testPublishers, 60),
+			Other:    NewConfig(utils.NewUint64(3), testPublishers[:1], 60),
+			Expected: false,
+		},
+		"different publisher address": {
+			Config:   NewConfig(utils.NewUint64(3), testPublishers[:1], 60),
+			Other:    NewConfig(utils.NewUint64(3), []Publisher{{BlockchainID: testPublishers[0].BlockchainID, Address: common.HexToAddress("0x1003")}}, 60),
+			Expected: false,
+		},
+		"different max price age": {
+			Config:   NewConfig(utils.NewUint64(3), testPublishers, 60),
+			Other:    NewConfig(utils.NewUint64(3), testPublishers, 120),
+			Expected: false,
+		},
+	}
+	// Run equal tests.
+	testutils.RunEqualTests(t, tests)
+}
@@ -0,0 +1,396 @@
+// Code generated
+// This file is a generated precompile contract config with stubbed abstract functions.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package warporacle
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/accounts/abi"
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/vmerrs"
+	warpPayload "github.com/ava-labs/subnet-evm/warp/payload"
+	"github.com/ava-labs/subnet-evm/x/warp"
+
+	_ "embed"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+const (
+	// DeliverPriceGasCost reads the publisher, the max price age, the round and the count of the feed, writes the last
+	// two and an observation, and emits PriceDelivered.
+	// The bytes of the warp message in the predicate are charged on top with warp.GasCostPerWarpMessageBytes.
+	DeliverPriceGasCost uint64 = warp.GetVerifiedWarpMessageBaseCost + 4*contract.ReadGasCostPerSlot + 3*contract.WriteGasCostPerSlot + priceDeliveredGasCost
+	// GetUnderlyingPriceGasCost reads the count of the feed and its latest observation
+	GetUnderlyingPriceGasCost uint64 = 2 * contract.ReadGasCostPerSlot
+	// GetUnderlyingTwapPriceGasCost reads the count of the feed. Every observation that is read is charged on top with
+	// contract.ReadGasCostPerSlot.
+	GetUnderlyingTwapPriceGasCost uint64 = contract.ReadGasCostPerSlot
+
+	// priceDeliveredGasCost is a log with 2 indexed topics (besides the event id) and 2 words of data
+	priceDeliveredGasCost uint64 = params.LogGas + 3*params.LogTopicGas + 2*common.HashLength*params.LogDataGas
+)
+
+// ObservationsPerMarket is the number of latest prices that are kept for each market, which bounds the TWAP window
+// to ObservationsPerMarket times the interval prices are published at.
+const ObservationsPerMarket = 32
+
+// Storage of the precompile
+const (
+	// MAX_PRICE_AGE_SLOT is Config.MaxPriceAge
+	MAX_PRICE_AGE_SLOT int64 = 0
+	// PUBLISHERS_SLOT is mapping(bytes32 blockchainID => address publisher)
+	PUBLISHERS_SLOT int64 = 1
+	// FEEDS_SLOT is mapping(address underlying => Feed), where
+	// struct Feed { uint256 round; uint256 count; uint256[ObservationsPerMarket] observations; }
+	// keeps the observations in a ring buffer, observation i at observations[i % ObservationsPerMarket] packed as
+	// (timestamp << 192 | price).
+	FEEDS_SLOT int64 = 2
+)
+
+var (
+	errNoWarpMessage      = errors.New("no warp message in the transaction")
+	errInvalidDestination = errors.New("warp message is not addressed to the warp oracle of this chain")
+	errUnknownPublisher   = errors.New("warp message is not sent by the publisher of the source chain")
+	errInvalidPrice       = errors.New("price must be positive")
+	errFuturePrice        = errors.New("price timestamp is after the block timestamp")
+	errStalePrice         = errors.New("price is older than the max price age")
+	errStaleRound         = errors.New("round is not after the latest round of the market")
+	errOutOfOrderPrice    = errors.New("price timestamp is before the latest price of the market")
+	errNoPrice            = errors.New("no price for the underlying")
+)
+
+// Singleton StatefulPrecompiledContract and signatures.
+var (
+
+	// WarpOracleRawABI contains the raw ABI of WarpOracle contract.
+	//go:embed contract.abi
+	WarpOracleRawABI string
+
+	WarpOracleABI = contract.ParseABI(WarpOracleRawABI)
+
+	WarpOraclePrecompile = createWarpOraclePrecompile()
+)
+
+// Observation is a price of a market, as of [Timestamp]
+type Observation struct {
+	Price     *big.Int
+	Timestamp uint64
+}
+
+type GetUnderlyingTwapPriceInput struct {
+	Underlying        common.Address
+	PeriodStart       *big.Int
+	IntervalInSeconds *big.Int
+}
+
+// PackDeliverPrice packs the include selector (first 4 func signature bytes).
+// This function is mostly used for tests.
+func PackDeliverPrice() ([]byte, error) {
+	return WarpOracleABI.Pack("deliverPrice")
+}
+
+// deliverPrice records the price in the warp message of the transaction predicate. Anyone can relay a price, it is
+// trusted because a quorum of the validators of the publisher's chain signed it.
+func deliverPrice(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, DeliverPriceGasCost); err != nil {
+		return nil, 0, err
+	}
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+	stateDB := accessibleState.GetStateDB()
+	predicateBytes, exists := stateDB.GetPredicateStorageSlots(warp.ContractAddress)
+	if !exists {
+		return nil, remainingGas, errNoWarpMessage
+	}
+	msgBytesGas, overflow := math.SafeMul(warp.GasCostPerWarpMessageBytes, uint64(len(predicateBytes)))
+	if overflow {
+		return nil, 0, vmerrs.ErrOutOfGas
+	}
+	if remainingGas, err = contract.DeductGas(remainingGas, msgBytesGas); err != nil {
+		return nil, 0, err
+	}
+
+	warpMessage, err := warp.ParseVerifiedWarpMessage(predicateBytes)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	if warpMessage.DestinationChainID != common.Hash(accessibleState.GetSnowContext().ChainID) || warpMessage.DestinationAddress != ContractAddress {
+		return nil, remainingGas, errInvalidDestination
+	}
+	if publisher := getPublisher(stateDB, warpMessage.OriginChainID); publisher == (common.Address{}) || publisher != warpMessage.OriginSenderAddress {
+		return nil, remainingGas, errUnknownPublisher
+	}
+	oraclePrice, err := warpPayload.ParseOraclePrice(warpMessage.Payload)
+	if err != nil {
+		return nil, remainingGas, fmt.Errorf("invalid oracle price: %w", err)
+	}
+	if err := validatePrice(stateDB, oraclePrice, accessibleState.GetBlockContext().Timestamp()); err != nil {
+		return nil, remainingGas, err
+	}
+
+	addObservation(stateDB, oraclePrice.Market, oraclePrice.Round, Observation{
+		Price:     new(big.Int).SetUint64(oraclePrice.Price),
+		Timestamp: oraclePrice.Timestamp,
+	})
+	topics, data, err := WarpOracleABI.PackEvent("PriceDelivered", oraclePrice.Market, oraclePrice.Round, new(big.Int).SetUint64(oraclePrice.Price), new(big.Int).SetUint64(oraclePrice.Timestamp))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	stateDB.AddLog(ContractAddress, topics, data, accessibleState.GetBlockContext().Number().Uint64())
+	return []byte{}, remainingGas, nil
+}
+
+// validatePrice checks that [price] is fresh and newer than the latest price of its market
+func validatePrice(stateDB contract.StateDB, price *warpPayload.OraclePrice, blockTimestamp uint64) error {
+	if price.Price == 0 {
+		return errInvalidPrice
+	}
+	if price.Timestamp > blockTimestamp {
+		return errFuturePrice
+	}
+	if maxAge := getMaxPriceAge(stateDB); maxAge != 0 && price.Timestamp+maxAge < blockTimestamp {
+		return errStalePrice
+	}
+	round, count := getFeed(stateDB, price.Market)
+	if count == 0 {
+		return nil
+	}
+	if price.Round <= round {
+		return errStaleRound
+	}
+	if price.Timestamp < getObservation(stateDB, price.Market, count-1).Timestamp {
+		return errOutOfOrderPrice
+	}
+	return nil
+}
+
+// UnpackGetUnderlyingPriceInput attempts to unpack [input] into the common.Address type argument
+// assumes that [input] does not include selector (omits first 4 func signature bytes)
+func UnpackGetUnderlyingPriceInput(input []byte) (common.Address, error) {
+	res, err := WarpOracleABI.UnpackInput("getUnderlyingPrice", input)
+	if err != nil {
+		return common.Address{}, err
+	}
+	unpacked := *abi.ConvertType(res[0], new(common.Address)).(*common.Address)
+	return unpacked, nil
+}
+
+// PackGetUnderlyingPrice packs [underlying] of type common.Address into the appropriate arguments for getUnderlyingPrice.
+// the packed bytes include selector (first 4 func signature bytes).
+// This function is mostly used for tests.
+func PackGetUnderlyingPrice(underlying common.Address) ([]byte, error) {
+	return WarpOracleABI.Pack("getUnderlyingPrice", underlying)
+}
+
+// PackGetUnderlyingPriceOutput attempts to pack given answer of type *big.Int
+// to conform the ABI outputs.
+func PackGetUnderlyingPriceOutput(answer *big.Int) ([]byte, error) {
+	return WarpOracleABI.PackOutput("getUnderlyingPrice", answer)
+}
+
+func getUnderlyingPrice(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, GetUnderlyingPriceGasCost); err != nil {
+		return nil, 0, err
+	}
+	underlying, err := UnpackGetUnderlyingPriceInput(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	observations := GetLatestObservations(accessibleState.GetStateDB(), underlying, 1)
+	if len(observations) == 0 {
+		return nil, remainingGas, errNoPrice
+	}
+	packedOutput, err := PackGetUnderlyingPriceOutput(observations[0].Price)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return packedOutput, remainingGas, nil
+}
+
+// UnpackGetUnderlyingTwapPriceInput attempts to unpack [input] as GetUnderlyingTwapPriceInput
+// assumes that [input] does not include selector (omits first 4 func signature bytes)
+func UnpackGetUnderlyingTwapPriceInput(input []byte) (GetUnderlyingTwapPriceInput, error) {
+	inputStruct := GetUnderlyingTwapPriceInput{}
+	err := WarpOracleABI.UnpackInputIntoInterface(&inputStruct, "getUnderlyingTwapPrice", input)
+
+	return inputStruct, err
+}
+
+// PackGetUnderlyingTwapPrice packs [inputStruct] of type GetUnderlyingTwapPriceInput into the appropriate arguments for getUnderlyingTwapPrice.
+func PackGetUnderlyingTwapPrice(inputStruct GetUnderlyingTwapPriceInput) ([]byte, error) {
+	return WarpOracleABI.Pack("getUnderlyingTwapPrice", inputStruct.Underlying, inputStruct.PeriodStart, inputStruct.IntervalInSeconds)
+}
+
+// PackGetUnderlyingTwapPriceOutput attempts to pack given answer of type *big.Int
+// to conform the ABI outputs.
+func PackGetUnderlyingTwapPriceOutput(answer *big.Int) ([]byte, error) {
+	return WarpOracleABI.PackOutput("getUnderlyingTwapPrice", answer)
+}
+
+// getUnderlyingTwapPrice is the index TWAP that the ClearingHouse settles funding with, when the warp oracle is the
+// oracle of the AMM
+func getUnderlyingTwapPrice(accessibleState contract.AccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = contract.DeductGas(suppliedGas, GetUnderlyingTwapPriceGasCost); err != nil {
+		return nil, 0, err
+	}
+	inputStruct, err := UnpackGetUnderlyingTwapPriceInput(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	if !inputStruct.PeriodStart.IsUint64() || !inputStruct.IntervalInSeconds.IsUint64() {
+		return nil, remainingGas, errors.New("periodStart and intervalInSeconds must fit in uint64")
+	}
+	twap, read, twapErr := GetTwapPrice(accessibleState.GetStateDB(), inputStruct.Underlying, inputStruct.PeriodStart.Uint64(), inputStruct.IntervalInSeconds.Uint64())
+	if remainingGas, err = contract.DeductGas(remainingGas, uint64(read)*contract.ReadGasCostPerSlot); err != nil {
+		return nil, 0, err
+	}
+	if twapErr != nil {
+		return nil, remainingGas, twapErr
+	}
+	packedOutput, err := PackGetUnderlyingTwapPriceOutput(twap)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return packedOutput, remainingGas, nil
+}
+
+// GetTwapPrice returns the time weighted average of the prices of [underlying] over the [interval] seconds before
+// [periodStart], and the number of observations read. The price at the start of the window is the latest one
+// observed before it; when the window starts before the oldest kept observation, only the part that is covered
+// by the observations is averaged. A zero [interval] returns the latest price.
+func GetTwapPrice(stateDB contract.StateDB, underlying common.Address, periodStart uint64, interval uint64) (*big.Int, int, error) {
+	_, count := getFeed(stateDB, underlying)
+	if count == 0 {
+		return nil, 0, errNoPrice
+	}
+	if interval == 0 {
+		return getObservation(stateDB, underlying, count-1).Price, 1, nil
+	}
+	start := uint64(0)
+	if periodStart > interval {
+		start = periodStart - interval
+	}
+	var (
+		weighted = big.NewInt(0)
+		total    = uint64(0)
+		end      = periodStart
+		read     = 0
+	)
+	for i := count; i > 0 && count-i < ObservationsPerMarket && end > start; i-- {
+		observation := getObservation(stateDB, underlying, i-1)
+		read++
+		if observation.Timestamp >= end {
+			// observed after the window
+			continue
+		}
+		from := observation.Timestamp
+		if from < start {
+			from = start
+		}
+		weighted.Add(weighted, new(big.Int).Mul(observation.Price, new(big.Int).SetUint64(end-from)))
+		total += end - from
+		end = from
+	}
+	if total == 0 {
+		return nil, read, errNoPrice
+	}
+	return weighted.Div(weighted, new(big.Int).SetUint64(total)), read, nil
+}
+
+// GetLatestObservations returns up to the [n] latest prices of [underlying], newest first
+func GetLatestObservations(stateDB contract.StateDB, underlying common.Address, n int) []Observation {
+	_, count := getFeed(stateDB, underlying)
+	observations := []Observation{}
+	for i := count; i > 0 && len(observations) < n && count-i < ObservationsPerMarket; i-- {
+		observations = append(observations, getObservation(stateDB, underlying, i-1))
+	}
+	return observations
+}
+
+func getMaxPriceAge(stateDB contract.StateDB) uint64 {
+	return stateDB.GetState(ContractAddress, contract.StorageSlot(MAX_PRICE_AGE_SLOT)).Big().Uint64()
+}
+
+func setMaxPriceAge(stateDB contract.StateDB, maxPriceAge uint64) {
+	stateDB.SetState(ContractAddress, contract.StorageSlot(MAX_PRICE_AGE_SLOT), common.BigToHash(new(big.Int).SetUint64(maxPriceAge)))
+}
+
+func getPublisher(stateDB contract.StateDB, blockchainID common.Hash) common.Address {
+	value := stateDB.GetState(ContractAddress, publisherSlot(blockchainID))
+	return contract.StorageAddress(value[:])
+}
+
+func setPublisher(stateDB contract.StateDB, blockchainID common.Hash, publisher common.Address) {
+	stateDB.SetState(ContractAddress, publisherSlot(blockchainID), common.BytesToHash(publisher.Bytes()))
+}
+
+func publisherSlot(blockchainID common.Hash) common.Hash {
+	return contract.MappingSlot(contract.FixedBytesKey(blockchainID), contract.StorageSlot(PUBLISHERS_SLOT))
+}
+
+func feedSlot(underlying common.Address) common.Hash {
+	return contract.MappingSlot(contract.AddressKey(underlying), contract.StorageSlot(FEEDS_SLOT))
+}
+
+// getFeed returns the latest round of [underlying] and the number of prices delivered for it
+func getFeed(stateDB contract.StateDB, underlying common.Address) (uint64, uint64) {
+	slot := feedSlot(underlying)
+	round := stateDB.GetState(ContractAddress, slot).Big().Uint64()
+	count := stateDB.GetState(ContractAddress, contract.AddToSlot(slot, big.NewInt(1))).Big().Uint64()
+	return round, count
+}
+
+func observationSlot(underlying common.Address, index uint64) common.Hash {
+	return contract.AddToSlot(feedSlot(underlying), new(big.Int).SetUint64(2+index%ObservationsPerMarket))
+}
+
+func getObservation(stateDB contract.StateDB, underlying common.Address, index uint64) Observation {
+	value := stateDB.GetState(ContractAddress, observationSlot(underlying, index))
+	return Observation{
+		Price:     contract.StorageUint(value[8:]),
+		Timestamp: contract.StorageUint(value[:8]).Uint64(),
+	}
+}
+
+func addObservation(stateDB contract.StateDB, underlying common.Address, round uint64, observation Observation) {
+	_, count := getFeed(stateDB, underlying)
+	packed := new(big.Int).Lsh(new(big.Int).SetUint64(observation.Timestamp), 192)
+	packed.Or(packed, observation.Price)
+	stateDB.SetState(ContractAddress, observationSlot(underlying, count), common.BigToHash(packed))
+	slot := feedSlot(underlying)
+	stateDB.SetState(ContractAddress, slot, common.BigToHash(new(big.Int).SetUint64(round)))
+	stateDB.SetState(ContractAddress, contract.AddToSlot(slot, big.NewInt(1)), common.BigToHash(new(big.Int).SetUint64(count+1)))
+}
+
+// createWarpOraclePrecompile returns a StatefulPrecompiledContract with getters and setters for the precompile.
+func createWarpOraclePrecompile() contract.StatefulPrecompiledContract {
+	var functions []*contract.StatefulPrecompileFunction
+
+	abiFunctionMap := map[string]contract.RunStatefulPrecompileFunc{
+		"deliverPrice":           deliverPrice,
+		"getUnderlyingPrice":     getUnderlyingPrice,
+		"getUnderlyingTwapPrice": getUnderlyingTwapPrice,
+	}
+
+	for name, function := range abiFunctionMap {
+		method, ok := WarpOracleABI.Methods[name]
+		if !ok {
+			panic(fmt.Errorf("given method (%s) does not exist in the ABI", name))
+		}
+		functions = append(functions, contract.NewStatefulPrecompileFunction(method.ID, function))
+	}
+	// Construct the contract with no fallback function.
+	statefulContract, err := contract.NewStatefulPrecompileContract(nil, functions)
+	if err != nil {
+		panic(err)
+	}
+	return statefulContract
+}
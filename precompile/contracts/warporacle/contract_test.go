@@ -0,0 +1,309 @@
+// Code generated
+// This file is a generated precompile contract test with the skeleton of test functions.
+// The file is generated by a template. Please inspect every code and comment in this file before use.
+
+package warporacle
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
+	avalancheWarp "github.com/ava-labs/avalanchego/vms/platformvm/warp"
+	"github.com/ava-labs/subnet-evm/commontype"
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/precompile/contract"
+	"github.com/ava-labs/subnet-evm/precompile/testutils"
+	"github.com/ava-labs/subnet-evm/utils"
+	predicateutils "github.com/ava-labs/subnet-evm/utils/predicate"
+	"github.com/ava-labs/subnet-evm/vmerrs"
+	warpPayload "github.com/ava-labs/subnet-evm/warp/payload"
+	"github.com/ava-labs/subnet-evm/x/warp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	relayer         = common.HexToAddress("0x0123")
+	underlying      = common.HexToAddress("0x0a55e7")
+	sourceChainID   = ids.GenerateTestID()
+	sourcePublisher = common.HexToAddress("0x456789")
+)
+
+// packPricePredicate returns the predicate of a transaction that delivers [price], sent by [sender] on the source
+// chain to [destinationChainID]
+func packPricePredicate(t testing.TB, sender common.Address, destinationChainID ids.ID, price *warpPayload.OraclePrice) []byte {
+	addressedPayload, err := warpPayload.NewAddressedPayload(sender, common.Hash(destinationChainID), ContractAddress, price.Bytes())
+	require.NoError(t, err)
+	unsignedWarpMsg, err := avalancheWarp.NewUnsignedMessage(snow.DefaultContextTest().NetworkID, sourceChainID, addressedPayload.Bytes())
+	require.NoError(t, err)
+	// the signature is verified by the predicate before execution, so it is left empty
+	warpMessage, err := avalancheWarp.NewMessage(unsignedWarpMsg, &avalancheWarp.BitSetSignature{})
+	require.NoError(t, err)
+	return predicateutils.PackPredicate(warpMessage.Bytes())
+}
+
+func newOraclePrice(t testing.TB, price uint64, timestamp uint64, round uint64) *warpPayload.OraclePrice {
+	oraclePrice, err := warpPayload.NewOraclePrice(underlying, price, timestamp, round)
+	require.NoError(t, err)
+	return oraclePrice
+}
+
+func TestDeliverPrice(t *testing.T) {
+	destinationChainID := snow.DefaultContextTest().ChainID
+	config := NewConfig(utils.NewUint64(0), []Publisher{{BlockchainID: common.Hash(sourceChainID), Address: sourcePublisher}}, 60)
+	// the block timestamp of precompile tests is 0
+	predicate := packPricePredicate(t, sourcePublisher, destinationChainID, newOraclePrice(t, 1800e6, 0, 7))
+	gas := DeliverPriceGasCost + warp.GasCostPerWarpMessageBytes*uint64(len(predicate))
+	input, err := PackDeliverPrice()
+	require.NoError(t, err)
+
+	withPredicate := func(predicate []byte) func(t testing.TB, state contract.StateDB) {
+		return func(t testing.TB, state contract.StateDB) {
+			state.SetPredicateStorageSlots(warp.ContractAddress, predicate)
+		}
+	}
+	// gasFor is the gas to deliver the price in [predicate], which is larger for larger prices and rounds
+	gasFor := func(predicate []byte) uint64 {
+		return DeliverPriceGasCost + warp.GasCostPerWarpMessageBytes*uint64(len(predicate))
+	}
+
+	tests := map[string]testutils.PrecompileTest{
+		"deliver success": {
+			Caller:      relayer,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(predicate),
+			SuppliedGas: gas,
+			ExpectedRes: []byte{},
+			AfterHook: func(t testing.TB, state contract.StateDB) {
+				require.Equal(t, []Observation{{Price: big.NewInt(1800e6), Timestamp: 0}}, GetLatestObservations(state, underlying, 2))
+				round, _ := getFeed(state, underlying)
+				require.Equal(t, uint64(7), round)
+
+				logsData := state.GetLogData()
+				require.Len(t, logsData, 1)
+				event, err := WarpOracleABI.Unpack("PriceDelivered", logsData[0])
+				require.NoError(t, err)
+				require.Len(t, event, 2)
+				require.Equal(t, 0, big.NewInt(1800e6).Cmp(event[0].(*big.Int)))
+				require.Zero(t, event[1].(*big.Int).Sign())
+			},
+		},
+		"deliver readOnly": {
+			Caller:      relayer,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(predicate),
+			SuppliedGas: DeliverPriceGasCost,
+			ReadOnly:    true,
+			ExpectedErr: vmerrs.ErrWriteProtection.Error(),
+		},
+		"deliver insufficient gas": {
+			Caller:      relayer,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(predicate),
+			SuppliedGas: gas - 1,
+			ExpectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
+		"deliver without a warp message": {
+			Caller:      relayer,
+			Input:       input,
+			Config:      config,
+			SuppliedGas: DeliverPriceGasCost,
+			ExpectedErr: errNoWarpMessage.Error(),
+		},
+		"deliver from an unknown sender": {
+			Caller:      relayer,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(packPricePredicate(t, relayer, destinationChainID, newOraclePrice(t, 1800e6, 0, 7))),
+			SuppliedGas: gas,
+			ExpectedErr: errUnknownPublisher.Error(),
+		},
+		"deliver without a publisher": {
+			Caller:      relayer,
+			Input:       input,
+			BeforeHook:  withPredicate(predicate),
+			SuppliedGas: gas,
+			ExpectedErr: errUnknownPublisher.Error(),
+		},
+		"deliver to another chain": {
+			Caller:      relayer,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(packPricePredicate(t, sourcePublisher, ids.GenerateTestID(), newOraclePrice(t, 1800e6, 0, 7))),
+			SuppliedGas: gas,
+			ExpectedErr: errInvalidDestination.Error(),
+		},
+		"deliver zero price": {
+			Caller:      relayer,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(packPricePredicate(t, sourcePublisher, destinationChainID, newOraclePrice(t, 0, 0, 7))),
+			SuppliedGas: gasFor(packPricePredicate(t, sourcePublisher, destinationChainID, newOraclePrice(t, 0, 0, 7))),
+			ExpectedErr: errInvalidPrice.Error(),
+		},
+		"deliver price from the future": {
+			Caller:      relayer,
+			Input:       input,
+			Config:      config,
+			BeforeHook:  withPredicate(packPricePredicate(t, sourcePublisher, destinationChainID, newOraclePrice(t, 1800e6, 1, 7))),
+			SuppliedGas: gas,
+			ExpectedErr: errFuturePrice.Error(),
+		},
+		"deliver replayed round": {
+			Caller: relayer,
+			Input:  input,
+			Config: config,
+			BeforeHook: func(t testing.TB, state contract.StateDB) {
+				withPredicate(predicate)(t, state)
+				addObservation(state, underlying, 7, Observation{Price: big.NewInt(1750e6), Timestamp: 0})
+			},
+			SuppliedGas: gas,
+			ExpectedErr: errStaleRound.Error(),
+		},
+	}
+
+	testutils.RunPrecompileTests(t, Module, state.NewTestStateDB, tests)
+}
+
+func TestValidatePrice(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	setMaxPriceAge(stateDB, 60)
+
+	require.ErrorIs(t, validatePrice(stateDB, newOraclePrice(t, 1800e6, 1001, 1), 1000), errFuturePrice)
+	require.ErrorIs(t, validatePrice(stateDB, newOraclePrice(t, 1800e6, 939, 1), 1000), errStalePrice)
+	require.NoError(t, validatePrice(stateDB, newOraclePrice(t, 1800e6, 940, 1), 1000))
+	// without a max price age, any price that is not from the future is accepted
+	setMaxPriceAge(stateDB, 0)
+	require.NoError(t, validatePrice(stateDB, newOraclePrice(t, 1800e6, 1, 1), 1000))
+
+	addObservation(stateDB, underlying, 5, Observation{Price: big.NewInt(1800e6), Timestamp: 980})
+	require.ErrorIs(t, validatePrice(stateDB, newOraclePrice(t, 1800e6, 990, 5), 1000), errStaleRound)
+	require.ErrorIs(t, validatePrice(stateDB, newOraclePrice(t, 1800e6, 970, 6), 1000), errOutOfOrderPrice)
+	require.NoError(t, validatePrice(stateDB, newOraclePrice(t, 1800e6, 980, 6), 1000))
+}
+
+func TestGetLatestObservations(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	require.Empty(t, GetLatestObservations(stateDB, underlying, 2))
+
+	for i := uint64(1); i <= ObservationsPerMarket+3; i++ {
+		addObservation(stateDB, underlying, i, Observation{Price: new(big.Int).SetUint64(i * 1e6), Timestamp: i * 60})
+	}
+	require.Equal(t, []Observation{
+		{Price: big.NewInt((ObservationsPerMarket + 3) * 1e6), Timestamp: (ObservationsPerMarket + 3) * 60},
+		{Price: big.NewInt((ObservationsPerMarket + 2) * 1e6), Timestamp: (ObservationsPerMarket + 2) * 60},
+	}, GetLatestObservations(stateDB, underlying, 2))
+	// older observations are overwritten
+	observations := GetLatestObservations(stateDB, underlying, 2*ObservationsPerMarket)
+	require.Len(t, observations, ObservationsPerMarket)
+	require.Equal(t, Observation{Price: big.NewInt(4e6), Timestamp: 240}, observations[ObservationsPerMarket-1])
+}
+
+func TestGetTwapPrice(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	_, _, err := GetTwapPrice(stateDB, underlying, 1000, 100)
+	require.ErrorIs(t, err, errNoPrice)
+
+	addObservation(stateDB, underlying, 1, Observation{Price: big.NewInt(100e6), Timestamp: 900})
+	addObservation(stateDB, underlying, 2, Observation{Price: big.NewInt(200e6), Timestamp: 950})
+	addObservation(stateDB, underlying, 3, Observation{Price: big.NewInt(400e6), Timestamp: 1100})
+
+	// 50s at 100 and 50s at 200; the price after the window is skipped
+	twap, read, err := GetTwapPrice(stateDB, underlying, 1000, 100)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(150e6), twap)
+	require.Equal(t, 3, read)
+
+	// the window starts before the first price, so only the 100s from it are averaged
+	twap, _, err = GetTwapPrice(stateDB, underlying, 1000, 500)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(150e6), twap)
+
+	// 20s at 200
+	twap, read, err = GetTwapPrice(stateDB, underlying, 1000, 20)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(200e6), twap)
+	require.Equal(t, 2, read)
+
+	// zero interval is the latest price
+	twap, _, err = GetTwapPrice(stateDB, underlying, 1000, 0)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(400e6), twap)
+
+	// no price before the window
+	_, _, err = GetTwapPrice(stateDB, underlying, 900, 100)
+	require.ErrorIs(t, err, errNoPrice)
+}
+
+func TestGetUnderlyingPrice(t *testing.T) {
+	input, err := PackGetUnderlyingPrice(underlying)
+	require.NoError(t, err)
+	output, err := PackGetUnderlyingPriceOutput(big.NewInt(1800e6))
+	require.NoError(t, err)
+	twapInput, err := PackGetUnderlyingTwapPrice(GetUnderlyingTwapPriceInput{Underlying: underlying, PeriodStart: big.NewInt(1000), IntervalInSeconds: big.NewInt(100)})
+	require.NoError(t, err)
+	twapOutput, err := PackGetUnderlyingTwapPriceOutput(big.NewInt(1750e6))
+	require.NoError(t, err)
+	withPrices := func(t testing.TB, state contract.StateDB) {
+		addObservation(state, underlying, 1, Observation{Price: big.NewInt(1700e6), Timestamp: 900})
+		addObservation(state, underlying, 2, Observation{Price: big.NewInt(1800e6), Timestamp: 950})
+	}
+
+	tests := map[string]testutils.PrecompileTest{
+		"latest price": {
+			Caller:      relayer,
+			Input:       input,
+			BeforeHook:  withPrices,
+			SuppliedGas: GetUnderlyingPriceGasCost,
+			ReadOnly:    true,
+			ExpectedRes: output,
+		},
+		"no price": {
+			Caller:      relayer,
+			Input:       input,
+			SuppliedGas: GetUnderlyingPriceGasCost,
+			ReadOnly:    true,
+			ExpectedErr: errNoPrice.Error(),
+		},
+		"twap price": {
+			Caller:      relayer,
+			Input:       twapInput,
+			BeforeHook:  withPrices,
+			SuppliedGas: GetUnderlyingTwapPriceGasCost + 2*contract.ReadGasCostPerSlot,
+			ReadOnly:    true,
+			ExpectedRes: twapOutput,
+		},
+		"twap insufficient gas": {
+			Caller:      relayer,
+			Input:       twapInput,
+			BeforeHook:  withPrices,
+			SuppliedGas: GetUnderlyingTwapPriceGasCost + contract.ReadGasCostPerSlot,
+			ReadOnly:    true,
+			ExpectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
+	}
+
+	testutils.RunPrecompileTests(t, Module, state.NewTestStateDB, tests)
+}
+
+func TestConfigurePublishers(t *testing.T) {
+	stateDB := state.NewTestStateDB(t)
+	chainConfig := contract.NewMockChainState(commontype.ValidTestFeeConfig, false)
+	blockContext := contract.NewMockBlockContext(big.NewInt(0), 0)
+
+	require.NoError(t, Module.Configure(chainConfig, NewConfig(utils.NewUint64(0), testPublishers, 60), stateDB, blockContext))
+	require.Equal(t, testPublishers[0].Address, getPublisher(stateDB, testPublishers[0].BlockchainID))
+	require.Equal(t, testPublishers[1].Address, getPublisher(stateDB, testPublishers[1].BlockchainID))
+	require.Equal(t, uint64(60), getMaxPriceAge(stateDB))
+
+	// a later upgrade removes a publisher with the zero address, and keeps the others
+	require.NoError(t, Module.Configure(chainConfig, NewConfig(utils.NewUint64(1), []Publisher{{BlockchainID: testPublishers[0].BlockchainID}}, 0), stateDB, blockContext))
+	require.Equal(t, common.Address{}, getPublisher(stateDB, testPublishers[0].BlockchainID))
+	require.Equal(t, testPublishers[1].Address, getPublisher(stateDB, testPublishers[1].BlockchainID))
+	require.Equal(t, uint64(0), getMaxPriceAge(stateDB))
+}
@@ -20,6 +20,8 @@ import (
 	_ "github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
 	_ "github.com/ava-labs/subnet-evm/precompile/contracts/hubblebibliophile"
 	_ "github.com/ava-labs/subnet-evm/precompile/contracts/juror"
+	_ "github.com/ava-labs/subnet-evm/precompile/contracts/marginbridge"
+	_ "github.com/ava-labs/subnet-evm/precompile/contracts/warporacle"
 	_ "github.com/ava-labs/subnet-evm/x/warp"
 	// ADD YOUR PRECOMPILE HERE
 	// _ "github.com/ava-labs/subnet-evm/precompile/contracts/yourprecompile"
@@ -51,4 +53,6 @@ import (
 // bibliophile       = common.HexToAddress("0x0300000000000000000000000000000000000004")
 // juror       = common.HexToAddress("0x0300000000000000000000000000000000000005")
 // iocOrderBook       = common.HexToAddress("0x635c5F96989a4226953FE6361f12B96c5d50289b")
+// marginBridge       = common.HexToAddress("0x0300000000000000000000000000000000000007")
+// warpOracle       = common.HexToAddress("0x0300000000000000000000000000000000000008")
 // {YourPrecompile}Address = common.HexToAddress("0x03000000000000000000000000000000000000??")
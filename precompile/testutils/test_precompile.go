@@ -85,8 +85,8 @@ func (test PrecompileTest) setup(t testing.TB, module modules.Module, state cont
 	}
 
 	blockContext := contract.NewMockBlockContext(big.NewInt(test.BlockNumber), 0)
-	accesibleState := contract.NewMockAccessibleState(state, blockContext, snow.DefaultContextTest())
 	chainConfig := contract.NewMockChainState(commontype.ValidTestFeeConfig, false)
+	accesibleState := contract.NewMockAccessibleState(state, blockContext, snow.DefaultContextTest(), chainConfig)
 
 	if test.Config != nil {
 		err := module.Configure(chainConfig, test.Config, state, blockContext)
@@ -0,0 +1,145 @@
+package stateupgrade
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// configureHubble compiles the hubble upgrade into storage writes to the ClearingHouse, the AMMs and the bibliophile
+// precompile (which keeps the oracle configs), using the same slots that the bibliophile reads. The upgrade is checked
+// against the state, after the accounts of the state upgrade are applied, before anything is written, so the hubble
+// storage is left untouched if an error is returned.
+func configureHubble(upgrade *params.HubbleUpgrade, state StateDB) error {
+	clearingHouse := common.HexToAddress(bibliophile.CLEARING_HOUSE_GENESIS_ADDRESS)
+	if state.GetCodeSize(clearingHouse) == 0 {
+		return fmt.Errorf("clearing house %s is not deployed", clearingHouse.Hex())
+	}
+
+	markets := getMarkets(state, clearingHouse)
+	listed := make(map[common.Address]bool, len(markets))
+	for _, amm := range markets {
+		listed[amm] = true
+	}
+	for i, market := range upgrade.AddMarkets {
+		if listed[market.AMM] {
+			return fmt.Errorf("addMarkets[%d]: amm %s is already listed", i, market.AMM.Hex())
+		}
+		if state.GetCodeSize(market.AMM) == 0 {
+			return fmt.Errorf("addMarkets[%d]: amm %s is not deployed", i, market.AMM.Hex())
+		}
+		listed[market.AMM] = true
+		markets = append(markets, market.AMM)
+	}
+	for i, params := range upgrade.MarketParams {
+		if params.Market >= int64(len(markets)) {
+			return fmt.Errorf("marketParams[%d]: market %d does not exist, there are %d markets", i, params.Market, len(markets))
+		}
+	}
+
+	// a new market needs an oracle and a min size, they are checked on the values to be written (here or in
+	// marketParams) so that a failed upgrade doesn't leave the state half written
+	for i, market := range upgrade.AddMarkets {
+		oracle, minSizeRequirement := market.Oracle, market.MinSizeRequirement
+		for _, params := range upgrade.MarketParams {
+			if markets[params.Market] != market.AMM {
+				continue
+			}
+			if params.Oracle != nil {
+				oracle = params.Oracle
+			}
+			if params.MinSizeRequirement != nil {
+				minSizeRequirement = params.MinSizeRequirement
+			}
+		}
+		if (oracle == nil && getAddress(state, market.AMM, bibliophile.ORACLE_SLOT) == common.Address{}) || (oracle != nil && *oracle == common.Address{}) {
+			return fmt.Errorf("addMarkets[%d]: amm %s has no oracle", i, market.AMM.Hex())
+		}
+		if minSizeRequirement == nil && state.GetState(market.AMM, slot(bibliophile.MIN_SIZE_REQUIREMENT_SLOT)) == (common.Hash{}) {
+			return fmt.Errorf("addMarkets[%d]: amm %s has no minSizeRequirement", i, market.AMM.Hex())
+		}
+	}
+
+	firstNewMarket := int64(len(markets) - len(upgrade.AddMarkets))
+	for i, market := range upgrade.AddMarkets {
+		state.SetState(clearingHouse, bibliophile.MarketStorageSlot(firstNewMarket+int64(i)), common.BytesToHash(market.AMM.Bytes()))
+		setMarketConfig(state, market.AMM, market.HubbleMarketConfig)
+	}
+	state.SetState(clearingHouse, slot(bibliophile.AMMS_SLOT), common.BigToHash(big.NewInt(int64(len(markets)))))
+	for _, params := range upgrade.MarketParams {
+		setMarketConfig(state, markets[params.Market], params.HubbleMarketConfig)
+	}
+	return nil
+}
+
+// getMarkets returns the AMMs listed in the ClearingHouse
+func getMarkets(state StateDB, clearingHouse common.Address) []common.Address {
+	count := state.GetState(clearingHouse, slot(bibliophile.AMMS_SLOT)).Big().Int64()
+	markets := make([]common.Address, 0, count)
+	for i := int64(0); i < count; i++ {
+		markets = append(markets, common.BytesToAddress(state.GetState(clearingHouse, bibliophile.MarketStorageSlot(i)).Bytes()))
+	}
+	return markets
+}
+
+// setMarketConfig writes the parameters that are set in [config] to the storage of the [amm]
+func setMarketConfig(state StateDB, amm common.Address, config params.HubbleMarketConfig) {
+	if config.Oracle != nil {
+		state.SetState(amm, slot(bibliophile.ORACLE_SLOT), common.BytesToHash(config.Oracle.Bytes()))
+	}
+	if config.UnderlyingAsset != nil {
+		state.SetState(amm, slot(bibliophile.UNDERLYING_ASSET_SLOT), common.BytesToHash(config.UnderlyingAsset.Bytes()))
+	}
+	setUint256(state, amm, bibliophile.MAX_ORACLE_SPREAD_RATIO_SLOT, config.MaxOracleSpreadRatio)
+	setUint256(state, amm, bibliophile.MAX_LIQUIDATION_RATIO_SLOT, config.MaxLiquidationRatio)
+	setUint256(state, amm, bibliophile.MAX_LIQUIDATION_PRICE_SPREAD, config.MaxLiquidationPriceSpread)
+	setUint256(state, amm, bibliophile.MIN_SIZE_REQUIREMENT_SLOT, config.MinSizeRequirement)
+	setUint64(state, amm, bibliophile.FUNDING_PERIOD_SLOT, config.FundingPeriod)
+	setUint64(state, amm, bibliophile.NEXT_FUNDING_TIME_SLOT, config.NextFundingTime)
+	setOracleConfig(state, amm, config)
+}
+
+// setOracleConfig writes the oracle parameters that are set in [config] to the storage of the bibliophile precompile,
+// the AMM contracts have no storage for them
+func setOracleConfig(state StateDB, amm common.Address, config params.HubbleMarketConfig) {
+	if config.OracleType == nil && config.MaxOraclePriceAge == nil && config.MaxOraclePriceDeviation == nil {
+		return
+	}
+	if state.GetNonce(bibliophile.ContractAddress) == 0 {
+		// keep the storage if the bibliophile precompile is not activated yet, an empty account is cleaned up
+		state.SetNonce(bibliophile.ContractAddress, 1)
+	}
+	if config.OracleType != nil {
+		state.SetState(bibliophile.ContractAddress, bibliophile.OracleTypeStorageSlot(amm), common.BigToHash(new(big.Int).SetUint64(uint64(*config.OracleType))))
+	}
+	if config.MaxOraclePriceAge != nil {
+		state.SetState(bibliophile.ContractAddress, bibliophile.MaxOraclePriceAgeStorageSlot(amm), common.BigToHash(new(big.Int).SetUint64(*config.MaxOraclePriceAge)))
+	}
+	if config.MaxOraclePriceDeviation != nil {
+		state.SetState(bibliophile.ContractAddress, bibliophile.MaxOraclePriceDeviationStorageSlot(amm), common.BigToHash((*big.Int)(config.MaxOraclePriceDeviation)))
+	}
+}
+
+func setUint256(state StateDB, account common.Address, key int64, value *math.HexOrDecimal256) {
+	if value != nil {
+		state.SetState(account, slot(key), common.BigToHash((*big.Int)(value)))
+	}
+}
+
+func setUint64(state StateDB, account common.Address, key int64, value *uint64) {
+	if value != nil {
+		state.SetState(account, slot(key), common.BigToHash(new(big.Int).SetUint64(*value)))
+	}
+}
+
+func getAddress(state StateDB, account common.Address, key int64) common.Address {
+	return common.BytesToAddress(state.GetState(account, slot(key)).Bytes())
+}
+
+func slot(key int64) common.Hash {
+	return common.BigToHash(big.NewInt(key))
+}
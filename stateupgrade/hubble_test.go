@@ -0,0 +1,144 @@
+package stateupgrade
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/subnet-evm/core/state"
+	"github.com/ava-labs/subnet-evm/params"
+	"github.com/ava-labs/subnet-evm/precompile/contracts/bibliophile"
+	"github.com/ava-labs/subnet-evm/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	clearingHouse = common.HexToAddress(bibliophile.CLEARING_HOUSE_GENESIS_ADDRESS)
+	listedAMM     = common.Address{1}
+	newAMM        = common.Address{2}
+	oracle        = common.Address{3}
+)
+
+type testBlockContext struct{}
+
+func (testBlockContext) Number() *big.Int { return big.NewInt(1) }
+
+func newHubbleTestState(t *testing.T) *state.StateDB {
+	stateDB := state.NewTestStateDB(t).(*state.StateDB)
+	stateDB.SetCode(clearingHouse, []byte{0x1})
+	stateDB.SetCode(listedAMM, []byte{0x1})
+	stateDB.SetState(clearingHouse, slot(bibliophile.AMMS_SLOT), common.BigToHash(big.NewInt(1)))
+	stateDB.SetState(clearingHouse, bibliophile.MarketStorageSlot(0), common.BytesToHash(listedAMM.Bytes()))
+	return stateDB
+}
+
+func TestConfigureHubble(t *testing.T) {
+	t.Run("lists a market and sets its parameters", func(t *testing.T) {
+		require := require.New(t)
+		stateDB := newHubbleTestState(t)
+		upgrade := &params.StateUpgrade{
+			BlockTimestamp: utils.NewUint64(1),
+			StateUpgradeAccounts: map[common.Address]params.StateUpgradeAccount{
+				newAMM: {Code: []byte{0x1}},
+			},
+			Hubble: &params.HubbleUpgrade{
+				AddMarkets: []params.HubbleMarket{{AMM: newAMM, HubbleMarketConfig: params.HubbleMarketConfig{
+					Oracle:             &oracle,
+					MinSizeRequirement: (*math.HexOrDecimal256)(big.NewInt(1e16)),
+					FundingPeriod:      utils.NewUint64(3600),
+					NextFundingTime:    utils.NewUint64(1700000000),
+				}}},
+				MarketParams: []params.HubbleMarketParams{{Market: 0, HubbleMarketConfig: params.HubbleMarketConfig{
+					MaxLiquidationRatio: (*math.HexOrDecimal256)(big.NewInt(25e4)),
+				}}},
+			},
+		}
+		require.NoError(Configure(upgrade, params.TestChainConfig, stateDB, testBlockContext{}))
+
+		require.Equal([]common.Address{listedAMM, newAMM}, bibliophile.GetMarkets(stateDB))
+		require.Equal(int64(1e16), bibliophile.GetMinSizeRequirement(stateDB, 1).Int64())
+		require.Equal(int64(3600), bibliophile.GetFundingPeriod(stateDB, 1).Int64())
+		require.Equal(int64(1700000000), bibliophile.GetNextFundingTime(stateDB, 1).Int64())
+		require.Equal(oracle, getAddress(stateDB, newAMM, bibliophile.ORACLE_SLOT))
+		require.Equal(int64(25e4), bibliophile.GetMaxLiquidationRatio(stateDB, 0).Int64())
+	})
+
+	t.Run("fails for a market that is already listed", func(t *testing.T) {
+		stateDB := newHubbleTestState(t)
+		upgrade := &params.HubbleUpgrade{AddMarkets: []params.HubbleMarket{{AMM: listedAMM}}}
+		require.ErrorContains(t, configureHubble(upgrade, stateDB), "addMarkets[0]: amm 0x0100000000000000000000000000000000000000 is already listed")
+	})
+
+	t.Run("fails for an amm that is not deployed", func(t *testing.T) {
+		stateDB := newHubbleTestState(t)
+		upgrade := &params.HubbleUpgrade{AddMarkets: []params.HubbleMarket{{AMM: newAMM}}}
+		require.ErrorContains(t, configureHubble(upgrade, stateDB), "is not deployed")
+	})
+
+	t.Run("fails for a new market without oracle", func(t *testing.T) {
+		stateDB := newHubbleTestState(t)
+		stateDB.SetCode(newAMM, []byte{0x1})
+		upgrade := &params.HubbleUpgrade{AddMarkets: []params.HubbleMarket{{AMM: newAMM, HubbleMarketConfig: params.HubbleMarketConfig{
+			MinSizeRequirement: (*math.HexOrDecimal256)(big.NewInt(1e16)),
+		}}}}
+		require.ErrorContains(t, configureHubble(upgrade, stateDB), "has no oracle")
+		require.Equal(t, int64(1), bibliophile.GetActiveMarketsCount(stateDB))
+	})
+
+	t.Run("takes the parameters of a new market from marketParams", func(t *testing.T) {
+		stateDB := newHubbleTestState(t)
+		stateDB.SetCode(newAMM, []byte{0x1})
+		upgrade := &params.HubbleUpgrade{
+			AddMarkets: []params.HubbleMarket{{AMM: newAMM, HubbleMarketConfig: params.HubbleMarketConfig{Oracle: &oracle}}},
+			MarketParams: []params.HubbleMarketParams{{Market: 1, HubbleMarketConfig: params.HubbleMarketConfig{
+				MinSizeRequirement: (*math.HexOrDecimal256)(big.NewInt(1e16)),
+			}}},
+		}
+		require.NoError(t, configureHubble(upgrade, stateDB))
+		require.Equal(t, int64(1e16), bibliophile.GetMinSizeRequirement(stateDB, 1).Int64())
+	})
+
+	t.Run("sets the oracle config in the bibliophile precompile", func(t *testing.T) {
+		require := require.New(t)
+		stateDB := newHubbleTestState(t)
+		oracleType := uint8(bibliophile.ChainlinkOracle)
+		upgrade := &params.HubbleUpgrade{MarketParams: []params.HubbleMarketParams{{Market: 0, HubbleMarketConfig: params.HubbleMarketConfig{
+			OracleType:              &oracleType,
+			MaxOraclePriceAge:       utils.NewUint64(60),
+			MaxOraclePriceDeviation: (*math.HexOrDecimal256)(big.NewInt(5e4)),
+		}}}}
+		require.NoError(configureHubble(upgrade, stateDB))
+		require.Equal(uint64(1), stateDB.GetNonce(bibliophile.ContractAddress))
+		require.Equal(common.BigToHash(big.NewInt(int64(bibliophile.ChainlinkOracle))), stateDB.GetState(bibliophile.ContractAddress, bibliophile.OracleTypeStorageSlot(listedAMM)))
+		require.Equal(int64(60), bibliophile.GetMaxOraclePriceAge(stateDB, 0).Int64())
+		require.Equal(int64(5e4), bibliophile.GetMaxOraclePriceDeviation(stateDB, 0).Int64())
+	})
+
+	t.Run("is skipped at activation if it doesn't apply, and fails verification", func(t *testing.T) {
+		require := require.New(t)
+		upgrade := &params.StateUpgrade{
+			BlockTimestamp: utils.NewUint64(1),
+			StateUpgradeAccounts: map[common.Address]params.StateUpgradeAccount{
+				oracle: {Storage: map[common.Hash]common.Hash{{1}: {1}}},
+			},
+			Hubble: &params.HubbleUpgrade{AddMarkets: []params.HubbleMarket{{AMM: listedAMM}}},
+		}
+		stateDB := newHubbleTestState(t)
+		require.ErrorContains(Verify(upgrade, params.TestChainConfig, stateDB, testBlockContext{}), "hubble: addMarkets[0]: amm 0x0100000000000000000000000000000000000000 is already listed")
+
+		stateDB = newHubbleTestState(t)
+		require.NoError(Configure(upgrade, params.TestChainConfig, stateDB, testBlockContext{}))
+		// the accounts are still upgraded
+		require.Equal(common.Hash{1}, stateDB.GetState(oracle, common.Hash{1}))
+		require.Equal(int64(1), bibliophile.GetActiveMarketsCount(stateDB))
+	})
+
+	t.Run("fails for params of a market that does not exist", func(t *testing.T) {
+		stateDB := newHubbleTestState(t)
+		upgrade := &params.HubbleUpgrade{MarketParams: []params.HubbleMarketParams{{Market: 1, HubbleMarketConfig: params.HubbleMarketConfig{
+			FundingPeriod: utils.NewUint64(3600),
+		}}}}
+		require.ErrorContains(t, configureHubble(upgrade, stateDB), "marketParams[0]: market 1 does not exist, there are 1 markets")
+	})
+}
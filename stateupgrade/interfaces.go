@@ -11,7 +11,9 @@ import (
 
 // StateDB is the interface for accessing EVM state in state upgrades
 type StateDB interface {
+	GetState(common.Address, common.Hash) common.Hash
 	SetState(common.Address, common.Hash, common.Hash)
+	GetCodeSize(common.Address) int
 	SetCode(common.Address, []byte)
 	AddBalance(common.Address, *big.Int)
 
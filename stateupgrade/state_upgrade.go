@@ -4,14 +4,45 @@
 package stateupgrade
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ava-labs/subnet-evm/params"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 // Configure applies the state upgrade to the state.
+// A hubble upgrade that doesn't apply to the state is skipped and logged rather than failing every block from its
+// timestamp on, which would halt the chain. It is checked against the last accepted state when the config is loaded
+// (see Verify), so this only happens if the state changed in a way that breaks it since.
 func Configure(stateUpgrade *params.StateUpgrade, chainConfig ChainContext, state StateDB, blockContext BlockContext) error {
+	if err := configureAccounts(stateUpgrade, chainConfig, state, blockContext); err != nil {
+		return err
+	}
+	if stateUpgrade.Hubble != nil {
+		if err := configureHubble(stateUpgrade.Hubble, state); err != nil {
+			log.Error("Skipping the hubble state upgrade, it doesn't apply to the state", "blockTimestamp", stateUpgrade.BlockTimestamp, "err", err)
+		}
+	}
+	return nil
+}
+
+// Verify applies the state upgrade to the state like Configure, but returns an error if its hubble upgrade doesn't
+// apply. It is meant to be run on a copy of the last accepted state for the upgrades that are not activated yet.
+func Verify(stateUpgrade *params.StateUpgrade, chainConfig ChainContext, state StateDB, blockContext BlockContext) error {
+	if err := configureAccounts(stateUpgrade, chainConfig, state, blockContext); err != nil {
+		return err
+	}
+	if stateUpgrade.Hubble != nil {
+		if err := configureHubble(stateUpgrade.Hubble, state); err != nil {
+			return fmt.Errorf("hubble: %w", err)
+		}
+	}
+	return nil
+}
+
+func configureAccounts(stateUpgrade *params.StateUpgrade, chainConfig ChainContext, state StateDB, blockContext BlockContext) error {
 	isEIP158 := chainConfig.IsEIP158(blockContext.Number())
 	for account, upgrade := range stateUpgrade.StateUpgradeAccounts {
 		if err := upgradeAccount(account, upgrade, state, isEIP158); err != nil {
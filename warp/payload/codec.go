@@ -23,6 +23,8 @@ func init() {
 	errs := wrappers.Errs{}
 	errs.Add(
 		lc.RegisterType(&AddressedPayload{}),
+		lc.RegisterType(&OraclePrice{}),
+		lc.RegisterType(&OrderBookCheckpoint{}),
 		c.RegisterCodec(codecVersion, lc),
 	)
 	if errs.Errored() {
@@ -0,0 +1,67 @@
+// Copyright (C) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package payload
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OraclePrice defines the format for publishing the price of a market from an oracle chain, delivered to the
+// destination chain inside the Payload of an AddressedPayload.
+type OraclePrice struct {
+	// Market is the underlying asset of the market, as its AMMs are configured with on the destination chain
+	Market common.Address `serialize:"true"`
+	// Price has 6 decimals
+	Price uint64 `serialize:"true"`
+	// Timestamp is the unix timestamp (in seconds) the price was observed at
+	Timestamp uint64 `serialize:"true"`
+	// Round increases with every price published for the market
+	Round uint64 `serialize:"true"`
+
+	bytes []byte
+}
+
+// NewOraclePrice creates a new *OraclePrice and initializes it.
+func NewOraclePrice(market common.Address, price uint64, timestamp uint64, round uint64) (*OraclePrice, error) {
+	op := &OraclePrice{
+		Market:    market,
+		Price:     price,
+		Timestamp: timestamp,
+		Round:     round,
+	}
+	return op, op.initialize()
+}
+
+// ParseOraclePrice converts a slice of bytes into an initialized OraclePrice.
+func ParseOraclePrice(b []byte) (*OraclePrice, error) {
+	var unmarshalledPayloadIntf any
+	if _, err := c.Unmarshal(b, &unmarshalledPayloadIntf); err != nil {
+		return nil, err
+	}
+	payload, ok := unmarshalledPayloadIntf.(*OraclePrice)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse unexpected type %T as oracle price", unmarshalledPayloadIntf)
+	}
+	payload.bytes = b
+	return payload, nil
+}
+
+// initialize recalculates the result of Bytes().
+func (o *OraclePrice) initialize() error {
+	oIntf := any(o)
+	bytes, err := c.Marshal(codecVersion, &oIntf)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal warp oracle price: %w", err)
+	}
+	o.bytes = bytes
+	return nil
+}
+
+// Bytes returns the binary representation of this payload. It assumes that the
+// payload is initialized from either NewOraclePrice or ParseOraclePrice.
+func (o *OraclePrice) Bytes() []byte {
+	return o.bytes
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package payload
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OrderBookCheckpoint defines the format for committing to the open orders and positions of the orderbook at an
+// accepted block. The validators sign it as the Payload of a warp message sourced from the hubble chain.
+type OrderBookCheckpoint struct {
+	// BlockNumber and BlockHash are the accepted block the orderbook was taken at
+	BlockNumber uint64      `serialize:"true"`
+	BlockHash   common.Hash `serialize:"true"`
+	// Root is the Merkle root of the order and position leaves, see plugin/evm/orderbook/checkpoint
+	Root common.Hash `serialize:"true"`
+
+	bytes []byte
+}
+
+// NewOrderBookCheckpoint creates a new *OrderBookCheckpoint and initializes it.
+func NewOrderBookCheckpoint(blockNumber uint64, blockHash common.Hash, root common.Hash) (*OrderBookCheckpoint, error) {
+	oc := &OrderBookCheckpoint{
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+		Root:        root,
+	}
+	return oc, oc.initialize()
+}
+
+// ParseOrderBookCheckpoint converts a slice of bytes into an initialized OrderBookCheckpoint.
+func ParseOrderBookCheckpoint(b []byte) (*OrderBookCheckpoint, error) {
+	var unmarshalledPayloadIntf any
+	if _, err := c.Unmarshal(b, &unmarshalledPayloadIntf); err != nil {
+		return nil, err
+	}
+	payload, ok := unmarshalledPayloadIntf.(*OrderBookCheckpoint)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse unexpected type %T as orderbook checkpoint", unmarshalledPayloadIntf)
+	}
+	payload.bytes = b
+	return payload, nil
+}
+
+// initialize recalculates the result of Bytes().
+func (o *OrderBookCheckpoint) initialize() error {
+	oIntf := any(o)
+	bytes, err := c.Marshal(codecVersion, &oIntf)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal warp orderbook checkpoint: %w", err)
+	}
+	o.bytes = bytes
+	return nil
+}
+
+// Bytes returns the binary representation of this payload. It assumes that the
+// payload is initialized from either NewOrderBookCheckpoint or ParseOrderBookCheckpoint.
+func (o *OrderBookCheckpoint) Bytes() []byte {
+	return o.bytes
+}
@@ -52,3 +52,47 @@ func TestParseAddressedPayload(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, payload, parsedPayload)
 }
+
+func TestOraclePrice(t *testing.T) {
+	require := require.New(t)
+
+	oraclePrice, err := NewOraclePrice(common.Address(ids.GenerateTestShortID()), 1800e6, 1_700_000_000, 42)
+	require.NoError(err)
+
+	oraclePrice2, err := ParseOraclePrice(oraclePrice.Bytes())
+	require.NoError(err)
+	require.Equal(oraclePrice, oraclePrice2)
+
+	// an addressed payload is not an oracle price
+	addressedPayload, err := NewAddressedPayload(common.Address{1}, common.Hash{2}, common.Address{3}, oraclePrice.Bytes())
+	require.NoError(err)
+	_, err = ParseOraclePrice(addressedPayload.Bytes())
+	require.ErrorContains(err, "failed to parse unexpected type")
+}
+
+func TestParseOraclePriceJunk(t *testing.T) {
+	_, err := ParseOraclePrice(utils.RandomBytes(1024))
+	require.Error(t, err)
+}
+
+func TestOrderBookCheckpoint(t *testing.T) {
+	require := require.New(t)
+
+	checkpoint, err := NewOrderBookCheckpoint(1000, common.Hash(ids.GenerateTestID()), common.Hash(ids.GenerateTestID()))
+	require.NoError(err)
+
+	checkpoint2, err := ParseOrderBookCheckpoint(checkpoint.Bytes())
+	require.NoError(err)
+	require.Equal(checkpoint, checkpoint2)
+
+	// an oracle price is not a checkpoint
+	oraclePrice, err := NewOraclePrice(common.Address{1}, 1800e6, 1_700_000_000, 42)
+	require.NoError(err)
+	_, err = ParseOrderBookCheckpoint(oraclePrice.Bytes())
+	require.ErrorContains(err, "failed to parse unexpected type")
+}
+
+func TestParseOrderBookCheckpointJunk(t *testing.T) {
+	_, err := ParseOrderBookCheckpoint(utils.RandomBytes(1024))
+	require.Error(t, err)
+}
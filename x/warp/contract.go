@@ -145,28 +145,13 @@ func getVerifiedWarpMessage(accessibleState contract.AccessibleState, caller com
 	}
 	// Note: since the predicate is verified in advance of execution, the precompile should not
 	// hit an error during execution.
-	unpackedPredicateBytes, err := predicateutils.UnpackPredicate(predicateBytes)
+	warpMessage, err := ParseVerifiedWarpMessage(predicateBytes)
 	if err != nil {
-		return nil, remainingGas, fmt.Errorf("%w: %s", errInvalidPredicateBytes, err)
-	}
-	warpMessage, err := warp.ParseMessage(unpackedPredicateBytes)
-	if err != nil {
-		return nil, remainingGas, fmt.Errorf("%w: %s", errInvalidWarpMsg, err)
-	}
-
-	addressedPayload, err := warpPayload.ParseAddressedPayload(warpMessage.UnsignedMessage.Payload)
-	if err != nil {
-		return nil, remainingGas, fmt.Errorf("%w: %s", errInvalidAddressedPayload, err)
+		return nil, remainingGas, err
 	}
 	packedOutput, err := PackGetVerifiedWarpMessageOutput(GetVerifiedWarpMessageOutput{
-		Message: WarpMessage{
-			OriginChainID:       common.Hash(warpMessage.SourceChainID),
-			OriginSenderAddress: addressedPayload.SourceAddress,
-			DestinationChainID:  addressedPayload.DestinationChainID,
-			DestinationAddress:  addressedPayload.DestinationAddress,
-			Payload:             addressedPayload.Payload,
-		},
-		Exists: true,
+		Message: warpMessage,
+		Exists:  true,
 	})
 	if err != nil {
 		return nil, remainingGas, err
@@ -176,6 +161,31 @@ func getVerifiedWarpMessage(accessibleState contract.AccessibleState, caller com
 	return packedOutput, remainingGas, nil
 }
 
+// ParseVerifiedWarpMessage parses the warp message from the [predicateBytes] of a transaction, which were verified
+// by the predicate before execution. It is used by the precompiles that deliver warp messages.
+func ParseVerifiedWarpMessage(predicateBytes []byte) (WarpMessage, error) {
+	unpackedPredicateBytes, err := predicateutils.UnpackPredicate(predicateBytes)
+	if err != nil {
+		return WarpMessage{}, fmt.Errorf("%w: %s", errInvalidPredicateBytes, err)
+	}
+	warpMessage, err := warp.ParseMessage(unpackedPredicateBytes)
+	if err != nil {
+		return WarpMessage{}, fmt.Errorf("%w: %s", errInvalidWarpMsg, err)
+	}
+
+	addressedPayload, err := warpPayload.ParseAddressedPayload(warpMessage.UnsignedMessage.Payload)
+	if err != nil {
+		return WarpMessage{}, fmt.Errorf("%w: %s", errInvalidAddressedPayload, err)
+	}
+	return WarpMessage{
+		OriginChainID:       common.Hash(warpMessage.SourceChainID),
+		OriginSenderAddress: addressedPayload.SourceAddress,
+		DestinationChainID:  addressedPayload.DestinationChainID,
+		DestinationAddress:  addressedPayload.DestinationAddress,
+		Payload:             addressedPayload.Payload,
+	}, nil
+}
+
 // UnpackSendWarpMessageInput attempts to unpack [input] as SendWarpMessageInput
 // assumes that [input] does not include selector (omits first 4 func signature bytes)
 func UnpackSendWarpMessageInput(input []byte) (SendWarpMessageInput, error) {
@@ -214,14 +224,19 @@ func sendWarpMessage(accessibleState contract.AccessibleState, caller common.Add
 		return nil, remainingGas, fmt.Errorf("%w: %s", errInvalidSendInput, err)
 	}
 
-	var (
-		sourceChainID      = accessibleState.GetSnowContext().ChainID
-		destinationChainID = inputStruct.DestinationChainID
-		sourceAddress      = caller
-		destinationAddress = inputStruct.DestinationAddress
-		payload            = inputStruct.Payload
-	)
+	if err := AddSendWarpMessageLog(accessibleState, caller, inputStruct.DestinationChainID, inputStruct.DestinationAddress, inputStruct.Payload); err != nil {
+		return nil, remainingGas, err
+	}
 
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
+// AddSendWarpMessageLog constructs an Avalanche Warp Message containing an AddressedPayload from [sourceAddress], and
+// emits the SendWarpMessage log that signals validators to sign it once the block is accepted. Other precompiles use
+// it to send warp messages on behalf of their own address, the caller is responsible for charging the gas.
+func AddSendWarpMessageLog(accessibleState contract.AccessibleState, sourceAddress common.Address, destinationChainID common.Hash, destinationAddress common.Address, payload []byte) error {
+	sourceChainID := accessibleState.GetSnowContext().ChainID
 	addressedPayload, err := warpPayload.NewAddressedPayload(
 		sourceAddress,
 		destinationChainID,
@@ -229,7 +244,7 @@ func sendWarpMessage(accessibleState contract.AccessibleState, caller common.Add
 		payload,
 	)
 	if err != nil {
-		return nil, remainingGas, err
+		return err
 	}
 	unsignedWarpMessage, err := warp.NewUnsignedMessage(
 		accessibleState.GetSnowContext().NetworkID,
@@ -237,7 +252,7 @@ func sendWarpMessage(accessibleState contract.AccessibleState, caller common.Add
 		addressedPayload.Bytes(),
 	)
 	if err != nil {
-		return nil, remainingGas, err
+		return err
 	}
 
 	// Add a log to be handled if this action is finalized.
@@ -252,9 +267,7 @@ func sendWarpMessage(accessibleState contract.AccessibleState, caller common.Add
 		unsignedWarpMessage.Bytes(),
 		accessibleState.GetBlockContext().Number().Uint64(),
 	)
-
-	// Return an empty output and the remaining gas
-	return []byte{}, remainingGas, nil
+	return nil
 }
 
 // createWarpPrecompile returns a StatefulPrecompiledContract with getters and setters for the precompile.